@@ -0,0 +1,203 @@
+package mux
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// allowedMethods holds the routes of a router and its subrouters, flattened
+// so that the methods allowed for a request are found without walking the
+// routes.
+//
+// The routes whose host and path templates alone decide whether they match
+// are grouped by template: a request matching a route also matches the
+// routes with its templates. The other routes, with variable patterns,
+// other matchers or a path prefix, are ambiguous and matched one by one.
+type allowedMethods struct {
+	generation uint64
+	routes     []allowedRoute
+	byTemplate map[allowedMethodsKey][]allowedRoute
+	ambiguous  []allowedRoute
+}
+
+// allowedMethodsKey identifies the routes with the same templates.
+type allowedMethodsKey struct {
+	host, path string
+	prefix     bool
+}
+
+func newAllowedMethodsKey(route *Route) allowedMethodsKey {
+	var key allowedMethodsKey
+	if rr := route.regexp.host; rr != nil {
+		key.host = strings.ToLower(rr.template)
+	}
+	if rr := route.regexp.path; rr != nil {
+		key.path = rr.template
+		key.prefix = rr.regexpType == regexpTypePrefix
+	}
+	return key
+}
+
+// allowedRoute is a route with the routes leading to it.
+type allowedRoute struct {
+	// index orders the routes as they are matched.
+	index int
+	// chain is the ancestors of the route followed by the route.
+	chain []*Route
+	// methods is nil for routes accepting any method.
+	methods []string
+}
+
+// loadAllowedMethods returns the routes of r for computing allowed methods,
+// flattening them if routes were added since they were last flattened.
+func (r *Router) loadAllowedMethods() *allowedMethods {
+	generation := r.tree.loadRoutes()
+	if a := r.allowed.Load(); a != nil && a.generation == generation {
+		return a
+	}
+
+	a := &allowedMethods{generation: generation, byTemplate: make(map[allowedMethodsKey][]allowedRoute)}
+	_ = r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		if route.buildOnly || route.err != nil || isSubrouterRoute(route) {
+			return nil
+		}
+		ar := allowedRoute{index: len(a.routes), chain: append(slices.Clone(ancestors), route)}
+		for _, m := range route.matchers {
+			if m, ok := m.(methodMatcher); ok {
+				ar.methods = m
+				break
+			}
+		}
+		a.routes = append(a.routes, ar)
+		if ar.ambiguous() {
+			a.ambiguous = append(a.ambiguous, ar)
+		} else {
+			key := newAllowedMethodsKey(route)
+			a.byTemplate[key] = append(a.byTemplate[key], ar)
+		}
+		return nil
+	})
+	r.allowed.Store(a)
+	return a
+}
+
+// isSubrouterRoute reports whether route only dispatches to another router.
+func isSubrouterRoute(route *Route) bool {
+	if _, ok := route.handler.(*Router); ok {
+		return true
+	}
+	for _, m := range route.matchers {
+		if _, ok := m.(*Router); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedMethods returns the union of the methods accepted by the routes of
+// r and its subrouters matching req apart from its method, given route, a
+// route matching req apart from its method. If route is nil, all the routes
+// are matched against req. It returns false if no route matches, or a route
+// accepting any method does.
+func (r *Router) allowedMethods(route *Route, req *http.Request) ([]string, bool) {
+	a := r.loadAllowedMethods()
+	var matched []allowedRoute
+	if route == nil {
+		for _, ar := range a.routes {
+			if ar.matchesIgnoringMethod(req) {
+				matched = append(matched, ar)
+			}
+		}
+	} else {
+		key := newAllowedMethodsKey(route)
+		matched = append(matched, a.byTemplate[key]...)
+		if key.host != "" {
+			// Routes without a host template match any host.
+			key.host = ""
+			matched = append(matched, a.byTemplate[key]...)
+		}
+		for _, ar := range a.ambiguous {
+			if ar.matchesIgnoringMethod(req) {
+				matched = append(matched, ar)
+			}
+		}
+		slices.SortFunc(matched, func(ar1, ar2 allowedRoute) int {
+			return ar1.index - ar2.index
+		})
+	}
+
+	var methods []string
+	for _, ar := range matched {
+		if ar.methods == nil {
+			return nil, false
+		}
+		for _, method := range ar.methods {
+			if !slices.Contains(methods, method) {
+				methods = append(methods, method)
+			}
+		}
+	}
+	return methods, methods != nil
+}
+
+// ambiguous reports whether the route may match requests which other routes
+// with its templates do not match, or the reverse: it has no path template,
+// a path prefix, variables with patterns, or other matchers.
+func (ar allowedRoute) ambiguous() bool {
+	if rr := ar.chain[len(ar.chain)-1].regexp.path; rr == nil || rr.regexpType == regexpTypePrefix {
+		return true
+	}
+	for _, route := range ar.chain {
+		if route.enabled != nil {
+			return true
+		}
+		for _, m := range route.matchers {
+			switch m := m.(type) {
+			case methodMatcher, *Router:
+			case *routeRegexp:
+				if m.regexpType == regexpTypeQuery || m.hasVarPatterns() {
+					return true
+				}
+			default:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasVarPatterns reports whether a variable of the host or path regexp r has
+// a pattern other than the default one.
+func (r *routeRegexp) hasVarPatterns() bool {
+	for _, patt := range r.varsP {
+		switch {
+		case r.regexpType == regexpTypeHost && patt == "[^.]+":
+		case r.regexpType != regexpTypeHost && (patt == "[^/]+" || patt == "[^/]+?" || patt == extensionPattern):
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// matchesIgnoringMethod reports whether the matchers of the route and its
+// ancestors, except the method matchers, match req.
+func (ar allowedRoute) matchesIgnoringMethod(req *http.Request) bool {
+	for _, route := range ar.chain {
+		if route.enabled != nil && !route.enabled(req.Context(), req) {
+			return false
+		}
+		for _, m := range route.matchers {
+			switch m.(type) {
+			case methodMatcher, *Router:
+				continue
+			}
+			var match RouteMatch
+			if !m.Match(req, &match) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestAllowHeader(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/users/{id}", dummyHandler).Methods("GET", "HEAD")
+	router.HandleFunc("/users/{id}", dummyHandler).Methods("PUT", "GET")
+	router.HandleFunc("/users", dummyHandler).Methods("POST")
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/items", dummyHandler).Methods("GET")
+
+	for _, tc := range []struct {
+		path, expected string
+	}{
+		{"/users/42", "GET, HEAD, PUT"},
+		{"/users", "POST"},
+		{"/api/items", "GET"},
+	} {
+		res := NewRecorder()
+		_ = router.ServeHTTP(context.Background(), res, newRequest("DELETE", "http://localhost"+tc.path), nil)
+		if res.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: expected 405, got %d", tc.path, res.Code)
+		}
+		if allow := res.Header().Get("Allow"); allow != tc.expected {
+			t.Errorf("%s: expected Allow %q, got %q", tc.path, tc.expected, allow)
+		}
+	}
+
+	// Routes added later are taken into account.
+	router.HandleFunc("/users", dummyHandler).Methods("PATCH")
+	res := NewRecorder()
+	_ = router.ServeHTTP(context.Background(), res, newRequest("DELETE", "http://localhost/users"), nil)
+	if allow := res.Header().Get("Allow"); allow != "POST, PATCH" {
+		t.Errorf("expected Allow %q, got %q", "POST, PATCH", allow)
+	}
+}
+
+func TestAllowHeaderCustomHandler(t *testing.T) {
+	router := NewRouter()
+	router.MethodNotAllowedHandler = HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		w.WriteHeader(http.StatusTeapot)
+		return nil
+	})
+	router.HandleFunc("/", dummyHandler).Methods("GET")
+
+	res := NewRecorder()
+	_ = router.ServeHTTP(context.Background(), res, newRequest("POST", "http://localhost/"), nil)
+	if res.Code != http.StatusTeapot || res.Header().Get("Allow") != "GET" {
+		t.Errorf("expected 418 with Allow GET, got %d %q", res.Code, res.Header().Get("Allow"))
+	}
+}
+
+func TestAllowedMethods(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/a", dummyHandler).Methods("GET")
+	router.HandleFunc("/a", dummyHandler).Methods("OPTIONS", "GET")
+	router.HandleFunc("/b", dummyHandler)
+	router.HandleFunc("/b", dummyHandler).Methods("GET")
+	router.PathPrefix("/c").Methods("POST")
+
+	if methods, ok := router.allowedMethods(nil, newRequest("DELETE", "http://localhost/a")); !ok || !reflect.DeepEqual(methods, []string{"GET", "OPTIONS"}) {
+		t.Errorf("expected [GET OPTIONS], got %v", methods)
+	}
+	if methods, ok := router.allowedMethods(nil, newRequest("DELETE", "http://localhost/b")); ok {
+		t.Errorf("expected any method, got %v", methods)
+	}
+	if methods, ok := router.allowedMethods(nil, newRequest("DELETE", "http://localhost/c/d")); !ok || !reflect.DeepEqual(methods, []string{"POST"}) {
+		t.Errorf("expected [POST], got %v", methods)
+	}
+	if methods, ok := router.allowedMethods(nil, newRequest("DELETE", "http://localhost/d")); ok {
+		t.Errorf("expected no methods for an unknown path, got %v", methods)
+	}
+}
+
+func TestAllowedMethodsByTemplate(t *testing.T) {
+	router := NewRouter()
+	get := router.HandleFunc("/users/{id}", dummyHandler).Methods("GET")
+	router.HandleFunc("/users/{id}", dummyHandler).Host("example.com").Methods("PUT")
+	hosted := router.HandleFunc("/users/{id}", dummyHandler).Host("api.example.com").Methods("PATCH")
+	router.HandleFunc("/users/{id:[0-9]+}", dummyHandler).Methods("DELETE")
+	router.HandleFunc("/users/{id}", dummyHandler).Headers("X-Batch", "1").Methods("POST")
+	api := router.PathPrefix("/api").Subrouter()
+	apiGet := api.HandleFunc("/users/{id}", dummyHandler).Methods("GET")
+
+	a := router.loadAllowedMethods()
+	if len(a.byTemplate) != 4 || len(a.ambiguous) != 2 {
+		t.Fatalf("expected 4 templates and 2 ambiguous routes, got %d and %d", len(a.byTemplate), len(a.ambiguous))
+	}
+
+	for _, tc := range []struct {
+		route    *Route
+		url      string
+		expected []string
+	}{
+		{get, "http://localhost/users/abc", []string{"GET"}},
+		{get, "http://localhost/users/1", []string{"GET", "DELETE"}},
+		{hosted, "http://api.example.com/users/abc", []string{"GET", "PATCH"}},
+		{apiGet, "http://localhost/api/users/abc", []string{"GET"}},
+	} {
+		methods, ok := router.allowedMethods(tc.route, newRequest("OPTIONS", tc.url))
+		if !ok || !reflect.DeepEqual(methods, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.url, tc.expected, methods)
+		}
+	}
+}
+
+func TestAllowHeaderOverlappingTemplates(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/users/{id}", dummyHandler).Methods("PATCH").Queries("fields", "{fields}")
+	router.HandleFunc("/users/{id}", dummyHandler).Methods("GET")
+	router.HandleFunc("/users/{id:[0-9]+}", dummyHandler).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/users/{id}", dummyHandler).Methods("POST").Headers("X-Batch", "1")
+	router.Use(CORSMethodMiddleware(router))
+
+	for _, tc := range []struct {
+		path     string
+		header   bool
+		expected string
+	}{
+		{"/users/42", false, "GET, PUT, OPTIONS"},
+		{"/users/abc", false, "GET"},
+		{"/users/42?fields=name", false, "PATCH, GET, PUT, OPTIONS"},
+		{"/users/abc", true, "GET, POST"},
+	} {
+		req := newRequest("DELETE", "http://localhost"+tc.path)
+		if tc.header {
+			req.Header.Set("X-Batch", "1")
+		}
+		res := NewRecorder()
+		_ = router.ServeHTTP(context.Background(), res, req, nil)
+		if res.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: expected 405, got %d", tc.path, res.Code)
+		}
+		if allow := res.Header().Get("Allow"); allow != tc.expected {
+			t.Errorf("%s: expected Allow %q, got %q", tc.path, tc.expected, allow)
+		}
+	}
+
+	// The CORS methods of a matched route include the overlapping routes.
+	res := NewRecorder()
+	_ = router.ServeHTTP(context.Background(), res, newRequest("GET", "http://localhost/users/42"), nil)
+	if methods := res.Header().Get("Access-Control-Allow-Methods"); methods != "GET,PUT,OPTIONS" {
+		t.Errorf("expected Access-Control-Allow-Methods %q, got %q", "GET,PUT,OPTIONS", methods)
+	}
+	res = NewRecorder()
+	_ = router.ServeHTTP(context.Background(), res, newRequest("GET", "http://localhost/users/abc"), nil)
+	if methods := res.Header().Get("Access-Control-Allow-Methods"); methods != "" {
+		t.Errorf("expected no Access-Control-Allow-Methods, got %q", methods)
+	}
+}
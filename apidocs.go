@@ -0,0 +1,219 @@
+package mux
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// descriptionKey, requestTypeKey and responseTypeKey are the metadata keys
+// of the route description and of the request and response body types.
+type (
+	descriptionKey  struct{}
+	requestTypeKey  struct{}
+	responseTypeKey struct{}
+)
+
+// bodyType is the type of a request or response body, and the value it was
+// given as.
+type bodyType struct {
+	typ   reflect.Type
+	value any
+}
+
+// Describe sets the description of the route in the API documentation, see
+// Router.WriteDocs.
+func (r *Route) Describe(description string) *Route {
+	return r.Metadata(descriptionKey{}, description)
+}
+
+// RequestType sets the type of the request body decoded by the route
+// handler, given as a value of the type, for the API documentation. Unless
+// the value is the zero value it serves as the example request, see
+// RouteExample:
+//
+//	r.HandleFunc("/users", createUser).Methods(http.MethodPost).
+//	    Describe("Creates a user.").
+//	    RequestType(CreateUserRequest{Name: "ann"}).
+//	    ResponseType(User{})
+func (r *Route) RequestType(v any) *Route {
+	return r.Metadata(requestTypeKey{}, bodyType{typ: reflect.TypeOf(v), value: v})
+}
+
+// ResponseType sets the type of the response body written by the route
+// handler, like RequestType.
+func (r *Route) ResponseType(v any) *Route {
+	return r.Metadata(responseTypeKey{}, bodyType{typ: reflect.TypeOf(v), value: v})
+}
+
+// DocsFormat is the format of API documentation.
+type DocsFormat int
+
+const (
+	// DocsMarkdown is Markdown.
+	DocsMarkdown DocsFormat = iota
+	// DocsHTML is an HTML page.
+	DocsHTML
+)
+
+// DocsOptions configures Router.WriteDocs.
+type DocsOptions struct {
+	// Title is the title of the documentation. It defaults to "API".
+	Title  string
+	Format DocsFormat
+	// BaseURL is the URL of the service in the examples, see RouteExample.
+	// It defaults to "http://localhost".
+	BaseURL string
+}
+
+// routeDoc is the documentation of a route.
+type routeDoc struct {
+	Methods     string
+	Host        string
+	Path        string
+	Name        string
+	Description string
+	Queries     []string
+	Vars        []string
+	RequestType string
+	Fields      []fieldDoc
+	Example     *RouteExample
+}
+
+// fieldDoc is a field of a request type.
+type fieldDoc struct {
+	Name string
+	Type string
+}
+
+// WriteDocs writes API documentation of the routes with a handler to w, as
+// a lighter alternative to OpenAPI for internal services. Each route is
+// documented with its methods, host, path and query templates, variables,
+// name, description set with Route.Describe, the fields of the request type
+// set with Route.RequestType, named like encoding/json names them, and an
+// example if the route has a request or response type.
+func (r *Router) WriteDocs(w io.Writer, options DocsOptions) error {
+	if options.Title == "" {
+		options.Title = "API"
+	}
+	data := struct {
+		Title  string
+		Routes []routeDoc
+	}{Title: options.Title}
+
+	err := r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		if route.handler == nil {
+			return nil
+		}
+		if _, ok := route.handler.(*Router); ok {
+			return nil
+		}
+		doc := routeDoc{Methods: "ANY", Name: route.GetName()}
+		if methods, err := route.GetMethods(); err == nil {
+			doc.Methods = strings.Join(methods, ", ")
+		}
+		doc.Host, _ = route.GetHostTemplate()
+		doc.Path, _ = route.GetPathTemplate()
+		doc.Queries, _ = route.GetQueriesTemplates()
+		doc.Vars, _ = route.GetVarNames()
+		if description, ok := route.metadata[descriptionKey{}].(string); ok {
+			doc.Description = description
+		}
+		if body, ok := route.metadata[requestTypeKey{}].(bodyType); ok && body.typ != nil {
+			doc.RequestType = body.typ.String()
+			doc.Fields = fieldDocs(body.typ)
+		}
+		doc.Example = route.example(options.BaseURL)
+		data.Routes = append(data.Routes, doc)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if options.Format == DocsHTML {
+		return htmlDocsTemplate.Execute(w, data)
+	}
+	return markdownDocsTemplate.Execute(w, data)
+}
+
+// fieldDocs returns the exported fields of the struct type typ, or of the
+// struct typ points to, with their JSON names.
+func fieldDocs(typ reflect.Type) []fieldDoc {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	var fields []fieldDoc
+	for _, f := range reflect.VisibleFields(typ) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		fields = append(fields, fieldDoc{Name: name, Type: f.Type.String()})
+	}
+	return fields
+}
+
+var markdownDocsTemplate = template.Must(template.New("docs").Parse(`# {{.Title}}
+{{range .Routes}}
+## {{.Methods}} {{.Host}}{{.Path}}
+{{if .Name}}
+Name: ` + "`{{.Name}}`" + `
+{{end}}{{if .Description}}
+{{.Description}}
+{{end}}{{if .Queries}}
+Query: {{range $i, $q := .Queries}}{{if $i}}, {{end}}` + "`{{$q}}`" + `{{end}}
+{{end}}{{if .Vars}}
+Variables: {{range $i, $v := .Vars}}{{if $i}}, {{end}}` + "`{{$v}}`" + `{{end}}
+{{end}}{{if .RequestType}}
+Request body: ` + "`{{.RequestType}}`" + `
+{{if .Fields}}
+| Field | Type |
+| --- | --- |
+{{range .Fields}}| ` + "`{{.Name}}`" + ` | ` + "`{{.Type}}`" + ` |
+{{end}}{{end}}{{end}}{{with .Example}}
+Example:
+
+` + "```sh\n{{.Curl}}\n```" + `
+{{if .Response}}
+Response:
+
+` + "```json\n{{printf \"%s\" .Response}}\n```" + `
+{{end}}{{end}}{{end}}`))
+
+var htmlDocsTemplate = htmltemplate.Must(htmltemplate.New("docs").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Routes}}<h2><code>{{.Methods}} {{.Host}}{{.Path}}</code></h2>
+{{if .Name}}<p>Name: <code>{{.Name}}</code></p>
+{{end}}{{if .Description}}<p>{{.Description}}</p>
+{{end}}{{if .Queries}}<p>Query: {{range $i, $q := .Queries}}{{if $i}}, {{end}}<code>{{$q}}</code>{{end}}</p>
+{{end}}{{if .Vars}}<p>Variables: {{range $i, $v := .Vars}}{{if $i}}, {{end}}<code>{{$v}}</code>{{end}}</p>
+{{end}}{{if .RequestType}}<p>Request body: <code>{{.RequestType}}</code></p>
+{{if .Fields}}<table border="1" cellpadding="4">
+<tr><th>Field</th><th>Type</th></tr>
+{{range .Fields}}<tr><td><code>{{.Name}}</code></td><td><code>{{.Type}}</code></td></tr>
+{{end}}</table>
+{{end}}{{end}}{{with .Example}}<p>Example:</p>
+<pre>{{.Curl}}</pre>
+{{if .Response}}<p>Response:</p>
+<pre>{{printf "%s" .Response}}</pre>
+{{end}}{{end}}{{end}}</body>
+</html>
+`))
@@ -0,0 +1,66 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type createUserRequest struct {
+	Name    string `json:"name"`
+	Email   string `json:"email,omitempty"`
+	Age     int
+	Secret  string `json:"-"`
+	private bool
+}
+
+func docsTestRouter() *Router {
+	r := NewRouter()
+	r.HandleFunc("/users", dummyHandler).Methods(http.MethodPost).Name("createUser").
+		Describe("Creates a <user>.").
+		RequestType(&createUserRequest{})
+	r.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodGet, http.MethodHead).Queries("fields", "{fields}").
+		ResponseType(map[string]int{"n": 1})
+	return r
+}
+
+func TestWriteDocsMarkdown(t *testing.T) {
+	var b strings.Builder
+	if err := docsTestRouter().WriteDocs(&b, DocsOptions{Title: "Users"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Users\n" +
+		"\n## POST /users\n" +
+		"\nName: `createUser`\n" +
+		"\nCreates a <user>.\n" +
+		"\nRequest body: `*mux.createUserRequest`\n" +
+		"\n| Field | Type |\n| --- | --- |\n| `name` | `string` |\n| `email` | `string` |\n| `Age` | `int` |\n" +
+		"\nExample:\n\n```sh\ncurl -X POST 'http://localhost/users' -H 'Content-Type: application/json' -d '{\n  \"name\": \"string\",\n  \"email\": \"string\",\n  \"Age\": 1\n}'\n```\n" +
+		"\n## GET, HEAD /users/{id}\n" +
+		"\nQuery: `fields={fields}`\n" +
+		"\nVariables: `id`, `fields`\n" +
+		"\nExample:\n\n```sh\ncurl 'http://localhost/users/{id}?fields={fields}'\n```\n" +
+		"\nResponse:\n\n```json\n{\n  \"n\": 1\n}\n```\n"
+	if b.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, b.String())
+	}
+}
+
+func TestWriteDocsHTML(t *testing.T) {
+	var b strings.Builder
+	if err := docsTestRouter().WriteDocs(&b, DocsOptions{Format: DocsHTML}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<title>API</title>",
+		"<h2><code>POST /users</code></h2>",
+		"<p>Creates a &lt;user&gt;.</p>",
+		"<tr><td><code>email</code></td><td><code>string</code></td></tr>",
+		"<p>Variables: <code>id</code>, <code>fields</code></p>",
+		"<pre>curl &#39;http://localhost/users/{id}?fields={fields}&#39;</pre>",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("expected %q in\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Audit outcomes reported in AuditEvent.Outcome.
+const (
+	// AuditOutcomeSuccess is reported for responses with a status below 400.
+	AuditOutcomeSuccess = "success"
+	// AuditOutcomeFailure is reported for responses with a 4xx status.
+	AuditOutcomeFailure = "failure"
+	// AuditOutcomeError is reported for 5xx responses and handler errors.
+	AuditOutcomeError = "error"
+)
+
+// AuditEvent describes a single request handled by the router.
+type AuditEvent struct {
+	Time       time.Time           `json:"time"`
+	Actor      string              `json:"actor,omitempty"`
+	Route      string              `json:"route,omitempty"`
+	Template   string              `json:"template,omitempty"`
+	Method     string              `json:"method"`
+	Path       string              `json:"path"`
+	Vars       map[string]string   `json:"vars,omitempty"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+	RemoteAddr string              `json:"remote_addr,omitempty"`
+	Status     int                 `json:"status"`
+	Outcome    string              `json:"outcome"`
+	Error      string              `json:"error,omitempty"`
+	Duration   time.Duration       `json:"duration_ns"`
+	Fields     map[string]any      `json:"fields,omitempty"`
+}
+
+// AuditSink receives audit events, e.g. to ship them to a SIEM system.
+// Implementations must be safe for concurrent use.
+type AuditSink interface {
+	Audit(ctx context.Context, event *AuditEvent) error
+}
+
+// AuditSinkFunc adapts a function to the AuditSink interface.
+type AuditSinkFunc func(ctx context.Context, event *AuditEvent) error
+
+// Audit implements AuditSink.
+func (f AuditSinkFunc) Audit(ctx context.Context, event *AuditEvent) error {
+	return f(ctx, event)
+}
+
+// jsonAuditSink writes events as JSON lines.
+type jsonAuditSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONAuditSink returns an AuditSink writing one JSON document per event
+// to w.
+func NewJSONAuditSink(w io.Writer) AuditSink {
+	return &jsonAuditSink{enc: json.NewEncoder(w)}
+}
+
+func (s *jsonAuditSink) Audit(ctx context.Context, event *AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(event)
+}
+
+// AuditOptions configures AuditMiddleware.
+type AuditOptions struct {
+	// Sink receives all events. It is required.
+	Sink AuditSink
+	// Actor extracts the acting principal from the request. It is called
+	// after the handler returned. Actors set with SetAuditActor take
+	// precedence.
+	Actor func(ctx context.Context, r *http.Request) string
+	// Headers lists the request headers copied into events.
+	Headers []string
+	// RedactVars lists route variables whose values are replaced in events.
+	RedactVars []string
+	// RedactHeaders lists headers whose values are replaced in events.
+	// Authorization and Cookie are always redacted.
+	RedactHeaders []string
+	// ErrorHandler is called when the sink fails. Sink errors are never
+	// returned to the client. If it is nil, sink errors are logged with the
+	// router's Logger.
+	ErrorHandler func(ctx context.Context, err error)
+}
+
+// auditRecord collects data contributed by handlers during a request.
+type auditRecord struct {
+	mu     sync.Mutex
+	actor  string
+	fields map[string]any
+}
+
+// SetAuditActor sets the actor reported in the audit event of the current
+// request. It is meant to be called by authentication middlewares or
+// handlers running inside an AuditMiddleware, and is a no-op otherwise.
+func SetAuditActor(ctx context.Context, actor string) {
+	if rec, ok := ctx.Value(auditKey).(*auditRecord); ok {
+		rec.mu.Lock()
+		rec.actor = actor
+		rec.mu.Unlock()
+	}
+}
+
+// AddAuditField adds a custom field to the audit event of the current
+// request. It is a no-op outside of an AuditMiddleware.
+func AddAuditField(ctx context.Context, key string, value any) {
+	if rec, ok := ctx.Value(auditKey).(*auditRecord); ok {
+		rec.mu.Lock()
+		if rec.fields == nil {
+			rec.fields = make(map[string]any)
+		}
+		rec.fields[key] = value
+		rec.mu.Unlock()
+	}
+}
+
+// AuditMiddleware emits an AuditEvent for every request to the configured
+// sink. It has to be registered with Router.Use or Route.Use so the matched
+// route and its variables are known.
+func AuditMiddleware(options AuditOptions) MiddlewareFunc {
+	redactVars := make(map[string]bool, len(options.RedactVars))
+	for _, v := range options.RedactVars {
+		redactVars[v] = true
+	}
+	redactHeaders := map[string]bool{"Authorization": true, "Cookie": true}
+	for _, h := range options.RedactHeaders {
+		redactHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+			rec := new(auditRecord)
+			ctx = withRouterValue(ctx, auditKey, rec)
+			req = req.WithContext(withRouterValue(req.Context(), auditKey, rec))
+
+			rw := NewResponseRecorderWriter(w)
+			start := time.Now()
+			err := next(ctx, rw, req, binder)
+
+			event := &AuditEvent{
+				Time:       start,
+				Method:     req.Method,
+				Path:       req.URL.Path,
+				RemoteAddr: req.RemoteAddr,
+				Status:     rw.StatusOrDefault(err),
+				Duration:   time.Since(start),
+			}
+
+			if route := CurrentRoute(req); route != nil {
+				event.Route = route.GetName()
+				event.Template, _ = route.GetPathTemplate()
+			}
+
+			if vars := Vars(req); len(vars) > 0 {
+				event.Vars = make(map[string]string, len(vars))
+				for k, v := range vars {
+					if redactVars[k] {
+						v = redactedValue
+					}
+					event.Vars[k] = v
+				}
+			}
+
+			for _, h := range options.Headers {
+				h = http.CanonicalHeaderKey(h)
+				values := req.Header.Values(h)
+				if len(values) == 0 {
+					continue
+				}
+				if event.Headers == nil {
+					event.Headers = make(map[string][]string)
+				}
+				if redactHeaders[h] {
+					values = []string{redactedValue}
+				}
+				event.Headers[h] = values
+			}
+
+			switch {
+			case err != nil || event.Status >= http.StatusInternalServerError:
+				event.Outcome = AuditOutcomeError
+			case event.Status >= http.StatusBadRequest:
+				event.Outcome = AuditOutcomeFailure
+			default:
+				event.Outcome = AuditOutcomeSuccess
+			}
+			if err != nil {
+				event.Error = err.Error()
+			}
+
+			rec.mu.Lock()
+			event.Actor = rec.actor
+			event.Fields = rec.fields
+			rec.mu.Unlock()
+			if event.Actor == "" && options.Actor != nil {
+				event.Actor = options.Actor(ctx, req)
+			}
+
+			if sinkErr := options.Sink.Audit(ctx, event); sinkErr != nil {
+				if options.ErrorHandler != nil {
+					options.ErrorHandler(ctx, sinkErr)
+				} else {
+					requestLogger(req).Log(ctx, slog.LevelError, "mux: audit sink failed", "error", sinkErr)
+				}
+			}
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	var events []*AuditEvent
+	var sinkErrs []error
+
+	router := NewRouter()
+	router.Use(AuditMiddleware(AuditOptions{
+		Sink: AuditSinkFunc(func(ctx context.Context, event *AuditEvent) error {
+			events = append(events, event)
+			if event.Outcome == AuditOutcomeError {
+				return errors.New("sink down")
+			}
+			return nil
+		}),
+		Actor:         func(ctx context.Context, r *http.Request) string { return "anonymous" },
+		Headers:       []string{"X-Request-Id", "X-Api-Key"},
+		RedactVars:    []string{"token"},
+		RedactHeaders: []string{"x-api-key"},
+		ErrorHandler:  func(ctx context.Context, err error) { sinkErrs = append(sinkErrs, err) },
+	}))
+	router.HandleFunc("/reset/{user}/{token}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		SetAuditActor(ctx, "admin")
+		AddAuditField(ctx, "reason", "forgotten")
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}).Name("reset")
+	router.HandleFunc("/fail", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		return errors.New("boom")
+	})
+
+	req := newRequestWithHeaders("POST", "http://localhost/reset/bob/s3cr3t", "X-Request-Id", "abc", "X-Api-Key", "key")
+	if err := router.ServeHTTP(context.Background(), NewRecorder(), req, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/fail"), nil); err == nil {
+		t.Fatal("expected handler error")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+
+	event := events[0]
+	if event.Actor != "admin" || event.Route != "reset" || event.Template != "/reset/{user}/{token}" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if event.Vars["user"] != "bob" || event.Vars["token"] != redactedValue {
+		t.Errorf("unexpected vars %v", event.Vars)
+	}
+	if event.Headers["X-Request-Id"][0] != "abc" || event.Headers["X-Api-Key"][0] != redactedValue {
+		t.Errorf("unexpected headers %v", event.Headers)
+	}
+	if event.Status != http.StatusNoContent || event.Outcome != AuditOutcomeSuccess || event.Fields["reason"] != "forgotten" {
+		t.Errorf("unexpected outcome %+v", event)
+	}
+
+	if event := events[1]; event.Actor != "anonymous" || event.Outcome != AuditOutcomeError || event.Error != "boom" {
+		t.Errorf("unexpected error event %+v", event)
+	}
+	if len(sinkErrs) != 1 {
+		t.Errorf("expected sink error to be reported, got %v", sinkErrs)
+	}
+}
+
+func TestJSONAuditSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONAuditSink(&buf)
+	if err := sink.Audit(context.Background(), &AuditEvent{Method: "GET", Path: "/", Outcome: AuditOutcomeSuccess}); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["outcome"] != AuditOutcomeSuccess || decoded["method"] != "GET" {
+		t.Errorf("unexpected JSON %s", buf.String())
+	}
+}
@@ -6,6 +6,7 @@ package mux
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -80,3 +81,103 @@ func BenchmarkManyPathVariables(b *testing.B) {
 		router.ServeHTTP(context.Background(), recorder, notMatchingRequest, nil)
 	}
 }
+
+func BenchmarkRouteIndex(b *testing.B) {
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error { return nil }
+	for _, index := range []bool{false, true} {
+		router := NewRouter().RouteIndex(index)
+		for i := 0; i < 1500; i++ {
+			router.HandleFunc(fmt.Sprintf("/resource%d/{id}", i), handler).Methods("GET")
+		}
+		request, _ := http.NewRequest("GET", "/resource1499/42", nil)
+
+		b.Run(fmt.Sprintf("index=%v", index), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				router.ServeHTTP(context.Background(), nil, request, nil)
+			}
+		})
+	}
+}
+
+func BenchmarkMuxNotFound(b *testing.B) {
+	router := new(Router)
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error { return nil }
+	router.HandleFunc("/v1/{v1}", handler)
+	router.NotFoundHandler = HandlerFunc(handler)
+
+	request, _ := http.NewRequest("GET", "/v2/anything", nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(context.Background(), nil, request, nil)
+	}
+}
+
+func BenchmarkRouteIndexHosts(b *testing.B) {
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error { return nil }
+	for _, index := range []bool{false, true} {
+		router := NewRouter().RouteIndex(index)
+		for i := 0; i < 2000; i++ {
+			router.Host(fmt.Sprintf("tenant%d.example.com", i)).Path("/items/{id}").HandlerFunc(handler)
+		}
+		request, _ := http.NewRequest("GET", "http://tenant1999.example.com/items/42", nil)
+
+		b.Run(fmt.Sprintf("index=%v", index), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				router.ServeHTTP(context.Background(), nil, request, nil)
+			}
+		})
+	}
+}
+
+func BenchmarkVarsMiddlewares(b *testing.B) {
+	router := NewRouter()
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		_ = Vars(r)["id"]
+		return nil
+	}
+	router.HandleFunc("/users/{id}/posts/{post}", handler)
+	for i := 0; i < 5; i++ {
+		router.Use(func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+				_ = Var(r, "id")
+				return next(ctx, w, r, binder)
+			}
+		})
+	}
+
+	request, _ := http.NewRequest("GET", "/users/1/posts/2", nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(context.Background(), nil, request, nil)
+	}
+}
+
+func BenchmarkHeaderMatcher(b *testing.B) {
+	route := new(Route).Headers("content-type", "application/json", "x-requested-with", "")
+	request := newRequestWithHeaders("GET", "http://localhost/", "Content-Type", "application/json", "X-Requested-With", "XMLHttpRequest")
+	var match RouteMatch
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		route.Match(request, &match)
+	}
+}
+
+func BenchmarkMethodRoutes(b *testing.B) {
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error { return nil }
+	router := NewRouter()
+	for i := 0; i < 25; i++ {
+		for _, method := range []string{"GET", "PUT", "PATCH", "DELETE"} {
+			router.HandleFunc(fmt.Sprintf("/resource%d/{id:[0-9]+}", i), handler).Methods(method)
+		}
+	}
+
+	// Only the first routes match the path, the request is answered with
+	// 405 Method Not Allowed.
+	request, _ := http.NewRequest("POST", "/resource0/42", nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(context.Background(), NewRecorder(), request, nil)
+	}
+}
@@ -0,0 +1,97 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// RouteSpec describes a route registered by Router.HandleAll.
+type RouteSpec struct {
+	// Name is the optional route name, see Route.Name.
+	Name string
+	// Host is the optional host template, see Route.Host.
+	Host string
+	// Path is the path template, see Route.Path. If it is empty, PathPrefix
+	// is used instead, if set.
+	Path       string
+	PathPrefix string
+	// Methods, Schemes, Queries and Headers add the matchers of the
+	// respective Route methods if set. Queries and Headers hold key/value
+	// pairs.
+	Methods []string
+	Schemes []string
+	Queries []string
+	Headers []string
+	// Metadata is set with Route.Metadata.
+	Metadata map[any]any
+	Handler  Handler
+}
+
+// HandleAll registers the routes described by specs in one pass. The routes
+// are added at once, like by Update, so that the route index is rebuilt
+// once instead of after each route. This suits routers loading many routes
+// at startup.
+//
+// If a spec is invalid, no route is registered and the errors of all invalid
+// specs are returned.
+func (r *Router) HandleAll(specs []RouteSpec) error {
+	r.generations()
+	staging := &Router{routeConf: copyRouteConf(r.routeConf), namedRoutes: make(map[string]*Route)}
+	var errs []error
+	for i, spec := range specs {
+		if err := staging.handleSpec(spec).GetError(); err != nil {
+			errs = append(errs, fmt.Errorf("mux: route %d: %w", i, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	routes := staging.getRoutes()
+	for _, route := range routes {
+		route.namedRoutes = r.namedRoutes
+	}
+	maps.Copy(r.namedRoutes, staging.namedRoutes)
+	r.addRoutes(routes...)
+	return nil
+}
+
+// handleSpec registers the route described by spec.
+func (r *Router) handleSpec(spec RouteSpec) *Route {
+	route := r.NewRoute()
+	if spec.Host != "" {
+		route.Host(spec.Host)
+	}
+	switch {
+	case spec.Path != "":
+		route.Path(spec.Path)
+	case spec.PathPrefix != "":
+		route.PathPrefix(spec.PathPrefix)
+	}
+	// The matchers normalize their arguments in place.
+	if len(spec.Methods) > 0 {
+		route.Methods(slices.Clone(spec.Methods)...)
+	}
+	if len(spec.Schemes) > 0 {
+		route.Schemes(slices.Clone(spec.Schemes)...)
+	}
+	if len(spec.Queries) > 0 {
+		route.Queries(spec.Queries...)
+	}
+	if len(spec.Headers) > 0 {
+		route.Headers(spec.Headers...)
+	}
+	for k, v := range spec.Metadata {
+		route.Metadata(k, v)
+	}
+	if spec.Handler == nil {
+		route.setErr(errors.New("mux: route has no handler"))
+	}
+	route.Handler(spec.Handler)
+	if spec.Name != "" {
+		route.Name(spec.Name)
+	}
+	return route
+}
@@ -0,0 +1,75 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHandleAll(t *testing.T) {
+	router := NewRouter()
+	methods := []string{"get"}
+	err := router.HandleAll([]RouteSpec{
+		{Name: "user", Path: "/users/{id:[0-9]+}", Methods: methods, Handler: HandlerFunc(dummyHandler)},
+		{Host: "{sub}.example.com", PathPrefix: "/static/", Handler: HandlerFunc(dummyHandler)},
+		{Path: "/search", Queries: []string{"q", "{q}"}, Headers: []string{"Accept", "application/json"}, Metadata: map[any]any{"auth": true}, Handler: HandlerFunc(dummyHandler)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if methods[0] != "get" {
+		t.Error("expected the methods of the spec not to be modified")
+	}
+	if len(router.getRoutes()) != 3 {
+		t.Fatalf("expected 3 routes, got %d", len(router.getRoutes()))
+	}
+
+	route := router.Get("user")
+	if route == nil {
+		t.Fatal("expected the named route")
+	}
+	if u, err := route.URL("id", "42"); err != nil || u.String() != "/users/42" {
+		t.Errorf("expected /users/42, got %v, %v", u, err)
+	}
+
+	for _, tc := range []struct {
+		req      *http.Request
+		expected bool
+	}{
+		{newRequest("GET", "http://localhost/users/42"), true},
+		{newRequest("POST", "http://localhost/users/42"), false},
+		{newRequest("GET", "http://cdn.example.com/static/app.js"), true},
+		{newRequestWithHeaders("GET", "http://localhost/search?q=mux", "Accept", "application/json"), true},
+		{newRequest("GET", "http://localhost/search?q=mux"), false},
+	} {
+		var match RouteMatch
+		if matched := router.Match(tc.req, &match); matched != tc.expected {
+			t.Errorf("%s %s: expected %v, got %v", tc.req.Method, tc.req.URL, tc.expected, matched)
+		}
+	}
+	router.Name("other")
+	if route.namedRoutes["other"] == nil {
+		t.Error("expected the route to share the named routes of the router")
+	}
+}
+
+func TestHandleAllErrors(t *testing.T) {
+	router := NewRouter()
+	router.SetLogger(NopLogger())
+	err := router.HandleAll([]RouteSpec{
+		{Name: "ok", Path: "/ok", Handler: HandlerFunc(dummyHandler)},
+		{Path: "/{id:[}", Handler: HandlerFunc(dummyHandler)},
+		{Path: "/missing"},
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, expected := range []string{"mux: route 1:", "mux: route 2: mux: route has no handler"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %q", expected, err)
+		}
+	}
+	if len(router.getRoutes()) != 0 || router.Get("ok") != nil {
+		t.Error("expected no route to be registered")
+	}
+}
@@ -0,0 +1,64 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/cgi"
+	"net/http/fcgi"
+	"os/signal"
+)
+
+// ServeFastCGI serves router over FastCGI on addr until ctx is cancelled or
+// one of the shutdown signals is received. See Listen for the supported
+// address forms; an empty addr serves the listener the web server passed on
+// standard input, as for processes spawned by it. It returns nil once
+// stopped, and otherwise the error which stopped serving.
+//
+// The binder and error handler options of Serve apply. FastCGI has no
+// graceful shutdown: stopping closes the listener, and the requests in
+// flight are abandoned with their connections.
+func ServeFastCGI(ctx context.Context, addr string, router *Router, opts ...ServerOption) error {
+	config := newServerConfig(router, opts)
+	var ln net.Listener
+	if addr != "" {
+		var err error
+		if ln, err = Listen(addr); err != nil {
+			return err
+		}
+		if config.onListen != nil {
+			config.onListen(ln.Addr())
+		}
+	}
+	if len(config.signals) > 0 {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, config.signals...)
+		defer stop()
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- fcgi.Serve(ln, config.handler(router))
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	if ln == nil {
+		// The inherited listener is closed when the process exits.
+		return nil
+	}
+	ln.Close()
+	if err := <-errc; err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// ServeCGI serves the single request of a CGI invocation with router, see
+// cgi.Serve. The binder and error handler options of Serve apply.
+func ServeCGI(router *Router, opts ...ServerOption) error {
+	config := newServerConfig(router, opts)
+	return cgi.Serve(config.handler(router))
+}
@@ -0,0 +1,124 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func cgiTestRouter() *Router {
+	router := NewRouter()
+	router.HandleFunc("/users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		if Var(r, "id") == "0" {
+			return &FileError{Status: http.StatusNotFound, Err: errors.New("no user")}
+		}
+		fmt.Fprintf(w, "user %s", Var(r, "id"))
+		return nil
+	})
+	return router
+}
+
+// fcgiRequest sends a FastCGI request with params to conn and returns the
+// standard output of the response.
+func fcgiRequest(t *testing.T, conn net.Conn, params map[string]string) string {
+	t.Helper()
+	record := func(typ byte, content []byte) {
+		h := []byte{1, typ, 0, 1, 0, 0, 0, 0}
+		binary.BigEndian.PutUint16(h[4:], uint16(len(content)))
+		if _, err := conn.Write(append(h, content...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record(1, []byte{0, 1, 0, 0, 0, 0, 0, 0}) // begin request, responder role
+	var p bytes.Buffer
+	for name, value := range params {
+		p.WriteByte(byte(len(name)))
+		p.WriteByte(byte(len(value)))
+		p.WriteString(name + value)
+	}
+	record(4, p.Bytes()) // params
+	record(4, nil)
+	record(5, nil) // stdin
+
+	var stdout bytes.Buffer
+	for {
+		h := make([]byte, 8)
+		if _, err := io.ReadFull(conn, h); err != nil {
+			t.Fatal(err)
+		}
+		content := make([]byte, int(binary.BigEndian.Uint16(h[4:]))+int(h[6]))
+		if _, err := io.ReadFull(conn, content); err != nil {
+			t.Fatal(err)
+		}
+		switch h[1] {
+		case 6: // stdout
+			stdout.Write(content[:binary.BigEndian.Uint16(h[4:])])
+		case 3: // end request
+			return stdout.String()
+		}
+	}
+}
+
+func TestServeFastCGI(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	addrc := make(chan net.Addr, 1)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- ServeFastCGI(ctx, "127.0.0.1:0", cgiTestRouter(), WithOnListen(func(addr net.Addr) { addrc <- addr }))
+	}()
+	addr := <-addrc
+
+	for path, want := range map[string]string{"/users/42": "user 42", "/users/0": "Status: 404"} {
+		conn, err := net.Dial("tcp", addr.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := fcgiRequest(t, conn, map[string]string{
+			"REQUEST_METHOD":  "GET",
+			"SERVER_PROTOCOL": "HTTP/1.1",
+			"REQUEST_URI":     path,
+			"HTTP_HOST":       "localhost",
+		})
+		conn.Close()
+		if !strings.Contains(out, want) {
+			t.Errorf("%s: expected %q in the response, got %q", path, want, out)
+		}
+	}
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("expected a clean stop, got %v", err)
+	}
+}
+
+func TestServeCGI(t *testing.T) {
+	t.Setenv("REQUEST_METHOD", "GET")
+	t.Setenv("SERVER_PROTOCOL", "HTTP/1.1")
+	t.Setenv("REQUEST_URI", "/users/7")
+	t.Setenv("HTTP_HOST", "localhost")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	err = ServeCGI(cgiTestRouter())
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := io.ReadAll(r)
+	if !strings.Contains(string(out), "Status: 200") || !strings.HasSuffix(string(out), "user 7") {
+		t.Errorf("unexpected CGI response %q", out)
+	}
+}
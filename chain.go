@@ -0,0 +1,88 @@
+package mux
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// treeGenerations counts the changes to a router tree. The routers and
+// routes of a tree share it, so that a change only invalidates what was
+// computed for the same tree.
+type treeGenerations struct {
+	// routes is incremented when a route or matcher is added, invalidating
+	// the allowed methods and the route orders computed before.
+	routes atomic.Uint64
+	// middlewares is incremented when a handler or middleware is set,
+	// invalidating the chains composed before.
+	middlewares atomic.Uint64
+}
+
+// generations returns the generations of the router tree, allocating them
+// for a router or route created without NewRouter. It must only be called
+// while configuring routes.
+func (c *routeConf) generations() *treeGenerations {
+	if c.tree == nil {
+		c.tree = new(treeGenerations)
+	}
+	return c.tree
+}
+
+// loadRoutes returns the routes generation, 0 if nothing was configured.
+func (g *treeGenerations) loadRoutes() uint64 {
+	if g == nil {
+		return 0
+	}
+	return g.routes.Load()
+}
+
+// loadMiddlewares returns the middlewares generation, 0 if nothing was
+// configured.
+func (g *treeGenerations) loadMiddlewares() uint64 {
+	if g == nil {
+		return 0
+	}
+	return g.middlewares.Load()
+}
+
+// middlewareChain is a route handler composed with the route middlewares and
+// the middlewares of the routers it was matched through, so that requests do
+// not wrap the handler again.
+type middlewareChain struct {
+	generation uint64
+	handler    HandlerFunc
+	// route is the route whose handler is composed.
+	route *Route
+	// wrapped holds the chain wrapped in the middlewares of an enclosing
+	// router, by *Router.
+	wrapped sync.Map
+}
+
+// handlerChain returns the route handler wrapped in the route middlewares,
+// composing it if a handler or middleware was set since it was last composed.
+func (r *Route) handlerChain() *middlewareChain {
+	generation := r.tree.loadMiddlewares()
+	if c := r.chain.Load(); c != nil && c.generation == generation {
+		return c
+	}
+	c := &middlewareChain{generation: generation, handler: r.GetHandlerWithMiddlewares(), route: r}
+	r.chain.Store(c)
+	return c
+}
+
+// wrap returns c wrapped in the middlewares of router.
+func (c *middlewareChain) wrap(router *Router) *middlewareChain {
+	if len(router.middlewares) == 0 {
+		return c
+	}
+	if w, ok := c.wrapped.Load(router); ok {
+		return w.(*middlewareChain)
+	}
+	var handler Handler = c.handler
+	for i := len(router.middlewares) - 1; i >= 0; i-- {
+		if mw := router.middlewares[i]; appliesTo(mw, c.route) {
+			handler = mw.Middleware(HandlerToHandlerFunc(handler))
+		}
+	}
+	w, _ := c.wrapped.LoadOrStore(router, &middlewareChain{generation: c.generation, handler: HandlerToHandlerFunc(handler), route: c.route})
+	return w.(*middlewareChain)
+}
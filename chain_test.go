@@ -0,0 +1,128 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// countingMiddleware counts how often it wraps a handler.
+type countingMiddleware struct {
+	wrapped int
+	name    string
+	order   *[]string
+}
+
+func (m *countingMiddleware) Middleware(next HandlerFunc) HandlerFunc {
+	m.wrapped++
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		*m.order = append(*m.order, m.name)
+		return next(ctx, w, r, binder)
+	}
+}
+
+func TestMiddlewareChainComposedOnce(t *testing.T) {
+	var order []string
+	outer := &countingMiddleware{name: "outer", order: &order}
+	inner := &countingMiddleware{name: "inner", order: &order}
+	route := &countingMiddleware{name: "route", order: &order}
+
+	router := NewRouter()
+	router.Use(outer.Middleware)
+	sub := router.PathPrefix("/api").Subrouter()
+	sub.Use(inner.Middleware)
+	sub.HandleFunc("/items", dummyHandler).Use(route.Middleware)
+
+	for i := 0; i < 3; i++ {
+		if err := router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/api/items"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, m := range []*countingMiddleware{outer, inner, route} {
+		if m.wrapped != 1 {
+			t.Errorf("expected the %s middleware to wrap the handler once, got %d", m.name, m.wrapped)
+		}
+	}
+	if len(order) != 9 || order[0] != "outer" || order[1] != "inner" || order[2] != "route" {
+		t.Errorf("unexpected middleware order %v", order)
+	}
+}
+
+func TestMiddlewareChainRecomposed(t *testing.T) {
+	var order []string
+	first := &countingMiddleware{name: "first", order: &order}
+	second := &countingMiddleware{name: "second", order: &order}
+
+	router := NewRouter()
+	router.Use(first.Middleware)
+	route := router.HandleFunc("/", dummyHandler)
+
+	serve := func() {
+		order = nil
+		if err := router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	serve()
+	router.Use(second.Middleware)
+	serve()
+	if len(order) != 2 || order[1] != "second" {
+		t.Errorf("expected the added middleware to run, got %v", order)
+	}
+
+	var called bool
+	route.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		called = true
+		return nil
+	})
+	serve()
+	if !called {
+		t.Error("expected the new handler to be called")
+	}
+	if first.wrapped != 3 || second.wrapped != 2 {
+		t.Errorf("expected the chain to be composed on changes only, got %d and %d", first.wrapped, second.wrapped)
+	}
+}
+
+func TestMiddlewareChainPerTree(t *testing.T) {
+	var order []string
+	mw := &countingMiddleware{name: "mw", order: &order}
+
+	router := NewRouter()
+	router.Use(mw.Middleware)
+	sub := router.PathPrefix("/api").Subrouter()
+	sub.HandleFunc("/items", dummyHandler).Methods("GET")
+	serve := func() {
+		if err := router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/api/items"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	serve()
+	allowed := router.loadAllowedMethods()
+
+	// Changes to another router tree leave the chains and the allowed
+	// methods of this one alone.
+	other := NewRouter()
+	other.Use(mw.Middleware)
+	other.HandleFunc("/", dummyHandler).Use(mw.Middleware)
+	serve()
+	if mw.wrapped != 1 {
+		t.Errorf("expected the chain to be composed once, got %d", mw.wrapped)
+	}
+	if router.loadAllowedMethods() != allowed {
+		t.Error("expected the allowed methods to be kept")
+	}
+
+	// Changes to a subrouter invalidate the tree.
+	sub.HandleFunc("/items", dummyHandler).Methods("POST")
+	sub.Use(mw.Middleware)
+	serve()
+	if mw.wrapped != 3 {
+		t.Errorf("expected the chain to be composed again, got %d wraps", mw.wrapped)
+	}
+	if router.loadAllowedMethods() == allowed {
+		t.Error("expected the allowed methods to be computed again")
+	}
+}
@@ -0,0 +1,72 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	// reference is the original implementation of cleanPath.
+	reference := func(p string) string {
+		if p == "" {
+			return "/"
+		}
+		if p[0] != '/' {
+			p = "/" + p
+		}
+		np := path.Clean(p)
+		if p[len(p)-1] == '/' && np != "/" {
+			np += "/"
+		}
+		return np
+	}
+	for _, p := range []string{
+		"", "/", "//", "a", "a/", "/a", "/a/", "/a//", "/a/b", "/a/b/",
+		"/.", "/..", "/./", "/../", "/a/.", "/a/..", "/a/./b", "/a/../b",
+		"/a/.b", "/a/..b", "/a/b.", "/.a", "/...", "/a//b", "//a", "/a/b/./",
+	} {
+		if got, expected := cleanPath(p), reference(p); got != expected {
+			t.Errorf("cleanPath(%q): expected %q, got %q", p, expected, got)
+		}
+		if isCleanPath(p) != (reference(p) == p) {
+			t.Errorf("isCleanPath(%q) = %v", p, isCleanPath(p))
+		}
+	}
+}
+
+func TestCleanPathAllocs(t *testing.T) {
+	for _, p := range []string{"/", "/users/42", "/users/42/"} {
+		if n := testing.AllocsPerRun(100, func() { _ = cleanPath(p) }); n != 0 {
+			t.Errorf("cleanPath(%q): expected no allocations, got %v", p, n)
+		}
+	}
+}
+
+func TestSetPathCleaner(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/users/", dummyHandler)
+	r.SetPathCleaner(strings.ToLower)
+
+	res := NewRecorder()
+	_ = r.ServeHTTP(context.Background(), res, newRequest(http.MethodGet, "http://localhost/USERS/"), nil)
+	if res.Code != http.StatusMovedPermanently || res.Header().Get("Location") != "http://localhost/users/" {
+		t.Errorf("expected redirect to /users/, got %d %q", res.Code, res.Header().Get("Location"))
+	}
+
+	// The custom cleaner keeps repeated slashes.
+	res = NewRecorder()
+	_ = r.ServeHTTP(context.Background(), res, newRequest(http.MethodGet, "http://localhost//users/"), nil)
+	if res.Code == http.StatusMovedPermanently {
+		t.Errorf("unexpected redirect to %q", res.Header().Get("Location"))
+	}
+
+	r.SetPathCleaner(nil)
+	res = NewRecorder()
+	_ = r.ServeHTTP(context.Background(), res, newRequest(http.MethodGet, "http://localhost//users/"), nil)
+	if res.Code != http.StatusMovedPermanently || res.Header().Get("Location") != "http://localhost/users/" {
+		t.Errorf("expected redirect to /users/, got %d %q", res.Code, res.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,319 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// BodyBinder is implemented by Binders which decode request bodies. Helpers
+// which decode bodies, such as CloudEventHandler, use the binder passed to
+// the router if it implements BodyBinder.
+type BodyBinder interface {
+	Bind(r *http.Request, v any) error
+}
+
+// CloudEvent is an event of the CloudEvents specification, version 1.0, with
+// its data decoded into T.
+type CloudEvent[T any] struct {
+	ID              string
+	Source          string
+	SpecVersion     string
+	Type            string
+	Subject         string
+	Time            time.Time
+	DataContentType string
+	DataSchema      string
+	// Extensions holds the extension attributes.
+	Extensions map[string]string
+	Data       T
+}
+
+// CloudEventHandler returns a handler for CloudEvents delivered over HTTP in
+// binary or structured content mode, decoding the event data into T. The
+// data is decoded by the binder passed to the router if it implements
+// BodyBinder, with a request whose body is the data and whose Content-Type is
+// the datacontenttype of the event. Otherwise data of type []byte or string
+// is passed as is, and JSON data is decoded with encoding/json.
+//
+// Invalid events result in an error with status 400, see ErrorStatus, and
+// data which can't be decoded in an error with status 415. Routes can match
+// on the event attributes with Route.CloudEventTypes and
+// Route.CloudEventSources:
+//
+//	r.Handle("/events", mux.CloudEventHandler(onOrderCreated)).
+//	    Methods(http.MethodPost).
+//	    CloudEventTypes("com.example.order.created")
+func CloudEventHandler[T any](handler func(ctx context.Context, w http.ResponseWriter, event *CloudEvent[T]) error) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		e, err := parseCloudEvent(req)
+		if err != nil {
+			return err
+		}
+		event := &CloudEvent[T]{
+			ID:              e.id,
+			Source:          e.source,
+			SpecVersion:     e.specVersion,
+			Type:            e.typ,
+			Subject:         e.subject,
+			Time:            e.time,
+			DataContentType: e.dataContentType,
+			DataSchema:      e.dataSchema,
+			Extensions:      e.extensions,
+		}
+		if err := bindCloudEventData(req, binder, e, &event.Data); err != nil {
+			return err
+		}
+		return handler(ctx, w, event)
+	}
+}
+
+// CloudEventTypes adds a matcher for the type attribute of CloudEvents. A
+// type ending with "*" matches the types starting with the text before it.
+func (r *Route) CloudEventTypes(types ...string) *Route {
+	return r.addMatcher(cloudEventMatcher{attr: func(e *cloudEvent) string { return e.typ }, values: types})
+}
+
+// CloudEventSources adds a matcher for the source attribute of CloudEvents.
+// A source ending with "*" matches the sources starting with the text before
+// it.
+func (r *Route) CloudEventSources(sources ...string) *Route {
+	return r.addMatcher(cloudEventMatcher{attr: func(e *cloudEvent) string { return e.source }, values: sources})
+}
+
+// cloudEventMatcher matches an attribute of the CloudEvent of a request.
+type cloudEventMatcher struct {
+	attr   func(e *cloudEvent) string
+	values []string
+}
+
+func (m cloudEventMatcher) Match(req *http.Request, match *RouteMatch) bool {
+	e, err := parseCloudEvent(req)
+	if err != nil {
+		return false
+	}
+	value := m.attr(e)
+	for _, v := range m.values {
+		if prefix, ok := strings.CutSuffix(v, "*"); ok && strings.HasPrefix(value, prefix) || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// cloudEvent is a parsed CloudEvent with undecoded data.
+type cloudEvent struct {
+	id, source, specVersion, typ, subject string
+	dataContentType, dataSchema           string
+	time                                  time.Time
+	extensions                            map[string]string
+	data                                  []byte
+}
+
+// cloudEventBody replaces the body of structured mode requests once it was
+// read, so the event is parsed once for all matchers and the handler.
+type cloudEventBody struct {
+	*bytes.Reader
+	event *cloudEvent
+	err   error
+}
+
+func (b *cloudEventBody) Close() error {
+	return nil
+}
+
+// parseCloudEvent parses the CloudEvent of req.
+func parseCloudEvent(req *http.Request) (*cloudEvent, error) {
+	if b, ok := req.Body.(*cloudEventBody); ok {
+		return b.event, b.err
+	}
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType != "application/cloudevents+json" {
+		return parseBinaryCloudEvent(req)
+	}
+	if req.Body == nil {
+		return nil, cloudEventError("empty structured event")
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	body := &cloudEventBody{Reader: bytes.NewReader(data)}
+	req.Body = body
+	if err != nil {
+		body.err = err
+		return nil, err
+	}
+	body.event, body.err = parseStructuredCloudEvent(data)
+	return body.event, body.err
+}
+
+// parseBinaryCloudEvent parses a CloudEvent in binary content mode, with the
+// attributes in the ce- headers and the data in the body.
+func parseBinaryCloudEvent(req *http.Request) (*cloudEvent, error) {
+	e := &cloudEvent{dataContentType: req.Header.Get("Content-Type")}
+	for name, values := range req.Header {
+		attr, ok := strings.CutPrefix(strings.ToLower(name), "ce-")
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if err := e.set(attr, values[0]); err != nil {
+			return nil, err
+		}
+	}
+	return e, e.validate()
+}
+
+// parseStructuredCloudEvent parses a CloudEvent in structured content mode.
+func parseStructuredCloudEvent(data []byte) (*cloudEvent, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, cloudEventError("invalid structured event: %v", err)
+	}
+	e := &cloudEvent{}
+	for attr, raw := range fields {
+		switch attr {
+		case "data":
+			e.data = raw
+			continue
+		case "data_base64":
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, cloudEventError("invalid data_base64: %v", err)
+			}
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return nil, cloudEventError("invalid data_base64: %v", err)
+			}
+			e.data = b
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			// Extensions may be numbers or booleans.
+			value = string(raw)
+		}
+		if err := e.set(attr, value); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := fields["data"]; ok && !isJSONMediaType(e.dataContentType) {
+		// Non JSON data is embedded as a JSON string.
+		var s string
+		if json.Unmarshal(e.data, &s) == nil {
+			e.data = []byte(s)
+		}
+	}
+	return e, e.validate()
+}
+
+// set sets the attribute attr of e.
+func (e *cloudEvent) set(attr, value string) error {
+	switch attr {
+	case "id":
+		e.id = value
+	case "source":
+		e.source = value
+	case "specversion":
+		e.specVersion = value
+	case "type":
+		e.typ = value
+	case "subject":
+		e.subject = value
+	case "datacontenttype":
+		e.dataContentType = value
+	case "dataschema":
+		e.dataSchema = value
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return cloudEventError("invalid time %q", value)
+		}
+		e.time = t
+	default:
+		if e.extensions == nil {
+			e.extensions = make(map[string]string)
+		}
+		e.extensions[attr] = value
+	}
+	return nil
+}
+
+// validate checks the required attributes of e.
+func (e *cloudEvent) validate() error {
+	switch {
+	case e.specVersion == "":
+		return cloudEventError("not a CloudEvent")
+	case e.specVersion != "1.0":
+		return cloudEventError("unsupported specversion %q", e.specVersion)
+	case e.id == "" || e.source == "" || e.typ == "":
+		return cloudEventError("missing id, source or type")
+	}
+	return nil
+}
+
+// bindCloudEventData decodes the data of e into v.
+func bindCloudEventData(req *http.Request, binder Binder, e *cloudEvent, v any) error {
+	data := e.data
+	if _, ok := req.Body.(*cloudEventBody); !ok {
+		// Binary mode: the data is the body.
+		if b, ok := binder.(BodyBinder); ok {
+			return b.Bind(req, v)
+		}
+		if req.Body != nil {
+			var err error
+			if data, err = io.ReadAll(req.Body); err != nil {
+				return err
+			}
+		}
+	} else if b, ok := binder.(BodyBinder); ok {
+		r := req.Clone(req.Context())
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		r.ContentLength = int64(len(data))
+		contentType := e.dataContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		r.Header.Set("Content-Type", contentType)
+		return b.Bind(r, v)
+	}
+
+	switch v := v.(type) {
+	case *[]byte:
+		*v = data
+		return nil
+	case *string:
+		*v = string(data)
+		return nil
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if !isJSONMediaType(e.dataContentType) {
+		return &statusError{status: http.StatusUnsupportedMediaType, err: fmt.Errorf("mux: unsupported cloudevent datacontenttype %q", e.dataContentType)}
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return &statusError{status: http.StatusBadRequest, err: fmt.Errorf("mux: invalid cloudevent data: %w", err)}
+	}
+	return nil
+}
+
+// isJSONMediaType reports whether the media type of contentType is JSON. An
+// empty contentType is JSON, the default of CloudEvents.
+func isJSONMediaType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// cloudEventError returns an error with status 400 for an invalid event.
+func cloudEventError(format string, args ...any) error {
+	return &statusError{status: http.StatusBadRequest, err: fmt.Errorf("mux: cloudevent: "+format, args...)}
+}
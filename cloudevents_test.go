@@ -0,0 +1,107 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type orderEvent struct {
+	OrderID string `json:"orderId"`
+}
+
+// xmlBinder stands in for a binder decoding bodies of other content types.
+type xmlBinder struct{}
+
+func (xmlBinder) Bind(r *http.Request, v any) error {
+	if r.Header.Get("Content-Type") != "application/xml" {
+		return json.NewDecoder(r.Body).Decode(v)
+	}
+	v.(*orderEvent).OrderID = "from-xml"
+	return nil
+}
+
+func TestCloudEventHandler(t *testing.T) {
+	router := NewRouter()
+	handle := func(name string) func(ctx context.Context, w http.ResponseWriter, event *CloudEvent[orderEvent]) error {
+		return func(ctx context.Context, w http.ResponseWriter, event *CloudEvent[orderEvent]) error {
+			fmt.Fprintf(w, "%s %s %s %s", name, event.Type, event.Data.OrderID, event.Extensions["traceparent"])
+			return nil
+		}
+	}
+	router.Handle("/events", CloudEventHandler(handle("created"))).CloudEventTypes("com.example.order.created")
+	router.Handle("/events", CloudEventHandler(handle("order"))).CloudEventTypes("com.example.order.*").CloudEventSources("/shop")
+
+	tests := []struct {
+		name    string
+		headers []string
+		body    string
+		binder  Binder
+		want    string
+	}{
+		{
+			"binary",
+			[]string{"Ce-Specversion", "1.0", "Ce-Id", "1", "Ce-Source", "/shop", "Ce-Type", "com.example.order.created", "Ce-Traceparent", "00-1", "Content-Type", "application/json"},
+			`{"orderId":"42"}`, nil,
+			"created com.example.order.created 42 00-1",
+		},
+		{
+			"structured",
+			[]string{"Content-Type", "application/cloudevents+json; charset=utf-8"},
+			`{"specversion":"1.0","id":"2","source":"/shop","type":"com.example.order.shipped","data":{"orderId":"43"}}`, nil,
+			"order com.example.order.shipped 43 ",
+		},
+		{
+			"structured base64",
+			[]string{"Content-Type", "application/cloudevents+json"},
+			`{"specversion":"1.0","id":"3","source":"/shop","type":"com.example.order.created","data_base64":"eyJvcmRlcklkIjoiNDQifQ=="}`, nil,
+			"created com.example.order.created 44 ",
+		},
+		{
+			"binder",
+			[]string{"Content-Type", "application/cloudevents+json"},
+			`{"specversion":"1.0","id":"4","source":"/shop","type":"com.example.order.created","datacontenttype":"application/xml","data":"<order/>"}`, xmlBinder{},
+			"created com.example.order.created from-xml ",
+		},
+	}
+	for _, tt := range tests {
+		req := newRequestWithHeaders(http.MethodPost, "http://localhost/events", tt.headers...)
+		req.Body = io.NopCloser(strings.NewReader(tt.body))
+		rw := NewRecorder()
+		if err := router.ServeHTTP(context.Background(), rw, req, tt.binder); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if rw.Body.String() != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, rw.Body.String())
+		}
+	}
+}
+
+func TestCloudEventHandlerErrors(t *testing.T) {
+	h := CloudEventHandler(func(ctx context.Context, w http.ResponseWriter, event *CloudEvent[orderEvent]) error {
+		return nil
+	})
+	tests := []struct {
+		headers []string
+		body    string
+		status  int
+	}{
+		{[]string{"Content-Type", "application/json"}, `{}`, http.StatusBadRequest},
+		{[]string{"Content-Type", "application/cloudevents+json"}, `{"specversion":"1.0","id":"1"}`, http.StatusBadRequest},
+		{[]string{"Content-Type", "application/cloudevents+json"}, `{"specversion":"0.3","id":"1","source":"s","type":"t"}`, http.StatusBadRequest},
+		{[]string{"Ce-Specversion", "1.0", "Ce-Id", "1", "Ce-Source", "s", "Ce-Type", "t", "Content-Type", "text/plain"}, `hi`, http.StatusUnsupportedMediaType},
+		{[]string{"Ce-Specversion", "1.0", "Ce-Id", "1", "Ce-Source", "s", "Ce-Type", "t", "Ce-Time", "yesterday"}, ``, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := newRequestWithHeaders(http.MethodPost, "http://localhost/events", tt.headers...)
+		req.Body = io.NopCloser(strings.NewReader(tt.body))
+		err := h(context.Background(), NewRecorder(), req, nil)
+		if err == nil || ErrorStatus(err) != tt.status {
+			t.Errorf("%v %s: expected an error with status %d, got %v", tt.headers, tt.body, tt.status, err)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// generate returns the source of the matcher for spec.
+func generate(s *spec) ([]byte, error) {
+	root, err := buildTree(s.Routes)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{spec: s, prefix: lowerFirst(s.Type)}
+	g.walk(root)
+	slices.SortFunc(g.nodes, func(a, b *node) int { return a.id - b.id })
+	g.header()
+	for _, n := range g.nodes {
+		g.node(n)
+	}
+	src, err := format.Source([]byte(g.buf.String()))
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+type generator struct {
+	spec   *spec
+	prefix string
+	buf    strings.Builder
+	// nodes lists the nodes of the tree by id.
+	nodes []*node
+	// patterns lists the variable patterns by index.
+	patterns []string
+	// maxVars is the maximum number of variables of a route.
+	maxVars int
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// walk collects the nodes and patterns of the tree.
+func (g *generator) walk(n *node) {
+	g.nodes = append(g.nodes, n)
+	g.maxVars = max(g.maxVars, n.vars)
+	for _, key := range sortedKeys(n.static) {
+		g.walk(n.static[key])
+	}
+	for _, p := range n.params {
+		if p.pattern != "" && !slices.Contains(g.patterns, p.pattern) {
+			g.patterns = append(g.patterns, p.pattern)
+		}
+		g.walk(p.node)
+	}
+}
+
+func (g *generator) header() {
+	typ := g.spec.Type
+	g.printf("// Code generated by muxgen. DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", g.spec.Package)
+	g.printf("import (\n\t\"context\"\n\t\"net/http\"\n")
+	if len(g.patterns) > 0 {
+		g.printf("\t\"regexp\"\n")
+	}
+	g.printf("\t\"strings\"\n\n\t\"github.com/gorilla/mux\"\n)\n\n")
+
+	g.printf("// %s dispatches requests to the handlers of its routes. Routes with a nil\n", typ)
+	g.printf("// handler are not matched.\n")
+	g.printf("type %s struct {\n", typ)
+	for _, r := range g.spec.Routes {
+		g.printf("\t// %s handles %s.\n", r.Name, describe(r))
+		g.printf("\t%s mux.Handler\n", r.Name)
+	}
+	g.printf("\t// NotFoundHandler handles requests no route matches, or mux.NotFoundHandler\n")
+	g.printf("\t// if nil.\n")
+	g.printf("\tNotFoundHandler mux.Handler\n}\n\n")
+
+	if len(g.patterns) > 0 {
+		g.printf("var (\n")
+		for i, p := range g.patterns {
+			g.printf("\t%sPattern%d = regexp.MustCompile(%s)\n", g.prefix, i, strconv.Quote("^(?:"+p+")$"))
+		}
+		g.printf(")\n\n")
+	}
+	if g.maxVars > 0 {
+		g.printf("var (\n")
+		for _, r := range g.spec.Routes {
+			if len(r.vars) > 0 {
+				g.printf("\t%sVars%s = []string{%s}\n", g.prefix, r.Name, quoteAll(r.vars))
+			}
+		}
+		g.printf(")\n\n")
+	}
+
+	g.printf("// %sVars holds the values of the route variables.\n", g.prefix)
+	g.printf("type %sVars = [%d]string\n\n", g.prefix, g.maxVars)
+
+	g.printf(`// ServeHTTP dispatches the request to the handler of the matching route.
+// It replies 405 Method Not Allowed if routes only match the path.
+func (rt *%[1]s) ServeHTTP(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+	var vars %[2]sVars
+	var handler mux.Handler
+	var names []string
+	var allow string
+	if path := r.URL.Path; strings.HasPrefix(path, "/") {
+		handler, names, allow = rt.match0(r, path[1:], false, &vars)
+	}
+	if handler == nil {
+		if allow != "" {
+			w.Header().Set("Allow", allow)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return nil
+		}
+		if rt.NotFoundHandler != nil {
+			return rt.NotFoundHandler.ServeHTTP(ctx, w, r, binder)
+		}
+		return mux.NotFoundHandler().ServeHTTP(ctx, w, r, binder)
+	}
+	if len(names) > 0 {
+		m := make(map[string]string, len(names))
+		for i, name := range names {
+			m[name] = vars[i]
+		}
+		r = mux.SetURLVars(r, m)
+	}
+	return handler.ServeHTTP(ctx, w, r, binder)
+}
+
+`, typ, g.prefix)
+}
+
+// node generates the method matching the path below n. The path is what
+// follows the slash leading to n; end is true if there is no such slash.
+func (g *generator) node(n *node) {
+	g.printf("func (rt *%s) match%d(r *http.Request, path string, end bool, vars *%sVars) (mux.Handler, []string, string) {\n", g.spec.Type, n.id, g.prefix)
+	g.printf("\tif end {\n")
+	g.leaf(n)
+	g.printf("\t}\n")
+	if len(n.static) == 0 && len(n.params) == 0 {
+		g.printf("\treturn nil, nil, \"\"\n}\n\n")
+		return
+	}
+
+	g.printf("\tseg, rest, more := strings.Cut(path, \"/\")\n")
+	g.printf("\tvar allow string\n")
+	if len(n.static) > 0 {
+		g.printf("\tswitch seg {\n")
+		for _, key := range sortedKeys(n.static) {
+			g.printf("\tcase %s:\n", strconv.Quote(key))
+			g.child(n.static[key])
+		}
+		g.printf("\t}\n")
+	}
+	for _, p := range n.params {
+		if p.pattern == "" {
+			g.printf("\tif seg != \"\" {\n")
+		} else {
+			g.printf("\tif %sPattern%d.MatchString(seg) {\n", g.prefix, slices.Index(g.patterns, p.pattern))
+		}
+		g.printf("\t\tvars[%d] = seg\n", n.vars)
+		g.child(p.node)
+		g.printf("\t}\n")
+	}
+	g.printf("\treturn nil, nil, allow\n}\n\n")
+}
+
+// child generates the call of the method matching the path below n.
+func (g *generator) child(n *node) {
+	g.printf("\t\tif handler, names, a := rt.match%d(r, rest, !more, vars); handler != nil {\n", n.id)
+	g.printf("\t\t\treturn handler, names, \"\"\n")
+	g.printf("\t\t} else if allow == \"\" {\n")
+	g.printf("\t\t\tallow = a\n")
+	g.printf("\t\t}\n")
+}
+
+// leaf generates the dispatch on the method for the routes ending at n.
+func (g *generator) leaf(n *node) {
+	if len(n.routes) == 0 {
+		g.printf("\t\treturn nil, nil, \"\"\n")
+		return
+	}
+	var allow []string
+	var fallback *route
+	for _, r := range n.routes {
+		if len(r.Methods) == 0 {
+			fallback = r
+			continue
+		}
+		if allow == nil {
+			g.printf("\t\tswitch r.Method {\n")
+		}
+		allow = append(allow, r.Methods...)
+		g.printf("\t\tcase %s:\n", quoteAll(r.Methods))
+		g.printf("\t\t\tif rt.%s != nil {\n", r.Name)
+		g.printf("\t\t\t\treturn rt.%s, %s, \"\"\n", r.Name, g.varsName(r))
+		g.printf("\t\t\t}\n")
+	}
+	if allow != nil {
+		g.printf("\t\t}\n")
+	}
+	if fallback != nil {
+		g.printf("\t\tif rt.%s != nil {\n", fallback.Name)
+		g.printf("\t\t\treturn rt.%s, %s, \"\"\n", fallback.Name, g.varsName(fallback))
+		g.printf("\t\t}\n")
+		g.printf("\t\treturn nil, nil, \"\"\n")
+		return
+	}
+	g.printf("\t\treturn nil, nil, %s\n", strconv.Quote(strings.Join(allow, ", ")))
+}
+
+// varsName returns the expression listing the variable names of r.
+func (g *generator) varsName(r *route) string {
+	if len(r.vars) == 0 {
+		return "nil"
+	}
+	return g.prefix + "Vars" + r.Name
+}
+
+// describe returns a description of the requests r matches.
+func describe(r route) string {
+	if len(r.Methods) == 0 {
+		return r.Path
+	}
+	return strings.Join(r.Methods, ", ") + " " + r.Path
+}
+
+func quoteAll(s []string) string {
+	quoted := make([]string, len(s))
+	for i, v := range s {
+		quoted[i] = strconv.Quote(v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func lowerFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[n:]
+}
+
+func sortedKeys(m map[string]*node) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
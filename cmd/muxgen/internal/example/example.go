@@ -0,0 +1,4 @@
+// Package example holds a matcher generated by muxgen, see routes.json.
+package example
+
+//go:generate go run github.com/gorilla/mux/cmd/muxgen -in routes.json -out routes_gen.go
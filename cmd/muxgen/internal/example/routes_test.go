@@ -0,0 +1,80 @@
+package example
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRoutes(t *testing.T) {
+	var name string
+	var vars map[string]string
+	handler := func(n string) mux.Handler {
+		return mux.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+			name, vars = n, mux.Vars(r)
+			return nil
+		})
+	}
+	routes := &Routes{
+		Index:         handler("Index"),
+		ListUsers:     handler("ListUsers"),
+		CreateUser:    handler("CreateUser"),
+		Me:            handler("Me"),
+		GetUser:       handler("GetUser"),
+		GetUserByName: handler("GetUserByName"),
+		GetPost:       handler("GetPost"),
+	}
+
+	for _, tc := range []struct {
+		method, path string
+		name         string
+		vars         map[string]string
+		code         int
+		allow        string
+	}{
+		{method: "GET", path: "/", name: "Index"},
+		{method: "DELETE", path: "/", name: "Index"},
+		{method: "GET", path: "/users", name: "ListUsers"},
+		{method: "POST", path: "/users", name: "CreateUser"},
+		{method: "PUT", path: "/users", code: http.StatusMethodNotAllowed, allow: "GET, POST"},
+		{method: "GET", path: "/users/me", name: "Me"},
+		{method: "HEAD", path: "/users/42", name: "GetUser", vars: map[string]string{"id": "42"}},
+		{method: "GET", path: "/users/gopher", name: "GetUserByName", vars: map[string]string{"name": "gopher"}},
+		{method: "GET", path: "/users/42/posts/hello", name: "GetPost", vars: map[string]string{"id": "42", "post": "hello"}},
+		{method: "DELETE", path: "/users/42", code: http.StatusMethodNotAllowed, allow: "GET, HEAD"},
+		{method: "GET", path: "/users/", code: http.StatusNotFound},
+		{method: "GET", path: "/users/gopher/posts/hello", code: http.StatusNotFound},
+		// Files has no handler.
+		{method: "GET", path: "/static/", code: http.StatusNotFound},
+	} {
+		name, vars = "", nil
+		rec := httptest.NewRecorder()
+		if err := routes.ServeHTTP(context.Background(), rec, httptest.NewRequest(tc.method, tc.path, nil), nil); err != nil {
+			t.Fatal(err)
+		}
+		code := tc.code
+		if code == 0 {
+			code = http.StatusOK
+		}
+		if rec.Code != code || name != tc.name || !reflect.DeepEqual(vars, tc.vars) {
+			t.Errorf("%s %s: expected %d %q %v, got %d %q %v", tc.method, tc.path, code, tc.name, tc.vars, rec.Code, name, vars)
+		}
+		if allow := rec.Header().Get("Allow"); allow != tc.allow {
+			t.Errorf("%s %s: expected Allow %q, got %q", tc.method, tc.path, tc.allow, allow)
+		}
+	}
+}
+
+func BenchmarkRoutes(b *testing.B) {
+	handler := mux.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error { return nil })
+	routes := &Routes{GetPost: handler}
+	req := httptest.NewRequest("GET", "/users/42/posts/hello", nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = routes.ServeHTTP(context.Background(), nil, req, nil)
+	}
+}
@@ -0,0 +1,59 @@
+// Command muxgen generates a route matcher from a declarative route list.
+//
+// The generated type implements mux.Handler and matches requests with a
+// switch statement per path segment instead of regular expressions, for
+// services with a static route set where matching must be fast:
+//
+//	//go:generate go run github.com/gorilla/mux/cmd/muxgen -in routes.json -out routes_gen.go
+//
+// The route list is a JSON document:
+//
+//	{
+//	  "package": "api",
+//	  "type": "Routes",
+//	  "routes": [
+//	    {"name": "ListUsers", "methods": ["GET"], "path": "/users"},
+//	    {"name": "GetUser", "methods": ["GET", "HEAD"], "path": "/users/{id:[0-9]+}"}
+//	  ]
+//	}
+//
+// The generated type has a mux.Handler field per route name, and a
+// NotFoundHandler field. Path templates are restricted to segments which are
+// either static or a single variable; variable patterns are matched against
+// a whole segment. Static segments take precedence over variables, so the
+// routes should not be ambiguous. Route variables are available with
+// mux.Vars and mux.Var.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	in := flag.String("in", "routes.json", "route list to read")
+	out := flag.String("out", "routes_gen.go", "Go file to write")
+	flag.Parse()
+
+	if err := run(*in, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "muxgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out string) error {
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	spec, err := parseSpec(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+	src, err := generate(spec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+	return os.WriteFile(out, src, 0o644)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerateExample(t *testing.T) {
+	data, err := os.ReadFile("internal/example/routes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := parseSpec(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile("internal/example/routes_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, expected) {
+		t.Error("internal/example/routes_gen.go is out of date, run go generate")
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, tc := range []struct {
+		spec, expected string
+	}{
+		{`{"package": "p", "type": "R"}`, "no routes"},
+		{`{"package": "p", "type": "r", "routes": [{"name": "A", "path": "/"}]}`, "invalid type name"},
+		{`{"package": "p", "type": "R", "routes": [{"name": "a", "path": "/"}]}`, "invalid name"},
+		{`{"package": "p", "type": "R", "routes": [{"name": "A", "path": "/"}, {"name": "A", "path": "/b"}]}`, "duplicate name"},
+		{`{"package": "p", "type": "R", "routes": [{"name": "A", "path": "b"}]}`, "must start with a slash"},
+		{`{"package": "p", "type": "R", "routes": [{"name": "A", "path": "/a{id}"}]}`, "static or a single variable"},
+		{`{"package": "p", "type": "R", "routes": [{"name": "A", "path": "/{a}/{a}"}]}`, "duplicate variable"},
+		{`{"package": "p", "type": "R", "routes": [{"name": "A", "path": "/{id:[}"}]}`, "invalid pattern"},
+		{`{"package": "p", "type": "R", "routes": [{"name": "A", "methods": ["get"], "path": "/"}, {"name": "B", "methods": ["GET"], "path": "/"}]}`, "match the same requests"},
+		{`{"package": "p", "type": "R", "routes": [{"name": "A", "path": "/", "handler": "x"}]}`, "unknown field"},
+	} {
+		s, err := parseSpec([]byte(tc.spec))
+		if err == nil {
+			_, err = generate(s)
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%s: expected error %q, got %v", tc.spec, tc.expected, err)
+		}
+	}
+}
+
+func TestGenerateBraces(t *testing.T) {
+	s, err := parseSpec([]byte(`{"package": "p", "type": "R", "routes": [{"name": "A", "path": "/{id:[0-9]{3}}"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(src, []byte(`regexp.MustCompile("^(?:[0-9]{3})$")`)) {
+		t.Errorf("expected the pattern in\n%s", src)
+	}
+}
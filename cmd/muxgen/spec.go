@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// spec is the route list read by muxgen.
+type spec struct {
+	Package string  `json:"package"`
+	Type    string  `json:"type"`
+	Routes  []route `json:"routes"`
+}
+
+// route is a route of the route list.
+type route struct {
+	Name    string   `json:"name"`
+	Methods []string `json:"methods"`
+	Path    string   `json:"path"`
+
+	// vars holds the variable names in the order of the path.
+	vars []string
+}
+
+// node is a node of the tree of path segments, see generate.
+type node struct {
+	id     int
+	static map[string]*node
+	params []*param
+	// routes lists the routes whose path ends at this node.
+	routes []*route
+	// vars is the number of variables on the path to this node.
+	vars int
+}
+
+// param is a variable path segment. An empty pattern matches any segment.
+type param struct {
+	pattern string
+	node    *node
+}
+
+func parseSpec(data []byte) (*spec, error) {
+	var s spec
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return nil, err
+	}
+	if !token.IsIdentifier(s.Package) {
+		return nil, fmt.Errorf("invalid package name %q", s.Package)
+	}
+	if !token.IsIdentifier(s.Type) || !token.IsExported(s.Type) {
+		return nil, fmt.Errorf("invalid type name %q", s.Type)
+	}
+	if len(s.Routes) == 0 {
+		return nil, errors.New("no routes")
+	}
+	names := make(map[string]bool, len(s.Routes))
+	for i := range s.Routes {
+		r := &s.Routes[i]
+		if !token.IsIdentifier(r.Name) || !token.IsExported(r.Name) || r.Name == "NotFoundHandler" {
+			return nil, fmt.Errorf("route %d: invalid name %q", i, r.Name)
+		}
+		if names[r.Name] {
+			return nil, fmt.Errorf("route %d: duplicate name %q", i, r.Name)
+		}
+		names[r.Name] = true
+		for j, m := range r.Methods {
+			r.Methods[j] = strings.ToUpper(m)
+		}
+	}
+	return &s, nil
+}
+
+// buildTree returns the tree of the path segments of routes.
+func buildTree(routes []route) (*node, error) {
+	var ids int
+	newNode := func(vars int) *node {
+		ids++
+		return &node{id: ids - 1, vars: vars}
+	}
+	root := newNode(0)
+	for i := range routes {
+		r := &routes[i]
+		if !strings.HasPrefix(r.Path, "/") {
+			return nil, fmt.Errorf("route %s: path must start with a slash, got %q", r.Name, r.Path)
+		}
+		n := root
+		for _, seg := range strings.Split(r.Path[1:], "/") {
+			name, pattern, isVar, err := parseSegment(seg)
+			if err != nil {
+				return nil, fmt.Errorf("route %s: %w", r.Name, err)
+			}
+			if !isVar {
+				child := n.static[seg]
+				if child == nil {
+					if n.static == nil {
+						n.static = make(map[string]*node)
+					}
+					child = newNode(n.vars)
+					n.static[seg] = child
+				}
+				n = child
+				continue
+			}
+			if slices.Contains(r.vars, name) {
+				return nil, fmt.Errorf("route %s: duplicate variable %q", r.Name, name)
+			}
+			r.vars = append(r.vars, name)
+			j := slices.IndexFunc(n.params, func(p *param) bool { return p.pattern == pattern })
+			if j == -1 {
+				n.params = append(n.params, &param{pattern: pattern, node: newNode(n.vars + 1)})
+				j = len(n.params) - 1
+			}
+			n = n.params[j].node
+		}
+		if err := checkMethods(n.routes, r); err != nil {
+			return nil, err
+		}
+		n.routes = append(n.routes, r)
+	}
+	return root, nil
+}
+
+// parseSegment parses a path segment, which is static or a single variable.
+func parseSegment(seg string) (name, pattern string, isVar bool, err error) {
+	if !strings.ContainsAny(seg, "{}") {
+		return "", "", false, nil
+	}
+	if !strings.HasPrefix(seg, "{") || closingBrace(seg) != len(seg)-1 {
+		return "", "", false, fmt.Errorf("segment %q must be static or a single variable", seg)
+	}
+	name, pattern, _ = strings.Cut(seg[1:len(seg)-1], ":")
+	if name == "" {
+		return "", "", false, fmt.Errorf("missing name in %q", seg)
+	}
+	if pattern != "" {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return "", "", false, fmt.Errorf("invalid pattern in %q: %w", seg, err)
+		}
+	}
+	return name, pattern, true, nil
+}
+
+// closingBrace returns the index of the brace closing the one starting seg,
+// or -1.
+func closingBrace(seg string) int {
+	level := 0
+	for i := 0; i < len(seg); i++ {
+		switch seg[i] {
+		case '{':
+			level++
+		case '}':
+			if level--; level == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// checkMethods returns an error if r accepts a method of the routes with the
+// same path.
+func checkMethods(routes []*route, r *route) error {
+	for _, other := range routes {
+		if len(other.Methods) == 0 && len(r.Methods) == 0 {
+			return fmt.Errorf("routes %s and %s match the same requests", other.Name, r.Name)
+		}
+		for _, m := range r.Methods {
+			if slices.Contains(other.Methods, m) {
+				return fmt.Errorf("routes %s and %s match the same requests", other.Name, r.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Command muxroutes prints and compares route tables, for code review and
+// release checklists.
+//
+//	muxroutes print [-json] FILE
+//	muxroutes diff OLD NEW
+//
+// A route table is read from a JSON document, "-" for standard input, which
+// is either the route list of muxgen or the routes of a router as returned by
+// Router.RouteTable, e.g. served by the DebugHandler with format=json or
+// written by a registration function in a test:
+//
+//	func TestDumpRoutes(t *testing.T) {
+//	    r := mux.NewRouter()
+//	    registerRoutes(r)
+//	    data, _ := json.Marshal(r.RouteTable())
+//	    os.WriteFile("testdata/routes.json", data, 0o644)
+//	}
+//
+// print writes the routes as a table, or as JSON with -json. diff writes the
+// routes removed from OLD prefixed with "-" and the routes added in NEW
+// prefixed with "+", and exits with status 1 if there are any.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// errDiffer is returned by run if the route tables differ.
+var errDiffer = errors.New("route tables differ")
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout)
+	switch {
+	case err == errDiffer:
+		os.Exit(1)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "muxroutes:", err)
+		os.Exit(2)
+	}
+}
+
+const usage = "usage: muxroutes print [-json] FILE | muxroutes diff OLD NEW"
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "print":
+		flags := flag.NewFlagSet("print", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		asJSON := flags.Bool("json", false, "print JSON")
+		if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 1 {
+			return errors.New(usage)
+		}
+		routes, err := readRoutes(flags.Arg(0), stdin)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(stdout, routes)
+		}
+		return printTable(stdout, routes)
+	case "diff":
+		if len(args) != 3 {
+			return errors.New(usage)
+		}
+		old, err := readRoutes(args[1], stdin)
+		if err != nil {
+			return err
+		}
+		newRoutes, err := readRoutes(args[2], stdin)
+		if err != nil {
+			return err
+		}
+		if diffRoutes(stdout, old, newRoutes) {
+			return errDiffer
+		}
+		return nil
+	default:
+		return errors.New(usage)
+	}
+}
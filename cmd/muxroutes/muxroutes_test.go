@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func writeRouteTable(t *testing.T, register func(r *mux.Router)) string {
+	t.Helper()
+	r := mux.NewRouter()
+	register(r)
+	data, err := json.Marshal(r.RouteTable())
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "routes.json")
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func handler(r *mux.Router, tpl string) *mux.Route {
+	return r.Handle(tpl, mux.NotFoundHandler())
+}
+
+func TestPrint(t *testing.T) {
+	var out strings.Builder
+	if err := run([]string{"print", "../muxgen/internal/example/routes.json"}, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) != 10 || !strings.HasPrefix(lines[0], "NAME") || strings.Join(strings.Fields(lines[5]), " ") != "GetUser GET,HEAD /users/{id:[0-9]+}" {
+		t.Errorf("unexpected table:\n%s", out.String())
+	}
+
+	out.Reset()
+	stdin := strings.NewReader(`[{"name":"a","path":"/a","methods":["GET"]}]`)
+	if err := run([]string{"print", "-json", "-"}, stdin, &out); err != nil {
+		t.Fatal(err)
+	}
+	var routes []mux.RouteInfo
+	if err := json.Unmarshal([]byte(out.String()), &routes); err != nil || len(routes) != 1 || routes[0].Path != "/a" {
+		t.Errorf("unexpected JSON %s: %v", out.String(), err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := writeRouteTable(t, func(r *mux.Router) {
+		handler(r, "/users").Methods(http.MethodGet).Name("users")
+		handler(r, "/users/{id}").Methods(http.MethodGet, http.MethodDelete)
+		handler(r, "/health")
+	})
+	same := writeRouteTable(t, func(r *mux.Router) {
+		handler(r, "/health")
+		handler(r, "/users/{id}").Methods(http.MethodDelete, http.MethodGet)
+		handler(r, "/users").Methods(http.MethodGet).Name("users")
+	})
+	changed := writeRouteTable(t, func(r *mux.Router) {
+		handler(r, "/users").Methods(http.MethodGet).Name("users")
+		handler(r, "/users/{id}").Methods(http.MethodGet)
+		handler(r, "/health")
+		handler(r, "/search").Queries("q", "{q}")
+	})
+
+	var out strings.Builder
+	if err := run([]string{"diff", old, same}, nil, &out); err != nil || out.Len() != 0 {
+		t.Errorf("expected no difference, got %v %q", err, out.String())
+	}
+	if err := run([]string{"diff", old, changed}, nil, &out); err != errDiffer {
+		t.Errorf("expected %v, got %v", errDiffer, err)
+	}
+	want := "- DELETE,GET /users/{id}\n+ GET /users/{id}\n+ * /search query=q={q}\n"
+	if out.String() != want {
+		t.Errorf("expected diff\n%s\ngot\n%s", want, out.String())
+	}
+}
+
+func TestUsage(t *testing.T) {
+	for _, args := range [][]string{nil, {"print"}, {"diff", "a"}, {"show", "a"}} {
+		if err := run(args, nil, &strings.Builder{}); err == nil || err.Error() != usage {
+			t.Errorf("%q: expected the usage, got %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gorilla/mux"
+)
+
+// muxgenSpec is the part of a muxgen route list read by muxroutes.
+type muxgenSpec struct {
+	Routes []struct {
+		Name    string   `json:"name"`
+		Methods []string `json:"methods"`
+		Path    string   `json:"path"`
+	} `json:"routes"`
+}
+
+// readRoutes reads the route table in the file name, or stdin for "-".
+func readRoutes(name string, stdin io.Reader) ([]mux.RouteInfo, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	routes, err := parseRoutes(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return routes, nil
+}
+
+// parseRoutes parses a route table, a JSON array of mux.RouteInfo or a
+// muxgen route list.
+func parseRoutes(data []byte) ([]mux.RouteInfo, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var spec muxgenSpec
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return nil, err
+		}
+		routes := make([]mux.RouteInfo, len(spec.Routes))
+		for i, r := range spec.Routes {
+			routes[i] = mux.RouteInfo{Name: r.Name, Path: r.Path, Methods: r.Methods}
+		}
+		return routes, nil
+	}
+	var routes []mux.RouteInfo
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// methods returns the sorted methods of route, "*" for any.
+func methods(route mux.RouteInfo) string {
+	if len(route.Methods) == 0 {
+		return "*"
+	}
+	m := make([]string, len(route.Methods))
+	for i, method := range route.Methods {
+		m[i] = strings.ToUpper(method)
+	}
+	slices.Sort(m)
+	return strings.Join(slices.Compact(m), ",")
+}
+
+func printTable(w io.Writer, routes []mux.RouteInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tMETHODS\tHOST\tPATH\tQUERIES")
+	for _, r := range routes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Name, methods(r), r.Host, r.Path, strings.Join(r.Queries, " "))
+	}
+	return tw.Flush()
+}
+
+func printJSON(w io.Writer, routes []mux.RouteInfo) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(routes)
+}
+
+// describe returns a line describing route, comparable between route tables.
+func describe(route mux.RouteInfo) string {
+	fields := []string{methods(route), route.Path}
+	if route.Path == "" {
+		fields[1] = "-"
+	}
+	if route.Host != "" {
+		fields = append(fields, "host="+route.Host)
+	}
+	for _, q := range route.Queries {
+		fields = append(fields, "query="+q)
+	}
+	if route.Name != "" {
+		fields = append(fields, "name="+route.Name)
+	}
+	return strings.Join(fields, " ")
+}
+
+// diffRoutes writes the routes of old missing in newRoutes and the routes of
+// newRoutes missing in old to w, and reports whether there are any. Routes
+// which only moved are not reported.
+func diffRoutes(w io.Writer, old, newRoutes []mux.RouteInfo) bool {
+	count := func(routes []mux.RouteInfo) map[string]int {
+		m := make(map[string]int, len(routes))
+		for _, r := range routes {
+			m[describe(r)]++
+		}
+		return m
+	}
+	oldCount, newCount := count(old), count(newRoutes)
+	differ := false
+	for _, r := range old {
+		if line := describe(r); newCount[line] > 0 {
+			newCount[line]--
+		} else {
+			fmt.Fprintln(w, "-", line)
+			differ = true
+		}
+	}
+	for _, r := range newRoutes {
+		if line := describe(r); oldCount[line] > 0 {
+			oldCount[line]--
+		} else {
+			fmt.Fprintln(w, "+", line)
+			differ = true
+		}
+	}
+	return differ
+}
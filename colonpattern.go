@@ -0,0 +1,66 @@
+package mux
+
+import "strings"
+
+// ColonPatterns defines whether the path templates of new routes also accept
+// the parameter syntax of routers like echo, gin and httprouter, to ease
+// migrating route strings. The initial value is false. When true, path
+// segments starting with ":name" or "*name" are read as the variables
+// "{name}" and "{name:.*}" respectively, and a bare "*" as "{*:.*}":
+//
+//	r.ColonPatterns(true)
+//	r.HandleFunc("/users/:id", getUser)        // "/users/{id}"
+//	r.HandleFunc("/static/*path", serveFiles) // "/static/{path:.*}"
+//
+// A colon or star elsewhere in a segment is matched literally, and variables
+// in braces keep their meaning. The route templates, as returned by
+// Route.GetPathTemplate, use braces.
+func (r *Router) ColonPatterns(value bool) *Router {
+	r.colonPatterns = value
+	return r
+}
+
+// translateColonPattern rewrites the ":name" and "*name" segments of the path
+// template tpl to variables in braces, see Router.ColonPatterns.
+func translateColonPattern(tpl string) string {
+	if !strings.ContainsAny(tpl, ":*") {
+		return tpl
+	}
+	var b strings.Builder
+	level := 0
+	for i := 0; i < len(tpl); i++ {
+		c := tpl[i]
+		switch c {
+		case '{':
+			level++
+		case '}':
+			level--
+		}
+		if level > 0 || (c != ':' && c != '*') || (i > 0 && tpl[i-1] != '/') {
+			b.WriteByte(c)
+			continue
+		}
+		end := i + 1
+		for end < len(tpl) && isParamNameByte(tpl[end]) {
+			end++
+		}
+		name := tpl[i+1 : end]
+		switch {
+		case c == '*' && name == "":
+			b.WriteString("{*:.*}")
+		case c == '*':
+			b.WriteString("{" + name + ":.*}")
+		case name == "":
+			b.WriteByte(c)
+			continue
+		default:
+			b.WriteString("{" + name + "}")
+		}
+		i = end - 1
+	}
+	return b.String()
+}
+
+func isParamNameByte(c byte) bool {
+	return c == '_' || c == '-' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
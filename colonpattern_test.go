@@ -0,0 +1,67 @@
+package mux
+
+import "testing"
+
+func TestTranslateColonPattern(t *testing.T) {
+	tests := []struct {
+		tpl, expected string
+	}{
+		{"/users", "/users"},
+		{"/users/:id", "/users/{id}"},
+		{"/users/:id/posts/:post_id", "/users/{id}/posts/{post_id}"},
+		{"/files/:name.json", "/files/{name}.json"},
+		{"/static/*", "/static/{*:.*}"},
+		{"/static/*path", "/static/{path:.*}"},
+		{"/v1/things:batchGet", "/v1/things:batchGet"},
+		{"/a/:", "/a/:"},
+		{"/users/{id:[0-9]+}/:tab", "/users/{id:[0-9]+}/{tab}"},
+		{"/{id:/:x}", "/{id:/:x}"},
+	}
+	for _, tt := range tests {
+		if got := translateColonPattern(tt.tpl); got != tt.expected {
+			t.Errorf("translateColonPattern(%q) = %q, expected %q", tt.tpl, got, tt.expected)
+		}
+	}
+}
+
+func TestColonPatterns(t *testing.T) {
+	router := NewRouter().ColonPatterns(true)
+	user := router.HandleFunc("/users/:id", dummyHandler)
+	sub := router.PathPrefix("/orgs/:org").Subrouter()
+	member := sub.HandleFunc("/members/:member", dummyHandler)
+	files := router.HandleFunc("/static/*path", dummyHandler)
+
+	tests := []struct {
+		route *Route
+		url   string
+		vars  map[string]string
+	}{
+		{user, "http://localhost/users/1", map[string]string{"id": "1"}},
+		{member, "http://localhost/orgs/acme/members/2", map[string]string{"org": "acme", "member": "2"}},
+		{files, "http://localhost/static/css/site.css", map[string]string{"path": "css/site.css"}},
+	}
+	for _, tt := range tests {
+		match := &RouteMatch{}
+		if !router.Match(newRequest("GET", tt.url), match) || match.Route != tt.route {
+			t.Errorf("%s: expected a match", tt.url)
+			continue
+		}
+		for k, v := range tt.vars {
+			if match.Vars[k] != v {
+				t.Errorf("%s: expected %s=%q, got %v", tt.url, k, v, match.Vars)
+			}
+		}
+	}
+
+	if tpl, _ := member.GetPathTemplate(); tpl != "/orgs/{org}/members/{member}" {
+		t.Errorf("unexpected template %q", tpl)
+	}
+	if u, err := user.URL("id", "7"); err != nil || u.Path != "/users/7" {
+		t.Errorf("unexpected URL %v, %v", u, err)
+	}
+
+	plain := NewRouter().HandleFunc("/users/:id", dummyHandler)
+	if !plain.Match(newRequest("GET", "http://localhost/users/:id"), &RouteMatch{}) {
+		t.Error("expected the colon to be literal without ColonPatterns")
+	}
+}
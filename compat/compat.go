@@ -0,0 +1,497 @@
+// Package compat mirrors the API of the upstream gorilla/mux package on top of
+// this fork, so that code written for upstream can be migrated route by route.
+// Handlers and middlewares are standard http.Handler values:
+//
+//	r := compat.NewRouter()
+//	r.HandleFunc("/users/{id}", func(w http.ResponseWriter, req *http.Request) {
+//		fmt.Fprint(w, compat.Vars(req)["id"])
+//	}).Methods(http.MethodGet)
+//
+// Mux returns the underlying router, on which migrated routes are registered
+// with the native API of this fork. Both kinds of routes are matched in
+// registration order and share route names and middlewares.
+//
+// Errors returned by native handlers are passed to Router.ErrorHandler.
+// RouteMatch and MatcherFunc are the types of this fork, whose Handler field
+// is a mux.Handler.
+package compat
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Errors and types shared with this fork.
+var (
+	ErrMethodMismatch = mux.ErrMethodMismatch
+	ErrNotFound       = mux.ErrNotFound
+	SkipRouter        = mux.SkipRouter
+)
+
+type (
+	MatcherFunc   = mux.MatcherFunc
+	RouteMatch    = mux.RouteMatch
+	BuildVarsFunc = mux.BuildVarsFunc
+)
+
+// MiddlewareFunc is a function which receives an http.Handler and returns
+// another http.Handler.
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// Middleware allows MiddlewareFunc to implement the middleware interface of
+// upstream.
+func (mw MiddlewareFunc) Middleware(handler http.Handler) http.Handler {
+	return mw(handler)
+}
+
+// WalkFunc is the type of the function called for each route visited by
+// Router.Walk.
+type WalkFunc func(route *Route, router *Router, ancestors []*Route) error
+
+// Router registers routes with standard handlers on a mux.Router.
+type Router struct {
+	// NotFoundHandler and MethodNotAllowedHandler are used like the fields
+	// of upstream. They must be set before the router serves requests.
+	NotFoundHandler         http.Handler
+	MethodNotAllowedHandler http.Handler
+
+	// ErrorHandler handles the errors returned by native handlers. By default
+	// they are answered with the status of mux.ErrorStatus if no response was
+	// sent yet.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+	router *mux.Router
+	tree   *tree
+}
+
+// tree tracks the compat routers of a router and its subrouters.
+type tree struct {
+	once    sync.Once
+	mu      sync.Mutex
+	routers map[*mux.Router]*Router
+}
+
+func newTree() *tree {
+	return &tree{routers: make(map[*mux.Router]*Router)}
+}
+
+// router returns the compat router of r.
+func (t *tree) router(r *mux.Router) *Router {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c := t.routers[r]
+	if c == nil {
+		c = &Router{router: r, tree: t}
+		t.routers[r] = c
+	}
+	return c
+}
+
+func (t *tree) route(r *mux.Route) *Route {
+	if r == nil {
+		return nil
+	}
+	return &Route{route: r, tree: t}
+}
+
+// configure sets the handlers of the routers from the fields of their compat
+// routers.
+func (t *tree) configure() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for r, c := range t.routers {
+		if c.NotFoundHandler != nil {
+			r.NotFoundHandler = mux.WrapHTTPHandler(c.NotFoundHandler)
+		}
+		if c.MethodNotAllowedHandler != nil {
+			r.MethodNotAllowedHandler = mux.WrapHTTPHandler(c.MethodNotAllowedHandler)
+		}
+	}
+}
+
+// NewRouter returns a new router instance.
+func NewRouter() *Router {
+	return Wrap(mux.NewRouter())
+}
+
+// Wrap returns a router registering routes on r.
+func Wrap(r *mux.Router) *Router {
+	return newTree().router(r)
+}
+
+// Mux returns the underlying router.
+func (r *Router) Mux() *mux.Router {
+	return r.router
+}
+
+// ServeHTTP dispatches the request to the handler of the matched route.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.tree.once.Do(r.tree.configure)
+	rw := mux.NewResponseRecorderWriter(w)
+	if err := r.router.ServeHTTP(req.Context(), rw, req, nil); err != nil {
+		if r.ErrorHandler != nil {
+			r.ErrorHandler(rw, req, err)
+			return
+		}
+		if !rw.WroteHeader() {
+			status := mux.ErrorStatus(err)
+			http.Error(rw, http.StatusText(status), status)
+		}
+	}
+}
+
+// Match attempts to match the given request against the router's routes.
+func (r *Router) Match(req *http.Request, match *RouteMatch) bool {
+	return r.router.Match(req, match)
+}
+
+// Get returns a route registered with the given name.
+func (r *Router) Get(name string) *Route {
+	return r.tree.route(r.router.Get(name))
+}
+
+// GetRoute returns a route registered with the given name.
+//
+// Deprecated: Use Get instead.
+func (r *Router) GetRoute(name string) *Route {
+	return r.Get(name)
+}
+
+// StrictSlash defines the trailing slash behavior for new routes.
+func (r *Router) StrictSlash(value bool) *Router {
+	r.router.StrictSlash(value)
+	return r
+}
+
+// SkipClean defines the path cleaning behavior for new routes.
+func (r *Router) SkipClean(value bool) *Router {
+	r.router.SkipClean(value)
+	return r
+}
+
+// UseEncodedPath tells the router to match the encoded original path.
+func (r *Router) UseEncodedPath() *Router {
+	r.router.UseEncodedPath()
+	return r
+}
+
+// Use appends middlewares to the chain.
+func (r *Router) Use(mwf ...MiddlewareFunc) {
+	for _, mw := range mwf {
+		r.router.Use(mux.StdMiddleware(mw))
+	}
+}
+
+// NewRoute registers an empty route.
+func (r *Router) NewRoute() *Route {
+	return r.tree.route(r.router.NewRoute())
+}
+
+// Name registers a new route with a name.
+func (r *Router) Name(name string) *Route {
+	return r.NewRoute().Name(name)
+}
+
+// Handle registers a new route with a matcher for the URL path.
+func (r *Router) Handle(path string, handler http.Handler) *Route {
+	return r.NewRoute().Path(path).Handler(handler)
+}
+
+// HandleFunc registers a new route with a matcher for the URL path.
+func (r *Router) HandleFunc(path string, f func(http.ResponseWriter, *http.Request)) *Route {
+	return r.NewRoute().Path(path).HandlerFunc(f)
+}
+
+// Headers registers a new route with a matcher for request header values.
+func (r *Router) Headers(pairs ...string) *Route {
+	return r.NewRoute().Headers(pairs...)
+}
+
+// Host registers a new route with a matcher for the URL host.
+func (r *Router) Host(tpl string) *Route {
+	return r.NewRoute().Host(tpl)
+}
+
+// MatcherFunc registers a new route with a custom matcher function.
+func (r *Router) MatcherFunc(f MatcherFunc) *Route {
+	return r.NewRoute().MatcherFunc(f)
+}
+
+// Methods registers a new route with a matcher for HTTP methods.
+func (r *Router) Methods(methods ...string) *Route {
+	return r.NewRoute().Methods(methods...)
+}
+
+// Path registers a new route with a matcher for the URL path.
+func (r *Router) Path(tpl string) *Route {
+	return r.NewRoute().Path(tpl)
+}
+
+// PathPrefix registers a new route with a matcher for the URL path prefix.
+func (r *Router) PathPrefix(tpl string) *Route {
+	return r.NewRoute().PathPrefix(tpl)
+}
+
+// Queries registers a new route with a matcher for URL query values.
+func (r *Router) Queries(pairs ...string) *Route {
+	return r.NewRoute().Queries(pairs...)
+}
+
+// Schemes registers a new route with a matcher for URL schemes.
+func (r *Router) Schemes(schemes ...string) *Route {
+	return r.NewRoute().Schemes(schemes...)
+}
+
+// BuildVarsFunc registers a new route with a custom function for modifying
+// route variables before building a URL.
+func (r *Router) BuildVarsFunc(f BuildVarsFunc) *Route {
+	return r.NewRoute().BuildVarsFunc(f)
+}
+
+// Walk walks the router and all its subrouters, calling walkFn for each
+// route in the tree, see mux.Router.Walk.
+func (r *Router) Walk(walkFn WalkFunc) error {
+	return r.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		routes := make([]*Route, len(ancestors))
+		for i, ancestor := range ancestors {
+			routes[i] = r.tree.route(ancestor)
+		}
+		return walkFn(r.tree.route(route), r.tree.router(router), routes)
+	})
+}
+
+// Route wraps a mux.Route registered by a compat router.
+type Route struct {
+	route *mux.Route
+	tree  *tree
+}
+
+// Native returns the underlying route.
+func (r *Route) Native() *mux.Route {
+	return r.route
+}
+
+// Match matches the route against the request.
+func (r *Route) Match(req *http.Request, match *RouteMatch) bool {
+	return r.route.Match(req, match)
+}
+
+// GetError returns an error resulted from building the route, if any.
+func (r *Route) GetError() error {
+	return r.route.GetError()
+}
+
+// BuildOnly sets the route to never match: it is only used to build URLs.
+func (r *Route) BuildOnly() *Route {
+	r.route.BuildOnly()
+	return r
+}
+
+// SkipClean reports whether path cleaning is enabled for this route.
+func (r *Route) SkipClean() bool {
+	return r.route.SkipClean()
+}
+
+// Handler sets a handler for the route.
+func (r *Route) Handler(handler http.Handler) *Route {
+	if handler == nil {
+		r.route.Handler(nil)
+		return r
+	}
+	r.route.Handler(mux.WrapHTTPHandler(handler))
+	return r
+}
+
+// HandlerFunc sets a handler function for the route.
+func (r *Route) HandlerFunc(f func(http.ResponseWriter, *http.Request)) *Route {
+	return r.Handler(http.HandlerFunc(f))
+}
+
+// GetHandler returns the handler for the route, if any. Errors of a native
+// handler are answered with 500 Internal Server Error.
+func (r *Route) GetHandler() http.Handler {
+	h := r.route.GetHandler()
+	if h == nil {
+		return nil
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		serveNative(h, w, req)
+	})
+}
+
+// serveNative serves req with the native handler h.
+func serveNative(h mux.Handler, w http.ResponseWriter, req *http.Request) {
+	rw := mux.NewResponseRecorderWriter(w)
+	if err := h.ServeHTTP(req.Context(), rw, req, nil); err != nil && !rw.WroteHeader() {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// Name sets the name for the route, used to build URLs.
+func (r *Route) Name(name string) *Route {
+	r.route.Name(name)
+	return r
+}
+
+// GetName returns the name for the route, if any.
+func (r *Route) GetName() string {
+	return r.route.GetName()
+}
+
+// Headers adds a matcher for request header values.
+func (r *Route) Headers(pairs ...string) *Route {
+	r.route.Headers(pairs...)
+	return r
+}
+
+// HeadersRegexp adds a matcher for request header values matching regexps.
+func (r *Route) HeadersRegexp(pairs ...string) *Route {
+	r.route.HeadersRegexp(pairs...)
+	return r
+}
+
+// Host adds a matcher for the URL host.
+func (r *Route) Host(tpl string) *Route {
+	r.route.Host(tpl)
+	return r
+}
+
+// MatcherFunc adds a custom function to be used as request matcher.
+func (r *Route) MatcherFunc(f MatcherFunc) *Route {
+	r.route.MatcherFunc(f)
+	return r
+}
+
+// Methods adds a matcher for HTTP methods.
+func (r *Route) Methods(methods ...string) *Route {
+	r.route.Methods(methods...)
+	return r
+}
+
+// Path adds a matcher for the URL path.
+func (r *Route) Path(tpl string) *Route {
+	r.route.Path(tpl)
+	return r
+}
+
+// PathPrefix adds a matcher for the URL path prefix.
+func (r *Route) PathPrefix(tpl string) *Route {
+	r.route.PathPrefix(tpl)
+	return r
+}
+
+// Queries adds a matcher for URL query values.
+func (r *Route) Queries(pairs ...string) *Route {
+	r.route.Queries(pairs...)
+	return r
+}
+
+// Schemes adds a matcher for URL schemes.
+func (r *Route) Schemes(schemes ...string) *Route {
+	r.route.Schemes(schemes...)
+	return r
+}
+
+// BuildVarsFunc adds a custom function to be used to modify build variables
+// before a route's URL is built.
+func (r *Route) BuildVarsFunc(f BuildVarsFunc) *Route {
+	r.route.BuildVarsFunc(f)
+	return r
+}
+
+// Subrouter creates a subrouter for the route.
+func (r *Route) Subrouter() *Router {
+	t := r.tree
+	if t == nil {
+		t = newTree()
+	}
+	return t.router(r.route.Subrouter())
+}
+
+// URL builds a URL for the route.
+func (r *Route) URL(pairs ...string) (*url.URL, error) {
+	return r.route.URL(pairs...)
+}
+
+// URLHost builds the host part of the URL for a route.
+func (r *Route) URLHost(pairs ...string) (*url.URL, error) {
+	return r.route.URLHost(pairs...)
+}
+
+// URLPath builds the path part of the URL for a route.
+func (r *Route) URLPath(pairs ...string) (*url.URL, error) {
+	return r.route.URLPath(pairs...)
+}
+
+// GetPathTemplate returns the template used to build the route match.
+func (r *Route) GetPathTemplate() (string, error) {
+	return r.route.GetPathTemplate()
+}
+
+// GetPathRegexp returns the expanded regular expression used to match the
+// route path.
+func (r *Route) GetPathRegexp() (string, error) {
+	return r.route.GetPathRegexp()
+}
+
+// GetQueriesRegexp returns the expanded regular expressions used to match
+// the route queries.
+func (r *Route) GetQueriesRegexp() ([]string, error) {
+	return r.route.GetQueriesRegexp()
+}
+
+// GetQueriesTemplates returns the templates used to build the query
+// matching.
+func (r *Route) GetQueriesTemplates() ([]string, error) {
+	return r.route.GetQueriesTemplates()
+}
+
+// GetMethods returns the methods the route matches against.
+func (r *Route) GetMethods() ([]string, error) {
+	return r.route.GetMethods()
+}
+
+// GetHostTemplate returns the template used to build the route match.
+func (r *Route) GetHostTemplate() (string, error) {
+	return r.route.GetHostTemplate()
+}
+
+// GetVarNames returns the names of all variables added by regexp matchers.
+func (r *Route) GetVarNames() ([]string, error) {
+	return r.route.GetVarNames()
+}
+
+// Vars returns the route variables for the current request, if any.
+func Vars(r *http.Request) map[string]string {
+	return mux.Vars(r)
+}
+
+// CurrentRoute returns the matched route for the current request, if any.
+func CurrentRoute(r *http.Request) *Route {
+	if route := mux.CurrentRoute(r); route != nil {
+		return &Route{route: route}
+	}
+	return nil
+}
+
+// SetURLVars sets the URL variables for the given request, to be accessed
+// via Vars for testing route behaviour.
+func SetURLVars(r *http.Request, val map[string]string) *http.Request {
+	return mux.SetURLVars(r, val)
+}
+
+// CORSMethodMiddleware sets the Access-Control-Allow-Methods response header
+// on requests for routes that have an OPTIONS method matcher, see
+// mux.CORSMethodMiddleware.
+func CORSMethodMiddleware(r *Router) MiddlewareFunc {
+	mw := mux.CORSMethodMiddleware(r.router)
+	return func(next http.Handler) http.Handler {
+		h := mw(mux.WrapHTTPHandler(next))
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			serveNative(h, w, req)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package compat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func serve(r http.Handler, method, url string) *httptest.ResponseRecorder {
+	rw := httptest.NewRecorder()
+	r.ServeHTTP(rw, httptest.NewRequest(method, url, nil))
+	return rw
+}
+
+func TestRouter(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/users/{id:[0-9]+}", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "user %s via %s", Vars(req)["id"], CurrentRoute(req).GetName())
+	}).Methods(http.MethodGet).Name("user")
+
+	rw := serve(r, http.MethodGet, "http://localhost/users/42")
+	if rw.Code != http.StatusOK || rw.Body.String() != "user 42 via user" {
+		t.Errorf("unexpected response %d %q", rw.Code, rw.Body.String())
+	}
+
+	rw = serve(r, http.MethodPost, "http://localhost/users/42")
+	if rw.Code != http.StatusMethodNotAllowed || rw.Header().Get("Allow") != http.MethodGet {
+		t.Errorf("expected 405 with Allow header, got %d %v", rw.Code, rw.Header())
+	}
+
+	if rw := serve(r, http.MethodGet, "http://localhost/users/abc"); rw.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rw.Code)
+	}
+
+	u, err := r.Get("user").URL("id", "7")
+	if err != nil || u.String() != "/users/7" {
+		t.Errorf("unexpected URL %v, %v", u, err)
+	}
+}
+
+func TestRouterHandlers(t *testing.T) {
+	r := NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+	r.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {}).Methods(http.MethodGet)
+	api := r.PathPrefix("/api").Subrouter()
+	api.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+
+	tests := []struct {
+		method, url string
+		code        int
+	}{
+		{http.MethodGet, "http://localhost/missing", http.StatusTeapot},
+		{http.MethodPost, "http://localhost/", http.StatusConflict},
+		{http.MethodGet, "http://localhost/api/missing", http.StatusGone},
+	}
+	for _, tt := range tests {
+		if rw := serve(r, tt.method, tt.url); rw.Code != tt.code {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.url, tt.code, rw.Code)
+		}
+	}
+}
+
+func TestRouterMiddleware(t *testing.T) {
+	r := NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Middleware", "1")
+			next.ServeHTTP(w, req)
+		})
+	})
+	r.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {})
+
+	if rw := serve(r, http.MethodGet, "http://localhost/"); rw.Header().Get("X-Middleware") != "1" {
+		t.Error("expected the middleware to run")
+	}
+}
+
+func TestRouterNativeRoutes(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/legacy", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "legacy")
+	})
+	r.Mux().HandleFunc("/native/{id}", func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder mux.Binder) error {
+		if mux.Var(req, "id") == "fail" {
+			return errors.New("failed")
+		}
+		fmt.Fprint(w, "native")
+		return nil
+	}).Name("native")
+
+	for _, path := range []string{"/legacy", "/native/1"} {
+		if rw := serve(r, http.MethodGet, "http://localhost"+path); rw.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", path, rw.Code)
+		}
+	}
+	if rw := serve(r, http.MethodGet, "http://localhost/native/fail"); rw.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for a native error, got %d", rw.Code)
+	}
+
+	var handled error
+	r.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		handled = err
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	if rw := serve(r, http.MethodGet, "http://localhost/native/fail"); rw.Code != http.StatusBadGateway || handled == nil {
+		t.Errorf("expected the error handler to run, got %d", rw.Code)
+	}
+
+	if r.Get("native") == nil {
+		t.Error("expected native routes to be found by name")
+	}
+}
+
+func TestRouterWalk(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/a", func(w http.ResponseWriter, req *http.Request) {})
+	sub := r.PathPrefix("/sub").Subrouter()
+	sub.HandleFunc("/b", func(w http.ResponseWriter, req *http.Request) {})
+
+	var paths []string
+	err := r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		if router == sub {
+			tpl, _ := route.GetPathTemplate()
+			paths = append(paths, tpl)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != "/sub/b" {
+		t.Errorf("expected the subrouter to be walked as itself, got %v", paths)
+	}
+}
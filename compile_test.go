@@ -0,0 +1,83 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestLazyCompile(t *testing.T) {
+	router := NewRouter().LazyCompile(true).SetLogger(NopLogger())
+	var id string
+	route := router.HandleFunc("/users/{id:[0-9]+}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		id = Var(r, "id")
+		return nil
+	})
+	sub := router.PathPrefix("/api").Subrouter()
+	subRoute := sub.HandleFunc("/items/{id}", dummyHandler)
+
+	if rr := route.regexp.path; rr.compiled.regexp != nil {
+		t.Fatal("expected the route not to be compiled before the first match")
+	}
+	if rr := subRoute.regexp.path; rr.compiled.regexp != nil {
+		t.Fatal("expected subrouters to inherit lazy compilation")
+	}
+
+	if u, err := route.URL("id", "7"); err != nil || u.Path != "/users/7" {
+		t.Errorf("unexpected URL %v, %v", u, err)
+	}
+	if _, err := route.URL("id", "x"); err == nil {
+		t.Error("expected an error for an invalid variable")
+	}
+
+	_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/users/42"), nil)
+	if id != "42" {
+		t.Errorf("expected the lazy route to match, got id %q", id)
+	}
+	if err := router.Compile(); err != nil {
+		t.Errorf("unexpected compile error %v", err)
+	}
+}
+
+func TestLazyCompileError(t *testing.T) {
+	router := NewRouter().LazyCompile(true).SetLogger(NopLogger())
+	route := router.HandleFunc("/users/{id:[0-9}", dummyHandler)
+	router.HandleFunc("/users/{name}", dummyHandler).Name("name")
+
+	if err := route.GetError(); err != nil {
+		t.Fatalf("expected the error to be deferred, got %v", err)
+	}
+
+	var match RouteMatch
+	if !router.Match(newRequest("GET", "http://localhost/users/42"), &match) || match.Route.GetName() != "name" {
+		t.Error("expected the invalid route not to match")
+	}
+
+	if err := router.Compile(); err == nil {
+		t.Error("expected a compile error")
+	}
+	if err := route.GetError(); err == nil {
+		t.Error("expected Compile to set the route error")
+	}
+}
+
+func TestLazyCompileCapturingGroups(t *testing.T) {
+	router := NewRouter().LazyCompile(true).SetLogger(NopLogger())
+	route := router.HandleFunc("/a/{x:(foo|bar)}", dummyHandler)
+
+	rw := NewRecorder()
+	if err := router.ServeHTTP(context.Background(), rw, newRequest("GET", "http://localhost/a/foo"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if rw.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rw.Code)
+	}
+
+	if err := router.Compile(); !errors.Is(err, errCapturingGroups) {
+		t.Errorf("expected a capturing groups error, got %v", err)
+	}
+	if err := route.GetError(); !errors.Is(err, errCapturingGroups) {
+		t.Errorf("expected Compile to set the route error, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package mux
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// CompileCache records the route regexps which compiled successfully, so that
+// a process registering the same routes after a restart can skip compiling
+// them at startup, see Router.SetCompileCache. Compiled regexps themselves
+// cannot be serialized: the cached regexps are compiled on first use instead,
+// like with LazyCompile, while routes with other regexps are compiled, and
+// rejected if invalid, when they are registered.
+//
+// A cache is safe for concurrent use.
+type CompileCache struct {
+	mu sync.RWMutex
+	// regexps holds the keys of the regexps known to compile, see
+	// compileCacheKey.
+	regexps map[string][]string
+}
+
+// compileCacheFile is the serialized form of a CompileCache.
+type compileCacheFile struct {
+	// Go is the Go version which compiled the regexps.
+	Go      string     `json:"go"`
+	Regexps [][]string `json:"regexps"`
+}
+
+// NewCompileCache returns an empty cache.
+func NewCompileCache() *CompileCache {
+	return &CompileCache{regexps: make(map[string][]string)}
+}
+
+// ReadCompileCache reads a cache written by CompileCache.Save. A cache
+// written by another Go version is read as an empty cache, since the regexp
+// syntax may differ.
+func ReadCompileCache(rd io.Reader) (*CompileCache, error) {
+	var file compileCacheFile
+	if err := json.NewDecoder(rd).Decode(&file); err != nil {
+		return nil, errors.Join(errors.New("mux: invalid compile cache"), err)
+	}
+	c := NewCompileCache()
+	if file.Go != runtime.Version() {
+		return c, nil
+	}
+	for _, patterns := range file.Regexps {
+		if len(patterns) > 0 {
+			c.regexps[compileCacheKey(patterns)] = patterns
+		}
+	}
+	return c, nil
+}
+
+// Save writes the cache to w.
+func (c *CompileCache) Save(w io.Writer) error {
+	c.mu.RLock()
+	file := compileCacheFile{Go: runtime.Version(), Regexps: make([][]string, 0, len(c.regexps))}
+	for _, patterns := range c.regexps {
+		file.Regexps = append(file.Regexps, patterns)
+	}
+	c.mu.RUnlock()
+	slices.SortFunc(file.Regexps, slices.Compare)
+	return json.NewEncoder(w).Encode(file)
+}
+
+// Add compiles the routes of r, including the routes of subrouters, and
+// records the regexps of the valid routes. It returns the errors of invalid
+// routes like Router.Compile.
+func (c *CompileCache) Add(r *Router) error {
+	var errs []error
+	_ = r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		if err := route.Compile(); err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		for _, m := range route.matchers {
+			if rr, ok := m.(*routeRegexp); ok && !rr.static {
+				patterns := rr.compilePatterns()
+				c.mu.Lock()
+				c.regexps[compileCacheKey(patterns)] = patterns
+				c.mu.Unlock()
+			}
+		}
+		return nil
+	})
+	return errors.Join(errs...)
+}
+
+// Len returns the number of cached regexps.
+func (c *CompileCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.regexps)
+}
+
+// contains reports whether the regexps of rr are known to compile. The cache
+// may be nil.
+func (c *CompileCache) contains(rr *routeRegexp) bool {
+	if c == nil {
+		return false
+	}
+	key := compileCacheKey(rr.compilePatterns())
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.regexps[key]
+	return ok
+}
+
+// compilePatterns returns the patterns compiled by compileRegexps: the
+// expanded pattern followed by the variable patterns.
+func (r *routeRegexp) compilePatterns() []string {
+	return append([]string{r.pattern}, r.varsP...)
+}
+
+func compileCacheKey(patterns []string) string {
+	return strings.Join(patterns, "\x00")
+}
+
+// SetCompileCache makes new routes of the router, and of subrouters created
+// afterwards, skip compiling the regexps recorded by c when they are
+// registered; they are compiled on first use instead. A nil cache restores
+// the default. Typically the cache is read at startup and saved after all
+// routes were registered:
+//
+//	cache, err := mux.ReadCompileCache(f)
+//	r.SetCompileCache(cache)
+//	registerRoutes(r)
+//	err = cache.Add(r)
+//	err = cache.Save(w)
+func (r *Router) SetCompileCache(c *CompileCache) *Router {
+	r.compileCache = c
+	return r
+}
@@ -0,0 +1,95 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func compileCacheRoutes(r *Router) {
+	r.HandleFunc("/static", dummyHandler)
+	r.HandleFunc("/users/{id:[0-9]+}", dummyHandler).Name("user")
+	sub := r.Host("{tenant}.example.com").Subrouter()
+	sub.HandleFunc("/items", dummyHandler).Queries("page", "{page:[0-9]+}")
+}
+
+func TestCompileCache(t *testing.T) {
+	cache := NewCompileCache()
+	router := NewRouter()
+	compileCacheRoutes(router)
+	if err := cache.Add(router); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 3 {
+		t.Errorf("expected 3 cached regexps, got %d", cache.Len())
+	}
+
+	var buf bytes.Buffer
+	if err := cache.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadCompileCache(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != cache.Len() {
+		t.Fatalf("expected %d cached regexps, got %d", cache.Len(), loaded.Len())
+	}
+
+	router = NewRouter().SetCompileCache(loaded)
+	compileCacheRoutes(router)
+	other := router.HandleFunc("/other/{id}", dummyHandler)
+
+	user := router.Get("user")
+	if user.regexp.path.compiled.regexp != nil {
+		t.Error("expected the cached regexp not to be compiled")
+	}
+	if other.regexp.path.compiled.regexp == nil {
+		t.Error("expected the uncached regexp to be compiled")
+	}
+
+	for _, url := range []string{"http://localhost/users/1", "http://acme.example.com/items?page=2"} {
+		rw := NewRecorder()
+		if err := router.ServeHTTP(context.Background(), rw, newRequest("GET", url), nil); err != nil {
+			t.Fatal(err)
+		}
+		if rw.Code != 0 {
+			t.Errorf("%s: expected a match, got %d", url, rw.Code)
+		}
+	}
+	if user.regexp.path.compiled.regexp == nil {
+		t.Error("expected the cached regexp to be compiled on first use")
+	}
+}
+
+func TestCompileCacheInvalidRoute(t *testing.T) {
+	cache := NewCompileCache()
+	router := NewRouter()
+	router.HandleFunc("/valid/{id}", dummyHandler)
+	router.HandleFunc("/invalid/{id:[0-9}", dummyHandler)
+	if err := cache.Add(router); err == nil {
+		t.Error("expected the error of the invalid route")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("expected 1 cached regexp, got %d", cache.Len())
+	}
+
+	router = NewRouter().SetCompileCache(cache)
+	if err := router.HandleFunc("/invalid/{id:[0-9}", dummyHandler).GetError(); err == nil {
+		t.Error("expected the uncached invalid route to be rejected at registration")
+	}
+}
+
+func TestReadCompileCache(t *testing.T) {
+	if _, err := ReadCompileCache(strings.NewReader("{")); err == nil {
+		t.Error("expected an error for an invalid cache")
+	}
+	cache, err := ReadCompileCache(strings.NewReader(`{"go": "go1.0", "regexps": [["^/a/(?P<v0>[^/]+)$", "[^/]+"]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 0 {
+		t.Error("expected the cache of another Go version to be empty")
+	}
+}
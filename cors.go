@@ -0,0 +1,191 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSPolicy is the CORS policy of a route, set with Route.CORS or
+// Router.CORS and applied by CORSMethodMiddleware.
+type CORSPolicy struct {
+	// AllowedOrigins lists the origins allowed to make cross-origin
+	// requests, such as "https://example.com". "*" allows any origin.
+	AllowedOrigins []string
+
+	// AllowedHeaders lists the request headers allowed in cross-origin
+	// requests, sent as Access-Control-Allow-Headers in preflight responses
+	// whose Access-Control-Request-Headers are all allowed. "*" allows any
+	// header, echoing the requested headers.
+	AllowedHeaders []string
+
+	// ExposedHeaders lists the response headers readable by cross-origin
+	// callers besides the CORS-safelisted ones, sent as
+	// Access-Control-Expose-Headers.
+	ExposedHeaders []string
+
+	// AllowCredentials allows cross-origin requests with credentials such
+	// as cookies.
+	AllowCredentials bool
+
+	// MaxAge is how long browsers may cache preflight responses. Zero
+	// leaves it to the browser.
+	MaxAge time.Duration
+}
+
+// corsPolicyKey is the metadata key of the CORSPolicy of a route.
+type corsPolicyKey struct{}
+
+// CORS sets the CORS policy of the route, see CORSMethodMiddleware. Routes
+// for the same path answering different origins, such as public and partner
+// endpoints, each get their own policy:
+//
+//	r.HandleFunc("/v1/prices", prices).Methods(http.MethodGet, http.MethodOptions).
+//	    CORS(mux.CORSPolicy{AllowedOrigins: []string{"*"}})
+//	r.HandleFunc("/partner/orders", orders).Methods(http.MethodPost, http.MethodOptions).
+//	    CORS(mux.CORSPolicy{
+//	        AllowedOrigins:   []string{"https://partner.example.com"},
+//	        AllowedHeaders:   []string{"Authorization", "Content-Type"},
+//	        AllowCredentials: true,
+//	        MaxAge:           time.Hour,
+//	    })
+func (r *Route) CORS(policy CORSPolicy) *Route {
+	return r.Metadata(corsPolicyKey{}, policy)
+}
+
+// CORS sets the CORS policy of the routes created afterwards by the router
+// and its subrouters, see Route.CORS.
+func (r *Router) CORS(policy CORSPolicy) *Router {
+	return r.Metadata(corsPolicyKey{}, policy)
+}
+
+// EnableCORSPreflight makes the router answer CORS preflight requests for
+// the routes with a CORSPolicy, so that no OPTIONS route is needed for them.
+// A preflight is answered for the route which the request would match with
+// the method in its Access-Control-Request-Method header: with 204 No
+// Content, the headers of its policy, see CORSMethodMiddleware, and the
+// methods of the routes with its templates in Access-Control-Allow-Methods.
+//
+// Preflights matching an OPTIONS route are served by that route, and
+// preflights for routes without a policy get the usual 405 Method Not
+// Allowed response. It must be called on the router serving requests,
+// before it does so.
+func (r *Router) EnableCORSPreflight() *Router {
+	r.corsPreflight = true
+	return r
+}
+
+// corsPreflightHandler returns the handler answering the preflight req, or
+// nil if req is not a preflight for a route with a CORS policy or the router
+// does not answer preflights.
+func (r *Router) corsPreflightHandler(req *http.Request) Handler {
+	if !r.corsPreflight || !isPreflight(req) {
+		return nil
+	}
+
+	actual := *req
+	actual.Method = req.Header.Get("Access-Control-Request-Method")
+	var match RouteMatch
+	if !r.Match(&actual, &match) || match.MatchErr != nil {
+		return nil
+	}
+	policy, ok := routeCORSPolicy(match.Route)
+	if !ok {
+		return nil
+	}
+
+	return HandlerFunc(func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		h := w.Header()
+		policy.apply(h, req)
+		h.Add("Vary", "Access-Control-Request-Method")
+		if h.Get("Access-Control-Allow-Origin") != "" {
+			if methods, ok := r.allowedMethods(match.Route, &actual); ok {
+				h.Set("Access-Control-Allow-Methods", strings.Join(methods, ","))
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+}
+
+// routeCORSPolicy returns the CORS policy of route, if any.
+func routeCORSPolicy(route *Route) (CORSPolicy, bool) {
+	if route == nil {
+		return CORSPolicy{}, false
+	}
+	policy, err := MetadataValue[CORSPolicy](route, corsPolicyKey{})
+	return policy, err == nil
+}
+
+// allowsOrigin reports whether the policy allows origin.
+func (p CORSPolicy) allowsOrigin(origin string) bool {
+	return slices.Contains(p.AllowedOrigins, "*") || slices.ContainsFunc(p.AllowedOrigins, func(o string) bool {
+		return strings.EqualFold(o, origin)
+	})
+}
+
+// apply sets the CORS response headers of the policy for req. Requests
+// without an Origin header and requests from origins the policy does not
+// allow get no CORS headers besides Vary, so browsers reject them.
+func (p CORSPolicy) apply(h http.Header, req *http.Request) {
+	h.Add("Vary", "Origin")
+	origin := req.Header.Get("Origin")
+	if origin == "" || !p.allowsOrigin(origin) {
+		return
+	}
+
+	if slices.Contains(p.AllowedOrigins, "*") && !p.AllowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !isPreflight(req) {
+		if len(p.ExposedHeaders) > 0 {
+			h.Set("Access-Control-Expose-Headers", strings.Join(p.ExposedHeaders, ","))
+		}
+		return
+	}
+	h.Add("Vary", "Access-Control-Request-Headers")
+	if allowed := p.allowHeaders(req.Header.Values("Access-Control-Request-Headers")); allowed != "" {
+		h.Set("Access-Control-Allow-Headers", allowed)
+	}
+	if p.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge/time.Second)))
+	}
+}
+
+// allowHeaders returns the Access-Control-Allow-Headers value answering the
+// given Access-Control-Request-Headers values, or "" if the policy does not
+// allow all requested headers, so browsers reject the request.
+func (p CORSPolicy) allowHeaders(values []string) string {
+	var requested []string
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				requested = append(requested, name)
+			}
+		}
+	}
+
+	if slices.Contains(p.AllowedHeaders, "*") {
+		return strings.Join(requested, ",")
+	}
+	for _, name := range requested {
+		if !slices.ContainsFunc(p.AllowedHeaders, func(h string) bool { return strings.EqualFold(h, name) }) {
+			return ""
+		}
+	}
+	return strings.Join(p.AllowedHeaders, ",")
+}
+
+// isPreflight reports whether req is a CORS preflight request.
+func isPreflight(req *http.Request) bool {
+	return req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != ""
+}
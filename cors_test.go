@@ -0,0 +1,245 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRouteCORS(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/prices", stringHandler("prices")).Methods(http.MethodGet, http.MethodOptions).
+		CORS(CORSPolicy{AllowedOrigins: []string{"*"}})
+	partner := r.PathPrefix("/partner").Subrouter().CORS(CORSPolicy{
+		AllowedOrigins:   []string{"https://partner.example.com"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	})
+	partner.HandleFunc("/orders", stringHandler("orders")).Methods(http.MethodPost, http.MethodOptions)
+	r.HandleFunc("/internal", stringHandler("internal")).Methods(http.MethodGet)
+	r.Use(CORSMethodMiddleware(r))
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		origin  string
+		headers map[string]string
+	}{
+		{
+			name:   "public origin",
+			method: http.MethodGet,
+			path:   "/prices",
+			origin: "https://anyone.example.com",
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:   "partner origin",
+			method: http.MethodPost,
+			path:   "/partner/orders",
+			origin: "https://partner.example.com",
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":      "https://partner.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Headers":     "",
+				"Access-Control-Max-Age":           "",
+			},
+		},
+		{
+			name:   "partner preflight",
+			method: http.MethodOptions,
+			path:   "/partner/orders",
+			origin: "https://partner.example.com",
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":  "https://partner.example.com",
+				"Access-Control-Allow-Methods": "POST,OPTIONS",
+				"Access-Control-Allow-Headers": "Authorization,Content-Type",
+				"Access-Control-Max-Age":       "3600",
+			},
+		},
+		{
+			name:   "disallowed origin",
+			method: http.MethodPost,
+			path:   "/partner/orders",
+			origin: "https://anyone.example.com",
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":      "",
+				"Access-Control-Allow-Credentials": "",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:   "no policy",
+			method: http.MethodGet,
+			path:   "/internal",
+			origin: "https://anyone.example.com",
+			headers: map[string]string{
+				"Access-Control-Allow-Origin": "",
+				"Vary":                        "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := NewRecorder()
+			req := newRequest(tt.method, tt.path)
+			req.Header.Set("Origin", tt.origin)
+			if tt.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			if err := r.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tt.headers {
+				if got := rw.Header().Get(name); got != want {
+					t.Errorf("%s: expected %q, got %q", name, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSPolicyHeaders(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         CORSPolicy
+		method         string
+		requestHeaders []string
+		allowHeaders   string
+		exposeHeaders  string
+	}{
+		{
+			name:           "allowed request headers",
+			policy:         CORSPolicy{AllowedHeaders: []string{"Authorization", "Content-Type"}},
+			method:         http.MethodOptions,
+			requestHeaders: []string{"content-type, authorization"},
+			allowHeaders:   "Authorization,Content-Type",
+		},
+		{
+			name:           "disallowed request header",
+			policy:         CORSPolicy{AllowedHeaders: []string{"Authorization"}},
+			method:         http.MethodOptions,
+			requestHeaders: []string{"Authorization", "X-Debug"},
+			allowHeaders:   "",
+		},
+		{
+			name:           "any request header",
+			policy:         CORSPolicy{AllowedHeaders: []string{"*"}},
+			method:         http.MethodOptions,
+			requestHeaders: []string{"X-Debug,  X-Trace"},
+			allowHeaders:   "X-Debug,X-Trace",
+		},
+		{
+			name:          "exposed headers on actual request",
+			policy:        CORSPolicy{ExposedHeaders: []string{"X-Request-Id", "ETag"}},
+			method:        http.MethodGet,
+			exposeHeaders: "X-Request-Id,ETag",
+		},
+		{
+			name:   "no exposed headers on preflight",
+			policy: CORSPolicy{ExposedHeaders: []string{"X-Request-Id"}},
+			method: http.MethodOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.policy.AllowedOrigins = []string{"https://example.com"}
+			req := newRequest(tt.method, "/")
+			req.Header.Set("Origin", "https://example.com")
+			if tt.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			}
+			for _, v := range tt.requestHeaders {
+				req.Header.Add("Access-Control-Request-Headers", v)
+			}
+
+			h := http.Header{}
+			tt.policy.apply(h, req)
+			if got := h.Get("Access-Control-Allow-Headers"); got != tt.allowHeaders {
+				t.Errorf("expected Access-Control-Allow-Headers %q, got %q", tt.allowHeaders, got)
+			}
+			if got := h.Get("Access-Control-Expose-Headers"); got != tt.exposeHeaders {
+				t.Errorf("expected Access-Control-Expose-Headers %q, got %q", tt.exposeHeaders, got)
+			}
+			if tt.method == http.MethodOptions && !slices.Contains(h.Values("Vary"), "Access-Control-Request-Headers") {
+				t.Errorf("expected Vary to contain Access-Control-Request-Headers, got %q", h.Values("Vary"))
+			}
+		})
+	}
+}
+
+func TestEnableCORSPreflight(t *testing.T) {
+	r := NewRouter().EnableCORSPreflight()
+	api := r.PathPrefix("/api").Subrouter().CORS(CORSPolicy{
+		AllowedOrigins: []string{"https://example.com"},
+		AllowedHeaders: []string{"Content-Type"},
+		MaxAge:         10 * time.Minute,
+	})
+	api.HandleFunc("/users/{id}", stringHandler("get")).Methods(http.MethodGet)
+	api.HandleFunc("/users/{id}", stringHandler("put")).Methods(http.MethodPut)
+	api.HandleFunc("/teams", stringHandler("options")).Methods(http.MethodPost, http.MethodOptions)
+	r.HandleFunc("/internal", stringHandler("internal")).Methods(http.MethodPost)
+
+	preflight := func(path, method, origin string) *ResponseRecorder {
+		rw := NewRecorder()
+		req := newRequest(http.MethodOptions, path)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", method)
+		req.Header.Set("Access-Control-Request-Headers", "content-type")
+		if err := r.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+			t.Fatal(err)
+		}
+		return rw
+	}
+
+	rw := preflight("/api/users/1", http.MethodPut, "https://example.com")
+	if rw.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, rw.Code)
+	}
+	for name, want := range map[string]string{
+		"Access-Control-Allow-Origin":  "https://example.com",
+		"Access-Control-Allow-Methods": "GET,PUT",
+		"Access-Control-Allow-Headers": "Content-Type",
+		"Access-Control-Max-Age":       "600",
+		"Allow":                        "",
+	} {
+		if got := rw.Header().Get(name); got != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+	if got, want := rw.Header().Values("Vary"), []string{"Origin", "Access-Control-Request-Headers", "Access-Control-Request-Method"}; !slices.Equal(got, want) {
+		t.Errorf("Vary: expected %q, got %q", want, got)
+	}
+	if rw.Body.Len() != 0 {
+		t.Errorf("expected an empty body, got %q", rw.Body.String())
+	}
+
+	rw = preflight("/api/users/1", http.MethodPut, "https://evil.example.com")
+	if rw.Code != http.StatusNoContent || rw.Header().Get("Access-Control-Allow-Origin") != "" || rw.Header().Get("Access-Control-Allow-Methods") != "" {
+		t.Errorf("expected 204 without CORS headers for a disallowed origin, got %d %q", rw.Code, rw.Header())
+	}
+
+	rw = preflight("/api/teams", http.MethodPost, "https://example.com")
+	if rw.Body.String() != "options" {
+		t.Errorf("expected the OPTIONS route to serve the preflight, got %d %q", rw.Code, rw.Body.String())
+	}
+
+	for _, tt := range []struct{ path, method string }{
+		{"/internal", http.MethodPost},
+		{"/api/users/1", http.MethodDelete},
+	} {
+		rw = preflight(tt.path, tt.method, "https://example.com")
+		if rw.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, http.StatusMethodNotAllowed, rw.Code)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package mux
+
+// RecordCoverage makes the router count the requests served by each route,
+// including the routes of its subrouters, so that tests can tell which
+// routes were never exercised. The counts can be read with Route.Hits and
+// UncoveredRoutes.
+//
+// It must be called before the router serves requests.
+func (r *Router) RecordCoverage() *Router {
+	r.coverage = true
+	return r
+}
+
+// RecordsCoverage reports whether the router counts the requests served by
+// its routes, see RecordCoverage.
+func (r *Router) RecordsCoverage() bool {
+	return r.coverage
+}
+
+// Hits returns the number of requests served by the route. It is 0 unless
+// the serving router has RecordCoverage turned on.
+func (r *Route) Hits() uint64 {
+	return r.hits.Load()
+}
+
+// UncoveredRoutes returns the routes with a handler, including the routes of
+// subrouters, which served no request, in the order Walk visits them.
+// Build-only routes and the routes of subrouters themselves are skipped.
+func (r *Router) UncoveredRoutes() []*Route {
+	var routes []*Route
+	_ = r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		if route.handler != nil && !route.buildOnly && !isSubrouterRoute(route) && route.Hits() == 0 {
+			routes = append(routes, route)
+		}
+		return nil
+	})
+	return routes
+}
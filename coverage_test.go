@@ -0,0 +1,39 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestRecordCoverage(t *testing.T) {
+	router := NewRouter().RecordCoverage()
+	users := router.HandleFunc("/users", dummyHandler).Methods(http.MethodGet)
+	router.HandleFunc("/users", dummyHandler).Methods(http.MethodPost).Name("create")
+	api := router.PathPrefix("/api").Subrouter()
+	items := api.HandleFunc("/items", dummyHandler)
+	router.HandleFunc("/legacy", dummyHandler).BuildOnly()
+
+	for _, url := range []string{"http://localhost/users", "http://localhost/users", "http://localhost/api/items", "http://localhost/missing"} {
+		_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest(http.MethodGet, url), nil)
+	}
+	_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest(http.MethodDelete, "http://localhost/users"), nil)
+
+	if hits := users.Hits(); hits != 2 {
+		t.Errorf("expected 2 hits, got %d", hits)
+	}
+	if hits := items.Hits(); hits != 1 {
+		t.Errorf("expected 1 hit, got %d", hits)
+	}
+	uncovered := router.UncoveredRoutes()
+	if len(uncovered) != 1 || uncovered[0].GetName() != "create" {
+		t.Errorf("expected the create route to be uncovered, got %v", uncovered)
+	}
+
+	router = NewRouter()
+	route := router.HandleFunc("/users", dummyHandler)
+	_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest(http.MethodGet, "http://localhost/users"), nil)
+	if hits := route.Hits(); hits != 0 {
+		t.Errorf("expected no hits without coverage, got %d", hits)
+	}
+}
@@ -0,0 +1,135 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers read by DeadlineMiddleware by default.
+const (
+	RequestTimeoutHeader = "X-Request-Timeout"
+	GRPCTimeoutHeader    = "Grpc-Timeout"
+)
+
+// DeadlineOptions configures DeadlineMiddleware.
+type DeadlineOptions struct {
+	// Headers lists the headers carrying the requested timeout, in order of
+	// precedence. It defaults to X-Request-Timeout and grpc-timeout.
+	Headers []string
+	// Max bounds the requested timeout. Zero means no bound.
+	Max time.Duration
+	// Default is applied if the request carries no valid timeout. Zero
+	// means no deadline.
+	Default time.Duration
+}
+
+// DeadlineMiddleware applies the timeout requested by the client as a
+// deadline to the handler context, giving callers end-to-end deadline
+// semantics. Timeouts are read from X-Request-Timeout, as a Go duration such
+// as "1.5s" or a number of seconds, and from grpc-timeout in its gRPC wire
+// format such as "200m". Invalid values are ignored.
+//
+// If the deadline expired by the time the handler returns and no response
+// was written, 504 Gateway Timeout is sent and context.DeadlineExceeded
+// errors are swallowed.
+func DeadlineMiddleware(options DeadlineOptions) MiddlewareFunc {
+	headers := options.Headers
+	if len(headers) == 0 {
+		headers = []string{RequestTimeoutHeader, GRPCTimeoutHeader}
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+			timeout := options.Default
+			for _, h := range headers {
+				if d, ok := parseRequestTimeout(h, req.Header.Get(h)); ok {
+					timeout = d
+					break
+				}
+			}
+			if options.Max > 0 && (timeout <= 0 || timeout > options.Max) {
+				timeout = options.Max
+			}
+			if timeout <= 0 {
+				return next(ctx, w, req, binder)
+			}
+
+			deadline := time.Now().Add(timeout)
+			ctx, cancel := context.WithDeadline(ctx, deadline)
+			defer cancel()
+			reqCtx, reqCancel := context.WithDeadline(req.Context(), deadline)
+			defer reqCancel()
+			req = req.WithContext(reqCtx)
+
+			rw := NewResponseRecorderWriter(w)
+			err := next(ctx, rw, req, binder)
+
+			if ctx.Err() != context.DeadlineExceeded {
+				return err
+			}
+			if !rw.WroteHeader() {
+				http.Error(rw, http.StatusText(http.StatusGatewayTimeout), http.StatusGatewayTimeout)
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// parseRequestTimeout parses the value of a timeout header. The gRPC format
+// is used for grpc-timeout, all other headers take a Go duration or a number
+// of seconds.
+func parseRequestTimeout(header, value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if http.CanonicalHeaderKey(header) == GRPCTimeoutHeader {
+		return parseGRPCTimeout(value)
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d, true
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 && secs < float64(math.MaxInt64)/float64(time.Second) {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	return 0, false
+}
+
+// parseGRPCTimeout parses "TimeoutValue TimeoutUnit" as defined by the gRPC
+// over HTTP/2 protocol: at most 8 digits followed by one of H, M, S, m, u, n.
+func parseGRPCTimeout(value string) (time.Duration, bool) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, false
+	}
+	var unit time.Duration
+	switch value[len(value)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, false
+	}
+	n, err := strconv.ParseUint(value[:len(value)-1], 10, 64)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+	if n > uint64(math.MaxInt64/unit) {
+		return math.MaxInt64, true
+	}
+	return time.Duration(n) * unit, true
+}
@@ -0,0 +1,75 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRequestTimeout(t *testing.T) {
+	tests := []struct {
+		header, value string
+		expected      time.Duration
+		ok            bool
+	}{
+		{RequestTimeoutHeader, "1.5s", 1500 * time.Millisecond, true},
+		{RequestTimeoutHeader, "2", 2 * time.Second, true},
+		{RequestTimeoutHeader, "0.25", 250 * time.Millisecond, true},
+		{RequestTimeoutHeader, "-1s", 0, false},
+		{RequestTimeoutHeader, "soon", 0, false},
+		{"grpc-timeout", "200m", 200 * time.Millisecond, true},
+		{"grpc-timeout", "3S", 3 * time.Second, true},
+		{"grpc-timeout", "1H", time.Hour, true},
+		{"grpc-timeout", "100", 0, false},
+		{"grpc-timeout", "123456789S", 0, false},
+		{"grpc-timeout", "99999999H", 1<<63 - 1, true},
+	}
+
+	for _, test := range tests {
+		d, ok := parseRequestTimeout(test.header, test.value)
+		if d != test.expected || ok != test.ok {
+			t.Errorf("%s: %q: expected %s, %v, got %s, %v", test.header, test.value, test.expected, test.ok, d, ok)
+		}
+	}
+}
+
+func TestDeadlineMiddleware(t *testing.T) {
+	var remaining time.Duration
+	router := NewRouter()
+	router.Use(DeadlineMiddleware(DeadlineOptions{Max: time.Second}))
+	router.HandleFunc("/wait", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		deadline, ok := ctx.Deadline()
+		if reqDeadline, _ := r.Context().Deadline(); !ok || !reqDeadline.Equal(deadline) {
+			t.Error("expected the deadline on both contexts")
+		}
+		remaining = time.Until(deadline)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	router.HandleFunc("/fast", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		remaining = 0
+		if deadline, ok := ctx.Deadline(); ok {
+			remaining = time.Until(deadline)
+		}
+		return nil
+	})
+
+	rw := NewRecorder()
+	req := newRequestWithHeaders("GET", "http://localhost/wait", "Grpc-Timeout", "10m")
+	if err := router.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+		t.Fatal(err)
+	}
+	if rw.Code != http.StatusGatewayTimeout || remaining > 10*time.Millisecond {
+		t.Errorf("expected 504 after 10ms, got %d after %s", rw.Code, remaining)
+	}
+
+	rw = NewRecorder()
+	req = newRequestWithHeaders("GET", "http://localhost/fast", "X-Request-Timeout", "1h")
+	if err := router.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+		t.Fatal(err)
+	}
+	if rw.Code == http.StatusGatewayTimeout || remaining > time.Second || remaining < 900*time.Millisecond {
+		t.Errorf("expected the timeout to be bounded by the maximum, got %d after %s", rw.Code, remaining)
+	}
+}
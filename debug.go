@@ -0,0 +1,151 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// RouteInfo describes a route with a handler.
+type RouteInfo struct {
+	Name    string   `json:"name,omitempty"`
+	Host    string   `json:"host,omitempty"`
+	Path    string   `json:"path,omitempty"`
+	Methods []string `json:"methods,omitempty"`
+	Queries []string `json:"queries,omitempty"`
+	// Metadata holds the route metadata formatted with fmt.Sprint.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Middlewares is the number of middlewares applied to the route,
+	// including the ones of the enclosing routers.
+	Middlewares int `json:"middlewares"`
+	// Example is an example call if the route has a request or response
+	// type, see RouteExample.
+	Example *RouteExample `json:"example,omitempty"`
+}
+
+// RouteTable describes all routes with a handler in the order they are
+// walked by Router.Walk. Routes whose handler is another router are
+// described by the routes of that router.
+func (r *Router) RouteTable() []RouteInfo {
+	return r.routeTable("")
+}
+
+// routeTable returns the route table with examples calling baseURL.
+func (r *Router) routeTable(baseURL string) []RouteInfo {
+	var routes []RouteInfo
+	middlewares := map[*Router][]middleware{r: r.middlewares}
+
+	_ = r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		inherited := middlewares[router]
+		for _, m := range route.matchers {
+			if sub, ok := m.(*Router); ok {
+				middlewares[sub] = append(slices.Clip(inherited), sub.middlewares...)
+			}
+		}
+		if sub, ok := route.handler.(*Router); ok {
+			middlewares[sub] = append(append(slices.Clip(inherited), route.middlewares...), sub.middlewares...)
+			return nil
+		}
+		if route.handler == nil {
+			return nil
+		}
+
+		applied := len(route.middlewares)
+		for _, mw := range inherited {
+			if appliesTo(mw, route) {
+				applied++
+			}
+		}
+		info := RouteInfo{
+			Name:        route.GetName(),
+			Middlewares: applied,
+		}
+		info.Example = route.example(baseURL)
+		info.Host, _ = route.GetHostTemplate()
+		info.Path, _ = route.GetPathTemplate()
+		info.Methods, _ = route.GetMethods()
+		if queries, _ := route.GetQueriesTemplates(); len(queries) > 0 {
+			info.Queries = queries
+		}
+		if len(route.metadata) > 0 {
+			info.Metadata = make(map[string]string, len(route.metadata))
+			for k, v := range route.metadata {
+				info.Metadata[fmt.Sprint(k)] = fmt.Sprint(v)
+			}
+		}
+
+		routes = append(routes, info)
+		return nil
+	})
+
+	return routes
+}
+
+var debugTemplate = template.Must(template.New("routes").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Routes</title></head>
+<body>
+<table border="1" cellpadding="4">
+<tr><th>Name</th><th>Methods</th><th>Host</th><th>Path</th><th>Queries</th><th>Metadata</th><th>Middlewares</th><th>Example</th></tr>
+{{range .}}<tr><td>{{.Name}}</td><td>{{range .Methods}}{{.}} {{end}}</td><td>{{.Host}}</td><td>{{.Path}}</td><td>{{range .Queries}}{{.}} {{end}}</td><td>{{range $k, $v := .Metadata}}{{$k}}={{$v}}<br>{{end}}</td><td>{{.Middlewares}}</td><td>{{with .Example}}<pre>{{.Curl}}</pre>{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+var debugTracesTemplate = template.Must(template.New("traces").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Match traces</title></head>
+<body>
+<table border="1" cellpadding="4">
+<tr><th>Time</th><th>Method</th><th>Host</th><th>Path</th><th>Result</th><th>Route</th><th>Rejected by</th><th>Duration</th></tr>
+{{range .}}<tr><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Method}}</td><td>{{.Host}}</td><td>{{.Path}}</td><td>{{.Result}}</td><td>{{.Route}}</td><td>{{range .Steps}}{{.Route}}: {{.Matcher}}<br>{{end}}</td><td>{{.Duration}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// DebugHandler returns a handler serving the route table of the router, as
+// JSON if the request asks for application/json or has the query parameter
+// format=json, and as an HTML table otherwise. With the query parameter
+// view=traces, it serves the recent match traces instead, see
+// EnableMatchTracing. The given middlewares, such as an authentication
+// check, wrap the handler:
+//
+//	r.Handle("/_mux/routes", r.DebugHandler(requireAdmin)).Methods(http.MethodGet)
+func (r *Router) DebugHandler(mwf ...MiddlewareFunc) HandlerFunc {
+	handler := HandlerFunc(func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		query := req.URL.Query()
+		asJSON := query.Get("format") == "json" || strings.Contains(req.Header.Get("Accept"), "application/json")
+		if query.Get("view") == "traces" {
+			traces := r.MatchTraces()
+			if asJSON {
+				w.Header().Set("Content-Type", "application/json")
+				return json.NewEncoder(w).Encode(traces)
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			return debugTracesTemplate.Execute(w, traces)
+		}
+
+		scheme := "http"
+		if req.TLS != nil {
+			scheme = "https"
+		}
+		routes := r.routeTable(scheme + "://" + req.Host)
+		if asJSON {
+			w.Header().Set("Content-Type", "application/json")
+			return json.NewEncoder(w).Encode(routes)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		return debugTemplate.Execute(w, routes)
+	})
+
+	for i := len(mwf) - 1; i >= 0; i-- {
+		handler = mwf[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,71 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRouteTable(t *testing.T) {
+	mw := &testMiddleware{}
+	router := NewRouter()
+	router.Use(mw.Middleware)
+	router.HandleFunc("/users/{id}", dummyHandler).Methods("GET").Name("user").Metadata("owner", "team-a")
+	sub := router.PathPrefix("/api").Subrouter()
+	sub.Use(mw.Middleware)
+	sub.HandleFunc("/items", dummyHandler).Queries("page", "{page}").Use(mw.Middleware)
+
+	expected := []RouteInfo{
+		{Name: "user", Path: "/users/{id}", Methods: []string{"GET"}, Metadata: map[string]string{"owner": "team-a"}, Middlewares: 1},
+		{Path: "/api/items", Queries: []string{"page={page}"}, Middlewares: 3},
+	}
+	if routes := router.RouteTable(); !reflect.DeepEqual(routes, expected) {
+		t.Errorf("expected %+v, got %+v", expected, routes)
+	}
+}
+
+func TestDebugHandler(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/users/{id}", dummyHandler).Name("user")
+	denyAll := func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+			if r.Header.Get("X-Admin") == "" {
+				w.WriteHeader(http.StatusForbidden)
+				return nil
+			}
+			return next(ctx, w, r, binder)
+		}
+	}
+	router.Handle("/_mux/routes", router.DebugHandler(denyAll))
+
+	rw := NewRecorder()
+	_ = router.ServeHTTP(context.Background(), rw, newRequest("GET", "http://localhost/_mux/routes"), nil)
+	if rw.Code != http.StatusForbidden {
+		t.Errorf("expected the auth middleware to reject the request, got %d", rw.Code)
+	}
+
+	rw = NewRecorder()
+	req := newRequestWithHeaders("GET", "http://localhost/_mux/routes?format=json", "X-Admin", "1")
+	if err := router.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+		t.Fatal(err)
+	}
+	var routes []RouteInfo
+	if err := json.Unmarshal(rw.Body.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 || routes[0].Name != "user" {
+		t.Errorf("unexpected routes %+v", routes)
+	}
+
+	rw = NewRecorder()
+	req = newRequestWithHeaders("GET", "http://localhost/_mux/routes", "X-Admin", "1")
+	if err := router.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(rw.Body.String(), "<td>/users/{id}</td>") {
+		t.Errorf("unexpected HTML %s", rw.Body.String())
+	}
+}
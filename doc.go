@@ -57,6 +57,10 @@ calling mux.Vars():
 	vars := mux.Vars(request)
 	category := vars["category"]
 
+A single variable can be read with mux.Var(), which does not build the map:
+
+	category := mux.Var(request, "category")
+
 Note that if any capturing groups are present, mux will panic() during parsing. To prevent
 this, convert any capturing groups to non-capturing, e.g. change "/{sort:(asc|desc)}" to
 "/{sort:(?:asc|desc)}". This is a change from prior versions which behaved unpredictably
@@ -147,7 +151,8 @@ the inner routes use it as base for their paths:
 
 Note that the path provided to PathPrefix() represents a "wildcard": calling
 PathPrefix("/static/").Handler(...) means that the handler will be passed any
-request that matches "/static/*". This makes it easy to serve static files with mux:
+request that matches "/static/*". Router.ServeFiles registers such a route for the
+files of a http.FileSystem, returning a *FileError for files which can't be served:
 
 	func main() {
 		var dir string
@@ -157,7 +162,7 @@ request that matches "/static/*". This makes it easy to serve static files with
 		r := mux.NewRouter()
 
 		// This will serve files under http://localhost:8000/static/<filename>
-		r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir(dir))))
+		r.ServeFiles("/static/{path:*}", http.Dir(dir))
 
 		srv := &http.Server{
 			Handler:      r,
@@ -0,0 +1,238 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultDumpBodySize is the body size cap used when DumpOptions.MaxBodySize is zero.
+const defaultDumpBodySize = 64 << 10
+
+// redactedValue replaces the values of redacted headers in dumps.
+const redactedValue = "[REDACTED]"
+
+// Dump is a captured request/response pair.
+type Dump struct {
+	// Request is the wire representation of the request including its
+	// (possibly truncated) body.
+	Request []byte
+	// Response is the wire representation of the response including its
+	// (possibly truncated) body.
+	Response []byte
+	// Status is the status code sent by the handler.
+	Status int
+	// Duration is the time spent in the wrapped handler.
+	Duration time.Duration
+	// Err is the error returned by the wrapped handler.
+	Err error
+}
+
+// DumpOptions configures a DumpMiddleware.
+type DumpOptions struct {
+	// Output receives every dump in a human-readable form. It may be nil if
+	// Callback is set. Writes to Output are serialized.
+	Output io.Writer
+	// Callback is invoked with every dump.
+	Callback func(ctx context.Context, dump *Dump)
+	// MaxBodySize caps the number of body bytes captured for both request and
+	// response. Zero selects a default of 64KiB, a negative value disables
+	// body capturing.
+	MaxBodySize int64
+	// RedactHeaders lists header names whose values are replaced in dumps.
+	// Authorization, Cookie and Set-Cookie are always redacted.
+	RedactHeaders []string
+	// Enabled sets the initial state of the middleware.
+	Enabled bool
+}
+
+// DumpMiddleware captures full request and response dumps for debugging.
+// It can be switched on and off at runtime and costs a single atomic load
+// per request while disabled.
+type DumpMiddleware struct {
+	enabled atomic.Bool
+	options DumpOptions
+	redact  map[string]bool
+	mu      sync.Mutex
+}
+
+// NewDumpMiddleware returns a DumpMiddleware configured with options.
+func NewDumpMiddleware(options DumpOptions) *DumpMiddleware {
+	if options.MaxBodySize == 0 {
+		options.MaxBodySize = defaultDumpBodySize
+	}
+
+	d := &DumpMiddleware{
+		options: options,
+		redact: map[string]bool{
+			"Authorization": true,
+			"Cookie":        true,
+			"Set-Cookie":    true,
+		},
+	}
+	for _, h := range options.RedactHeaders {
+		d.redact[http.CanonicalHeaderKey(h)] = true
+	}
+	d.enabled.Store(options.Enabled)
+
+	return d
+}
+
+// SetEnabled switches dumping on or off.
+func (d *DumpMiddleware) SetEnabled(enabled bool) {
+	d.enabled.Store(enabled)
+}
+
+// Enabled reports whether dumping is switched on.
+func (d *DumpMiddleware) Enabled() bool {
+	return d.enabled.Load()
+}
+
+// Middleware implements the middleware interface.
+func (d *DumpMiddleware) Middleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		if !d.enabled.Load() {
+			return next(ctx, w, req, binder)
+		}
+
+		reqBody := d.captureRequestBody(req)
+		rw := NewResponseRecorderWriter(w)
+		capture := &bodyCapture{limit: d.options.MaxBodySize}
+		rw.OnWrite(capture.write)
+
+		start := time.Now()
+		err := next(ctx, rw, req, binder)
+		status := rw.StatusOrDefault(err)
+
+		dump := &Dump{
+			Request:  d.dumpRequest(req, reqBody),
+			Response: d.dumpResponse(req, rw.Header(), status, capture),
+			Status:   status,
+			Duration: time.Since(start),
+			Err:      err,
+		}
+		d.emit(ctx, req, dump)
+
+		return err
+	}
+}
+
+// captureRequestBody reads up to MaxBodySize bytes of the request body and
+// restores the body so the handler can still consume it in full.
+func (d *DumpMiddleware) captureRequestBody(req *http.Request) *bytes.Buffer {
+	if d.options.MaxBodySize < 0 || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	_, _ = io.CopyN(buf, req.Body, d.options.MaxBodySize)
+	req.Body = &multiReadCloser{
+		Reader: io.MultiReader(bytes.NewReader(buf.Bytes()), req.Body),
+		Closer: req.Body,
+	}
+
+	return buf
+}
+
+func (d *DumpMiddleware) dumpRequest(req *http.Request, body *bytes.Buffer) []byte {
+	r := req.Clone(req.Context())
+	r.Header = d.redactHeader(req.Header)
+	r.Body = nil
+
+	out, err := httputil.DumpRequest(r, false)
+	if err != nil {
+		out = []byte(fmt.Sprintf("%s %s\r\n\r\n", req.Method, req.URL.RequestURI()))
+	}
+	if body != nil {
+		out = append(out, body.Bytes()...)
+		if req.ContentLength > int64(body.Len()) {
+			out = append(out, fmt.Sprintf("\n[truncated %d bytes]", req.ContentLength-int64(body.Len()))...)
+		}
+	}
+
+	return out
+}
+
+func (d *DumpMiddleware) dumpResponse(req *http.Request, header http.Header, status int, body *bodyCapture) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %d %s\r\n", req.Proto, status, http.StatusText(status))
+	_ = d.redactHeader(header).WriteSubset(&buf, nil)
+	buf.WriteString("\r\n")
+	buf.Write(body.buf.Bytes())
+	if body.dropped > 0 {
+		fmt.Fprintf(&buf, "\n[truncated %d bytes]", body.dropped)
+	}
+
+	return buf.Bytes()
+}
+
+func (d *DumpMiddleware) redactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for k := range out {
+		if d.redact[k] {
+			out[k] = []string{redactedValue}
+		}
+	}
+	return out
+}
+
+func (d *DumpMiddleware) emit(ctx context.Context, req *http.Request, dump *Dump) {
+	if d.options.Output != nil {
+		var buf bytes.Buffer
+		buf.WriteString(">>> request\n")
+		buf.Write(dump.Request)
+		fmt.Fprintf(&buf, "\n<<< response (%s)\n", dump.Duration)
+		buf.Write(dump.Response)
+		if dump.Err != nil {
+			fmt.Fprintf(&buf, "\n!!! error: %v", dump.Err)
+		}
+		buf.WriteString("\n\n")
+
+		d.mu.Lock()
+		_, err := d.options.Output.Write(buf.Bytes())
+		d.mu.Unlock()
+		if err != nil {
+			requestLogger(req).Log(ctx, slog.LevelError, "mux: writing dump failed", "error", err)
+		}
+	}
+
+	if d.options.Callback != nil {
+		d.options.Callback(ctx, dump)
+	}
+}
+
+// multiReadCloser combines a replayed body prefix with the original body closer.
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyCapture records the first bytes of a response body.
+type bodyCapture struct {
+	limit   int64
+	buf     bytes.Buffer
+	dropped int64
+}
+
+func (c *bodyCapture) write(b []byte) {
+	if c.limit <= 0 {
+		return
+	}
+	room := c.limit - int64(c.buf.Len())
+	switch {
+	case room <= 0:
+		c.dropped += int64(len(b))
+	case int64(len(b)) <= room:
+		c.buf.Write(b)
+	default:
+		c.buf.Write(b[:room])
+		c.dropped += int64(len(b)) - room
+	}
+}
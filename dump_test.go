@@ -0,0 +1,75 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDumpMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	var dumps []*Dump
+
+	dumper := NewDumpMiddleware(DumpOptions{
+		Output:        &out,
+		Callback:      func(ctx context.Context, dump *Dump) { dumps = append(dumps, dump) },
+		MaxBodySize:   4,
+		RedactHeaders: []string{"x-api-key"},
+	})
+
+	router := NewRouter()
+	router.Use(dumper.Middleware)
+	router.HandleFunc("/echo", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, err = w.Write(body)
+		return err
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/echo", strings.NewReader("hello world"))
+		req.Header.Set("X-Api-Key", "secret")
+		req.Header.Set("Authorization", "Bearer secret")
+		rw := httptest.NewRecorder()
+		if err := router.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+			t.Fatal(err)
+		}
+		return rw
+	}
+
+	if rw := serve(); rw.Body.String() != "hello world" || len(dumps) != 0 {
+		t.Fatalf("disabled dumper interfered: body %q, %d dumps", rw.Body.String(), len(dumps))
+	}
+
+	dumper.SetEnabled(true)
+	if rw := serve(); rw.Body.String() != "hello world" {
+		t.Fatalf("handler did not receive full body, got %q", rw.Body.String())
+	}
+
+	if len(dumps) != 1 {
+		t.Fatalf("expected 1 dump, got %d", len(dumps))
+	}
+	dump := dumps[0]
+	if dump.Status != http.StatusCreated {
+		t.Errorf("expected status %d, got %d", http.StatusCreated, dump.Status)
+	}
+	if strings.Contains(string(dump.Request), "secret") {
+		t.Errorf("redacted header leaked into dump:\n%s", dump.Request)
+	}
+	if !strings.Contains(string(dump.Request), "hell\n[truncated 7 bytes]") {
+		t.Errorf("request body was not capped:\n%s", dump.Request)
+	}
+	if !strings.Contains(string(dump.Response), "hell\n[truncated 7 bytes]") {
+		t.Errorf("response body was not capped:\n%s", dump.Response)
+	}
+	if !strings.Contains(out.String(), ">>> request") {
+		t.Errorf("expected dump written to output, got %q", out.String())
+	}
+}
@@ -0,0 +1,62 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRouteEnabled(t *testing.T) {
+	var flag atomic.Bool
+	enabled := func(ctx context.Context, r *http.Request) bool { return flag.Load() }
+
+	router := NewRouter()
+	router.HandleFunc("/items", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		_, err := w.Write([]byte("new"))
+		return err
+	}).Methods("POST").Enabled(enabled)
+	router.HandleFunc("/items", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		_, err := w.Write([]byte("old"))
+		return err
+	}).Methods("GET")
+
+	serve := func(method, path string) *ResponseRecorder {
+		rw := NewRecorder()
+		_ = router.ServeHTTP(context.Background(), rw, newRequest(method, "http://localhost"+path), nil)
+		return rw
+	}
+
+	// As if unregistered, only the GET route remains.
+	if rw := serve("POST", "/items"); rw.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected disabled route to fall through to 405, got %d", rw.Code)
+	}
+	if rw := serve("GET", "/items"); rw.Body.String() != "old" {
+		t.Errorf("expected the enabled route to match, got %q", rw.Body.String())
+	}
+
+	flag.Store(true)
+	if rw := serve("POST", "/items"); rw.Body.String() != "new" {
+		t.Errorf("expected the route to be switched on, got %d %q", rw.Code, rw.Body.String())
+	}
+}
+
+func TestRouteEnabledSubrouter(t *testing.T) {
+	router := NewRouter()
+	sub := router.PathPrefix("/beta").Enabled(func(ctx context.Context, r *http.Request) bool {
+		return r.Header.Get("X-Beta") != ""
+	}).Subrouter()
+	sub.HandleFunc("/feature", dummyHandler)
+
+	rw := NewRecorder()
+	_ = router.ServeHTTP(context.Background(), rw, newRequest("GET", "http://localhost/beta/feature"), nil)
+	if rw.Code != http.StatusNotFound {
+		t.Errorf("expected disabled subrouter to be skipped, got %d", rw.Code)
+	}
+
+	rw = NewRecorder()
+	_ = router.ServeHTTP(context.Background(), rw, newRequestWithHeaders("GET", "http://localhost/beta/feature", "X-Beta", "1"), nil)
+	if rw.Code == http.StatusNotFound {
+		t.Error("expected enabled subrouter to match")
+	}
+}
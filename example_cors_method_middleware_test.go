@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -38,3 +39,32 @@ func ExampleCORSMethodMiddleware() {
 	// GET,PUT,PATCH,OPTIONS
 	// http://example.com
 }
+
+func ExampleRouter_EnableCORSPreflight() {
+	r := mux.NewRouter().EnableCORSPreflight()
+
+	r.HandleFunc("/foo", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		// Handle the request
+		return nil
+	}).Methods(http.MethodGet, http.MethodPut).CORS(mux.CORSPolicy{
+		AllowedOrigins: []string{"http://example.com"},
+		MaxAge:         24 * time.Hour,
+	})
+
+	rw := httptest.NewRecorder()
+	req, _ := http.NewRequest("OPTIONS", "/foo", nil)
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	req.Header.Set("Origin", "http://example.com")
+
+	r.ServeHTTP(context.Background(), rw, req, nil)
+
+	fmt.Println(rw.Code)
+	fmt.Println(rw.Header().Get("Access-Control-Allow-Methods"))
+	fmt.Println(rw.Header().Get("Access-Control-Allow-Origin"))
+	fmt.Println(rw.Header().Get("Access-Control-Max-Age"))
+	// Output:
+	// 204
+	// GET,PUT
+	// http://example.com
+	// 86400
+}
@@ -0,0 +1,176 @@
+package mux
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// RouteExample is an example call of a route, generated from the request
+// and response types set with Route.RequestType and Route.ResponseType.
+type RouteExample struct {
+	// Curl is a curl command calling the route, with the variables of the
+	// route as placeholders, such as "{id}".
+	Curl string `json:"curl"`
+	// Request and Response are example bodies, as indented JSON.
+	Request  json.RawMessage `json:"request,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// exampleMaxDepth limits the nesting of generated examples, which would
+// otherwise be infinite for recursive types.
+const exampleMaxDepth = 5
+
+// example returns the example of the route, or nil if it has neither a
+// request nor a response type. baseURL defaults to "http://localhost".
+func (r *Route) example(baseURL string) *RouteExample {
+	request, hasRequest := r.metadata[requestTypeKey{}].(bodyType)
+	response, hasResponse := r.metadata[responseTypeKey{}].(bodyType)
+	if !hasRequest && !hasResponse {
+		return nil
+	}
+	if baseURL == "" {
+		baseURL = "http://localhost"
+	}
+	if host, err := r.GetHostTemplate(); err == nil {
+		scheme := "http"
+		if r.buildScheme != "" {
+			scheme = r.buildScheme
+		}
+		baseURL = scheme + "://" + stripVarPatterns(host)
+	}
+
+	target := strings.TrimSuffix(baseURL, "/")
+	if path, err := r.GetPathTemplate(); err == nil {
+		target += stripVarPatterns(path)
+	}
+	if queries, err := r.GetQueriesTemplates(); err == nil {
+		for i, q := range queries {
+			if i == 0 {
+				target += "?"
+			} else {
+				target += "&"
+			}
+			target += stripVarPatterns(q)
+		}
+	}
+
+	ex := &RouteExample{}
+	method := "GET"
+	if methods, err := r.GetMethods(); err == nil && len(methods) > 0 {
+		method = methods[0]
+	}
+	curl := []string{"curl"}
+	if method != "GET" {
+		curl = append(curl, "-X", method)
+	}
+	curl = append(curl, shellQuote(target))
+	if hasRequest && request.typ != nil {
+		ex.Request = exampleJSON(request)
+		curl = append(curl, "-H", shellQuote("Content-Type: application/json"), "-d", shellQuote(string(ex.Request)))
+	}
+	if hasResponse && response.typ != nil {
+		ex.Response = exampleJSON(response)
+	}
+	ex.Curl = strings.Join(curl, " ")
+	return ex
+}
+
+// stripVarPatterns returns the template tpl with the patterns of its
+// variables removed, "{id:[0-9]+}" becoming "{id}".
+func stripVarPatterns(tpl string) string {
+	idxs, err := braceIndices(tpl)
+	if err != nil {
+		return tpl
+	}
+	var b strings.Builder
+	end := 0
+	for i := 0; i < len(idxs); i += 2 {
+		name, _, _ := strings.Cut(tpl[idxs[i]+1:idxs[i+1]-1], ":")
+		b.WriteString(tpl[end:idxs[i]])
+		b.WriteString("{" + name + "}")
+		end = idxs[i+1]
+	}
+	b.WriteString(tpl[end:])
+	return b.String()
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// exampleJSON returns the example of body as indented JSON: its value unless
+// it is, or points to, the zero value, and else a value of its type filled
+// with sample values.
+func exampleJSON(body bodyType) json.RawMessage {
+	v := reflect.ValueOf(body.value)
+	elem := v
+	for elem.Kind() == reflect.Pointer && !elem.IsNil() {
+		elem = elem.Elem()
+	}
+	if !elem.IsValid() || elem.IsZero() {
+		v = reflect.New(body.typ).Elem()
+		fillExample(v, 0)
+	}
+	data, err := json.MarshalIndent(v.Interface(), "", "  ")
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// fillExample sets v to a sample value of its type.
+func fillExample(v reflect.Value, depth int) {
+	if depth > exampleMaxDepth {
+		return
+	}
+	if v.Type() == timeType {
+		v.Set(reflect.ValueOf(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)))
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("string")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		fillExample(elem.Elem(), depth+1)
+		v.Set(elem)
+	case reflect.Slice:
+		s := reflect.MakeSlice(v.Type(), 1, 1)
+		fillExample(s.Index(0), depth+1)
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fillExample(v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		m := reflect.MakeMap(v.Type())
+		key := reflect.New(v.Type().Key()).Elem()
+		key.SetString("key")
+		elem := reflect.New(v.Type().Elem()).Elem()
+		fillExample(elem, depth+1)
+		m.SetMapIndex(key, elem)
+		v.Set(m)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fillExample(v.Field(i), depth+1)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type exampleNode struct {
+	Name     string         `json:"name"`
+	Created  time.Time      `json:"created"`
+	Tags     []string       `json:"tags"`
+	Labels   map[string]int `json:"labels"`
+	Children []*exampleNode `json:"children,omitempty"`
+}
+
+func TestRouteExample(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/teams/{team:[a-z]+}/nodes", dummyHandler).Methods(http.MethodPut).
+		Host("{region}.example.com").Schemes("https").
+		RequestType(exampleNode{Name: "it's"}).
+		ResponseType(&exampleNode{})
+	r.HandleFunc("/plain", dummyHandler)
+
+	ex := r.getRoutes()[0].example("")
+	wantCurl := `curl -X PUT 'https://{region}.example.com/teams/{team}/nodes' -H 'Content-Type: application/json' -d '{
+  "name": "it'\''s",
+  "created": "0001-01-01T00:00:00Z",
+  "tags": null,
+  "labels": null
+}'`
+	if ex == nil || ex.Curl != wantCurl {
+		t.Fatalf("expected curl\n%s\ngot\n%+v", wantCurl, ex)
+	}
+	var node exampleNode
+	if err := json.Unmarshal(ex.Response, &node); err != nil {
+		t.Fatal(err)
+	}
+	if node.Name != "string" || node.Created.IsZero() || len(node.Tags) != 1 || node.Labels["key"] != 1 || len(node.Children) != 1 || node.Children[0].Name != "string" {
+		t.Errorf("unexpected response example %s", ex.Response)
+	}
+	if r.getRoutes()[1].example("") != nil {
+		t.Error("expected no example for a route without types")
+	}
+}
+
+func TestDebugHandlerExamples(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/users", dummyHandler).Methods(http.MethodPost).RequestType(struct {
+		Name string `json:"name"`
+	}{})
+	rw := NewRecorder()
+	req := newRequest(http.MethodGet, "http://api.example.com/_routes?format=json")
+	if err := r.DebugHandler()(context.Background(), rw, req, nil); err != nil {
+		t.Fatal(err)
+	}
+	var routes []RouteInfo
+	if err := json.Unmarshal(rw.Body.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 1 || routes[0].Example == nil || !strings.HasPrefix(routes[0].Example.Curl, "curl -X POST 'http://api.example.com/users'") {
+		t.Errorf("unexpected routes %s", rw.Body.String())
+	}
+}
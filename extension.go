@@ -0,0 +1,87 @@
+package mux
+
+import (
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// extensionPattern is the default pattern of extension variables.
+const extensionPattern = "[a-zA-Z0-9]+"
+
+// extensionMediaTypes are the media types of the formats selected by
+// extension variables, for the formats mime.TypeByExtension may not know.
+var extensionMediaTypes = map[string]string{
+	"csv":  "text/csv",
+	"html": "text/html",
+	"json": "application/json",
+	"txt":  "text/plain",
+	"xml":  "application/xml",
+	"yaml": "application/yaml",
+}
+
+// formatList matches the patterns of extension variables listing their
+// formats, such as "json|csv".
+var formatList = regexp.MustCompile(`^[a-zA-Z0-9]+(?:\|[a-zA-Z0-9]+)*$`)
+
+// ExtensionVars defines whether the path templates of new routes accept
+// extension variables, such as "{.format:json|csv}" in
+// "/reports/{id}{.format:json|csv}", selecting the response format, see
+// Route.Path. The initial value is false, for which variable names starting
+// with a dot are ordinary variables as in earlier versions: "{.format}"
+// matches a path segment into the variable ".format".
+//
+//	r.ExtensionVars(true)
+//	r.HandleFunc("/reports/{id}{.format:json|csv}", getReport)
+func (r *Router) ExtensionVars(value bool) *Router {
+	r.extensionVars = value
+	return r
+}
+
+// isExtensionTag reports whether the template tag, with braces, declares an
+// extension variable, such as "{.format}", with Router.ExtensionVars.
+func isExtensionTag(tag string) bool {
+	return strings.HasPrefix(tag, "{.")
+}
+
+// isExtensionVar reports whether the variable at index i is an extension
+// variable.
+func (r *routeRegexp) isExtensionVar(i int) bool {
+	return r.extension && i == len(r.varsN)-1
+}
+
+// negotiateExtension returns the format of the extension variable for a
+// request without an extension: the format listed by the pattern of the
+// variable with the highest weight in the Accept header, the first one on
+// ties and without an Accept header. It returns "" if the pattern does not
+// list the formats, or none is acceptable.
+func (r *routeRegexp) negotiateExtension(req *http.Request) string {
+	patt := r.varsP[len(r.varsP)-1]
+	if !formatList.MatchString(patt) {
+		return ""
+	}
+	formats := strings.Split(patt, "|")
+	accept := req.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return formats[0]
+	}
+
+	best, bestQ := "", 0.0
+	for _, format := range formats {
+		if q := acceptQuality(accept, formatMediaType(format)); q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// formatMediaType returns the media type of format, without parameters.
+func formatMediaType(format string) string {
+	format = strings.ToLower(format)
+	if mediaType, ok := extensionMediaTypes[format]; ok {
+		return mediaType
+	}
+	mediaType, _, _ := strings.Cut(mime.TypeByExtension("."+format), ";")
+	return strings.TrimSpace(mediaType)
+}
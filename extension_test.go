@@ -0,0 +1,113 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestPathExtension(t *testing.T) {
+	r := NewRouter().ExtensionVars(true)
+	report := func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		vars := Vars(req)
+		format, ok := vars["format"]
+		if !ok {
+			format = "-"
+		}
+		_, err := w.Write([]byte(vars["id"] + " " + format + " " + Var(req, "format")))
+		return err
+	}
+	r.HandleFunc("/reports/{id}{.format:json|csv}", report).Name("report")
+	r.HandleFunc("/files/{id}{.format}", report)
+	r.PathPrefix("/api").Subrouter().HandleFunc("/reports/{id:[0-9]+}{.format:json|xml}", report)
+
+	tests := []struct {
+		path, accept string
+		body         string
+	}{
+		{path: "/reports/42.json", body: "42 json json"},
+		{path: "/reports/42.csv", accept: "application/json", body: "42 csv csv"},
+		{path: "/reports/42", body: "42 json json"},
+		{path: "/reports/42", accept: "text/csv, application/json;q=0.5", body: "42 csv csv"},
+		{path: "/reports/42", accept: "text/*", body: "42 csv csv"},
+		{path: "/reports/42", accept: "image/png", body: "42 - "},
+		{path: "/reports/v1.2", body: "v1.2 json json"},
+		{path: "/reports/v1.2.csv", body: "v1.2 csv csv"},
+		{path: "/reports/42.pdf", body: "42.pdf json json"},
+		{path: "/files/notes.txt", body: "notes txt txt"},
+		{path: "/files/notes", accept: "text/plain", body: "notes - "},
+		{path: "/api/reports/7.xml", body: "7 xml xml"},
+		{path: "/api/reports/7", accept: "application/xml", body: "7 xml xml"},
+		{path: "/api/reports/7.csv", body: "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		rw := NewRecorder()
+		req := newRequest(http.MethodGet, "http://localhost"+tt.path)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		if err := r.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+			t.Fatal(err)
+		}
+		if rw.Body.String() != tt.body {
+			t.Errorf("%s (Accept %q): expected %q, got %q", tt.path, tt.accept, tt.body, rw.Body.String())
+		}
+	}
+}
+
+func TestPathExtensionURL(t *testing.T) {
+	route := NewRouter().ExtensionVars(true).Path("/reports/{id}{.format:json|csv}")
+	for _, tt := range []struct {
+		pairs []string
+		path  string
+	}{
+		{pairs: []string{"id", "42", "format", "csv"}, path: "/reports/42.csv"},
+		{pairs: []string{"id", "42", "format", ""}, path: "/reports/42"},
+		{pairs: []string{"id", "42"}, path: "/reports/42"},
+	} {
+		u, err := route.URLPath(tt.pairs...)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.pairs, err)
+		}
+		if u.Path != tt.path {
+			t.Errorf("%v: expected %q, got %q", tt.pairs, tt.path, u.Path)
+		}
+	}
+
+	var urlErr *URLError
+	if _, err := route.URLPath("id", "42", "format", "pdf"); !errors.As(err, &urlErr) || urlErr.Variable != "format" {
+		t.Errorf("expected an error for the format variable, got %v", err)
+	}
+}
+
+func TestPathExtensionErrors(t *testing.T) {
+	for _, tpl := range []string{"/reports/{.format}/{id}", "/reports/{.format}.gz", "/reports/{.}"} {
+		var tplErr *TemplateError
+		if _, err := newRouteRegexp(tpl, regexpTypePath, routeRegexpOptions{extensionVars: true}); !errors.As(err, &tplErr) {
+			t.Errorf("%s: expected a template error, got %v", tpl, err)
+		}
+	}
+}
+
+func TestPathExtensionDisabled(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/reports/{.id}.gz", func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		_, err := w.Write([]byte(Var(req, ".id")))
+		return err
+	})
+	r.HandleFunc("/files/{id}{.format}", dummyHandler)
+
+	rw := NewRecorder()
+	if err := r.ServeHTTP(context.Background(), rw, newRequest("GET", "http://localhost/reports/42.gz"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if rw.Body.String() != "42" {
+		t.Errorf("expected the variable .id, got %q", rw.Body.String())
+	}
+
+	var match RouteMatch
+	if !r.Match(newRequest("GET", "http://localhost/files/notes.txt"), &match) || match.Vars["id"] == "" || match.Vars[".format"] == "" {
+		t.Errorf("expected {.format} to be an ordinary variable, got %v", match.Vars)
+	}
+}
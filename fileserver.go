@@ -0,0 +1,275 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// FileError is the error returned by the handlers of FileServer and
+// Router.ServeFiles for requests which can't be served. It unwraps to the
+// error of the file system, so errors.Is(err, fs.ErrNotExist) reports
+// missing files.
+type FileError struct {
+	Status int
+	Path   string
+	Err    error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("mux: serve file %q: %v", e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e *FileError) StatusCode() int {
+	return e.Status
+}
+
+// ErrorStatus returns the HTTP status code for err: the status of the first
+// error in its chain with a StatusCode method, such as *FileError, or 500.
+func ErrorStatus(err error) int {
+	var se interface{ StatusCode() int }
+	if errors.As(err, &se) {
+		if status := se.StatusCode(); status != 0 {
+			return status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// statusError is an error with the HTTP status code of its response.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+func (e *statusError) StatusCode() int {
+	return e.status
+}
+
+// FileServer returns a handler serving the files of fsys at the request
+// path. Directories are served by their index.html file. Unlike
+// http.FileServer, nothing is written for requests which can't be served:
+// a *FileError is returned instead, with status 404 for missing files and
+// directories without an index, 403 for files which may not be read and 500
+// otherwise.
+//
+// Byte ranges and conditional requests are supported, with ETags derived
+// from the modification time and size of files, or from their content for
+// files without a modification time such as those of an embed.FS.
+// Precompressed sidecar files, "name.br" and "name.gz", are served instead of
+// the file "name" to clients accepting their encoding.
+func FileServer(fsys http.FileSystem) HandlerFunc {
+	return fileServer(fsys, "", nil)
+}
+
+// ServeFiles registers a route serving the files of fsys, see FileServer. The
+// path template tpl must end with a variable, which selects the file, e.g.
+// "/static/{path:*}". The pattern "*" of the variable matches the rest of the
+// path, including slashes:
+//
+//	r.ServeFiles("/static/{path:*}", http.Dir("public"))
+func (r *Router) ServeFiles(tpl string, fsys http.FileSystem) *Route {
+	return r.serveFiles(tpl, fsys, nil)
+}
+
+func (r *Router) serveFiles(tpl string, fsys http.FileSystem, listing *ListingOptions) *Route {
+	var name string
+	if i := strings.LastIndexByte(tpl, '{'); i != -1 && strings.HasSuffix(tpl, "}") {
+		name, _, _ = strings.Cut(tpl[i+1:len(tpl)-1], ":")
+		if strings.HasSuffix(tpl, ":*}") {
+			tpl = tpl[:len(tpl)-len("*}")] + ".*}"
+		}
+	}
+	route := r.NewRoute().Path(tpl).Methods(http.MethodGet, http.MethodHead)
+	if name == "" {
+		route.setErr(fmt.Errorf("mux: file server template %q does not end with a variable", tpl))
+		return route
+	}
+	return route.Handler(fileServer(fsys, name, listing))
+}
+
+// fileServer returns a handler serving the files of fsys at the value of the
+// route variable name, or at the request path if name is empty. Directories
+// without an index are listed if listing is not nil.
+func fileServer(fsys http.FileSystem, name string, listing *ListingOptions) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		p := req.URL.Path
+		if name != "" {
+			p = Vars(req)[name]
+		}
+		p = path.Clean("/" + p)
+		err := serveFile(w, req, fsys, p)
+		if fe := (*FileError)(nil); listing != nil && errors.As(err, &fe) && fe.Path == p && errors.Is(err, fs.ErrNotExist) {
+			if listed, lerr := listDirectory(w, req, fsys, p, listing); listed {
+				return lerr
+			}
+		}
+		return err
+	}
+}
+
+// serveFile serves the file name of fsys, or the index.html file if name is a
+// directory.
+func serveFile(w http.ResponseWriter, req *http.Request, fsys http.FileSystem, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return fileError(name, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fileError(name, err)
+	}
+	if info.IsDir() {
+		index := path.Join(name, "index.html")
+		ff, err := fsys.Open(index)
+		if err != nil {
+			return fileError(name, err)
+		}
+		defer ff.Close()
+		fi, err := ff.Stat()
+		if err != nil {
+			return fileError(index, err)
+		}
+		if fi.IsDir() {
+			return fileError(index, fs.ErrNotExist)
+		}
+		name, f, info = index, ff, fi
+	}
+	return serveContent(w, req, fsys, name, f, info)
+}
+
+// precompressed lists the encodings of the precompressed sidecar files served
+// by serveContent, in order of preference, with their file extension.
+var precompressed = []struct{ encoding, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// serveContent serves the file f, or a precompressed sidecar file of it such
+// as "app.js.br" if the client accepts its encoding. Range requests and the
+// conditional request headers are answered by http.ServeContent, based on the
+// modification time and an ETag derived from the file.
+func serveContent(w http.ResponseWriter, req *http.Request, fsys http.FileSystem, name string, f http.File, info fs.FileInfo) error {
+	h := w.Header()
+	vary := false
+	for _, p := range precompressed {
+		sidecar, sinfo, err := openSidecar(fsys, name+p.ext)
+		if err != nil {
+			continue
+		}
+		defer sidecar.Close()
+		if !vary {
+			h.Add("Vary", "Accept-Encoding")
+			vary = true
+		}
+		if !acceptsEncoding(req.Header.Get("Accept-Encoding"), p.encoding) {
+			continue
+		}
+		if h.Get("Content-Type") == "" {
+			ctype := mime.TypeByExtension(path.Ext(name))
+			if ctype == "" {
+				ctype = "application/octet-stream"
+			}
+			h.Set("Content-Type", ctype)
+		}
+		h.Set("Content-Encoding", p.encoding)
+		f, info = sidecar, sinfo
+		break
+	}
+	if h.Get("Etag") == "" {
+		etag, err := fileETag(f, info)
+		if err != nil {
+			return fileError(name, err)
+		}
+		h.Set("Etag", etag)
+	}
+	http.ServeContent(w, req, path.Base(name), info.ModTime(), f)
+	return nil
+}
+
+// openSidecar opens the regular file name of fsys.
+func openSidecar(fsys http.FileSystem, name string) (http.File, fs.FileInfo, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err == nil && info.IsDir() {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, info, nil
+}
+
+// fileETag returns a strong ETag for f derived from its size and modification
+// time, or from a hash of its content if it has no modification time, as is
+// the case for the files of an embed.FS.
+func fileETag(f http.File, info fs.FileInfo) (string, error) {
+	if !info.ModTime().IsZero() {
+		return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()), nil
+	}
+	hash := fnv.New64a()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`"%x-%x"`, hash.Sum64(), info.Size()), nil
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header value accepts
+// encoding.
+func acceptsEncoding(accept, encoding string) bool {
+	for _, value := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(value, ";")
+		coding = strings.TrimSpace(coding)
+		if !strings.EqualFold(coding, encoding) && coding != "*" {
+			continue
+		}
+		q, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q=")
+		if !ok {
+			return true
+		}
+		weight, err := strconv.ParseFloat(q, 64)
+		return err == nil && weight > 0
+	}
+	return false
+}
+
+// fileError wraps an error of the file system.
+func fileError(name string, err error) *FileError {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		status = http.StatusNotFound
+	case errors.Is(err, fs.ErrPermission):
+		status = http.StatusForbidden
+	}
+	return &FileError{Status: status, Path: name, Err: err}
+}
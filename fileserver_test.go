@@ -0,0 +1,146 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testFiles = fstest.MapFS{
+	"index.html":        {Data: []byte("home")},
+	"css/site.css":      {Data: []byte("body{}")},
+	"docs/index.html":   {Data: []byte("docs")},
+	"empty/placeholder": {Data: []byte("")},
+}
+
+func TestServeFiles(t *testing.T) {
+	router := NewRouter()
+	router.ServeFiles("/static/{path:*}", http.FS(testFiles))
+
+	tests := []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{"http://localhost/static/css/site.css", http.StatusOK, "body{}"},
+		{"http://localhost/static/", http.StatusOK, "home"},
+		{"http://localhost/static/docs", http.StatusOK, "docs"},
+		{"http://localhost/static/missing.js", http.StatusNotFound, ""},
+		{"http://localhost/static/empty/", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rw := NewRecorder()
+		err := router.ServeHTTP(context.Background(), rw, newRequest(http.MethodGet, tt.url), nil)
+		if tt.status != http.StatusOK {
+			var fe *FileError
+			if !errors.As(err, &fe) || !errors.Is(err, fs.ErrNotExist) || ErrorStatus(err) != tt.status {
+				t.Errorf("%s: expected a file error with status %d, got %v", tt.url, tt.status, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.url, err)
+		}
+		if rw.Body.String() != tt.body {
+			t.Errorf("%s: expected body %q, got %q", tt.url, tt.body, rw.Body.String())
+		}
+	}
+
+	if err := router.ServeFiles("/static", http.FS(testFiles)).GetError(); err == nil {
+		t.Error("expected an error for a template without a variable")
+	}
+}
+
+func TestFileServer(t *testing.T) {
+	router := NewRouter()
+	router.PathPrefix("/").Handler(FileServer(http.FS(testFiles)))
+
+	rw := NewRecorder()
+	if err := router.ServeHTTP(context.Background(), rw, newRequest(http.MethodGet, "http://localhost/css/site.css"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if rw.Body.String() != "body{}" {
+		t.Errorf("unexpected body %q", rw.Body.String())
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	if status := ErrorStatus(errors.New("failed")); status != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", status)
+	}
+	err := fmt.Errorf("wrapped: %w", &FileError{Status: http.StatusForbidden, Err: fs.ErrPermission})
+	if status := ErrorStatus(err); status != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", status)
+	}
+}
+
+func TestFileServerContent(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := fstest.MapFS{
+		"app.js":          {Data: []byte("console.log(1)"), ModTime: modTime},
+		"app.js.br":       {Data: []byte("brotli"), ModTime: modTime},
+		"app.js.gz":       {Data: []byte("gzip"), ModTime: modTime},
+		"embedded.txt":    {Data: []byte("0123456789")},
+		"data.unknown":    {Data: []byte("raw")},
+		"data.unknown.gz": {Data: []byte("gz")},
+	}
+	router := NewRouter()
+	router.ServeFiles("/{path:*}", http.FS(files))
+	serve := func(url string, headers ...string) *ResponseRecorder {
+		rw := NewRecorder()
+		if err := router.ServeHTTP(context.Background(), rw, newRequestWithHeaders(http.MethodGet, url, headers...), nil); err != nil {
+			t.Fatal(err)
+		}
+		return rw
+	}
+
+	tests := []struct {
+		url, acceptEncoding string
+		body, encoding      string
+		contentType         string
+	}{
+		{"http://localhost/app.js", "", "console.log(1)", "", "text/javascript; charset=utf-8"},
+		{"http://localhost/app.js", "gzip, br", "brotli", "br", "text/javascript; charset=utf-8"},
+		{"http://localhost/app.js", "gzip", "gzip", "gzip", "text/javascript; charset=utf-8"},
+		{"http://localhost/app.js", "br;q=0, gzip;q=0.5", "gzip", "gzip", "text/javascript; charset=utf-8"},
+		{"http://localhost/app.js", "*", "brotli", "br", "text/javascript; charset=utf-8"},
+		{"http://localhost/data.unknown", "gzip", "gz", "gzip", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		rw := serve(tt.url, "Accept-Encoding", tt.acceptEncoding)
+		if rw.Body.String() != tt.body || rw.Header().Get("Content-Encoding") != tt.encoding {
+			t.Errorf("%s with %q: unexpected response %q encoded %q", tt.url, tt.acceptEncoding, rw.Body.String(), rw.Header().Get("Content-Encoding"))
+		}
+		if rw.Header().Get("Content-Type") != tt.contentType || rw.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%s with %q: unexpected headers %v", tt.url, tt.acceptEncoding, rw.Header())
+		}
+	}
+
+	rw := serve("http://localhost/app.js")
+	etag := rw.Header().Get("Etag")
+	if etag == "" || rw.Header().Get("Last-Modified") != modTime.Format(http.TimeFormat) {
+		t.Fatalf("expected ETag and Last-Modified headers, got %v", rw.Header())
+	}
+	if rw := serve("http://localhost/app.js", "If-None-Match", etag); rw.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for a matching ETag, got %d", rw.Code)
+	}
+	if rw := serve("http://localhost/app.js", "If-Modified-Since", modTime.Format(http.TimeFormat)); rw.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for If-Modified-Since, got %d", rw.Code)
+	}
+	if rw := serve("http://localhost/app.js", "Accept-Encoding", "br"); rw.Header().Get("Etag") == etag {
+		t.Error("expected a different ETag for the precompressed file")
+	}
+
+	rw = serve("http://localhost/embedded.txt", "Range", "bytes=2-4")
+	if rw.Code != http.StatusPartialContent || rw.Body.String() != "234" || rw.Header().Get("Content-Range") != "bytes 2-4/10" {
+		t.Errorf("unexpected range response %d %q %v", rw.Code, rw.Body.String(), rw.Header())
+	}
+	if rw.Header().Get("Etag") == "" || rw.Header().Get("Vary") != "" {
+		t.Errorf("expected a content ETag and no Vary header, got %v", rw.Header())
+	}
+}
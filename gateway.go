@@ -0,0 +1,159 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MountGateway mounts gateway, e.g. the runtime.ServeMux of grpc-gateway,
+// under the path prefix template prefix, so REST and gRPC services share a
+// router:
+//
+//	gw := runtime.NewServeMux()
+//	pb.RegisterUsersHandlerServer(ctx, gw, server)
+//	api := r.MountGateway("/api", gw)
+//	api.HandleFunc("/health", health)
+//
+// The prefix is stripped from the request path before it is passed to the
+// gateway, and the route variables and the values of the handler context
+// are available from the request context. The returned subrouter takes
+// precedence over the gateway: requests it does not match are served by the
+// gateway. Errors returned by its handlers are written as gateway style JSON
+// errors, see GatewayError.
+func (r *Router) MountGateway(prefix string, gateway http.Handler) *Router {
+	route := r.PathPrefix(prefix)
+	sub := route.Subrouter()
+	sub.Use(gatewayErrors)
+	sub.NotFoundHandler = gatewayErrors(WrapHTTPHandler(gatewayStripPrefix(route, gateway)))
+	sub.MethodNotAllowedHandler = gatewayErrors(func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		return &GatewayError{Status: http.StatusMethodNotAllowed}
+	})
+	return sub
+}
+
+// gatewayStripPrefix returns h serving requests with the path prefix of route
+// removed from the request path.
+func gatewayStripPrefix(route *Route, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var pairs []string
+		for name, value := range Vars(req) {
+			pairs = append(pairs, name, value)
+		}
+		u, err := route.URLPath(pairs...)
+		if err != nil {
+			WriteGatewayError(w, err)
+			return
+		}
+		prefix := strings.TrimSuffix(u.Path, "/")
+		path := strings.TrimPrefix(req.URL.Path, prefix)
+		if path == "" {
+			path = "/"
+		}
+		r2 := new(http.Request)
+		*r2 = *req
+		r2.URL = new(url.URL)
+		*r2.URL = *req.URL
+		r2.URL.Path = path
+		r2.URL.RawPath = ""
+		h.ServeHTTP(w, r2)
+	})
+}
+
+// gatewayErrors writes the errors returned by next as gateway style JSON
+// errors.
+func gatewayErrors(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		rw := NewResponseRecorderWriter(w)
+		if err := next(ctx, rw, req, binder); err != nil {
+			if rw.WroteHeader() {
+				return err
+			}
+			WriteGatewayError(rw, err)
+		}
+		return nil
+	}
+}
+
+// GatewayError is an error in the JSON format of grpc-gateway, which is the
+// JSON encoding of a google.rpc.Status. Handlers of a router mounted with
+// MountGateway may return it to choose the response status.
+type GatewayError struct {
+	// Status is the HTTP status code of the response. If Code is 0 it is
+	// derived from Status.
+	Status  int    `json:"-"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Details []any  `json:"details"`
+}
+
+func (e *GatewayError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return http.StatusText(e.Status)
+}
+
+// WriteGatewayError writes err as a gateway style JSON error. Errors which
+// are not a *GatewayError result in a response with the status of ErrorStatus
+// and the message of http.StatusText, which does not leak internal details to
+// clients.
+func WriteGatewayError(w http.ResponseWriter, err error) {
+	e := &GatewayError{Status: ErrorStatus(err)}
+	var ge *GatewayError
+	if errors.As(err, &ge) {
+		*e = *ge
+	}
+	if e.Status == 0 {
+		e.Status = http.StatusInternalServerError
+	}
+	if e.Code == 0 {
+		e.Code = grpcCodeFromStatus(e.Status)
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(e.Status)
+	}
+	if e.Details == nil {
+		e.Details = []any{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.Status)
+	_ = json.NewEncoder(w).Encode(e)
+}
+
+// grpcCodeFromStatus maps an HTTP status code to a gRPC status code, the
+// inverse of the mapping of grpc-gateway.
+func grpcCodeFromStatus(status int) int {
+	switch status {
+	case http.StatusOK:
+		return 0 // OK
+	case 499:
+		return 1 // Canceled
+	case http.StatusBadRequest:
+		return 3 // InvalidArgument
+	case http.StatusGatewayTimeout:
+		return 4 // DeadlineExceeded
+	case http.StatusNotFound:
+		return 5 // NotFound
+	case http.StatusConflict:
+		return 6 // AlreadyExists
+	case http.StatusForbidden:
+		return 7 // PermissionDenied
+	case http.StatusTooManyRequests:
+		return 8 // ResourceExhausted
+	case http.StatusPreconditionFailed:
+		return 9 // FailedPrecondition
+	case http.StatusNotImplemented, http.StatusMethodNotAllowed:
+		return 12 // Unimplemented
+	case http.StatusServiceUnavailable:
+		return 14 // Unavailable
+	case http.StatusUnauthorized:
+		return 16 // Unauthenticated
+	case http.StatusInternalServerError:
+		return 13 // Internal
+	}
+	return 2 // Unknown
+}
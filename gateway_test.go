@@ -0,0 +1,71 @@
+//go:build go1.22
+
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type gatewayKey struct{}
+
+func TestMountGateway(t *testing.T) {
+	gateway := http.NewServeMux()
+	gateway.HandleFunc("GET /v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "user %s of %s, %v", r.PathValue("id"), Var(r, "tenant"), r.Context().Value(gatewayKey{}))
+	})
+
+	router := NewRouter()
+	api := router.MountGateway("/api/{tenant}", gateway)
+	api.HandleFunc("/health", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		fmt.Fprint(w, "ok")
+		return nil
+	}).Methods(http.MethodGet)
+	api.HandleFunc("/fail", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		return &GatewayError{Status: http.StatusNotFound, Message: "no such thing"}
+	})
+	api.HandleFunc("/boom", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		return errors.New("secret")
+	})
+
+	serve := func(method, url string) *ResponseRecorder {
+		rw := NewRecorder()
+		ctx := context.WithValue(context.Background(), gatewayKey{}, "ctx")
+		if err := router.ServeHTTP(ctx, rw, newRequest(method, url), nil); err != nil {
+			t.Fatal(err)
+		}
+		return rw
+	}
+
+	if rw := serve(http.MethodGet, "http://localhost/api/acme/v1/users/7"); rw.Body.String() != "user 7 of acme, ctx" {
+		t.Errorf("unexpected gateway response %d %q", rw.Code, rw.Body.String())
+	}
+	if rw := serve(http.MethodGet, "http://localhost/api/acme/health"); rw.Body.String() != "ok" {
+		t.Errorf("unexpected route response %q", rw.Body.String())
+	}
+
+	tests := []struct {
+		method, url string
+		status      int
+		code        int
+		message     string
+	}{
+		{http.MethodGet, "http://localhost/api/acme/fail", http.StatusNotFound, 5, "no such thing"},
+		{http.MethodGet, "http://localhost/api/acme/boom", http.StatusInternalServerError, 13, "Internal Server Error"},
+		{http.MethodPost, "http://localhost/api/acme/health", http.StatusMethodNotAllowed, 12, "Method Not Allowed"},
+	}
+	for _, tt := range tests {
+		rw := serve(tt.method, tt.url)
+		var e GatewayError
+		if err := json.Unmarshal(rw.Body.Bytes(), &e); err != nil {
+			t.Fatalf("%s %s: %v in %q", tt.method, tt.url, err, rw.Body.String())
+		}
+		if rw.Code != tt.status || e.Code != tt.code || e.Message != tt.message || e.Details == nil {
+			t.Errorf("%s %s: unexpected error %d %+v", tt.method, tt.url, rw.Code, e)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// GRPCBinding binds a route to a unary gRPC method, like a google.api.http
+// annotation binds a method to an HTTP rule. It is set as route metadata
+// with Route.GRPC and used by GRPCTranscoder.
+type GRPCBinding struct {
+	// Method is the full name of the method, "/package.Service/Method".
+	Method string
+
+	// Body is the field of the request message the request body is decoded
+	// into: "*" for the whole message, a field name for that field, or ""
+	// if the request has no body.
+	Body string
+
+	// NewRequest and NewResponse return new request and response messages
+	// of the method.
+	NewRequest  func() any
+	NewResponse func() any
+}
+
+// grpcBindingKey is the metadata key of the GRPCBinding of a route.
+type grpcBindingKey struct{}
+
+// GRPC sets the gRPC method binding of the route, see GRPCTranscoder.
+func (r *Route) GRPC(binding GRPCBinding) *Route {
+	return r.Metadata(grpcBindingKey{}, binding)
+}
+
+// GRPCTranscoder is a Handler transcoding the requests of routes with a
+// GRPCBinding into unary gRPC calls, for migrating REST APIs to gRPC route by
+// route:
+//
+//	grpc := &mux.GRPCTranscoder{
+//	    Invoke: func(ctx context.Context, method string, req, reply any) error {
+//	        return conn.Invoke(ctx, method, req, reply)
+//	    },
+//	    Marshal:   func(v any) ([]byte, error) { return protojson.Marshal(v.(proto.Message)) },
+//	    Unmarshal: func(b []byte, v any) error { return protojson.Unmarshal(b, v.(proto.Message)) },
+//	}
+//	r.Handle("/v1/users/{user.id}", grpc).Methods(http.MethodPatch).GRPC(mux.GRPCBinding{
+//	    Method:      "/users.v1.Users/UpdateUser",
+//	    Body:        "user",
+//	    NewRequest:  func() any { return new(pb.UpdateUserRequest) },
+//	    NewResponse: func() any { return new(pb.User) },
+//	})
+//
+// The request message is built from a JSON object holding the route
+// variables and query parameters, with dots in their names denoting nested
+// fields, and the request body at the field of GRPCBinding.Body. Their
+// values are JSON strings, which protojson accepts for numbers as well; with
+// encoding/json numeric fields need the ",string" option. The response
+// message is written as JSON. Errors of the call are returned as is.
+type GRPCTranscoder struct {
+	// Invoke calls the method, e.g. with grpc.ClientConn.Invoke.
+	Invoke func(ctx context.Context, method string, req, reply any) error
+
+	// Marshal and Unmarshal convert messages from and to JSON. They default
+	// to the functions of encoding/json.
+	Marshal   func(v any) ([]byte, error)
+	Unmarshal func(data []byte, v any) error
+}
+
+// ServeHTTP implements Handler.
+func (t *GRPCTranscoder) ServeHTTP(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+	route := CurrentRoute(req)
+	if route == nil {
+		return errors.New("mux: grpc transcoding outside of a route")
+	}
+	value, err := route.GetMetadataValue(grpcBindingKey{})
+	if err != nil {
+		return errors.New("mux: route has no grpc binding")
+	}
+	binding := value.(GRPCBinding)
+
+	fields := make(map[string]any)
+	switch binding.Body {
+	case "":
+	case "*":
+		if err := decodeGRPCBody(req, &fields); err != nil {
+			return err
+		}
+	default:
+		var body any
+		if err := decodeGRPCBody(req, &body); err != nil {
+			return err
+		}
+		if body != nil {
+			fields[binding.Body] = body
+		}
+	}
+	// Path variables take precedence over query parameters.
+	for name, values := range req.URL.Query() {
+		setGRPCField(fields, name, values[0])
+	}
+	for name, value := range Vars(req) {
+		setGRPCField(fields, name, value)
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	unmarshal, marshal := t.Unmarshal, t.Marshal
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+	if marshal == nil {
+		marshal = json.Marshal
+	}
+	in := binding.NewRequest()
+	if err := unmarshal(data, in); err != nil {
+		return &statusError{status: http.StatusBadRequest, err: fmt.Errorf("mux: grpc request: %w", err)}
+	}
+	out := binding.NewResponse()
+	if err := t.Invoke(ctx, binding.Method, in, out); err != nil {
+		return err
+	}
+	data, err = marshal(out)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	return err
+}
+
+// decodeGRPCBody decodes the JSON body of req into v. An empty body leaves v
+// unchanged.
+func decodeGRPCBody(req *http.Request, v any) error {
+	if req.Body == nil {
+		return nil
+	}
+	if err := json.NewDecoder(req.Body).Decode(v); err != nil && err != io.EOF {
+		return &statusError{status: http.StatusBadRequest, err: fmt.Errorf("mux: grpc request body: %w", err)}
+	}
+	return nil
+}
+
+// setGRPCField sets the field path of fields, a dot separated list of field
+// names, to value.
+func setGRPCField(fields map[string]any, path, value string) {
+	name, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		fields[name] = value
+		return
+	}
+	sub, ok := fields[name].(map[string]any)
+	if !ok {
+		sub = make(map[string]any)
+		fields[name] = sub
+	}
+	setGRPCField(sub, rest, value)
+}
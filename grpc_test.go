@@ -0,0 +1,85 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type testUser struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type testUpdateUserRequest struct {
+	User testUser `json:"user"`
+	Mask string   `json:"mask"`
+}
+
+func TestGRPCTranscoder(t *testing.T) {
+	var calls []string
+	transcoder := &GRPCTranscoder{
+		Invoke: func(ctx context.Context, method string, req, reply any) error {
+			calls = append(calls, method)
+			switch method {
+			case "/users.v1.Users/UpdateUser":
+				in := req.(*testUpdateUserRequest)
+				*reply.(*testUser) = in.User
+				reply.(*testUser).Email = in.Mask
+			case "/users.v1.Users/GetUser":
+				*reply.(*testUser) = *req.(*testUser)
+			default:
+				return errors.New("unknown method")
+			}
+			return nil
+		},
+	}
+	router := NewRouter()
+	router.Handle("/v1/users/{user.id}", transcoder).Methods(http.MethodPatch).GRPC(GRPCBinding{
+		Method:      "/users.v1.Users/UpdateUser",
+		Body:        "user",
+		NewRequest:  func() any { return new(testUpdateUserRequest) },
+		NewResponse: func() any { return new(testUser) },
+	})
+	router.Handle("/v1/users/{id}", transcoder).Methods(http.MethodGet).GRPC(GRPCBinding{
+		Method:      "/users.v1.Users/GetUser",
+		NewRequest:  func() any { return new(testUser) },
+		NewResponse: func() any { return new(testUser) },
+	})
+	router.Handle("/v1/unbound", transcoder)
+
+	tests := []struct {
+		method, url, body string
+		want              string
+	}{
+		{http.MethodPatch, "http://localhost/v1/users/7?mask=name&user.id=8", `{"name":"ann","id":"9"}`, `{"id":"7","name":"ann","email":"name"}`},
+		{http.MethodGet, "http://localhost/v1/users/7?name=bob", ``, `{"id":"7","name":"bob","email":""}`},
+	}
+	for _, tt := range tests {
+		req := newRequest(tt.method, tt.url)
+		req.Body = io.NopCloser(strings.NewReader(tt.body))
+		rw := NewRecorder()
+		if err := router.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+			t.Fatalf("%s %s: %v", tt.method, tt.url, err)
+		}
+		if rw.Body.String() != tt.want || rw.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s %s: expected %s, got %s", tt.method, tt.url, tt.want, rw.Body.String())
+		}
+	}
+
+	req := newRequest(http.MethodPatch, "http://localhost/v1/users/7")
+	req.Body = io.NopCloser(strings.NewReader(`{`))
+	if err := router.ServeHTTP(context.Background(), NewRecorder(), req, nil); ErrorStatus(err) != http.StatusBadRequest {
+		t.Errorf("expected a 400 error for an invalid body, got %v", err)
+	}
+	if err := router.ServeHTTP(context.Background(), NewRecorder(), newRequest(http.MethodGet, "http://localhost/v1/unbound"), nil); err == nil {
+		t.Error("expected an error for a route without binding")
+	}
+	if len(calls) != 2 {
+		t.Errorf("expected 2 calls, got %v", calls)
+	}
+}
@@ -25,6 +25,47 @@ func (f HandlerFunc) ServeHTTP(ctx context.Context, writer http.ResponseWriter,
 	return f(ctx, writer, request, binder)
 }
 
+// WrapHTTPHandler adapts a standard http.Handler, such as the handlers of
+// net/http/pprof or http.FileServer, to the Handler interface. The values of
+// ctx are available from the context of the request passed to h, next to the
+// values of the request context such as the route variables, and the context
+// of the request is done when ctx or the request context is. The returned
+// handler always returns nil.
+func WrapHTTPHandler(h http.Handler) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		if ctx != nil && ctx != r.Context() {
+			reqCtx, cancel := context.WithCancelCause(r.Context())
+			defer cancel(nil)
+			if deadline, ok := ctx.Deadline(); ok {
+				var cancelDeadline context.CancelFunc
+				reqCtx, cancelDeadline = context.WithDeadline(reqCtx, deadline)
+				defer cancelDeadline()
+			}
+			if ctx.Done() != nil {
+				stop := context.AfterFunc(ctx, func() { cancel(context.Cause(ctx)) })
+				defer stop()
+			}
+			r = r.WithContext(handlerContext{Context: reqCtx, values: ctx})
+		}
+		h.ServeHTTP(w, r)
+		return nil
+	}
+}
+
+// handlerContext is the request context passed by WrapHTTPHandler. Values
+// missing from the request context are looked up in the handler context.
+type handlerContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c handlerContext) Value(key any) any {
+	if v := c.Context.Value(key); v != nil {
+		return v
+	}
+	return c.values.Value(key)
+}
+
 // NotFound replies to the request with an HTTP 404 not found error.
 func NotFound(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
 	http.Error(w, "404 page not found", http.StatusNotFound)
@@ -0,0 +1,73 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWrapHTTPHandler(t *testing.T) {
+	type ctxKey struct{}
+	var value any
+	var vars map[string]string
+	std := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value = r.Context().Value(ctxKey{})
+		vars = Vars(r)
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	router := NewRouter()
+	router.Handle("/std/{id}", WrapHTTPHandler(std))
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	rw := NewRecorder()
+	if err := router.ServeHTTP(ctx, rw, newRequest("GET", "http://localhost/std/1"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if rw.Code != http.StatusTeapot {
+		t.Errorf("expected status %d, got %d", http.StatusTeapot, rw.Code)
+	}
+	if value != "value" {
+		t.Errorf("expected the handler context to carry the value, got %v", value)
+	}
+	if vars["id"] != "1" {
+		t.Errorf("expected the route variables, got %v", vars)
+	}
+}
+
+func TestWrapHTTPHandlerCancel(t *testing.T) {
+	errc := make(chan error, 1)
+	var deadline time.Time
+	std := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ = r.Context().Deadline()
+		<-r.Context().Done()
+		errc <- context.Cause(r.Context())
+	})
+
+	router := NewRouter()
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+			ctx, cancel := context.WithTimeout(ctx, time.Hour)
+			defer cancel()
+			ctx, cancelCause := context.WithCancelCause(ctx)
+			time.AfterFunc(10*time.Millisecond, func() { cancelCause(errTestCanceled) })
+			return next(ctx, w, r, binder)
+		}
+	})
+	router.Handle("/std", WrapHTTPHandler(std))
+
+	start := time.Now()
+	if err := router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/std"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != errTestCanceled {
+		t.Errorf("expected the request context to be cancelled with ctx, got %v", err)
+	}
+	if deadline.IsZero() || deadline.Before(start) || deadline.After(start.Add(time.Hour+time.Second)) {
+		t.Errorf("expected the deadline of ctx, got %v", deadline)
+	}
+}
+
+var errTestCanceled = errors.New("test canceled")
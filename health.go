@@ -0,0 +1,215 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// HealthStatusOK is reported for passing checks.
+	HealthStatusOK = "ok"
+	// HealthStatusFail is reported for failing checks.
+	HealthStatusFail = "fail"
+
+	// defaultHealthCheckTimeout bounds checks registered without a timeout.
+	defaultHealthCheckTimeout = 5 * time.Second
+)
+
+// HealthCheckFunc reports the health of a single component. A nil error
+// means healthy.
+type HealthCheckFunc func(ctx context.Context) error
+
+// HealthReport is the JSON document served by the health endpoints.
+type HealthReport struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks,omitempty"`
+}
+
+// HealthCheckResult is the outcome of a single check.
+type HealthCheckResult struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+type healthCheck struct {
+	name    string
+	check   HealthCheckFunc
+	timeout time.Duration
+}
+
+// HealthChecker aggregates liveness and readiness checks and serves them as
+// JSON. It is safe to register checks while serving requests.
+type HealthChecker struct {
+	mu        sync.RWMutex
+	liveness  []healthCheck
+	readiness []healthCheck
+	logger    Logger
+}
+
+// Health returns an empty HealthChecker. Register checks and mount the
+// endpoints on a router:
+//
+//	health := mux.Health()
+//	health.AddReadinessCheck("db", db.PingContext, time.Second)
+//	health.Register(r)
+func Health() *HealthChecker {
+	return &HealthChecker{}
+}
+
+// AddLivenessCheck registers a check which has to pass for the process to be
+// considered alive. A zero timeout selects a default of five seconds.
+func (h *HealthChecker) AddLivenessCheck(name string, check HealthCheckFunc, timeout time.Duration) *HealthChecker {
+	h.mu.Lock()
+	h.liveness = append(h.liveness, healthCheck{name: name, check: check, timeout: timeout})
+	h.mu.Unlock()
+	return h
+}
+
+// AddReadinessCheck registers a check which has to pass for the process to be
+// considered ready to serve traffic. A zero timeout selects a default of five
+// seconds.
+func (h *HealthChecker) AddReadinessCheck(name string, check HealthCheckFunc, timeout time.Duration) *HealthChecker {
+	h.mu.Lock()
+	h.readiness = append(h.readiness, healthCheck{name: name, check: check, timeout: timeout})
+	h.mu.Unlock()
+	return h
+}
+
+// Liveness runs all liveness checks.
+func (h *HealthChecker) Liveness(ctx context.Context) HealthReport {
+	h.mu.RLock()
+	checks := append([]healthCheck(nil), h.liveness...)
+	logger := h.logger
+	h.mu.RUnlock()
+
+	return runHealthChecks(ctx, logger, checks)
+}
+
+// Readiness runs all liveness and readiness checks, as a process which is not
+// alive can't be ready either.
+func (h *HealthChecker) Readiness(ctx context.Context) HealthReport {
+	h.mu.RLock()
+	checks := append(append([]healthCheck(nil), h.liveness...), h.readiness...)
+	logger := h.logger
+	h.mu.RUnlock()
+
+	return runHealthChecks(ctx, logger, checks)
+}
+
+// LivenessHandler serves the liveness report with status 200 if all checks
+// pass and 503 otherwise.
+func (h *HealthChecker) LivenessHandler() HandlerFunc {
+	return healthHandler(h.Liveness)
+}
+
+// ReadinessHandler serves the readiness report with status 200 if all checks
+// pass and 503 otherwise.
+func (h *HealthChecker) ReadinessHandler() HandlerFunc {
+	return healthHandler(h.Readiness)
+}
+
+// Register mounts the liveness handler on /healthz and the readiness handler
+// on /readyz. The routes are named "healthz" and "readyz". Panicking checks
+// are reported to the router's Logger.
+func (h *HealthChecker) Register(router *Router) {
+	h.mu.Lock()
+	h.logger = router.getLogger()
+	h.mu.Unlock()
+
+	router.Handle("/healthz", h.LivenessHandler()).Methods(http.MethodGet, http.MethodHead).Name("healthz")
+	router.Handle("/readyz", h.ReadinessHandler()).Methods(http.MethodGet, http.MethodHead).Name("readyz")
+}
+
+func healthHandler(run func(context.Context) HealthReport) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		report := run(ctx)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Status != HealthStatusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if r.Method == http.MethodHead {
+			return nil
+		}
+
+		return json.NewEncoder(w).Encode(report)
+	}
+}
+
+// runHealthChecks runs all checks concurrently, each bounded by its timeout.
+func runHealthChecks(ctx context.Context, logger Logger, checks []healthCheck) HealthReport {
+	report := HealthReport{Status: HealthStatusOK}
+	if len(checks) == 0 {
+		return report
+	}
+
+	results := make([]HealthCheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c healthCheck) {
+			defer wg.Done()
+			results[i] = runHealthCheck(ctx, logger, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report.Checks = make(map[string]HealthCheckResult, len(checks))
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != HealthStatusOK {
+			report.Status = HealthStatusFail
+		}
+	}
+
+	return report
+}
+
+func runHealthCheck(ctx context.Context, logger Logger, c healthCheck) HealthCheckResult {
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				if logger == nil {
+					logger = defaultLogger{}
+				}
+				logger.Log(ctx, slog.LevelError, "mux: health check panicked", "check", c.name, "panic", p)
+				errc <- fmt.Errorf("mux: health check panicked: %v", p)
+			}
+		}()
+		errc <- c.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = fmt.Errorf("mux: health check timed out after %s", timeout)
+	}
+
+	result := HealthCheckResult{
+		Status:     HealthStatusOK,
+		DurationMS: float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		result.Status = HealthStatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,64 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	ready := errors.New("warming up")
+
+	health := Health()
+	health.AddLivenessCheck("ping", func(ctx context.Context) error { return nil }, 0)
+	health.AddReadinessCheck("cache", func(ctx context.Context) error { return ready }, 0)
+	health.AddReadinessCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, 10*time.Millisecond)
+
+	router := NewRouter()
+	health.Register(router)
+
+	serve := func(path string) (int, HealthReport) {
+		rw := NewRecorder()
+		if err := router.ServeHTTP(context.Background(), rw, newRequest("GET", "http://localhost"+path), nil); err != nil {
+			t.Fatal(err)
+		}
+		var report HealthReport
+		if err := json.Unmarshal(rw.Body.Bytes(), &report); err != nil {
+			t.Fatalf("%s: invalid JSON %q: %v", path, rw.Body.String(), err)
+		}
+		code := rw.Code
+		if code == 0 {
+			code = http.StatusOK
+		}
+		return code, report
+	}
+
+	code, report := serve("/healthz")
+	if code != http.StatusOK || report.Status != HealthStatusOK || len(report.Checks) != 1 {
+		t.Errorf("unexpected liveness result %d %+v", code, report)
+	}
+
+	code, report = serve("/readyz")
+	if code != http.StatusServiceUnavailable || report.Status != HealthStatusFail {
+		t.Errorf("unexpected readiness result %d %+v", code, report)
+	}
+	if report.Checks["cache"].Error != "warming up" {
+		t.Errorf("expected cache check error, got %+v", report.Checks["cache"])
+	}
+	if report.Checks["slow"].Status != HealthStatusFail {
+		t.Errorf("expected slow check to time out, got %+v", report.Checks["slow"])
+	}
+	if report.Checks["ping"].Status != HealthStatusOK {
+		t.Errorf("expected liveness checks to be part of readiness, got %+v", report.Checks)
+	}
+
+	if router.Get("healthz") == nil || router.Get("readyz") == nil {
+		t.Error("expected named health routes")
+	}
+}
@@ -0,0 +1,121 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// MatchHook is called after a request matched a route, before the handler
+// chain runs.
+type MatchHook func(ctx context.Context, match *RouteMatch)
+
+// ErrorHook is called when the handler chain returned an error.
+type ErrorHook func(ctx context.Context, r *http.Request, err error)
+
+// ResponseHook is called after every request handled by the router, including
+// requests answered by the not found and method not allowed handlers.
+type ResponseHook func(ctx context.Context, r *http.Request, status int, duration time.Duration)
+
+// routerHooks holds the lifecycle hooks of a router.
+type routerHooks struct {
+	match    []MatchHook
+	err      []ErrorHook
+	response []ResponseHook
+}
+
+// OnMatch registers a hook called whenever a request matched a route. Hooks
+// run in registration order and regardless of any middleware.
+func (r *Router) OnMatch(hook MatchHook) *Router {
+	r.hooks.match = append(r.hooks.match, hook)
+	return r
+}
+
+// OnError registers a hook called whenever the handler chain returned an
+// error. The error is still returned from ServeHTTP.
+func (r *Router) OnError(hook ErrorHook) *Router {
+	r.hooks.err = append(r.hooks.err, hook)
+	return r
+}
+
+// OnResponse registers a hook called after every request with the response
+// status and the time spent in the handler chain. If the handler did not
+// write a status, 200 is reported, or 500 if it returned an error.
+func (r *Router) OnResponse(hook ResponseHook) *Router {
+	r.hooks.response = append(r.hooks.response, hook)
+	return r
+}
+
+// instrumented reports whether serving requests requires more than calling
+// the handler.
+func (r *Router) instrumented() bool {
+	return r.stats != nil || r.latencyBounds != nil || r.coverage || len(r.hooks.match) > 0 || len(r.hooks.err) > 0 || len(r.hooks.response) > 0
+}
+
+// serveInstrumented calls handler and feeds the outcome into the router's
+// statistics and hooks.
+func (r *Router) serveInstrumented(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder, handler Handler, match *RouteMatch) error {
+	if match.MatchErr == nil && match.Route != nil && len(r.hooks.match) > 0 {
+		if match.lazyVars && len(match.vars) > 0 {
+			// Hooks have no request to read the variables from.
+			match.Vars = Vars(req)
+		}
+		for _, hook := range r.hooks.match {
+			hook(ctx, match)
+		}
+	}
+
+	if r.coverage && match.MatchErr == nil && match.Route != nil {
+		match.Route.hits.Add(1)
+	}
+
+	var rw *ResponseRecorderWriter
+	if len(r.hooks.response) > 0 {
+		rw = NewResponseRecorderWriter(w)
+		w = rw
+	}
+
+	if r.stats != nil {
+		defer func() {
+			if p := recover(); p != nil {
+				r.stats.recordPanic(match)
+				panic(p)
+			}
+		}()
+	}
+
+	start := time.Now()
+	err := handler.ServeHTTP(ctx, w, req, binder)
+	elapsed := time.Since(start)
+
+	if r.stats != nil {
+		var errorType string
+		if err != nil {
+			classify := r.errorClassifier
+			if classify == nil {
+				classify = DefaultErrorClassifier
+			}
+			errorType = classify(err)
+		}
+		r.stats.record(match, elapsed, err, errorType)
+	}
+
+	if r.latencyBounds != nil && match.MatchErr == nil && match.Route != nil {
+		r.observeLatency(match.Route, elapsed)
+	}
+
+	if err != nil {
+		for _, hook := range r.hooks.err {
+			hook(ctx, req, err)
+		}
+	}
+
+	if rw != nil {
+		status := rw.StatusOrDefault(err)
+		for _, hook := range r.hooks.response {
+			hook(ctx, req, status, elapsed)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,74 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRouterHooks(t *testing.T) {
+	boom := errors.New("boom")
+
+	var matched []string
+	var errs []error
+	var statuses []int
+
+	router := NewRouter()
+	router.OnMatch(func(ctx context.Context, match *RouteMatch) {
+		tpl, _ := match.Route.GetPathTemplate()
+		matched = append(matched, tpl)
+	}).OnError(func(ctx context.Context, r *http.Request, err error) {
+		errs = append(errs, err)
+	}).OnResponse(func(ctx context.Context, r *http.Request, status int, duration time.Duration) {
+		statuses = append(statuses, status)
+	})
+
+	// Middleware short-circuiting the chain must not prevent hooks from firing.
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+			if r.URL.Path == "/denied" {
+				w.WriteHeader(http.StatusForbidden)
+				return nil
+			}
+			return next(ctx, w, r, binder)
+		}
+	})
+
+	router.HandleFunc("/ok", dummyHandler)
+	router.HandleFunc("/denied", dummyHandler)
+	router.HandleFunc("/fail", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		return boom
+	})
+
+	for _, path := range []string{"/ok", "/denied", "/fail", "/missing"} {
+		_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost"+path), nil)
+	}
+
+	if !reflect.DeepEqual(matched, []string{"/ok", "/denied", "/fail"}) {
+		t.Errorf("unexpected matches %v", matched)
+	}
+	if len(errs) != 1 || errs[0] != boom {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	expected := []int{http.StatusOK, http.StatusForbidden, http.StatusInternalServerError, http.StatusNotFound}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("expected statuses %v, got %v", expected, statuses)
+	}
+}
+
+func TestMatchHookVars(t *testing.T) {
+	var vars map[string]string
+	router := NewRouter()
+	router.OnMatch(func(ctx context.Context, match *RouteMatch) {
+		vars = match.Vars
+	})
+	router.HandleFunc("/users/{id}", dummyHandler)
+
+	_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/users/42"), nil)
+	if expected := map[string]string{"id": "42"}; !reflect.DeepEqual(vars, expected) {
+		t.Errorf("expected %v, got %v", expected, vars)
+	}
+}
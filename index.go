@@ -0,0 +1,396 @@
+package mux
+
+import (
+	"net/http"
+	"regexp/syntax"
+	"slices"
+	"strings"
+)
+
+// RouteIndex defines whether the router, and subrouters created afterwards,
+// look up the routes which can match the request path in a tree of path
+// segments instead of trying every route in turn. The initial value is true.
+//
+// The index shards the routes by their path segments, so that a request for
+// "/users/1" never tries the routes below "/orders". Static segments and
+// variables whose pattern cannot match a slash are indexed; routes with other
+// paths, such as "/files/{path:.*}", are indexed up to the first such
+// segment, and routes without a path are tried for every request. Routes are
+// further indexed by host if the host template is static, such as
+// "api.example.com", or ends with a static domain, such as
+// "{tenant}.example.com". The candidates are matched in the usual order, and
+// the effect of the other routes failing on their path is replayed, so the
+// result is the same as without the index. Requests traced with
+// EnableMatchTracing try every route, so that their trace lists all the
+// routes rejecting them.
+//
+// The index is built on the first match after routes were added.
+// Routes must not be modified once the router serves requests. Disable the
+// index if a custom RegexpCompileFunc changes how literal text matches, e.g.
+// to match paths case-insensitively.
+func (r *Router) RouteIndex(value bool) *Router {
+	r.linearMatch = !value
+	return r
+}
+
+// EnableRouteIndex enables the route index, see RouteIndex.
+//
+// Deprecated: the route index is enabled by default.
+func (r *Router) EnableRouteIndex() *Router {
+	return r.RouteIndex(true)
+}
+
+// loadIndex returns the route index, building it if needed, or nil if the
+// index is disabled.
+func (r *Router) loadIndex() *routeIndex {
+	t := r.table.Load()
+	if r.linearMatch || t == nil {
+		return nil
+	}
+	ix := t.index.Load()
+	if ix == nil {
+		ix = newRouteIndex(r.matchOrder(t))
+		t.index.Store(ix)
+	}
+	return ix
+}
+
+// routeIndex maps requests to the routes of a router which can match them.
+type routeIndex struct {
+	routes []*Route
+	// paths indexes the routes not indexed by host.
+	paths pathIndex
+	// hosts and domains index routes by host and by the domain the host
+	// ends with, starting with a dot.
+	hosts   map[string]*pathIndex
+	domains map[string]*pathIndex
+}
+
+// pathIndex maps request paths to routes.
+type pathIndex struct {
+	// always lists the routes which are not indexed by path, ascending.
+	always []int
+	// trees index the routes matching req.URL.Path and
+	// req.URL.EscapedPath() respectively.
+	trees [2]*indexNode
+}
+
+// indexNode is a node of a tree of path segments.
+type indexNode struct {
+	static map[string]*indexNode
+	param  *indexNode
+	// routes lists the routes whose path ends at this node.
+	routes []int
+	// prefix lists the routes matching any remaining path.
+	prefix []int
+}
+
+// indexSegment is a path segment of a route template.
+type indexSegment struct {
+	text  string
+	param bool
+}
+
+func newRouteIndex(routes []*Route) *routeIndex {
+	ix := &routeIndex{routes: routes}
+	for i, route := range routes {
+		if route.buildOnly || route.err != nil {
+			// Never matches, see routeIndex.skip.
+			continue
+		}
+
+		host, path := indexedMatchers(route)
+		paths := &ix.paths
+		if host != nil {
+			if domain, exact, ok := hostKey(host); ok {
+				index := &ix.domains
+				if exact {
+					index = &ix.hosts
+				}
+				if *index == nil {
+					*index = make(map[string]*pathIndex)
+				}
+				if paths = (*index)[domain]; paths == nil {
+					paths = new(pathIndex)
+					(*index)[domain] = paths
+				}
+			}
+		}
+		paths.insert(path, i)
+	}
+	return ix
+}
+
+// insert indexes route i by its path matcher rr, which may be nil.
+func (ix *pathIndex) insert(rr *routeRegexp, i int) {
+	if rr == nil {
+		ix.always = append(ix.always, i)
+		return
+	}
+	segs, prefix, ok := templateSegments(rr)
+	if !ok {
+		ix.always = append(ix.always, i)
+		return
+	}
+
+	tree := 0
+	if rr.options.useEncodedPath {
+		tree = 1
+	}
+	if ix.trees[tree] == nil {
+		ix.trees[tree] = &indexNode{}
+	}
+	ix.trees[tree].insert(segs, prefix, i)
+
+	// With strict slash the trailing slash is optional.
+	if rr.options.strictSlash && !prefix {
+		if last := segs[len(segs)-1]; !last.param && last.text == "" {
+			ix.trees[tree].insert(segs[:len(segs)-1], false, i)
+		} else {
+			ix.trees[tree].insert(append(segs[:len(segs):len(segs)], indexSegment{}), false, i)
+		}
+	}
+}
+
+// hostKey returns the key indexing a host matcher: the host if exact is
+// true, or else the domain the host must end with.
+func hostKey(rr *routeRegexp) (key string, exact bool, ok bool) {
+	if !rr.wildcardHostPort {
+		// The template has a port, which is rare.
+		return "", false, false
+	}
+	idxs, err := braceIndices(rr.template)
+	if err != nil {
+		return "", false, false
+	}
+	if len(idxs) == 0 {
+		return strings.ToLower(rr.template), true, true
+	}
+	suffix := rr.template[idxs[len(idxs)-1]:]
+	if i := strings.IndexByte(suffix, '.'); i != -1 {
+		return strings.ToLower(suffix[i:]), false, true
+	}
+	return "", false, false
+}
+
+// indexedMatchers returns the host and path matchers used to index route, if
+// any. Only matchers which fail like these may precede them, so that skipping
+// the route leaves the same RouteMatch behind.
+func indexedMatchers(route *Route) (host, path *routeRegexp) {
+	for _, m := range route.matchers {
+		switch m := m.(type) {
+		case methodMatcher, schemeMatcher:
+			continue
+		case *routeRegexp:
+			switch m.regexpType {
+			case regexpTypeHost:
+				host = m
+				continue
+			case regexpTypePath, regexpTypePrefix:
+				path = m
+				continue
+			}
+		}
+		break
+	}
+	return host, path
+}
+
+// templateSegments splits the template of a path matcher into segments. If
+// prefix is true, the last segments were dropped and the route matches any
+// path starting with the returned segments.
+func templateSegments(rr *routeRegexp) (segs []indexSegment, prefix bool, ok bool) {
+	tpl := rr.template
+	if !strings.HasPrefix(tpl, "/") || rr.options.strictSlash && tpl == "/" {
+		// "/" with strict slash also matches the empty path.
+		return nil, false, false
+	}
+	idxs, err := braceIndices(tpl)
+	if err != nil {
+		return nil, false, false
+	}
+
+	start, brace := 1, 0
+	for i := 1; i <= len(tpl); i++ {
+		if brace < len(idxs) && i == idxs[brace] {
+			// Skip the variable, its pattern may contain slashes.
+			i = idxs[brace+1] - 1
+			brace += 2
+			continue
+		}
+		if i < len(tpl) && tpl[i] != '/' {
+			continue
+		}
+		seg, ok := templateSegment(tpl[start:i])
+		if !ok {
+			return segs, true, true
+		}
+		segs = append(segs, seg)
+		start = i + 1
+	}
+
+	if rr.regexpType == regexpTypePrefix {
+		// The last segment only needs to be a prefix of the path segment.
+		return segs[:len(segs)-1], true, true
+	}
+	return segs, false, true
+}
+
+// templateSegment parses a path segment of a template. It fails if a
+// variable pattern could match a slash.
+func templateSegment(text string) (indexSegment, bool) {
+	idxs, _ := braceIndices(text)
+	for i := 0; i < len(idxs); i += 2 {
+		tag := text[idxs[i]+1 : idxs[i+1]-1]
+		if _, patt, ok := strings.Cut(tag, ":"); ok && patternMatchesSlash(patt) {
+			return indexSegment{}, false
+		}
+	}
+	return indexSegment{text: text, param: len(idxs) > 0}, true
+}
+
+// patternMatchesSlash reports whether the regexp pattern could match a
+// slash. It errs on the side of true.
+func patternMatchesSlash(patt string) bool {
+	re, err := syntax.Parse(patt, syntax.Perl)
+	if err != nil {
+		return true
+	}
+	return regexpMatchesSlash(re)
+}
+
+func regexpMatchesSlash(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return true
+	case syntax.OpLiteral:
+		return slices.Contains(re.Rune, '/')
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			if re.Rune[i] <= '/' && '/' <= re.Rune[i+1] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, sub := range re.Sub {
+		if regexpMatchesSlash(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *indexNode) insert(segs []indexSegment, prefix bool, id int) {
+	for _, seg := range segs {
+		var child *indexNode
+		if seg.param {
+			if n.param == nil {
+				n.param = &indexNode{}
+			}
+			child = n.param
+		} else {
+			if child = n.static[seg.text]; child == nil {
+				if n.static == nil {
+					n.static = make(map[string]*indexNode)
+				}
+				child = &indexNode{}
+				n.static[seg.text] = child
+			}
+		}
+		n = child
+	}
+	if prefix {
+		n.prefix = append(n.prefix, id)
+	} else {
+		n.routes = append(n.routes, id)
+	}
+}
+
+// lookup appends the routes matching path to ids. The path is what follows
+// the slash leading to n; end is true if there is no such slash.
+func (n *indexNode) lookup(path string, end bool, ids []int) []int {
+	ids = append(ids, n.prefix...)
+	if end {
+		return append(ids, n.routes...)
+	}
+	seg, rest, more := strings.Cut(path, "/")
+	if child := n.static[seg]; child != nil {
+		ids = child.lookup(rest, !more, ids)
+	}
+	if n.param != nil {
+		ids = n.param.lookup(rest, !more, ids)
+	}
+	return ids
+}
+
+// candidates appends the routes which can match req to ids, ascending.
+func (ix *routeIndex) candidates(req *http.Request, ids []int) []int {
+	ids = ix.paths.lookup(req, ids)
+	if ix.hosts != nil || ix.domains != nil {
+		host := strings.ToLower(getHost(req))
+		if i := strings.IndexByte(host, ':'); i != -1 {
+			host = host[:i]
+		}
+		if paths := ix.hosts[host]; paths != nil {
+			ids = paths.lookup(req, ids)
+		}
+		for i := 0; ix.domains != nil && i < len(host); i++ {
+			if host[i] != '.' {
+				continue
+			}
+			if paths := ix.domains[host[i:]]; paths != nil {
+				ids = paths.lookup(req, ids)
+			}
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// lookup appends the routes which can match the path of req to ids.
+func (ix *pathIndex) lookup(req *http.Request, ids []int) []int {
+	ids = append(ids, ix.always...)
+	for tree, root := range ix.trees {
+		if root == nil {
+			continue
+		}
+		path := req.URL.Path
+		if tree == 1 {
+			path = req.URL.EscapedPath()
+		}
+		if strings.HasPrefix(path, "/") {
+			ids = root.lookup(path[1:], false, ids)
+		}
+	}
+	return ids
+}
+
+// match tries the routes which can match req in order, see Router.Match.
+func (ix *routeIndex) match(r *Router, req *http.Request, match *RouteMatch) bool {
+	var buf [16]int
+	next := 0
+	for _, i := range ix.candidates(req, buf[:0]) {
+		ix.skip(next, i, req, match)
+		if r.matchRoute(ix.routes[i], req, match) {
+			return true
+		}
+		next = i + 1
+	}
+	ix.skip(next, len(ix.routes), req, match)
+	return false
+}
+
+// skip has the effect of routes[from:to] failing to match: a route failing
+// on its path clears ErrNotFound left by a previous route, see Route.Match.
+func (ix *routeIndex) skip(from, to int, req *http.Request, match *RouteMatch) {
+	if match.MatchErr != ErrNotFound {
+		return
+	}
+	for _, route := range ix.routes[from:to] {
+		if !route.buildOnly && route.err == nil && (route.enabled == nil || route.enabled(req.Context(), req)) {
+			match.MatchErr = nil
+			return
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func indexTestRouter(index, strictSlash, encoded bool) *Router {
+	r := NewRouter().StrictSlash(strictSlash).RouteIndex(index)
+	if encoded {
+		r.UseEncodedPath()
+	}
+	never := func(ctx context.Context, r *http.Request) bool { return false }
+
+	r.HandleFunc("/", dummyHandler).Methods("GET").Name("root")
+	r.HandleFunc("/users", dummyHandler).Methods("GET").Name("users-get")
+	r.HandleFunc("/users", dummyHandler).Methods("POST").Name("users-post")
+	r.HandleFunc("/users/{id:[0-9]+}", dummyHandler).Methods("PUT").Name("user-put")
+	r.HandleFunc("/users/{id}", dummyHandler).Methods("GET").Name("user")
+	r.HandleFunc("/users/{id}/posts/{post}", dummyHandler).Name("post")
+	r.HandleFunc("/files/{path:.*}", dummyHandler).Name("files")
+	r.PathPrefix("/static/").HandlerFunc(dummyHandler).Name("static")
+	r.HandleFunc("/doc.{ext}", dummyHandler).Name("doc")
+	r.Host("example.com").Path("/host").HandlerFunc(dummyHandler).Name("host")
+	r.Host("{tenant}.example.com").Path("/dashboard").HandlerFunc(dummyHandler).Name("tenant")
+	r.Host("{tenant:[a-z]+}.eu.example.com").HandlerFunc(dummyHandler).Name("tenant-eu")
+	r.Host("localhost:{port}").Path("/port").HandlerFunc(dummyHandler).Name("port")
+	r.HandleFunc("/search", dummyHandler).Queries("q", "{q}").Name("search-q")
+	r.HandleFunc("/search", dummyHandler).Methods("POST").Name("search")
+	r.HandleFunc("/trailing/", dummyHandler).Name("trailing")
+	r.HandleFunc("/build", dummyHandler).BuildOnly().Name("build")
+	r.HandleFunc("/toggle", dummyHandler).Enabled(never).Name("toggle")
+	r.MatcherFunc(func(r *http.Request, rm *RouteMatch) bool {
+		return r.Header.Get("X-Any") != ""
+	}).HandlerFunc(dummyHandler).Name("any")
+
+	api := r.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/items", dummyHandler).Methods("GET").Name("items")
+	api.HandleFunc("/items/{id}", dummyHandler).Methods("GET", "DELETE").Name("item")
+	api.HandleFunc("/items/{id:[a-z]+}", dummyHandler).Name("item-alpha")
+
+	r.HandleFunc("/{a}/{b}", dummyHandler).Methods("PATCH").Name("two")
+	r.PathPrefix("/").HandlerFunc(dummyHandler).Methods("OPTIONS").Name("options")
+	return r
+}
+
+func TestRouteIndex(t *testing.T) {
+	requests := []*http.Request{
+		newRequest("GET", "http://localhost/"),
+		newRequest("POST", "http://localhost/"),
+		newRequest("GET", "http://localhost/users"),
+		newRequest("POST", "http://localhost/users/"),
+		newRequest("DELETE", "http://localhost/users"),
+		newRequest("GET", "http://localhost/users/42"),
+		newRequest("PUT", "http://localhost/users/42"),
+		newRequest("PUT", "http://localhost/users/abc"),
+		newRequest("GET", "http://localhost/users/42/"),
+		newRequest("GET", "http://localhost/users//posts/1"),
+		newRequest("GET", "http://localhost/users/1/posts/2"),
+		newRequest("GET", "http://localhost/files/a/b/c.txt"),
+		newRequest("GET", "http://localhost/files/"),
+		newRequest("GET", "http://localhost/static/css/site.css"),
+		newRequest("GET", "http://localhost/static"),
+		newRequest("GET", "http://localhost/doc.pdf"),
+		newRequest("GET", "http://example.com/host"),
+		newRequest("GET", "http://localhost/host"),
+		newRequest("GET", "http://acme.example.com/dashboard"),
+		newRequest("GET", "http://acme.example.com:8080/dashboard"),
+		newRequest("GET", "http://ACME.Example.com/dashboard"),
+		newRequest("GET", "http://Example.com/host"),
+		newRequest("GET", "http://Acme.EU.example.com/anything"),
+		newRequest("GET", "http://example.com/dashboard"),
+		newRequest("GET", "http://acme.eu.example.com/dashboard"),
+		newRequest("GET", "http://acme.eu.example.com/anything"),
+		newRequest("GET", "http://42.eu.example.com/anything"),
+		newRequest("GET", "http://localhost:8080/port"),
+		newRequest("GET", "http://localhost/search?q=go"),
+		newRequest("GET", "http://localhost/search"),
+		newRequest("POST", "http://localhost/search"),
+		newRequest("GET", "http://localhost/trailing"),
+		newRequest("GET", "http://localhost/trailing/"),
+		newRequest("GET", "http://localhost/build"),
+		newRequest("GET", "http://localhost/toggle"),
+		newRequestWithHeaders("GET", "http://localhost/nowhere", "X-Any", "1"),
+		newRequest("GET", "http://localhost/api/items"),
+		newRequest("POST", "http://localhost/api/items"),
+		newRequest("DELETE", "http://localhost/api/items/7"),
+		newRequest("POST", "http://localhost/api/items/abc"),
+		newRequest("POST", "http://localhost/api/items/7"),
+		newRequest("PATCH", "http://localhost/x/y"),
+		newRequest("GET", "http://localhost/x/y"),
+		newRequest("GET", "http://localhost/a%2Fb/c"),
+		newRequest("OPTIONS", "http://localhost/anything/at/all"),
+		newRequest("GET", "http://localhost/unknown"),
+	}
+
+	for _, strictSlash := range []bool{false, true} {
+		for _, encoded := range []bool{false, true} {
+			linear := indexTestRouter(false, strictSlash, encoded)
+			indexed := indexTestRouter(true, strictSlash, encoded)
+			var always []string
+			for _, i := range indexed.loadIndex().paths.always {
+				always = append(always, indexed.getRoutes()[i].GetName())
+			}
+			expected := []string{"any"}
+			if strictSlash {
+				// With strict slash "/" also matches the empty path.
+				expected = []string{"root", "any"}
+			}
+			if !reflect.DeepEqual(always, expected) {
+				t.Errorf("expected %v to be tried always, got %v", expected, always)
+			}
+			if ix := indexed.loadIndex(); len(ix.hosts) != 1 || ix.domains[".example.com"] == nil || ix.domains[".eu.example.com"] == nil {
+				t.Errorf("unexpected host index %v %v", ix.hosts, ix.domains)
+			}
+			for _, req := range requests {
+				name := fmt.Sprintf("%s %s strictSlash=%v encoded=%v", req.Method, req.URL, strictSlash, encoded)
+				var want, got RouteMatch
+				wantOK := linear.Match(req, &want)
+				gotOK := indexed.Match(req, &got)
+				if gotOK != wantOK || got.MatchErr != want.MatchErr || !reflect.DeepEqual(got.Vars, want.Vars) ||
+					(got.Route == nil) != (want.Route == nil) || (got.Handler == nil) != (want.Handler == nil) {
+					t.Errorf("%s: expected %v %+v, got %v %+v", name, wantOK, want, gotOK, got)
+					continue
+				}
+				if got.Route != nil && got.Route.GetName() != want.Route.GetName() {
+					t.Errorf("%s: expected route %q, got %q", name, want.Route.GetName(), got.Route.GetName())
+				}
+			}
+		}
+	}
+}
+
+func TestRouteIndexRebuild(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/a", dummyHandler)
+
+	req := newRequest("GET", "http://localhost/b")
+	if r.Match(req, &RouteMatch{}) {
+		t.Fatal("unexpected match")
+	}
+	r.HandleFunc("/b", dummyHandler)
+	if !r.Match(req, &RouteMatch{}) {
+		t.Error("expected the index to include the new route")
+	}
+}
+
+func TestRouteIndexShards(t *testing.T) {
+	for _, index := range []bool{true, false} {
+		tried := map[string]int{}
+		counting := func(name string) EnabledFunc {
+			return func(ctx context.Context, r *http.Request) bool {
+				tried[name]++
+				return true
+			}
+		}
+		// The index is enabled by default.
+		r := NewRouter()
+		if !index {
+			r.RouteIndex(false)
+		}
+		for _, name := range []string{"users", "orders"} {
+			r.HandleFunc("/"+name, dummyHandler).Enabled(counting(name))
+			r.HandleFunc("/"+name+"/{id}", dummyHandler).Enabled(counting(name + "/{id}"))
+		}
+		r.HandleFunc("/{section}/{id}/edit", dummyHandler).Enabled(counting("{section}"))
+
+		for _, path := range []string{"/users/1", "/users/1/edit", "/users/1/missing"} {
+			r.Match(newRequest("GET", "http://localhost"+path), &RouteMatch{})
+		}
+
+		expected := map[string]int{"users/{id}": 1, "{section}": 1}
+		if !index {
+			expected = map[string]int{"users": 3, "users/{id}": 3, "orders": 2, "orders/{id}": 2, "{section}": 2}
+		}
+		if !reflect.DeepEqual(tried, expected) {
+			t.Errorf("index=%v: expected the routes tried to be %v, got %v", index, expected, tried)
+		}
+	}
+}
+
+func TestTemplateSegments(t *testing.T) {
+	tests := []struct {
+		template string
+		typ      regexpType
+		segs     []indexSegment
+		prefix   bool
+	}{
+		{"/", regexpTypePath, []indexSegment{{}}, false},
+		{"/a/{b}/c", regexpTypePath, []indexSegment{{text: "a"}, {text: "{b}", param: true}, {text: "c"}}, false},
+		{"/a/{id:[0-9]{2,}}", regexpTypePath, []indexSegment{{text: "a"}, {text: "{id:[0-9]{2,}}", param: true}}, false},
+		{"/a/{rest:.+}/b", regexpTypePath, []indexSegment{{text: "a"}}, true},
+		{"/a/{rest:[a/]+}", regexpTypePath, []indexSegment{{text: "a"}}, true},
+		{"/api", regexpTypePrefix, []indexSegment{}, true},
+		{"/api/", regexpTypePrefix, []indexSegment{{text: "api"}}, true},
+	}
+	for _, tt := range tests {
+		rr, err := newRouteRegexp(tt.template, tt.typ, routeRegexpOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		segs, prefix, ok := templateSegments(rr)
+		if !ok || prefix != tt.prefix || !reflect.DeepEqual(segs, tt.segs) {
+			t.Errorf("%s: expected %v %v, got %v %v %v", tt.template, tt.segs, tt.prefix, segs, prefix, ok)
+		}
+	}
+}
+
+func TestRouteIndexMethods(t *testing.T) {
+	newRouter := func(index bool) (*Router, *testMiddleware) {
+		mw := &testMiddleware{}
+		r := NewRouter().RouteIndex(index)
+		r.HandleFunc("/x", dummyHandler).Methods("POST")
+		r.HandleFunc("/x", dummyHandler).Methods("GET").Use(mw.Middleware)
+		r.HandleFunc("/x", dummyHandler).Methods("PATCH").Queries("q", "{q}")
+		return r, mw
+	}
+	linear, linearMW := newRouter(false)
+	indexed, indexedMW := newRouter(true)
+
+	// A route rejecting the method before the matching route has the same
+	// effect on its middlewares with the index.
+	_ = linear.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/x"), nil)
+	_ = indexed.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/x"), nil)
+	if indexedMW.timesCalled != linearMW.timesCalled {
+		t.Errorf("expected the middleware to be called %d times, got %d", linearMW.timesCalled, indexedMW.timesCalled)
+	}
+
+	for _, req := range []*http.Request{
+		newRequest("PUT", "http://localhost/x"),
+		newRequest("PUT", "http://localhost/x?q=1"),
+		newRequest("PUT", "http://localhost/y"),
+	} {
+		var want, got RouteMatch
+		wantOK := linear.Match(req, &want)
+		if gotOK := indexed.Match(req, &got); gotOK != wantOK || got.MatchErr != want.MatchErr {
+			t.Errorf("%s %s: expected %v %v, got %v %v", req.Method, req.URL, wantOK, want.MatchErr, gotOK, got.MatchErr)
+		}
+	}
+}
+
+// testRouteIndexed checks that test.route matches test.request the same way
+// with and without the route index, see testRoute.
+func testRouteIndexed(t *testing.T, test routeTest) {
+	if test.route == nil || test.request == nil {
+		return
+	}
+	linear := NewRouter().RouteIndex(false)
+	linear.addRoutes(test.route)
+	indexed := NewRouter()
+	indexed.addRoutes(test.route)
+
+	var want, got RouteMatch
+	wantOK := linear.Match(test.request, &want)
+	gotOK := indexed.Match(test.request, &got)
+	if gotOK != wantOK || got.MatchErr != want.MatchErr || got.Route != want.Route || !reflect.DeepEqual(got.Vars, want.Vars) {
+		t.Errorf("(%v) expected %v %v %v with the route index, got %v %v %v", test.title, wantOK, want.MatchErr, want.Vars, gotOK, got.MatchErr, got.Vars)
+	}
+}
@@ -0,0 +1,177 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrContentLengthRequired is returned by BodyIntegrityMiddleware for
+	// requests without a Content-Length header.
+	ErrContentLengthRequired = errors.New("mux: content length required")
+	// ErrChecksumRequired is returned by BodyIntegrityMiddleware for requests
+	// without a checksum it supports.
+	ErrChecksumRequired = errors.New("mux: body checksum required")
+	// ErrChecksumMismatch is returned by BodyIntegrityMiddleware for requests
+	// whose body does not match their checksums.
+	ErrChecksumMismatch = errors.New("mux: body checksum mismatch")
+)
+
+// BodyIntegrityOptions configures BodyIntegrityMiddleware.
+type BodyIntegrityOptions struct {
+	// RequireContentLength rejects requests without a Content-Length
+	// header, such as chunked requests.
+	RequireContentLength bool
+	// RequireChecksum rejects requests without a Digest or Content-MD5
+	// header with a supported algorithm.
+	RequireChecksum bool
+	// MaxBodySize bounds the size of the bodies read for verification.
+	// Zero means no bound.
+	MaxBodySize int64
+}
+
+// digestAlgorithms are the Digest algorithms verified by
+// BodyIntegrityMiddleware, by lower case name.
+var digestAlgorithms = map[string]func() hash.Hash{
+	"md5":     md5.New,
+	"sha":     sha1.New,
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// BodyIntegrityMiddleware verifies the integrity of request bodies, as
+// needed by webhook endpoints: the body of requests with a Content-MD5 or a
+// Digest header (RFC 3230) with the MD5, SHA, SHA-256 or SHA-512 algorithm
+// is read and checked against all of them before the handler is called.
+// Other Digest algorithms are ignored.
+//
+// Rejected requests are not passed to the handler; an error is returned
+// instead, with a StatusCode method, see ErrorStatus:
+//   - 411 Length Required wrapping ErrContentLengthRequired if a
+//     Content-Length is required and missing,
+//   - 400 Bad Request wrapping ErrChecksumRequired if a checksum is required
+//     and missing,
+//   - 400 Bad Request wrapping ErrChecksumMismatch if a checksum is
+//     malformed or does not match the body, or the body is shorter or longer
+//     than its Content-Length,
+//   - 413 Request Entity Too Large if it exceeds the MaxBodySize.
+func BodyIntegrityMiddleware(options BodyIntegrityOptions) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+			if options.RequireContentLength && req.ContentLength < 0 {
+				return &statusError{status: http.StatusLengthRequired, err: ErrContentLengthRequired}
+			}
+
+			checksums, err := requestChecksums(req.Header)
+			if err != nil {
+				return err
+			}
+			if len(checksums) == 0 {
+				if options.RequireChecksum {
+					return &statusError{status: http.StatusBadRequest, err: ErrChecksumRequired}
+				}
+				return next(ctx, w, req, binder)
+			}
+
+			body, err := readVerifiedBody(req, options.MaxBodySize, checksums)
+			if err != nil {
+				return err
+			}
+			// The request of the caller keeps its body.
+			r2 := new(http.Request)
+			*r2 = *req
+			r2.Body = io.NopCloser(bytes.NewReader(body))
+
+			return next(ctx, w, r2, binder)
+		}
+	}
+}
+
+// bodyChecksum is an expected checksum of a request body.
+type bodyChecksum struct {
+	header, algorithm string
+	newHash           func() hash.Hash
+	sum               []byte
+}
+
+// requestChecksums returns the checksums of the Content-MD5 and Digest
+// headers with supported algorithms.
+func requestChecksums(h http.Header) ([]bodyChecksum, error) {
+	var checksums []bodyChecksum
+	for _, v := range h.Values("Content-MD5") {
+		sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(v))
+		if err != nil {
+			return nil, checksumError("invalid Content-MD5 %q", v)
+		}
+		checksums = append(checksums, bodyChecksum{header: "Content-MD5", algorithm: "MD5", newHash: md5.New, sum: sum})
+	}
+	for _, v := range h.Values("Digest") {
+		for _, instance := range strings.Split(v, ",") {
+			algorithm, value, ok := strings.Cut(strings.TrimSpace(instance), "=")
+			if !ok {
+				return nil, checksumError("invalid Digest %q", v)
+			}
+			newHash, ok := digestAlgorithms[strings.ToLower(algorithm)]
+			if !ok {
+				continue
+			}
+			sum, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, checksumError("invalid %s Digest %q", algorithm, value)
+			}
+			checksums = append(checksums, bodyChecksum{header: "Digest", algorithm: algorithm, newHash: newHash, sum: sum})
+		}
+	}
+	return checksums, nil
+}
+
+// readVerifiedBody reads the body of req and checks it against its
+// Content-Length and the checksums.
+func readVerifiedBody(req *http.Request, maxSize int64, checksums []bodyChecksum) ([]byte, error) {
+	var body []byte
+	if req.Body != nil {
+		r := io.Reader(req.Body)
+		if maxSize > 0 {
+			r = io.LimitReader(r, maxSize+1)
+		}
+		var err error
+		if body, err = io.ReadAll(r); err != nil {
+			return nil, &statusError{status: http.StatusBadRequest, err: fmt.Errorf("mux: reading request body: %w", err)}
+		}
+		if err := req.Body.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if maxSize > 0 && int64(len(body)) > maxSize {
+		return nil, &statusError{status: http.StatusRequestEntityTooLarge, err: fmt.Errorf("mux: request body larger than %d bytes", maxSize)}
+	}
+	if req.ContentLength >= 0 && int64(len(body)) != req.ContentLength {
+		return nil, checksumError("body of %d bytes does not match Content-Length %d", len(body), req.ContentLength)
+	}
+
+	for _, c := range checksums {
+		h := c.newHash()
+		h.Write(body)
+		if subtle.ConstantTimeCompare(h.Sum(nil), c.sum) != 1 {
+			return nil, checksumError("%s %s does not match", c.header, c.algorithm)
+		}
+	}
+	return body, nil
+}
+
+// checksumError returns a 400 Bad Request error wrapping ErrChecksumMismatch.
+func checksumError(format string, args ...any) error {
+	return &statusError{status: http.StatusBadRequest, err: fmt.Errorf("%w: "+format, append([]any{ErrChecksumMismatch}, args...)...)}
+}
@@ -0,0 +1,167 @@
+package mux
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyIntegrityMiddleware(t *testing.T) {
+	const body = `{"event":"paid"}`
+	md5Sum := md5.Sum([]byte(body))
+	sha256Sum := sha256.Sum256([]byte(body))
+	contentMD5 := base64.StdEncoding.EncodeToString(md5Sum[:])
+	digest := "SHA-256=" + base64.StdEncoding.EncodeToString(sha256Sum[:])
+
+	tests := []struct {
+		name    string
+		options BodyIntegrityOptions
+		body    string
+		chunked bool
+		headers map[string]string
+		status  int
+		err     error
+	}{
+		{name: "no checksum", body: body},
+		{name: "content md5", body: body, headers: map[string]string{"Content-MD5": contentMD5}},
+		{name: "digest", body: body, headers: map[string]string{"Digest": "unixsum=30637, " + digest}},
+		{name: "content md5 and digest", body: body, headers: map[string]string{"Content-MD5": contentMD5, "Digest": digest}},
+		{
+			name:    "content md5 mismatch",
+			body:    body + " ",
+			headers: map[string]string{"Content-MD5": contentMD5},
+			status:  http.StatusBadRequest,
+			err:     ErrChecksumMismatch,
+		},
+		{
+			name:    "digest mismatch",
+			body:    strings.ToUpper(body),
+			headers: map[string]string{"Digest": digest},
+			status:  http.StatusBadRequest,
+			err:     ErrChecksumMismatch,
+		},
+		{
+			name:    "malformed digest",
+			body:    body,
+			headers: map[string]string{"Digest": "SHA-256=%%%"},
+			status:  http.StatusBadRequest,
+			err:     ErrChecksumMismatch,
+		},
+		{
+			name:    "unsupported digest only",
+			options: BodyIntegrityOptions{RequireChecksum: true},
+			body:    body,
+			headers: map[string]string{"Digest": "unixsum=30637"},
+			status:  http.StatusBadRequest,
+			err:     ErrChecksumRequired,
+		},
+		{
+			name:    "checksum required",
+			options: BodyIntegrityOptions{RequireChecksum: true},
+			body:    body,
+			status:  http.StatusBadRequest,
+			err:     ErrChecksumRequired,
+		},
+		{
+			name:    "content length required",
+			options: BodyIntegrityOptions{RequireContentLength: true},
+			body:    body,
+			chunked: true,
+			status:  http.StatusLengthRequired,
+			err:     ErrContentLengthRequired,
+		},
+		{
+			name:    "too large",
+			options: BodyIntegrityOptions{MaxBodySize: 4},
+			body:    body,
+			headers: map[string]string{"Content-MD5": contentMD5},
+			status:  http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := BodyIntegrityMiddleware(tt.options)(func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+				b, err := io.ReadAll(req.Body)
+				got = string(b)
+				return err
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			err := handler(context.Background(), NewRecorder(), req, nil)
+			if tt.status == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got != tt.body {
+					t.Errorf("expected the handler to read %q, got %q", tt.body, got)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected an error, the handler read %q", got)
+			}
+			if status := ErrorStatus(err); status != tt.status {
+				t.Errorf("expected status %d, got %d (%v)", tt.status, status, err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestBodyIntegrityMiddlewareContentLength(t *testing.T) {
+	handler := BodyIntegrityMiddleware(BodyIntegrityOptions{})(func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		t.Error("the handler was called")
+		return nil
+	})
+
+	sum := md5.Sum([]byte("abc"))
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader("abc"))
+	req.ContentLength = 4
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+
+	if err := handler(context.Background(), NewRecorder(), req, nil); !errors.Is(err, ErrChecksumMismatch) || ErrorStatus(err) != http.StatusBadRequest {
+		t.Errorf("expected a 400 checksum mismatch, got %v", err)
+	}
+}
+
+func TestBodyIntegrityMiddlewareKeepsRequest(t *testing.T) {
+	var got string
+	handler := BodyIntegrityMiddleware(BodyIntegrityOptions{})(func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		b, err := io.ReadAll(req.Body)
+		got = string(b)
+		return err
+	})
+
+	sum := md5.Sum([]byte("abc"))
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader("abc"))
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	body := req.Body
+
+	if err := handler(context.Background(), NewRecorder(), req, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got != "abc" {
+		t.Errorf("expected the verified body, got %q", got)
+	}
+	if req.Body != body {
+		t.Error("expected the body of the caller's request to be kept")
+	}
+}
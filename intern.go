@@ -0,0 +1,31 @@
+package mux
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// methodSets holds the interned method matchers by their methods joined
+// with spaces.
+var methodSets sync.Map
+
+// internMethods returns a shared method matcher for methods if they are all
+// standard methods. Other methods are not interned to bound methodSets.
+func internMethods(methods []string) methodMatcher {
+	for _, m := range methods {
+		switch m {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+			http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		default:
+			return methods
+		}
+	}
+	key := strings.Join(methods, " ")
+	if m, ok := methodSets.Load(key); ok {
+		return m.(methodMatcher)
+	}
+	m, _ := methodSets.LoadOrStore(key, methodMatcher(slices.Clone(methods)))
+	return m.(methodMatcher)
+}
@@ -0,0 +1,12 @@
+//go:build go1.23
+
+package mux
+
+import "unique"
+
+// intern returns the canonical copy of s, so that large route tables hold
+// strings repeated across routes, such as templates registered for several
+// methods, only once. Interned strings are released when no longer used.
+func intern(s string) string {
+	return unique.Make(s).Value()
+}
@@ -0,0 +1,18 @@
+//go:build go1.23
+
+package mux
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestInternTemplates(t *testing.T) {
+	r := NewRouter()
+	get := r.HandleFunc("/users/{id}", dummyHandler).Methods("GET")
+	put := r.HandleFunc("/users/{id}", dummyHandler).Methods("PUT")
+
+	if unsafe.StringData(get.regexp.path.pattern) != unsafe.StringData(put.regexp.path.pattern) {
+		t.Error("expected routes with the same template to share the pattern")
+	}
+}
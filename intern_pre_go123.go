@@ -0,0 +1,8 @@
+//go:build !go1.23
+
+package mux
+
+// intern returns s, strings are interned with the unique package of Go 1.23.
+func intern(s string) string {
+	return s
+}
@@ -0,0 +1,31 @@
+package mux
+
+import "testing"
+
+func TestInternRoutes(t *testing.T) {
+	r := NewRouter()
+	get := r.HandleFunc("/users/{id}", dummyHandler).Methods("get")
+	put := r.HandleFunc("/users/{id}", dummyHandler).Methods("PUT")
+	del := r.HandleFunc("/users/{id}", dummyHandler).Methods("GET")
+
+	if get.regexp.path.compiled.regexp != put.regexp.path.compiled.regexp {
+		t.Error("expected routes with the same template to share the compiled regexp")
+	}
+	if &get.matchers[0].(methodMatcher)[0] != &del.matchers[0].(methodMatcher)[0] {
+		t.Error("expected routes with the same methods to share the method matcher")
+	}
+
+	methods, _ := get.GetMethods()
+	methods[0] = "POST"
+	if methods, _ := del.GetMethods(); methods[0] != "GET" {
+		t.Error("expected GetMethods to return a copy")
+	}
+}
+
+func TestInternMethodsNonStandard(t *testing.T) {
+	a := internMethods([]string{"PURGE"})
+	b := internMethods([]string{"PURGE"})
+	if &a[0] == &b[0] {
+		t.Error("expected non-standard methods not to be interned")
+	}
+}
@@ -0,0 +1,192 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// The error codes defined by JSON-RPC 2.0.
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+)
+
+// JSONRPCError is the error object of a JSON-RPC 2.0 response. Methods may
+// return it to reply with a specific error; other errors are answered with
+// JSONRPCInternalError, without their message.
+type JSONRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *JSONRPCError) Error() string {
+	return fmt.Sprintf("mux: jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// JSONRPC is a Handler serving a JSON-RPC 2.0 endpoint, dispatching the
+// requests, single or in batches, to the methods registered with
+// RegisterJSONRPC:
+//
+//	rpc := mux.NewJSONRPC()
+//	mux.RegisterJSONRPC(rpc, "users.get", getUser)
+//	r.Handle("/rpc", rpc).Methods(http.MethodPost)
+type JSONRPC struct {
+	mu      sync.RWMutex
+	methods map[string]jsonRPCMethod
+}
+
+// jsonRPCMethod calls a method with its undecoded params.
+type jsonRPCMethod func(ctx context.Context, req *http.Request, binder Binder, params json.RawMessage) (any, error)
+
+// NewJSONRPC returns a JSON-RPC 2.0 endpoint without methods.
+func NewJSONRPC() *JSONRPC {
+	return &JSONRPC{methods: make(map[string]jsonRPCMethod)}
+}
+
+// RegisterJSONRPC registers fn as the method name of j. The params of calls
+// are decoded into P by the binder passed to the router if it implements
+// BodyBinder, and with encoding/json otherwise. Params which can't be decoded
+// are answered with JSONRPCInvalidParams. The result of fn is encoded with
+// encoding/json.
+func RegisterJSONRPC[P, R any](j *JSONRPC, name string, fn func(ctx context.Context, params P) (R, error)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.methods[name] = func(ctx context.Context, req *http.Request, binder Binder, raw json.RawMessage) (any, error) {
+		var params P
+		if len(raw) > 0 {
+			if err := bindJSON(req, binder, raw, &params); err != nil {
+				return nil, &JSONRPCError{Code: JSONRPCInvalidParams, Message: "Invalid params", Data: err.Error()}
+			}
+		}
+		return fn(ctx, params)
+	}
+}
+
+// bindJSON decodes the JSON data into v, with the binder if it implements
+// BodyBinder.
+func bindJSON(req *http.Request, binder Binder, data []byte, v any) error {
+	if b, ok := binder.(BodyBinder); ok {
+		r := req.Clone(req.Context())
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		r.ContentLength = int64(len(data))
+		r.Header.Set("Content-Type", "application/json")
+		return b.Bind(r, v)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// jsonRPCRequest is a JSON-RPC 2.0 request object.
+type jsonRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// jsonRPCResponse is a JSON-RPC 2.0 response object.
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  any             `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+var jsonRPCNull = json.RawMessage("null")
+
+// ServeHTTP implements Handler. Calls without an id are notifications and
+// get no response; a request consisting of notifications only is answered
+// with 204 No Content.
+func (j *JSONRPC) ServeHTTP(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	body = bytes.TrimSpace(body)
+
+	var reply any
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			reply = jsonRPCErrorResponse(jsonRPCNull, JSONRPCParseError, "Parse error")
+		} else if len(batch) == 0 {
+			reply = jsonRPCErrorResponse(jsonRPCNull, JSONRPCInvalidRequest, "Invalid Request")
+		} else {
+			responses := []*jsonRPCResponse{}
+			for _, raw := range batch {
+				if resp := j.call(ctx, req, binder, raw); resp != nil {
+					responses = append(responses, resp)
+				}
+			}
+			if len(responses) > 0 {
+				reply = responses
+			}
+		}
+	} else if resp := j.call(ctx, req, binder, body); resp != nil {
+		reply = resp
+	}
+
+	if reply == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(reply)
+}
+
+// call handles the request object raw. It returns nil for notifications.
+func (j *JSONRPC) call(ctx context.Context, req *http.Request, binder Binder, raw json.RawMessage) *jsonRPCResponse {
+	var r jsonRPCRequest
+	if err := json.Unmarshal(raw, &r); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return jsonRPCErrorResponse(jsonRPCNull, JSONRPCParseError, "Parse error")
+		}
+		return jsonRPCErrorResponse(jsonRPCNull, JSONRPCInvalidRequest, "Invalid Request")
+	}
+	id := r.ID
+	if id == nil {
+		id = jsonRPCNull
+	}
+	if r.JSONRPC != "2.0" || r.Method == "" || len(r.Params) > 0 && r.Params[0] != '[' && r.Params[0] != '{' {
+		return jsonRPCErrorResponse(id, JSONRPCInvalidRequest, "Invalid Request")
+	}
+
+	j.mu.RLock()
+	method, ok := j.methods[r.Method]
+	j.mu.RUnlock()
+	var result any
+	var err error
+	if ok {
+		result, err = method(ctx, req, binder, r.Params)
+	} else {
+		err = &JSONRPCError{Code: JSONRPCMethodNotFound, Message: "Method not found"}
+	}
+	if r.ID == nil {
+		return nil
+	}
+	if err != nil {
+		var rpcErr *JSONRPCError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &JSONRPCError{Code: JSONRPCInternalError, Message: "Internal error"}
+		}
+		return &jsonRPCResponse{JSONRPC: "2.0", Error: rpcErr, ID: id}
+	}
+	if result == nil {
+		result = jsonRPCNull
+	}
+	return &jsonRPCResponse{JSONRPC: "2.0", Result: result, ID: id}
+}
+
+func jsonRPCErrorResponse(id json.RawMessage, code int, message string) *jsonRPCResponse {
+	return &jsonRPCResponse{JSONRPC: "2.0", Error: &JSONRPCError{Code: code, Message: message}, ID: id}
+}
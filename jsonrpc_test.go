@@ -0,0 +1,66 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestJSONRPC(t *testing.T) {
+	rpc := NewJSONRPC()
+	RegisterJSONRPC(rpc, "sum", func(ctx context.Context, params []int) (int, error) {
+		sum := 0
+		for _, n := range params {
+			sum += n
+		}
+		return sum, nil
+	})
+	RegisterJSONRPC(rpc, "greet", func(ctx context.Context, params struct{ Name string }) (string, error) {
+		if params.Name == "" {
+			return "", &JSONRPCError{Code: 1, Message: "name required"}
+		}
+		return "hello " + params.Name, nil
+	})
+	RegisterJSONRPC(rpc, "fail", func(ctx context.Context, params any) (any, error) {
+		return nil, errors.New("secret")
+	})
+	router := NewRouter()
+	router.Handle("/rpc", rpc).Methods(http.MethodPost)
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"jsonrpc":"2.0","method":"sum","params":[1,2,3],"id":1}`, `{"jsonrpc":"2.0","result":6,"id":1}`},
+		{`{"jsonrpc":"2.0","method":"greet","params":{"Name":"ann"},"id":"a"}`, `{"jsonrpc":"2.0","result":"hello ann","id":"a"}`},
+		{`{"jsonrpc":"2.0","method":"greet","params":{},"id":2}`, `{"jsonrpc":"2.0","error":{"code":1,"message":"name required"},"id":2}`},
+		{`{"jsonrpc":"2.0","method":"fail","id":3}`, `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":3}`},
+		{`{"jsonrpc":"2.0","method":"missing","id":4}`, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":4}`},
+		{`{"jsonrpc":"2.0","method":"sum","params":{"a":1},"id":5}`, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"json: cannot unmarshal object into Go value of type []int"},"id":5}`},
+		{`{"jsonrpc":"2.0","method":"sum","params":1,"id":6}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":6}`},
+		{`{"jsonrpc":"2.0","method":"sum","params":[1]`, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`},
+		{`[]`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`},
+		{
+			`[{"jsonrpc":"2.0","method":"sum","params":[1],"id":1},{"jsonrpc":"2.0","method":"sum","params":[2]},1]`,
+			`[{"jsonrpc":"2.0","result":1,"id":1},{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}]`,
+		},
+		{`{"jsonrpc":"2.0","method":"sum","params":[1]}`, ``},
+	}
+	for _, tt := range tests {
+		req := newRequest(http.MethodPost, "http://localhost/rpc")
+		req.Body = io.NopCloser(strings.NewReader(tt.body))
+		rw := NewRecorder()
+		if err := router.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSpace(rw.Body.String()); got != tt.want {
+			t.Errorf("%s:\nexpected %s\ngot      %s", tt.body, tt.want, got)
+		}
+		if tt.want == "" && rw.Code != http.StatusNoContent {
+			t.Errorf("%s: expected 204 for a notification, got %d", tt.body, rw.Code)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package mux
+
+import (
+	"encoding/json"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyBuckets are the histogram bucket upper bounds used when
+// EnableLatencyHistograms is called without bounds.
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// LatencyHistogram is a snapshot of the latency distribution of a route.
+type LatencyHistogram struct {
+	// Buckets are cumulative: each bucket counts the requests which took at
+	// most its upper bound. The last bucket has no upper bound.
+	Buckets []LatencyBucket
+	// Count is the total number of observed requests.
+	Count uint64
+	// Sum is the total time spent in the handler.
+	Sum time.Duration
+}
+
+// LatencyBucket is a single cumulative histogram bucket.
+type LatencyBucket struct {
+	// UpperBound is the inclusive upper bound, or 0 for the overflow bucket.
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// Mean returns the average latency, or 0 if nothing was observed.
+func (h LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// MarshalJSON implements json.Marshaler, encoding durations in milliseconds
+// and the overflow bucket with the upper bound "+Inf".
+func (h LatencyHistogram) MarshalJSON() ([]byte, error) {
+	type bucket struct {
+		LE    any    `json:"le_ms"`
+		Count uint64 `json:"count"`
+	}
+	buckets := make([]bucket, len(h.Buckets))
+	for i, b := range h.Buckets {
+		buckets[i] = bucket{LE: "+Inf", Count: b.Count}
+		if b.UpperBound > 0 {
+			buckets[i].LE = float64(b.UpperBound) / float64(time.Millisecond)
+		}
+	}
+	return json.Marshal(struct {
+		Buckets []bucket `json:"buckets"`
+		Count   uint64   `json:"count"`
+		SumMS   float64  `json:"sum_ms"`
+	}{buckets, h.Count, float64(h.Sum) / float64(time.Millisecond)})
+}
+
+// latencyHistogram records latencies lock-free.
+type latencyHistogram struct {
+	bounds []time.Duration
+	counts []atomic.Uint64 // len(bounds)+1, non-cumulative
+	count  atomic.Uint64
+	sum    atomic.Int64
+}
+
+func newLatencyHistogram(bounds []time.Duration) *latencyHistogram {
+	return &latencyHistogram{
+		bounds: bounds,
+		counts: make([]atomic.Uint64, len(bounds)+1),
+	}
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	i := sort.Search(len(h.bounds), func(i int) bool { return d <= h.bounds[i] })
+	h.counts[i].Add(1)
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+}
+
+func (h *latencyHistogram) snapshot() LatencyHistogram {
+	s := LatencyHistogram{
+		Buckets: make([]LatencyBucket, len(h.counts)),
+		Count:   h.count.Load(),
+		Sum:     time.Duration(h.sum.Load()),
+	}
+	var cumulative uint64
+	for i := range h.counts {
+		cumulative += h.counts[i].Load()
+		s.Buckets[i].Count = cumulative
+		if i < len(h.bounds) {
+			s.Buckets[i].UpperBound = h.bounds[i]
+		}
+	}
+	return s
+}
+
+// EnableLatencyHistograms turns on latency histograms for all routes served
+// by the router, including the routes of its subrouters. The histograms can
+// be read with Route.Latency and are included in Router.Stats. The bucket
+// upper bounds must be sorted in increasing order; DefaultLatencyBuckets are
+// used if none are given.
+//
+// It must be called before the router serves requests.
+func (r *Router) EnableLatencyHistograms(bounds ...time.Duration) *Router {
+	if len(bounds) == 0 {
+		bounds = DefaultLatencyBuckets
+	}
+	r.latencyBounds = append([]time.Duration(nil), bounds...)
+	return r
+}
+
+// observeLatency records d in the histogram of route.
+func (r *Router) observeLatency(route *Route, d time.Duration) {
+	h := route.latency.Load()
+	if h == nil {
+		route.latency.CompareAndSwap(nil, newLatencyHistogram(r.latencyBounds))
+		h = route.latency.Load()
+	}
+	h.observe(d)
+}
+
+// Latency returns a snapshot of the latency histogram of the route. It is
+// empty unless the serving router has EnableLatencyHistograms turned on.
+func (r *Route) Latency() LatencyHistogram {
+	if h := r.latency.Load(); h != nil {
+		return h.snapshot()
+	}
+	return LatencyHistogram{}
+}
@@ -0,0 +1,68 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	h := newLatencyHistogram([]time.Duration{10 * time.Millisecond, 100 * time.Millisecond})
+	for _, d := range []time.Duration{time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond, time.Second} {
+		h.observe(d)
+	}
+
+	s := h.snapshot()
+	expected := []LatencyBucket{
+		{UpperBound: 10 * time.Millisecond, Count: 2},
+		{UpperBound: 100 * time.Millisecond, Count: 3},
+		{Count: 4},
+	}
+	if s.Count != 4 || len(s.Buckets) != len(expected) {
+		t.Fatalf("unexpected snapshot %+v", s)
+	}
+	for i := range expected {
+		if s.Buckets[i] != expected[i] {
+			t.Errorf("bucket %d: expected %+v, got %+v", i, expected[i], s.Buckets[i])
+		}
+	}
+	if s.Mean() != (1061*time.Millisecond)/4 {
+		t.Errorf("unexpected mean %s", s.Mean())
+	}
+
+	if _, err := json.Marshal(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRouteLatency(t *testing.T) {
+	router := NewRouter().EnableLatencyHistograms().EnableStats()
+	route := router.HandleFunc("/", dummyHandler)
+	sub := router.PathPrefix("/api").Subrouter()
+	subRoute := sub.HandleFunc("/items", dummyHandler)
+
+	if route.Latency().Count != 0 {
+		t.Fatal("expected empty histogram before serving")
+	}
+
+	for _, path := range []string{"/", "/", "/api/items", "/missing"} {
+		_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost"+path), nil)
+	}
+
+	if c := route.Latency().Count; c != 2 {
+		t.Errorf("expected 2 observations, got %d", c)
+	}
+	if c := subRoute.Latency().Count; c != 1 {
+		t.Errorf("expected 1 observation for subrouter route, got %d", c)
+	}
+	if len(route.Latency().Buckets) != len(DefaultLatencyBuckets)+1 {
+		t.Errorf("expected default buckets")
+	}
+
+	for _, rs := range router.Stats().Routes {
+		if rs.Histogram == nil {
+			t.Errorf("expected histogram in stats of %s", rs.Path)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"slices"
+)
+
+// LintCheck identifies a check of Router.Lint.
+type LintCheck string
+
+// The checks of Router.Lint.
+const (
+	// LintInvalid reports a route which failed to build, for example because
+	// of an invalid variable pattern. It never matches.
+	LintInvalid LintCheck = "invalid"
+	// LintUnbalancedBraces reports a template with unbalanced braces, which
+	// made the route fail to build.
+	LintUnbalancedBraces LintCheck = "unbalanced-braces"
+	// LintDuplicateVariable reports a variable defined more than once in a
+	// template. Matching keeps the last value, and URL building uses the same
+	// value for all occurrences.
+	LintDuplicateVariable LintCheck = "duplicate-variable"
+	// LintNeverMatches reports a variable pattern which can never match, such
+	// as an anchor in the middle of a template or an empty character class.
+	LintNeverMatches LintCheck = "never-matches"
+	// LintUnusedVariable reports a variable of a template replaced by a later
+	// one, for example by calling Host twice. The variable is still matched,
+	// but URL building ignores it.
+	LintUnusedVariable LintCheck = "unused-variable"
+)
+
+// LintDiagnostic is a problem of a route found by Router.Lint.
+type LintDiagnostic struct {
+	Check LintCheck
+	Route *Route
+	// Template is the template with the problem, empty for LintInvalid and
+	// LintUnbalancedBraces.
+	Template string
+	// Variable is the variable with the problem, if any.
+	Variable string
+	Message  string
+}
+
+// String returns the route, by name or path template, and the message.
+func (d LintDiagnostic) String() string {
+	label := routeLabel(d.Route)
+	if label == "" {
+		label = d.Template
+	}
+	if label == "" {
+		return fmt.Sprintf("%s: %s", d.Check, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", label, d.Check, d.Message)
+}
+
+// Lint checks the templates of the routes of r and its subrouters for common
+// mistakes, and returns the problems found in the order Walk visits the
+// routes. Unlike Compile, it also reports templates which build a valid
+// route that doesn't behave as intended, so that CI can reject them:
+//
+//	for _, d := range router.Lint() {
+//	    t.Error(d)
+//	}
+//
+// A problem of a template inherited from the route of a subrouter is
+// reported once, for that route.
+func (r *Router) Lint() []LintDiagnostic {
+	var diags []LintDiagnostic
+	_ = r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		diags = append(diags, lintRoute(route, ancestors)...)
+		return nil
+	})
+	return diags
+}
+
+// lintRoute returns the problems of route, skipping the matchers inherited
+// from ancestors.
+func lintRoute(route *Route, ancestors []*Route) []LintDiagnostic {
+	if err := route.Compile(); err != nil {
+		check := LintInvalid
+		if errors.Is(err, errUnbalancedBraces) {
+			check = LintUnbalancedBraces
+		}
+		return []LintDiagnostic{{Check: check, Route: route, Message: err.Error()}}
+	}
+
+	// The variables of the templates used to build URLs.
+	var built []string
+	if route.regexp.host != nil {
+		built = append(built, route.regexp.host.varsN...)
+	}
+	if route.regexp.path != nil {
+		built = append(built, route.regexp.path.varsN...)
+	}
+	for _, q := range route.regexp.queries {
+		built = append(built, q.varsN...)
+	}
+
+	var diags []LintDiagnostic
+	for _, m := range route.matchers {
+		rr, ok := m.(*routeRegexp)
+		if !ok || inheritedMatcher(rr, ancestors) {
+			continue
+		}
+		report := func(check LintCheck, name, format string, args ...any) {
+			diags = append(diags, LintDiagnostic{
+				Check:    check,
+				Route:    route,
+				Template: rr.template,
+				Variable: name,
+				Message:  fmt.Sprintf("%q: ", rr.template) + fmt.Sprintf(format, args...),
+			})
+		}
+
+		// Path templates start with the path of the subrouter's route, which
+		// is checked with that route.
+		inherited := 0
+		if n := len(ancestors); n > 0 && rr.regexpType != regexpTypeHost && rr.regexpType != regexpTypeQuery {
+			if parent := ancestors[n-1].regexp.path; parent != nil {
+				inherited = len(parent.varsN)
+			}
+		}
+
+		seen := make(map[string]int, len(rr.varsN))
+		for i, name := range rr.varsN {
+			if seen[name]++; seen[name] == 2 && i >= inherited {
+				report(LintDuplicateVariable, name, "variable %q is defined more than once", name)
+			}
+		}
+
+		idxs, _ := braceIndices(rr.template)
+		for i, patt := range rr.varsP[inherited:] {
+			i += inherited
+			first := idxs[2*i] == 0
+			last := idxs[2*i+1] == len(rr.template)
+			if reason := neverMatches(patt, first, last); reason != "" {
+				report(LintNeverMatches, rr.varsN[i], "variable %q can never match: %s", rr.varsN[i], reason)
+			}
+		}
+
+		for _, name := range rr.varsN {
+			if !slices.Contains(built, name) {
+				report(LintUnusedVariable, name, "variable %q is ignored when building URLs, a later template replaced this one", name)
+			}
+		}
+	}
+	return diags
+}
+
+// inheritedMatcher reports whether rr is a matcher of one of the ancestors.
+func inheritedMatcher(rr *routeRegexp, ancestors []*Route) bool {
+	for _, ancestor := range ancestors {
+		if slices.Contains(ancestor.matchers, matcher(rr)) {
+			return true
+		}
+	}
+	return false
+}
+
+// neverMatches returns why the variable pattern patt can never match, or ""
+// if it can. first and last tell whether the variable starts and ends the
+// template, where anchors can match.
+func neverMatches(patt string, first, last bool) string {
+	re, err := syntax.Parse(patt, syntax.Perl)
+	if err != nil {
+		// Reported by Compile.
+		return ""
+	}
+	var reason string
+	var walk func(re *syntax.Regexp)
+	walk = func(re *syntax.Regexp) {
+		switch re.Op {
+		case syntax.OpNoMatch:
+			reason = "the pattern matches nothing"
+		case syntax.OpCharClass:
+			if len(re.Rune) == 0 {
+				reason = "the character class is empty"
+			}
+		case syntax.OpBeginLine, syntax.OpBeginText:
+			if !first {
+				reason = "the pattern is anchored to the start, but the template doesn't start with the variable"
+			}
+		case syntax.OpEndLine, syntax.OpEndText:
+			if !last {
+				reason = "the pattern is anchored to the end, but the template doesn't end with the variable"
+			}
+		}
+		for _, sub := range re.Sub {
+			walk(sub)
+		}
+	}
+	walk(re)
+	return reason
+}
@@ -0,0 +1,52 @@
+package mux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	router := NewRouter().SetLogger(NopLogger())
+	router.HandleFunc("/users/{id}", dummyHandler).Name("user")
+	router.HandleFunc("/users/{id", dummyHandler).Name("braces")
+	router.HandleFunc("/users/{id:[}", dummyHandler).Name("regexp")
+	router.HandleFunc("/pairs/{id}/{id}", dummyHandler).Name("duplicate")
+	router.HandleFunc("/files/{name:^[a-z]+}", dummyHandler).Name("anchored")
+	router.HandleFunc("/files/{name:[a-z]+$}", dummyHandler).Name("anchored-end")
+	router.HandleFunc("/{name:[^\\x00-\\x{10FFFF}]}", dummyHandler).Name("empty-class")
+	router.HandleFunc("/", dummyHandler).Host("{tenant}.example.com").Host("api.example.com").Name("hosts")
+	api := router.PathPrefix("/api/{version}").Subrouter()
+	api.HandleFunc("/items", dummyHandler).Name("items")
+	api.HandleFunc("/items/{version}", dummyHandler).Name("items-version")
+
+	var got []string
+	for _, d := range router.Lint() {
+		got = append(got, d.String())
+	}
+	want := []string{
+		"unbalanced-braces: mux: unbalanced braces (template \"/users/{id\", position 7, segment \"{id\")",
+		"invalid: mux: error compiling regex",
+		"duplicate: duplicate-variable: \"/pairs/{id}/{id}\": variable \"id\" is defined more than once",
+		"anchored: never-matches: \"/files/{name:^[a-z]+}\": variable \"name\" can never match: the pattern is anchored to the start, but the template doesn't start with the variable",
+		"empty-class: never-matches: \"/{name:[^\\\\x00-\\\\x{10FFFF}]}\": variable \"name\" can never match: the character class is empty",
+		"hosts: unused-variable: \"{tenant}.example.com\": variable \"tenant\" is ignored when building URLs, a later template replaced this one",
+		"items-version: duplicate-variable: \"/api/{version}/items/{version}\": variable \"version\" is defined more than once",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %d:\n%s", len(want), len(got), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("diagnostic %d: expected prefix %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestLintClean(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/users/{id:[0-9]+}", dummyHandler).Queries("page", "{page}")
+	router.Host("{tenant}.example.com").Subrouter().HandleFunc("/files/{path:.*}", dummyHandler)
+	if diags := router.Lint(); len(diags) != 0 {
+		t.Errorf("unexpected diagnostics %v", diags)
+	}
+}
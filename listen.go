@@ -0,0 +1,92 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNoSystemdListener is returned by Listen if the requested socket was
+// not passed by systemd.
+var ErrNoSystemdListener = errors.New("mux: no matching systemd socket")
+
+// listenFDsStart is the first file descriptor passed by systemd.
+var listenFDsStart = 3
+
+var systemdFiles struct {
+	once  sync.Once
+	files []*os.File
+}
+
+// Listen announces on addr, which is one of
+//
+//   - "host:port" for a TCP socket,
+//   - "unix:/path/to/socket" for a unix domain socket. A stale socket file
+//     left behind by a previous process is removed first,
+//   - "systemd:" for the first socket passed by systemd socket activation,
+//     or "systemd:name" for the socket with the given FileDescriptorName.
+//
+// Permissions of unix sockets can be adjusted in a WithOnListen callback.
+func Listen(addr string) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(addr, "unix:"):
+		return listenUnix(strings.TrimPrefix(addr, "unix:"))
+	case strings.HasPrefix(addr, "systemd:"):
+		return listenSystemd(strings.TrimPrefix(addr, "systemd:"))
+	default:
+		return net.Listen("tcp", addr)
+	}
+}
+
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil && fi.Mode().Type() == fs.ModeSocket {
+		// Only remove the socket if nobody is listening on it anymore.
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("mux: unix socket %s is in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// listenSystemd returns a listener for an inherited socket, following the
+// sd_listen_fds protocol.
+func listenSystemd(name string) (net.Listener, error) {
+	systemdFiles.once.Do(func() {
+		defer os.Unsetenv("LISTEN_PID")
+		defer os.Unsetenv("LISTEN_FDS")
+		defer os.Unsetenv("LISTEN_FDNAMES")
+
+		if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+			return
+		}
+		n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+		if err != nil || n <= 0 {
+			return
+		}
+		names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+		for i := 0; i < n; i++ {
+			fd := listenFDsStart + i
+			fdName := "LISTEN_FD_" + strconv.Itoa(fd)
+			if i < len(names) && names[i] != "" {
+				fdName = names[i]
+			}
+			systemdFiles.files = append(systemdFiles.files, os.NewFile(uintptr(fd), fdName))
+		}
+	})
+
+	for _, f := range systemdFiles.files {
+		if name == "" || f.Name() == name {
+			return net.FileListener(f)
+		}
+	}
+	return nil, ErrNoSystemdListener
+}
@@ -0,0 +1,83 @@
+package mux
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestServeUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mux.sock")
+
+	// A stale socket file must not prevent listening.
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	router := NewRouter()
+	router.HandleFunc("/", protoHandler)
+	_, stop := serveForTest(t, router, "unix:"+path)
+	defer stop()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get("http://unix/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "HTTP/1.1" {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	if _, err := Listen("unix:" + path); err == nil {
+		t.Error("expected a socket in use to be rejected")
+	}
+}
+
+func TestListenSystemd(t *testing.T) {
+	inherited, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inherited.Close()
+	f, err := inherited.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	listenFDsStart = int(f.Fd())
+	defer func() { listenFDsStart = 3 }()
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "web")
+
+	if _, err := Listen("systemd:admin"); err != ErrNoSystemdListener {
+		t.Errorf("expected ErrNoSystemdListener, got %v", err)
+	}
+	ln, err := Listen("systemd:web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	if ln.Addr().String() != inherited.Addr().String() {
+		t.Errorf("expected inherited socket %s, got %s", inherited.Addr(), ln.Addr())
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Error("expected the environment to be cleared")
+	}
+}
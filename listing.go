@@ -0,0 +1,122 @@
+package mux
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ListingOptions configures the directory listings of FileServerWithListing
+// and Router.ServeFilesWithListing.
+type ListingOptions struct {
+	// Template renders the HTML listing. It is executed with a
+	// *DirectoryListing and defaults to a plain table of the entries.
+	Template *template.Template
+
+	// ShowHidden lists the entries whose name starts with a dot, which are
+	// omitted by default. They are served either way.
+	ShowHidden bool
+}
+
+// DirectoryListing is the listing of a directory.
+type DirectoryListing struct {
+	// Path is the request path of the directory.
+	Path    string           `json:"path"`
+	Entries []DirectoryEntry `json:"entries"`
+}
+
+// DirectoryEntry is an entry of a DirectoryListing.
+type DirectoryEntry struct {
+	Name string `json:"name"`
+	// URL is the path of the entry, relative to the root of the host.
+	URL     string    `json:"url"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	IsDir   bool      `json:"isDir"`
+}
+
+var listingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html><head><title>Index of {{.Path}}</title></head><body>
+<h1>Index of {{.Path}}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Modified</th></tr>
+{{range .Entries}}<tr><td><a href="{{.URL}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td><td>{{if not .IsDir}}{{.Size}}{{end}}</td><td>{{.ModTime.Format "2006-01-02 15:04:05"}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+// FileServerWithListing returns a handler serving the files of fsys like
+// FileServer, which lists the directories without an index.html file instead
+// of returning an error for them. Listings are written as JSON with the query
+// parameter format=json or for requests accepting "application/json", and as
+// HTML rendered by opts.Template otherwise.
+//
+// Directory listings disclose the names of all files, so they are disabled
+// for FileServer and Router.ServeFiles.
+func FileServerWithListing(fsys http.FileSystem, opts ListingOptions) HandlerFunc {
+	return fileServer(fsys, "", &opts)
+}
+
+// ServeFilesWithListing registers a route serving the files of fsys like
+// Router.ServeFiles, listing the directories without an index.html file, see
+// FileServerWithListing.
+func (r *Router) ServeFilesWithListing(tpl string, fsys http.FileSystem, opts ListingOptions) *Route {
+	return r.serveFiles(tpl, fsys, &opts)
+}
+
+// listDirectory writes the listing of the directory name of fsys. It reports
+// false if name is not a directory.
+func listDirectory(w http.ResponseWriter, req *http.Request, fsys http.FileSystem, name string, opts *ListingOptions) (bool, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return false, nil
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil || !info.IsDir() {
+		return false, nil
+	}
+	infos, err := f.Readdir(-1)
+	if err != nil {
+		return true, fileError(name, err)
+	}
+
+	base := req.URL.Path
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	listing := &DirectoryListing{Path: req.URL.Path, Entries: []DirectoryEntry{}}
+	for _, info := range infos {
+		if !opts.ShowHidden && strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		entry := DirectoryEntry{
+			Name:    info.Name(),
+			URL:     base + url.PathEscape(info.Name()),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			IsDir:   info.IsDir(),
+		}
+		if entry.IsDir {
+			entry.URL += "/"
+		}
+		listing.Entries = append(listing.Entries, entry)
+	}
+	slices.SortFunc(listing.Entries, func(a, b DirectoryEntry) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	if req.URL.Query().Get("format") == "json" || strings.Contains(req.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		return true, json.NewEncoder(w).Encode(listing)
+	}
+	tmpl := opts.Template
+	if tmpl == nil {
+		tmpl = listingTemplate
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return true, tmpl.Execute(w, listing)
+}
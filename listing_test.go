@@ -0,0 +1,82 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestServeFilesWithListing(t *testing.T) {
+	files := fstest.MapFS{
+		"reports/b.txt":         {Data: []byte("bb")},
+		"reports/a b.txt":       {Data: []byte("a")},
+		"reports/.secret":       {Data: []byte("s")},
+		"reports/2024/jan.txt":  {Data: []byte("jan")},
+		"site/index.html":       {Data: []byte("site")},
+		"site/assets/style.css": {Data: []byte("css")},
+	}
+	router := NewRouter()
+	router.ServeFilesWithListing("/files/{path:*}", http.FS(files), ListingOptions{})
+	serve := func(url string, headers ...string) (*ResponseRecorder, error) {
+		rw := NewRecorder()
+		err := router.ServeHTTP(context.Background(), rw, newRequestWithHeaders(http.MethodGet, url, headers...), nil)
+		return rw, err
+	}
+
+	rw, err := serve("http://localhost/files/reports?format=json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listing DirectoryListing
+	if err := json.Unmarshal(rw.Body.Bytes(), &listing); err != nil {
+		t.Fatal(err)
+	}
+	var urls []string
+	for _, e := range listing.Entries {
+		urls = append(urls, e.URL)
+	}
+	if listing.Path != "/files/reports" || strings.Join(urls, " ") != "/files/reports/2024/ /files/reports/a%20b.txt /files/reports/b.txt" {
+		t.Errorf("unexpected listing %+v", listing)
+	}
+
+	rw, err = serve("http://localhost/files/reports/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := rw.Body.String(); !strings.Contains(body, `<a href="/files/reports/b.txt">b.txt</a>`) || strings.Contains(body, ".secret") {
+		t.Errorf("unexpected HTML listing %q", body)
+	}
+
+	if rw, _ := serve("http://localhost/files/site/"); rw.Body.String() != "site" {
+		t.Errorf("expected the index instead of a listing, got %q", rw.Body.String())
+	}
+	if _, err := serve("http://localhost/files/missing/"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	router = NewRouter()
+	router.ServeFiles("/files/{path:*}", http.FS(files))
+	if _, err := serve("http://localhost/files/reports/"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected listings to be disabled, got %v", err)
+	}
+}
+
+func TestFileServerWithListingTemplate(t *testing.T) {
+	files := fstest.MapFS{"a.txt": {}, "b.txt": {}}
+	tmpl := template.Must(template.New("").Parse(`{{range .Entries}}{{.Name}};{{end}}`))
+	h := FileServerWithListing(http.FS(files), ListingOptions{Template: tmpl})
+
+	rw := NewRecorder()
+	if err := h(context.Background(), rw, newRequest(http.MethodGet, "http://localhost/"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if rw.Body.String() != "a.txt;b.txt;" {
+		t.Errorf("unexpected listing %q", rw.Body.String())
+	}
+}
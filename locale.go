@@ -0,0 +1,196 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// LocaleVar is the name of the route variable holding the locale of the
+// routes of a locale router, see Router.Locales.
+const LocaleVar = "locale"
+
+// Locales returns a subrouter for the paths prefixed by one of the
+// supported locales, such as "/en/about" and "/de-CH/about" for the route
+// "/about" of the subrouter with the supported locales "en" and "de-CH":
+//
+//	site := r.Locales("en", "de", "de-CH")
+//	site.HandleFunc("/about", about).Name("about")
+//
+// The locale is available as the route variable "locale", see LocaleVar,
+// and is stored in the handler and request contexts, see GetLocale. URLs
+// for the current locale are built with LocaleURL.
+//
+// GET and HEAD requests for paths without a locale prefix which match a
+// route of the subrouter once prefixed with the locale negotiated from their
+// Accept-Language header are redirected to the prefixed path with 302 Found.
+// The first supported locale is used if none is acceptable. The redirect is
+// only tried for requests matching no route of r, before its
+// NotFoundHandler, so it neither slows down nor shadows the other routes.
+func (r *Router) Locales(supported ...string) *Router {
+	patterns := make([]string, len(supported))
+	for i, locale := range supported {
+		patterns[i] = regexp.QuoteMeta(locale)
+	}
+	// Longer locales first, so that "de-CH" is not matched as "de".
+	slices.SortStableFunc(patterns, func(a, b string) int { return len(b) - len(a) })
+	route := r.PathPrefix("/{" + LocaleVar + ":" + strings.Join(patterns, "|") + "}")
+	if len(supported) == 0 {
+		route.setErr(errors.New("mux: no supported locales"))
+	}
+	sub := route.Subrouter()
+	sub.Use(localeMiddleware)
+
+	redirect := localeRedirect{route: route, supported: supported}
+	redirectRoute := r.newRoute().MatcherFunc(func(req *http.Request, match *RouteMatch) bool {
+		_, ok := redirect.target(req)
+		return ok
+	}).Handler(redirect)
+	r.mu.Lock()
+	r.localeRedirects = append(r.localeRedirects, redirectRoute)
+	r.mu.Unlock()
+
+	return sub
+}
+
+// localeMiddleware stores the locale of the matched route in the context.
+func localeMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		if locale := Var(req, LocaleVar); locale != "" {
+			ctx = withRouterValue(ctx, localeKey, locale)
+			req = req.WithContext(withRouterValue(req.Context(), localeKey, locale))
+		}
+		return next(ctx, w, req, binder)
+	}
+}
+
+// localeRedirect redirects paths without a locale prefix to the path with
+// the negotiated locale, see Router.Locales.
+type localeRedirect struct {
+	// route is the route of the locale router.
+	route     *Route
+	supported []string
+}
+
+// target returns the URL req is redirected to, if any.
+func (l localeRedirect) target(req *http.Request) (string, bool) {
+	if len(l.supported) == 0 || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return "", false
+	}
+	for _, locale := range l.supported {
+		if rest, ok := strings.CutPrefix(req.URL.Path, "/"+locale); ok && (rest == "" || rest[0] == '/') {
+			return "", false
+		}
+	}
+	locale := negotiateLocale(req.Header.Get("Accept-Language"), l.supported)
+
+	u := *req.URL
+	u.Path = "/" + locale + u.Path
+	u.RawPath = ""
+	prefixed := *req
+	prefixed.URL = &u
+	var match RouteMatch
+	if !l.route.Match(&prefixed, &match) || match.MatchErr != nil {
+		return "", false
+	}
+	return u.String(), true
+}
+
+func (l localeRedirect) ServeHTTP(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+	target, ok := l.target(req)
+	if !ok {
+		return NotFoundHandler().ServeHTTP(ctx, w, req, binder)
+	}
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Location", target)
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+// negotiateLocale returns the supported locale with the highest weight in
+// the Accept-Language header value acceptLanguage, the one matched most
+// specifically on ties, and the first one if none is acceptable.
+func negotiateLocale(acceptLanguage string, supported []string) string {
+	best, bestQ, bestSpecificity := supported[0], 0.0, -1
+	for _, locale := range supported {
+		q, specificity := languageQuality(acceptLanguage, locale)
+		if q > bestQ || (q == bestQ && q > 0 && specificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = locale, q, specificity
+		}
+	}
+	return best
+}
+
+// languageQuality returns the weight the Accept-Language header value
+// acceptLanguage gives locale, using the most specific range matching it,
+// and the specificity of that range. Ranges match their locale, its more
+// specific locales and its less specific locales, in this order of
+// specificity, so that "de-CH" is acceptable for "de" and the other way
+// around.
+func languageQuality(acceptLanguage, locale string) (float64, int) {
+	locale = strings.ToLower(locale)
+	q, specificity := 0.0, -1
+	for _, value := range strings.Split(acceptLanguage, ",") {
+		rng, params, _ := strings.Cut(value, ";")
+		rng = strings.ToLower(strings.TrimSpace(rng))
+		var s int
+		switch {
+		case rng == locale:
+			s = 3
+		case strings.HasPrefix(locale, rng+"-"):
+			s = 2
+		case strings.HasPrefix(rng, locale+"-"):
+			s = 1
+		case rng == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+		specificity, q = s, 1
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if weight, err := strconv.ParseFloat(v, 64); err == nil {
+					q = weight
+				}
+			}
+		}
+	}
+	return q, specificity
+}
+
+// GetLocale returns the locale of the request matched by a locale router,
+// see Router.Locales.
+func GetLocale(ctx context.Context) (string, bool) {
+	locale, ok := ctx.Value(localeKey).(string)
+	return locale, ok
+}
+
+// LocaleURL builds the URL of the route with the given name like URL, with
+// the locale of ctx, see GetLocale, unless pairs set the locale variable.
+// Links of localized pages thus stay in the current locale:
+//
+//	u, err := router.LocaleURL(ctx, "article", "id", "42") // /de-CH/articles/42
+func (r *Router) LocaleURL(ctx context.Context, name string, pairs ...string) (*url.URL, error) {
+	if locale, ok := GetLocale(ctx); ok && !hasPair(pairs, LocaleVar) {
+		pairs = append([]string{LocaleVar, locale}, pairs...)
+	}
+	return r.URL(name, pairs...)
+}
+
+// hasPair reports whether the key/value pairs contain key.
+func hasPair(pairs []string, key string) bool {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i] == key {
+			return true
+		}
+	}
+	return false
+}
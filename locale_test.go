@@ -0,0 +1,128 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestRouterLocales(t *testing.T) {
+	r := NewRouter()
+	site := r.Locales("en", "de", "de-CH")
+	site.HandleFunc("/about", func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		locale, _ := GetLocale(ctx)
+		reqLocale, _ := GetLocale(req.Context())
+		_, err := w.Write([]byte(locale + ":" + reqLocale + ":" + Vars(req)[LocaleVar]))
+		return err
+	}).Name("about")
+	site.HandleFunc("/contact", stringHandler("contact")).Methods(http.MethodPost)
+	r.HandleFunc("/health", stringHandler("health"))
+
+	tests := []struct {
+		method, url, acceptLanguage string
+		status                      int
+		body, location              string
+	}{
+		{method: http.MethodGet, url: "/en/about", status: http.StatusOK, body: "en:en:en"},
+		{method: http.MethodGet, url: "/de-CH/about", status: http.StatusOK, body: "de-CH:de-CH:de-CH"},
+		{method: http.MethodGet, url: "/fr/about", status: http.StatusNotFound},
+		{method: http.MethodGet, url: "/about", status: http.StatusFound, location: "http://localhost/en/about"},
+		{method: http.MethodGet, url: "/about?ref=home", acceptLanguage: "fr, de-CH;q=0.9, en;q=0.5", status: http.StatusFound, location: "http://localhost/de-CH/about?ref=home"},
+		{method: http.MethodGet, url: "/about", acceptLanguage: "de-AT", status: http.StatusFound, location: "http://localhost/de/about"},
+		{method: http.MethodGet, url: "/about", acceptLanguage: "de-CH;q=0, de;q=0.8", status: http.StatusFound, location: "http://localhost/de/about"},
+		{method: http.MethodHead, url: "/about", acceptLanguage: "*", status: http.StatusFound, location: "http://localhost/en/about"},
+		{method: http.MethodPost, url: "/contact", status: http.StatusNotFound},
+		{method: http.MethodGet, url: "/health", status: http.StatusOK, body: "health"},
+		{method: http.MethodGet, url: "/missing", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rw := NewRecorder()
+		req := newRequest(tt.method, "http://localhost"+tt.url)
+		if tt.acceptLanguage != "" {
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+		}
+		if err := r.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+			t.Fatal(err)
+		}
+		if rw.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.url, tt.status, rw.Code)
+			continue
+		}
+		if tt.body != "" && rw.Body.String() != tt.body {
+			t.Errorf("%s %s: expected %q, got %q", tt.method, tt.url, tt.body, rw.Body.String())
+		}
+		if location := rw.Header().Get("Location"); location != tt.location {
+			t.Errorf("%s %s: expected location %q, got %q", tt.method, tt.url, tt.location, location)
+		}
+		if tt.status == http.StatusFound && rw.Header().Get("Vary") != "Accept-Language" {
+			t.Errorf("%s %s: expected Vary: Accept-Language, got %q", tt.method, tt.url, rw.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestRouterLocaleURL(t *testing.T) {
+	r := NewRouter()
+	r.Locales("en", "de-CH").HandleFunc("/articles/{id}", stringHandler("article")).Name("article")
+
+	ctx := withRouterValue(context.Background(), localeKey, "de-CH")
+	for _, tt := range []struct {
+		ctx   context.Context
+		pairs []string
+		url   string
+	}{
+		{ctx: ctx, pairs: []string{"id", "42"}, url: "/de-CH/articles/42"},
+		{ctx: ctx, pairs: []string{"id", "42", LocaleVar, "en"}, url: "/en/articles/42"},
+		{ctx: context.Background(), pairs: []string{LocaleVar, "en", "id", "42"}, url: "/en/articles/42"},
+	} {
+		u, err := r.LocaleURL(tt.ctx, "article", tt.pairs...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.String() != tt.url {
+			t.Errorf("%v: expected %q, got %q", tt.pairs, tt.url, u)
+		}
+	}
+
+	if _, err := r.LocaleURL(ctx, "article", "id", "42", LocaleVar, "fr"); err == nil {
+		t.Error("expected an error for an unsupported locale")
+	}
+	if _, err := r.LocaleURL(context.Background(), "article", "id", "42"); err == nil {
+		t.Error("expected an error without a locale")
+	}
+}
+
+func TestRouterLocalesNone(t *testing.T) {
+	r := NewRouter().SetLogger(NopLogger())
+	r.Locales().HandleFunc("/about", stringHandler("about"))
+	if errs := r.Validate(); len(errs) == 0 {
+		t.Error("expected an error for a locale router without locales")
+	}
+}
+
+func TestRouterLocalesRedirectOnNotFound(t *testing.T) {
+	r := NewRouter()
+	r.NotFoundHandler = stringHandler("not found")
+	site := r.Locales("en", "de")
+	site.HandleFunc("/{page}", stringHandler("page"))
+	r.HandleFunc("/about", stringHandler("unlocalized about"))
+
+	tests := []struct {
+		url, body, location string
+	}{
+		// Routes registered after the locale router are not shadowed.
+		{url: "/about", body: "unlocalized about"},
+		{url: "/contact", location: "http://localhost/en/contact"},
+		// Paths with a locale prefix are not prefixed again.
+		{url: "/de", body: "not found"},
+		{url: "/de/contact/more", body: "not found"},
+	}
+	for _, tt := range tests {
+		rw := NewRecorder()
+		if err := r.ServeHTTP(context.Background(), rw, newRequest(http.MethodGet, "http://localhost"+tt.url), nil); err != nil {
+			t.Fatal(err)
+		}
+		if rw.Body.String() != tt.body || rw.Header().Get("Location") != tt.location {
+			t.Errorf("%s: expected %q, location %q, got %q, location %q", tt.url, tt.body, tt.location, rw.Body.String(), rw.Header().Get("Location"))
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package mux
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// Logger receives the diagnostics of the router and the built-in middlewares,
+// such as invalid route templates or failing audit sinks. *slog.Logger
+// implements it.
+type Logger interface {
+	Log(ctx context.Context, level slog.Level, msg string, args ...any)
+}
+
+// defaultLogger forwards to slog.Default at the time of logging, so that
+// changes made with slog.SetDefault take effect.
+type defaultLogger struct{}
+
+func (defaultLogger) Log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	slog.Default().Log(ctx, level, msg, args...)
+}
+
+// nopLogger discards everything.
+type nopLogger struct{}
+
+func (nopLogger) Log(ctx context.Context, level slog.Level, msg string, args ...any) {}
+
+// NopLogger returns a Logger which discards all diagnostics.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+// SetLogger sets the logger used by the router, its routes and the built-in
+// middlewares serving its requests. Routes and subrouters inherit the logger
+// of their parent when they are created, so it should be set before routes
+// are registered. By default diagnostics go to slog.Default.
+func (r *Router) SetLogger(logger Logger) *Router {
+	r.logger = logger
+	return r
+}
+
+// getLogger returns the configured logger or the default one.
+func (c *routeConf) getLogger() Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return defaultLogger{}
+}
+
+// requestLogger returns the logger of the router serving req.
+func requestLogger(req *http.Request) Logger {
+	if router := CurrentRouter(req); router != nil {
+		return router.getLogger()
+	}
+	return defaultLogger{}
+}
@@ -0,0 +1,39 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRouterLogger(t *testing.T) {
+	var buf bytes.Buffer
+	router := NewRouter().SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	router.HandleFunc("/{id:[}", dummyHandler).Name("a").Name("b")
+	router.Use(AuditMiddleware(AuditOptions{
+		Sink: AuditSinkFunc(func(ctx context.Context, event *AuditEvent) error {
+			return errors.New("sink down")
+		}),
+	}))
+	router.HandleFunc("/", dummyHandler)
+
+	if n := strings.Count(buf.String(), "mux: invalid route"); n != 1 {
+		t.Errorf("expected the invalid route to be logged once, got %d: %s", n, buf.String())
+	}
+
+	buf.Reset()
+	_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/"), nil)
+	if !strings.Contains(buf.String(), "mux: audit sink failed") || !strings.Contains(buf.String(), "sink down") {
+		t.Errorf("expected sink failure to be logged, got %q", buf.String())
+	}
+}
+
+func TestNopLogger(t *testing.T) {
+	router := NewRouter().SetLogger(NopLogger())
+	if router.HandleFunc("/{", dummyHandler).GetError() == nil {
+		t.Error("expected route error")
+	}
+}
@@ -0,0 +1,194 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// LogFormat selects the output format of LoggingMiddleware.
+type LogFormat int
+
+const (
+	// LogFormatCommon is the Apache Common Log Format.
+	LogFormatCommon LogFormat = iota
+	// LogFormatCombined is the Apache Combined Log Format, i.e. the Common
+	// Log Format followed by the referer and user agent.
+	LogFormatCombined
+	// LogFormatJSON writes one JSON document per request.
+	LogFormatJSON
+)
+
+// LoggingOptions configures LoggingMiddleware.
+type LoggingOptions struct {
+	// Output receives one line per request. Writes are serialized.
+	Output io.Writer
+	// Format selects the line format. It defaults to LogFormatCommon.
+	Format LogFormat
+}
+
+// LogEntry is a single access log entry. It is the document written in
+// LogFormatJSON.
+type LogEntry struct {
+	Time       time.Time     `json:"time"`
+	RemoteAddr string        `json:"remote_addr"`
+	User       string        `json:"user,omitempty"`
+	Method     string        `json:"method"`
+	URI        string        `json:"uri"`
+	Proto      string        `json:"proto"`
+	Route      string        `json:"route,omitempty"`
+	Tenant     string        `json:"tenant,omitempty"`
+	Status     int           `json:"status"`
+	Size       int64         `json:"size"`
+	Duration   time.Duration `json:"duration_ns"`
+	Referer    string        `json:"referer,omitempty"`
+	UserAgent  string        `json:"user_agent,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// LoggingMiddleware writes an access log line for every request to
+// options.Output.
+func LoggingMiddleware(options LoggingOptions) MiddlewareFunc {
+	var mu sync.Mutex
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+			rw := NewResponseRecorderWriter(w)
+			start := time.Now()
+			err := next(ctx, rw, req, binder)
+
+			entry := &LogEntry{
+				Time:       start,
+				RemoteAddr: req.RemoteAddr,
+				Method:     req.Method,
+				URI:        req.URL.RequestURI(),
+				Proto:      req.Proto,
+				Status:     rw.StatusOrDefault(err),
+				Size:       rw.BytesWritten(),
+				Duration:   time.Since(start),
+				Referer:    req.Referer(),
+				UserAgent:  req.UserAgent(),
+			}
+			if host, _, splitErr := net.SplitHostPort(req.RemoteAddr); splitErr == nil {
+				entry.RemoteAddr = host
+			}
+			if req.URL.User != nil {
+				entry.User = req.URL.User.Username()
+			} else if user, _, ok := req.BasicAuth(); ok {
+				entry.User = user
+			}
+			if route := CurrentRoute(req); route != nil {
+				entry.Route, _ = route.GetPathTemplate()
+			}
+			entry.Tenant, _ = GetTenant(req.Context())
+			if err != nil {
+				entry.Error = err.Error()
+			}
+
+			line := formatLogEntry(options.Format, entry)
+
+			mu.Lock()
+			_, writeErr := options.Output.Write(line)
+			mu.Unlock()
+			if writeErr != nil {
+				requestLogger(req).Log(ctx, slog.LevelError, "mux: writing access log failed", "error", writeErr)
+			}
+
+			return err
+		}
+	}
+}
+
+// formatLogEntry renders entry as a newline terminated line.
+func formatLogEntry(format LogFormat, entry *LogEntry) []byte {
+	if format == LogFormatJSON {
+		b, _ := json.Marshal(entry)
+		return append(b, '\n')
+	}
+
+	buf := make([]byte, 0, 3*len(entry.URI)+128)
+	buf = append(buf, orDash(entry.RemoteAddr)...)
+	buf = append(buf, " - "...)
+	if entry.User == "" {
+		buf = append(buf, '-')
+	} else {
+		buf = appendUnquoted(buf, entry.User)
+	}
+	buf = append(buf, " ["...)
+	buf = entry.Time.AppendFormat(buf, "02/Jan/2006:15:04:05 -0700")
+	buf = append(buf, `] "`...)
+	buf = appendQuoted(buf, entry.Method)
+	buf = append(buf, ' ')
+	buf = appendQuoted(buf, entry.URI)
+	buf = append(buf, ' ')
+	buf = appendQuoted(buf, entry.Proto)
+	buf = append(buf, `" `...)
+	buf = strconv.AppendInt(buf, int64(entry.Status), 10)
+	buf = append(buf, ' ')
+	buf = strconv.AppendInt(buf, entry.Size, 10)
+
+	if format == LogFormatCombined {
+		buf = append(buf, ` "`...)
+		buf = appendQuoted(buf, entry.Referer)
+		buf = append(buf, `" "`...)
+		buf = appendQuoted(buf, entry.UserAgent)
+		buf = append(buf, '"')
+	}
+
+	return append(buf, '\n')
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// appendQuoted appends s to buf, escaping quotes, backslashes and
+// non-printable characters so that a field cannot break the line format.
+func appendQuoted(buf []byte, s string) []byte {
+	return appendEscaped(buf, s, false)
+}
+
+// appendUnquoted appends s to buf like appendQuoted, and escapes spaces too
+// as the field is not quoted.
+func appendUnquoted(buf []byte, s string) []byte {
+	return appendEscaped(buf, s, true)
+}
+
+func appendEscaped(buf []byte, s string, space bool) []byte {
+	const lowerhex = "0123456789abcdef"
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, width := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && width == 1 {
+				buf = append(buf, `\x`...)
+				buf = append(buf, lowerhex[c>>4], lowerhex[c&0xf])
+			} else {
+				buf = append(buf, s[i:i+width]...)
+			}
+			i += width
+			continue
+		}
+		switch {
+		case c == '"' || c == '\\':
+			buf = append(buf, '\\', c)
+		case c < 0x20 || c == 0x7f || space && c == ' ':
+			buf = append(buf, `\x`...)
+			buf = append(buf, lowerhex[c>>4], lowerhex[c&0xf])
+		default:
+			buf = append(buf, c)
+		}
+		i++
+	}
+	return buf
+}
@@ -0,0 +1,100 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	tests := []struct {
+		format   LogFormat
+		expected string
+	}{
+		{
+			format:   LogFormatCommon,
+			expected: `^192\.0\.2\.1 - alice \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /items/1\?q=\\"x\\" HTTP/1\.1" 201 5\n$`,
+		},
+		{
+			format:   LogFormatCombined,
+			expected: `^192\.0\.2\.1 - alice \[.+\] "GET /items/1\?q=\\"x\\" HTTP/1\.1" 201 5 "http://example\.com/" "test-agent"\n$`,
+		},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		router := NewRouter()
+		router.Use(LoggingMiddleware(LoggingOptions{Output: &out, Format: test.format}))
+		router.HandleFunc("/items/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+			w.WriteHeader(http.StatusCreated)
+			_, err := w.Write([]byte("hello"))
+			return err
+		})
+
+		req := newRequestWithHeaders("GET", `http://localhost/items/1?q="x"`, "Referer", "http://example.com/", "User-Agent", "test-agent")
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.SetBasicAuth("alice", "secret")
+		if err := router.ServeHTTP(context.Background(), NewRecorder(), req, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if !regexp.MustCompile(test.expected).MatchString(out.String()) {
+			t.Errorf("format %d: unexpected line %q", test.format, out.String())
+		}
+	}
+}
+
+func TestLoggingMiddlewareUser(t *testing.T) {
+	for _, format := range []LogFormat{LogFormatCommon, LogFormatCombined} {
+		var out bytes.Buffer
+		router := NewRouter()
+		router.Use(LoggingMiddleware(LoggingOptions{Output: &out, Format: format}))
+		router.HandleFunc("/", dummyHandler)
+
+		req := newRequest("GET", "http://localhost/")
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.SetBasicAuth("eve x\n192.0.2.2 - admin", "secret")
+		if err := router.ServeHTTP(context.Background(), NewRecorder(), req, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		line := out.String()
+		if strings.Count(line, "\n") != 1 {
+			t.Fatalf("format %d: expected a single line, got %q", format, line)
+		}
+		fields := strings.Fields(line)
+		expected := 10
+		if format == LogFormatCombined {
+			expected = 12
+		}
+		if len(fields) != expected || fields[2] != `eve\x20x\x0a192.0.2.2\x20-\x20admin` {
+			t.Errorf("format %d: unexpected fields %q", format, fields)
+		}
+	}
+}
+
+func TestLoggingMiddlewareJSON(t *testing.T) {
+	var out bytes.Buffer
+	router := NewRouter()
+	router.Use(LoggingMiddleware(LoggingOptions{Output: &out, Format: LogFormatJSON}))
+	router.HandleFunc("/fail/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		return errors.New("boom")
+	})
+
+	if err := router.ServeHTTP(context.Background(), NewRecorder(), newRequest("POST", "http://localhost/fail/1"), nil); err == nil {
+		t.Fatal("expected handler error")
+	}
+
+	var entry LogEntry
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Method != "POST" || entry.URI != "/fail/1" || entry.Route != "/fail/{id}" || entry.Status != http.StatusInternalServerError || entry.Error != "boom" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
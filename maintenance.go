@@ -0,0 +1,50 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+)
+
+// maintenanceMode is the state of a router in maintenance.
+type maintenanceMode struct {
+	handler Handler
+	except  map[string]bool
+}
+
+// allows reports whether the matched route is exempt from maintenance.
+func (m *maintenanceMode) allows(match *RouteMatch) bool {
+	return match.MatchErr == nil && match.Route != nil && m.except[match.Route.GetName()]
+}
+
+// SetMaintenance atomically diverts all requests to handler, except requests
+// matching one of the routes named in except, such as health checks. Router
+// middlewares are not applied to the maintenance handler, and the diverted
+// requests are not recorded under the route they matched by the match hooks,
+// statistics, latency histograms and samples: RouterStats.Maintenance counts
+// them instead. If handler is nil, requests are answered with 503 Service
+// Unavailable. It is safe to call while the router serves requests.
+func (r *Router) SetMaintenance(handler Handler, except ...string) {
+	if handler == nil {
+		handler = HandlerFunc(maintenanceHandler)
+	}
+	m := &maintenanceMode{handler: handler, except: make(map[string]bool, len(except))}
+	for _, name := range except {
+		m.except[name] = true
+	}
+	r.maintenance.Store(m)
+}
+
+// ClearMaintenance ends the maintenance mode started with SetMaintenance.
+func (r *Router) ClearMaintenance() {
+	r.maintenance.Store(nil)
+}
+
+// InMaintenance reports whether the router is in maintenance mode.
+func (r *Router) InMaintenance() bool {
+	return r.maintenance.Load() != nil
+}
+
+func maintenanceHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	return nil
+}
@@ -0,0 +1,85 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMaintenance(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/healthz", dummyHandler).Name("healthz")
+	router.HandleFunc("/items", dummyHandler).Name("items")
+
+	serve := func(path string) int {
+		rw := NewRecorder()
+		_ = router.ServeHTTP(context.Background(), rw, newRequest("GET", "http://localhost"+path), nil)
+		if rw.Code == 0 {
+			return http.StatusOK
+		}
+		return rw.Code
+	}
+
+	router.SetMaintenance(nil, "healthz")
+	if !router.InMaintenance() {
+		t.Fatal("expected maintenance mode")
+	}
+	for path, expected := range map[string]int{"/healthz": http.StatusOK, "/items": http.StatusServiceUnavailable, "/missing": http.StatusServiceUnavailable} {
+		if code := serve(path); code != expected {
+			t.Errorf("%s: expected %d, got %d", path, expected, code)
+		}
+	}
+
+	router.SetMaintenance(HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		w.WriteHeader(http.StatusTeapot)
+		return nil
+	}))
+	if code := serve("/healthz"); code != http.StatusTeapot {
+		t.Errorf("expected custom maintenance handler, got %d", code)
+	}
+
+	router.ClearMaintenance()
+	if code := serve("/items"); code != http.StatusOK || router.InMaintenance() {
+		t.Errorf("expected normal operation, got %d", code)
+	}
+}
+
+func TestMaintenanceNotRecorded(t *testing.T) {
+	var matched []string
+	var sampled []*Route
+	router := NewRouter().EnableStats().EnableLatencyHistograms(time.Second)
+	router.OnMatch(func(ctx context.Context, match *RouteMatch) {
+		matched = append(matched, match.Route.GetName())
+	})
+	router.EnableSampling(SamplingOptions{Rate: 1, Callback: func(ctx context.Context, sample *Sample) {
+		sampled = append(sampled, sample.Route)
+	}})
+	router.HandleFunc("/healthz", dummyHandler).Name("healthz")
+	router.HandleFunc("/items", dummyHandler).Name("items")
+
+	router.SetMaintenance(nil, "healthz")
+	for _, path := range []string{"/healthz", "/items", "/missing"} {
+		_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost"+path), nil)
+	}
+
+	if len(matched) != 1 || matched[0] != "healthz" {
+		t.Errorf("expected the match hook for healthz only, got %v", matched)
+	}
+	if len(sampled) != 3 || sampled[0] == nil || sampled[1] != nil || sampled[2] != nil {
+		t.Errorf("expected the diverted samples without route, got %v", sampled)
+	}
+	stats := router.Stats()
+	if stats.Maintenance != 2 || stats.NotFound != 0 {
+		t.Errorf("expected 2 maintenance requests and none not found, got %d and %d", stats.Maintenance, stats.NotFound)
+	}
+	for _, rs := range stats.Routes {
+		expected := uint64(0)
+		if rs.Name == "healthz" {
+			expected = 1
+		}
+		if rs.Hits != expected || rs.Histogram != nil && rs.Histogram.Count != expected {
+			t.Errorf("%s: expected %d hits, got %d and histogram %+v", rs.Name, expected, rs.Hits, rs.Histogram)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package mux
+
+import "net/http"
+
+// MatchResult describes the route matching a request, see
+// Router.MatchRequest.
+type MatchResult struct {
+	// Route is the matched route. For routes of subrouters it is the route
+	// of the subrouter.
+	Route *Route
+	// Name and PathTemplate are the name and path template of Route, empty
+	// if it has none.
+	Name         string
+	PathTemplate string
+	// Vars holds the route variables.
+	Vars map[string]string
+}
+
+// MatchRequest returns the route req is dispatched to, without calling any
+// handler. It returns ErrNotFound if no route matches, even if the router has
+// a NotFoundHandler, and ErrMethodMismatch if routes only reject the request
+// method. Unlike ServeHTTP it does not redirect requests with paths which are
+// not clean.
+//
+// It is meant for table-driven tests of the route set, see also
+// muxtest.AssertRoutes.
+func (r *Router) MatchRequest(req *http.Request) (*MatchResult, error) {
+	var match RouteMatch
+	if !r.Match(req, &match) && match.MatchErr == nil {
+		return nil, ErrNotFound
+	}
+	if match.MatchErr != nil {
+		return nil, match.MatchErr
+	}
+	result := &MatchResult{Route: match.Route, Vars: match.Vars}
+	if result.Vars == nil {
+		result.Vars = map[string]string{}
+	}
+	if match.Route != nil {
+		result.Name = match.Route.GetName()
+		result.PathTemplate, _ = match.Route.GetPathTemplate()
+	}
+	return result, nil
+}
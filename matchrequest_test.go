@@ -0,0 +1,42 @@
+package mux
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMatchRequest(t *testing.T) {
+	router := NewRouter()
+	router.NotFoundHandler = HandlerFunc(NotFound)
+	router.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodGet).Name("user")
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/items/{item}", dummyHandler).Headers("X-Version", "2").Name("items.v2")
+	api.HandleFunc("/items/{item}", dummyHandler)
+
+	tests := []struct {
+		method, url string
+		headers     []string
+		name, tpl   string
+		vars        map[string]string
+		err         error
+	}{
+		{http.MethodGet, "http://localhost/users/1", nil, "user", "/users/{id}", map[string]string{"id": "1"}, nil},
+		{http.MethodGet, "http://localhost/api/items/a", []string{"X-Version", "2"}, "items.v2", "/api/items/{item}", map[string]string{"item": "a"}, nil},
+		{http.MethodGet, "http://localhost/api/items/a", nil, "", "/api/items/{item}", map[string]string{"item": "a"}, nil},
+		{http.MethodPost, "http://localhost/users/1", nil, "", "", nil, ErrMethodMismatch},
+		{http.MethodGet, "http://localhost/missing", nil, "", "", nil, ErrNotFound},
+	}
+	for _, tt := range tests {
+		result, err := router.MatchRequest(newRequestWithHeaders(tt.method, tt.url, tt.headers...))
+		if err != tt.err {
+			t.Errorf("%s %s: expected error %v, got %v", tt.method, tt.url, tt.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if result.Name != tt.name || result.PathTemplate != tt.tpl || !stringMapEqual(result.Vars, tt.vars) {
+			t.Errorf("%s %s: unexpected result %+v", tt.method, tt.url, result)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package mux
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MatchTracingOptions configures match tracing, see Router.EnableMatchTracing.
+type MatchTracingOptions struct {
+	// Size is the number of traces kept, 100 if not positive.
+	Size int
+	// Predicate selects the traced requests. It is optional; all requests
+	// are traced if nil.
+	Predicate func(r *http.Request) bool
+	// UnmatchedOnly keeps only the traces of requests no route matched.
+	UnmatchedOnly bool
+}
+
+// MatchTrace records how a request was matched against the routes.
+type MatchTrace struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Host   string    `json:"host"`
+	Path   string    `json:"path"`
+	// Result is "matched", "method not allowed" or "not found".
+	Result string `json:"result"`
+	// Route is the name, or else the path template, of the matched route.
+	Route string `json:"route,omitempty"`
+	// Steps lists the routes which rejected the request, in the order they
+	// were tried.
+	Steps []MatchStep `json:"steps,omitempty"`
+	// Duration is the time spent matching.
+	Duration time.Duration `json:"duration"`
+}
+
+// MatchStep describes a route rejecting a traced request.
+type MatchStep struct {
+	// Route is the name, or else the path template, of the route.
+	Route string `json:"route"`
+	// Matcher is the kind of matcher which rejected the request: "host",
+	// "path", "prefix", "query", "method", "scheme", "header", "router"
+	// for a subrouter, "custom" for a MatcherFunc or other matchers,
+	// "disabled" if the route is disabled and "invalid" if the route has an
+	// error.
+	Matcher string `json:"matcher"`
+	// Duration is the time spent matching the route.
+	Duration time.Duration `json:"duration"`
+}
+
+// matchTracing is a ring buffer of the recent match traces.
+type matchTracing struct {
+	options MatchTracingOptions
+	mu      sync.Mutex
+	traces  []MatchTrace
+	next    int
+	full    bool
+}
+
+// EnableMatchTracing turns on the tracing of route matching: for each traced
+// request, the routes which rejected it and the matcher responsible are
+// recorded, and the most recent traces are available from MatchTraces and
+// the "traces" view of DebugHandler. Traced requests try every route, without
+// the route index, see RouteIndex. Requests which are not traced are not
+// affected, so tracing can be left enabled with a narrow Predicate to debug
+// unexpected 404 and 405 responses in production.
+func (r *Router) EnableMatchTracing(options MatchTracingOptions) *Router {
+	if options.Size <= 0 {
+		options.Size = 100
+	}
+	r.matchTracing = &matchTracing{options: options, traces: make([]MatchTrace, options.Size)}
+	return r
+}
+
+// MatchTraces returns the recent match traces, the oldest first. It returns
+// nil if match tracing is not enabled.
+func (r *Router) MatchTraces() []MatchTrace {
+	t := r.matchTracing
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var traces []MatchTrace
+	if t.full {
+		traces = append(traces, t.traces[t.next:]...)
+	}
+	return append(traces, t.traces[:t.next]...)
+}
+
+// startMatchTrace returns a new trace if the request is selected for tracing.
+func (r *Router) startMatchTrace(req *http.Request) *MatchTrace {
+	if p := r.matchTracing.options.Predicate; p != nil && !p(req) {
+		return nil
+	}
+	return &MatchTrace{
+		Time:   time.Now(),
+		Method: req.Method,
+		Host:   req.Host,
+		Path:   req.URL.Path,
+	}
+}
+
+// finishMatchTrace completes the trace of match and records it.
+func (r *Router) finishMatchTrace(match *RouteMatch) {
+	trace := match.matchTrace
+	trace.Duration = time.Since(trace.Time)
+	switch {
+	case match.MatchErr == ErrMethodMismatch:
+		trace.Result = "method not allowed"
+	case match.MatchErr == nil && match.Route != nil:
+		trace.Result = "matched"
+		trace.Route = routeLabel(match.Route)
+	default:
+		trace.Result = "not found"
+	}
+
+	t := r.matchTracing
+	if t.options.UnmatchedOnly && trace.Result == "matched" {
+		return
+	}
+	t.mu.Lock()
+	t.traces[t.next] = *trace
+	if t.next++; t.next == len(t.traces) {
+		t.next = 0
+		t.full = true
+	}
+	t.mu.Unlock()
+}
+
+// reject records that route rejected the request because of m, or for the
+// given reason if m is nil.
+func (t *MatchTrace) reject(route *Route, m matcher, reason string, start time.Time) {
+	if m != nil {
+		reason = matcherKind(m)
+	}
+	t.Steps = append(t.Steps, MatchStep{
+		Route:    routeLabel(route),
+		Matcher:  reason,
+		Duration: time.Since(start),
+	})
+}
+
+// routeLabel returns the name, or else the path template, of route.
+func routeLabel(route *Route) string {
+	if name := route.GetName(); name != "" {
+		return name
+	}
+	if tpl, err := route.GetPathTemplate(); err == nil {
+		return tpl
+	}
+	return ""
+}
+
+// matcherKind returns the kind of m reported in MatchStep.Matcher.
+func matcherKind(m matcher) string {
+	switch m := m.(type) {
+	case *routeRegexp:
+		switch m.regexpType {
+		case regexpTypeHost:
+			return "host"
+		case regexpTypePrefix:
+			return "prefix"
+		case regexpTypeQuery:
+			return "query"
+		default:
+			return "path"
+		}
+	case methodMatcher:
+		return "method"
+	case schemeMatcher:
+		return "scheme"
+	case headerMatcher:
+		return "header"
+	case *Router:
+		return "router"
+	default:
+		return "custom"
+	}
+}
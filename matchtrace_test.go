@@ -0,0 +1,152 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func matchSteps(trace MatchTrace) []MatchStep {
+	steps := make([]MatchStep, len(trace.Steps))
+	for i, step := range trace.Steps {
+		steps[i] = MatchStep{Route: step.Route, Matcher: step.Matcher}
+	}
+	return steps
+}
+
+func TestMatchTracing(t *testing.T) {
+	router := NewRouter().EnableMatchTracing(MatchTracingOptions{})
+	router.HandleFunc("/users", dummyHandler).Methods("POST").Name("createUser")
+	router.HandleFunc("/users/{id:[0-9]+}", dummyHandler).Methods("GET").Name("getUser")
+	router.HandleFunc("/search", dummyHandler).Queries("q", "{q}")
+
+	tests := []struct {
+		method, url string
+		result      string
+		route       string
+		steps       []MatchStep
+	}{
+		{
+			method: "GET", url: "http://localhost/users/1",
+			result: "matched", route: "getUser",
+			steps: []MatchStep{{Route: "createUser", Matcher: "path"}},
+		},
+		{
+			method: "GET", url: "http://localhost/users",
+			result: "method not allowed",
+			steps: []MatchStep{
+				{Route: "createUser", Matcher: "method"},
+				{Route: "getUser", Matcher: "path"},
+				{Route: "/search", Matcher: "path"},
+			},
+		},
+		{
+			method: "GET", url: "http://localhost/search",
+			result: "not found",
+			steps: []MatchStep{
+				{Route: "createUser", Matcher: "path"},
+				{Route: "getUser", Matcher: "path"},
+				{Route: "/search", Matcher: "query"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if err := router.ServeHTTP(context.Background(), NewRecorder(), newRequest(tt.method, tt.url), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	traces := router.MatchTraces()
+	if len(traces) != len(tests) {
+		t.Fatalf("expected %d traces, got %d", len(tests), len(traces))
+	}
+	for i, tt := range tests {
+		trace := traces[i]
+		if trace.Method != tt.method || !strings.HasSuffix(tt.url, trace.Path) || trace.Result != tt.result || trace.Route != tt.route {
+			t.Errorf("%s %s: unexpected trace %+v", tt.method, tt.url, trace)
+		}
+		if steps := matchSteps(trace); !reflect.DeepEqual(steps, tt.steps) {
+			t.Errorf("%s %s: expected steps %+v, got %+v", tt.method, tt.url, tt.steps, steps)
+		}
+	}
+}
+
+func TestMatchTracingOptions(t *testing.T) {
+	router := NewRouter().EnableMatchTracing(MatchTracingOptions{
+		Size:          2,
+		Predicate:     func(r *http.Request) bool { return r.Header.Get("X-Trace") != "" },
+		UnmatchedOnly: true,
+	})
+	router.HandleFunc("/", dummyHandler)
+
+	for _, path := range []string{"/a", "/", "/b", "/c"} {
+		for _, trace := range []string{"", "1"} {
+			req := newRequestWithHeaders("GET", "http://localhost"+path, "X-Trace", trace)
+			if err := router.ServeHTTP(context.Background(), NewRecorder(), req, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	traces := router.MatchTraces()
+	if len(traces) != 2 || traces[0].Path != "/b" || traces[1].Path != "/c" {
+		t.Errorf("expected the traces of /b and /c, got %+v", traces)
+	}
+	if NewRouter().MatchTraces() != nil {
+		t.Error("expected no traces without match tracing")
+	}
+}
+
+func TestMatchTracingSubrouter(t *testing.T) {
+	router := NewRouter().EnableMatchTracing(MatchTracingOptions{})
+	router.MatcherFunc(func(r *http.Request, rm *RouteMatch) bool { return false }).HandlerFunc(dummyHandler).Name("never")
+	sub := router.PathPrefix("/api").Subrouter()
+	sub.HandleFunc("/items", dummyHandler).Schemes("https").Name("items")
+
+	if err := router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/api/items"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	traces := router.MatchTraces()
+	if len(traces) != 1 {
+		t.Fatalf("expected 1 trace, got %d", len(traces))
+	}
+	expected := []MatchStep{
+		{Route: "never", Matcher: "custom"},
+		{Route: "items", Matcher: "scheme"},
+		{Route: "/api", Matcher: "router"},
+	}
+	if steps := matchSteps(traces[0]); !reflect.DeepEqual(steps, expected) {
+		t.Errorf("expected steps %+v, got %+v", expected, steps)
+	}
+}
+
+func TestDebugHandlerTraces(t *testing.T) {
+	router := NewRouter().EnableMatchTracing(MatchTracingOptions{UnmatchedOnly: true})
+	router.Handle("/_mux/routes", router.DebugHandler())
+	_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/missing"), nil)
+
+	rw := NewRecorder()
+	if err := router.ServeHTTP(context.Background(), rw, newRequest("GET", "http://localhost/_mux/routes?view=traces&format=json"), nil); err != nil {
+		t.Fatal(err)
+	}
+	var traces []MatchTrace
+	if err := json.Unmarshal(rw.Body.Bytes(), &traces); err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 1 || traces[0].Path != "/missing" || traces[0].Result != "not found" {
+		t.Errorf("unexpected traces %+v", traces)
+	}
+
+	rw = NewRecorder()
+	if err := router.ServeHTTP(context.Background(), rw, newRequest("GET", "http://localhost/_mux/routes?view=traces"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(rw.Body.String(), "<td>/missing</td><td>not found</td>") {
+		t.Errorf("unexpected HTML %s", rw.Body.String())
+	}
+}
@@ -3,12 +3,18 @@ package mux
 import (
 	"context"
 	"net/http"
+	"reflect"
 	"strings"
 )
 
 // MiddlewareFunc is a function which receives an http.Handler and returns another http.Handler.
 // Typically, the returned handler is a closure which does something with the http.ResponseWriter and http.Request passed
 // to it, and then calls the handler passed as parameter to the MiddlewareFunc.
+//
+// The router composes the middlewares of a route once, when the route is first
+// matched, and again after a handler or middleware is added; the returned
+// handler serves all the requests in between and must not keep per-request
+// state outside of its own call.
 type MiddlewareFunc func(handlerFunc HandlerFunc) HandlerFunc
 
 // middleware interface is anything which implements a MiddlewareFunc named Middleware.
@@ -26,11 +32,101 @@ func (r *Router) Use(mwf ...MiddlewareFunc) {
 	for _, fn := range mwf {
 		r.middlewares = append(r.middlewares, fn)
 	}
+	r.generations().middlewares.Add(1)
+}
+
+// UseWhen appends MiddlewareFuncs to the chain which only apply to the routes of the router and its subrouters whose metadata value for key equals value, see Route.Metadata and Router.Metadata. Cross-cutting policies can thus follow the route attributes instead of the route tree:
+//
+//	r.UseWhen("auth", "required", authMiddleware)
+//	r.HandleFunc("/users", listUsers).Metadata("auth", "required")
+//
+// Other routes are served as if the middlewares were not registered.
+func (r *Router) UseWhen(key, value any, mwf ...MiddlewareFunc) {
+	for _, fn := range mwf {
+		r.middlewares = append(r.middlewares, metadataMiddleware{key: key, value: value, mw: fn})
+	}
+	r.generations().middlewares.Add(1)
+}
+
+// metadataMiddleware is a middleware applying to the routes with a metadata
+// value, see Router.UseWhen.
+type metadataMiddleware struct {
+	key, value any
+	mw         MiddlewareFunc
+}
+
+func (m metadataMiddleware) Middleware(handler HandlerFunc) HandlerFunc {
+	return m.mw(handler)
+}
+
+// appliesTo reports whether mw applies to route.
+func appliesTo(mw middleware, route *Route) bool {
+	m, ok := mw.(metadataMiddleware)
+	if !ok {
+		return true
+	}
+	if route == nil {
+		return false
+	}
+	value, ok := route.metadata[m.key]
+	if !ok {
+		return false
+	}
+	if t := reflect.TypeOf(value); t != nil && !t.Comparable() {
+		return false
+	}
+	return value == m.value
 }
 
 // useInterface appends a middleware to the chain. Middleware can be used to intercept or otherwise modify requests and/or responses, and are executed in the order that they are applied to the Router.
 func (r *Router) useInterface(mw middleware) {
 	r.middlewares = append(r.middlewares, mw)
+	r.generations().middlewares.Add(1)
+}
+
+// UseStd appends standard middlewares, such as the ones of gorilla/handlers,
+// to the chain, see StdMiddleware.
+func (r *Router) UseStd(mw ...func(http.Handler) http.Handler) {
+	for _, fn := range mw {
+		r.Use(StdMiddleware(fn))
+	}
+}
+
+// stdMiddlewareKey is the request context key of the stdMiddlewareState of
+// a request served by a standard middleware.
+type stdMiddlewareKey struct{}
+
+// stdMiddlewareState carries the arguments and the result of the next
+// handler through a standard middleware.
+type stdMiddlewareState struct {
+	ctx    context.Context
+	binder Binder
+	err    error
+}
+
+// StdMiddleware adapts a standard middleware to a MiddlewareFunc. The next
+// handler receives the context and binder given to the middleware, along with
+// the request and response writer passed on by mw, and its error is returned
+// by the adapted middleware. If mw does not call the next handler, nil is
+// returned. If mw replaces the request context by one not derived from it,
+// the next handler receives the replaced context and a nil binder, and its
+// error is lost.
+func StdMiddleware(mw func(http.Handler) http.Handler) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			state, ok := r.Context().Value(stdMiddlewareKey{}).(*stdMiddlewareState)
+			if !ok {
+				_ = next(r.Context(), w, r, nil)
+				return
+			}
+			state.err = next(state.ctx, w, r, state.binder)
+		}))
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+			state := &stdMiddlewareState{ctx: ctx, binder: binder}
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), stdMiddlewareKey{}, state)))
+			return state.err
+		}
+	}
 }
 
 // RouteMiddleware -------------------------------------------------------------
@@ -40,6 +136,7 @@ func (r *Route) Use(mwf ...MiddlewareFunc) *Route {
 	for _, fn := range mwf {
 		r.middlewares = append(r.middlewares, fn)
 	}
+	r.generations().middlewares.Add(1)
 
 	return r
 }
@@ -47,17 +144,36 @@ func (r *Route) Use(mwf ...MiddlewareFunc) *Route {
 // useInterface appends a MiddlewareFunc to the chain. Middleware can be used to intercept or otherwise modify requests and/or responses, and are executed in the order that they are applied to the Route. Route middleware are executed after the Router middleware but before the Route handler.
 func (r *Route) useInterface(mw middleware) {
 	r.middlewares = append(r.middlewares, mw)
+	r.generations().middlewares.Add(1)
 }
 
 // CORSMethodMiddleware automatically sets the Access-Control-Allow-Methods response header
 // on requests for routes that have an OPTIONS method matcher to all the method matchers on
 // the route. Routes that do not explicitly handle OPTIONS requests will not be processed
 // by the middleware. See examples for usage.
+//
+// The methods are those of the routes of r and its subrouters matching the request apart
+// from its method, grouped by template once. Only the routes whose templates do not
+// decide alone whether they match, such as routes with variable patterns or header and
+// query matchers, are matched against the request. If the route is omitted from the
+// request context, all the routes of r are matched against the request instead.
+//
+// If the matched route has a CORSPolicy, see Route.CORS, the middleware also sets the
+// Access-Control-Allow-Origin and Access-Control-Allow-Credentials headers for the
+// origins it allows, the Access-Control-Expose-Headers header on actual requests, and the
+// Access-Control-Allow-Headers and Access-Control-Max-Age headers on preflight requests.
+// Allow-Headers is only sent if the policy allows all headers in the
+// Access-Control-Request-Headers of the preflight. Preflight requests are matched to the
+// OPTIONS route, so it needs the policy as well.
 func CORSMethodMiddleware(r *Router) MiddlewareFunc {
 	return func(next HandlerFunc) HandlerFunc {
 		return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
-			allMethods, err := getAllMethodsForRoute(r, req)
-			if err == nil {
+			route := CurrentRoute(req)
+			if policy, ok := routeCORSPolicy(route); ok {
+				policy.apply(w.Header(), req)
+			}
+
+			if allMethods, ok := r.allowedMethods(route, req); ok {
 				for _, v := range allMethods {
 					if v == http.MethodOptions {
 						w.Header().Set("Access-Control-Allow-Methods", strings.Join(allMethods, ","))
@@ -70,22 +186,40 @@ func CORSMethodMiddleware(r *Router) MiddlewareFunc {
 	}
 }
 
-// getAllMethodsForRoute returns all the methods from method matchers matching a given
-// request.
-func getAllMethodsForRoute(r *Router, req *http.Request) ([]string, error) {
-	var allMethods []string
+// When returns a middleware which applies mw only to requests accepted by
+// the matcher. Requests rejected by the matcher are passed to the next
+// handler directly. The RouteMatch given to the matcher carries the matched
+// route, so matchers can decide based on route names or metadata as well as
+// on the request itself. It does not carry the route variables, which would
+// be collected into a map for every request: matchers read them with Var,
+// or Vars if they need all of them.
+//
+//	r.Use(mux.When(func(req *http.Request, match *mux.RouteMatch) bool {
+//	    return strings.HasPrefix(req.URL.Path, "/api/")
+//	}, authMiddleware))
+func When(m MatcherFunc, mw MiddlewareFunc) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		wrapped := mw(next)
 
-	for _, route := range r.routes {
-		var match RouteMatch
-		if route.Match(req, &match) || match.MatchErr == ErrMethodMismatch {
-			methods, err := route.GetMethods()
-			if err != nil {
-				return nil, err
+		return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+			match := RouteMatch{Route: CurrentRoute(req)}
+			if m.Match(req, &match) {
+				return wrapped(ctx, w, req, binder)
 			}
 
-			allMethods = append(allMethods, methods...)
+			return next(ctx, w, req, binder)
 		}
 	}
+}
 
-	return allMethods, nil
+// Unless returns a middleware which applies mw to all requests except those
+// accepted by the matcher, e.g. to skip logging for health checks:
+//
+//	r.Use(mux.Unless(func(req *http.Request, match *mux.RouteMatch) bool {
+//	    return match.Route != nil && match.Route.GetName() == "health"
+//	}, loggingMiddleware))
+func Unless(m MatcherFunc, mw MiddlewareFunc) MiddlewareFunc {
+	return When(func(req *http.Request, match *RouteMatch) bool {
+		return !m.Match(req, match)
+	}, mw)
 }
@@ -3,6 +3,7 @@ package mux
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
@@ -729,3 +730,157 @@ func TestMiddlewareOnMultiSubrouter(t *testing.T) {
 		}
 	})
 }
+
+func TestConditionalMiddleware(t *testing.T) {
+	isHealth := func(req *http.Request, match *RouteMatch) bool {
+		return match.Route != nil && match.Route.GetName() == "health"
+	}
+
+	router := NewRouter()
+	when := &testMiddleware{}
+	unless := &testMiddleware{}
+	router.Use(When(isHealth, when.Middleware), Unless(isHealth, unless.Middleware))
+	router.HandleFunc("/healthz", dummyHandler).Name("health")
+	router.HandleFunc("/api", dummyHandler)
+
+	for _, path := range []string{"/healthz", "/api", "/api"} {
+		if err := router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", path), nil); err != nil {
+			t.Fatalf("Failed to call ServeHTTP: %v", err)
+		}
+	}
+
+	if when.timesCalled != 1 {
+		t.Errorf("Expected When middleware to be called %d times, got %d", 1, when.timesCalled)
+	}
+	if unless.timesCalled != 2 {
+		t.Errorf("Expected Unless middleware to be called %d times, got %d", 2, unless.timesCalled)
+	}
+}
+
+func TestConditionalMiddlewareVars(t *testing.T) {
+	var matchVars map[string]string
+	isAdmin := func(req *http.Request, match *RouteMatch) bool {
+		matchVars = match.Vars
+		return Var(req, "user") == "admin"
+	}
+
+	router := NewRouter()
+	mw := &testMiddleware{}
+	router.Use(When(isAdmin, mw.Middleware))
+	router.HandleFunc("/users/{user}", dummyHandler)
+
+	for _, path := range []string{"/users/admin", "/users/guest"} {
+		if err := router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", path), nil); err != nil {
+			t.Fatalf("Failed to call ServeHTTP: %v", err)
+		}
+	}
+
+	if mw.timesCalled != 1 {
+		t.Errorf("Expected When middleware to be called %d times, got %d", 1, mw.timesCalled)
+	}
+	if matchVars != nil {
+		t.Errorf("Expected the variables not to be collected, got %v", matchVars)
+	}
+}
+
+func TestStdMiddleware(t *testing.T) {
+	type ctxKey struct{}
+	headerMiddleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Std", "1")
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, "std")))
+		})
+	}
+	denyMiddleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("deny") != "" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	errHandler := errors.New("handler error")
+	var ctxValue, reqValue any
+	var gotBinder Binder
+	router := NewRouter()
+	router.UseStd(headerMiddleware, denyMiddleware)
+	router.HandleFunc("/", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		ctxValue, reqValue, gotBinder = ctx.Value(ctxKey{}), r.Context().Value(ctxKey{}), binder
+		return errHandler
+	})
+
+	type ctxBinder struct{ Binder }
+	binder := &ctxBinder{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "ctx")
+	rw := NewRecorder()
+	if err := router.ServeHTTP(ctx, rw, newRequest("GET", "http://localhost/"), binder); err != errHandler {
+		t.Errorf("expected the handler error, got %v", err)
+	}
+	if rw.Header().Get("X-Std") != "1" {
+		t.Error("expected the standard middleware to set the header")
+	}
+	if ctxValue != "ctx" || reqValue != "std" || gotBinder != binder {
+		t.Errorf("unexpected handler arguments %v, %v, %v", ctxValue, reqValue, gotBinder)
+	}
+
+	rw = NewRecorder()
+	if err := router.ServeHTTP(ctx, rw, newRequest("GET", "http://localhost/?deny=1"), binder); err != nil {
+		t.Errorf("expected no error if the handler is not called, got %v", err)
+	}
+	if rw.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, rw.Code)
+	}
+}
+
+func TestMiddlewareUseWhen(t *testing.T) {
+	for _, sampled := range []bool{false, true} {
+		router := NewRouter()
+		if sampled {
+			router.EnableSampling(SamplingOptions{Rate: 1, Callback: func(context.Context, *Sample) {}})
+		}
+		var calls []string
+		record := func(name string) MiddlewareFunc {
+			return func(next HandlerFunc) HandlerFunc {
+				return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+					calls = append(calls, name)
+					return next(ctx, w, r, binder)
+				}
+			}
+		}
+		router.Use(record("log"))
+		router.UseWhen("auth", "required", record("auth"))
+		router.UseWhen("tags", []string{"x"}, record("never"))
+
+		router.HandleFunc("/public", dummyHandler)
+		router.HandleFunc("/users", dummyHandler).Metadata("auth", "required")
+		router.HandleFunc("/optional", dummyHandler).Metadata("auth", "optional").Metadata("tags", []string{"x"})
+		admin := router.PathPrefix("/admin").Subrouter().Metadata("auth", "required")
+		admin.HandleFunc("/users", dummyHandler)
+		late := router.HandleFunc("/late", dummyHandler)
+
+		serve := func(path string) []string {
+			calls = nil
+			_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest(http.MethodGet, "http://localhost"+path), nil)
+			return calls
+		}
+		tests := map[string][]string{
+			"/public":      {"log"},
+			"/users":       {"log", "auth"},
+			"/optional":    {"log"},
+			"/admin/users": {"log", "auth"},
+			"/late":        {"log"},
+		}
+		for path, want := range tests {
+			if got := serve(path); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("sampled %v, %s: expected middlewares %v, got %v", sampled, path, want, got)
+			}
+		}
+
+		late.Metadata("auth", "required")
+		if got := serve("/late"); fmt.Sprint(got) != "[log auth]" {
+			t.Errorf("sampled %v: expected the middleware after setting the metadata, got %v", sampled, got)
+		}
+	}
+}
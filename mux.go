@@ -8,10 +8,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
 	"path"
 	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var (
@@ -20,17 +25,22 @@ var (
 	ErrMethodMismatch = errors.New("method is not allowed")
 	// ErrNotFound is returned when no route match is found.
 	ErrNotFound = errors.New("no matching route was found")
-	// RegexpCompileFunc aliases regexp.Compile and enables overriding it.
+	// RegexpCompileFunc compiles the regexps of routes and enables
+	// overriding it. It defaults to DefaultRegexpCache.Compile, set it to
+	// regexp.Compile to disable caching. Paths without variables are
+	// matched by string comparison.
 	// Do not run this function from `init()` in importable packages.
 	// Changing this value is not safe for concurrent use.
-	RegexpCompileFunc = regexp.Compile
+	RegexpCompileFunc = DefaultRegexpCache.Compile
 	// ErrMetadataKeyNotFound is returned when the specified metadata key is not present in the map
 	ErrMetadataKeyNotFound = errors.New("key not found in metadata")
+	// ErrMetadataTypeMismatch is wrapped by the error of MetadataValue when the metadata value does not have the requested type
+	ErrMetadataTypeMismatch = errors.New("metadata value has a different type")
 )
 
 // NewRouter returns a new router instance.
 func NewRouter() *Router {
-	return &Router{namedRoutes: make(map[string]*Route)}
+	return &Router{routeConf: routeConf{tree: new(treeGenerations)}, namedRoutes: make(map[string]*Route)}
 }
 
 // Router registers routes to be matched and dispatches a handler.
@@ -61,7 +71,14 @@ type Router struct {
 	MethodNotAllowedHandler Handler
 
 	// Routes to be matched, in order.
-	routes []*Route
+	table atomic.Pointer[routeTable]
+
+	// Serializes route registration.
+	mu sync.Mutex
+
+	// Flattened routes grouped by template, computed on demand, see
+	// allowedMethods.
+	allowed atomic.Pointer[allowedMethods]
 
 	// Routes by name for URL building.
 	namedRoutes map[string]*Route
@@ -79,6 +96,86 @@ type Router struct {
 
 	// Binder is used to bind request data to the handler.
 	binder Binder
+
+	// Per-route statistics, nil unless enabled with EnableStats.
+	stats *routerStats
+
+	// Classifies handler errors in the statistics.
+	errorClassifier ErrorClassifier
+
+	// Lifecycle hooks called by ServeHTTP.
+	hooks routerHooks
+
+	// Latency histogram bucket bounds, nil unless enabled with
+	// EnableLatencyHistograms.
+	latencyBounds []time.Duration
+
+	// If true, routes count the requests they serve, see RecordCoverage.
+	coverage bool
+
+	// If true, CORS preflights are answered by the router, see
+	// EnableCORSPreflight.
+	corsPreflight bool
+
+	// Request sampling configuration, nil unless enabled with
+	// EnableSampling.
+	sampling *SamplingOptions
+
+	// Maintenance mode, nil unless enabled with SetMaintenance.
+	maintenance atomic.Pointer[maintenanceMode]
+
+	// Recent match traces, nil unless enabled with EnableMatchTracing.
+	matchTracing *matchTracing
+
+	// Patterns registered with HandlePattern, checked for conflicts. Guarded
+	// by mu.
+	servePatterns *http.ServeMux
+
+	// Resolves the tenant of requests, nil unless set with Tenants.
+	tenants *TenantResolver
+
+	// Routes of the tenant overlays by tenant, see TenantOverlay. Guarded
+	// by mu while routes are registered.
+	tenantOverlays map[string]*Route
+
+	// Routes redirecting to the locale routers of Locales, matched when no
+	// other route does. Guarded by mu while routes are registered.
+	localeRedirects []*Route
+
+	// Tenants with a literal host template or a tenant overlay, see
+	// TenantLabel.
+	knownTenants atomic.Pointer[knownTenants]
+}
+
+// routeTable is an immutable snapshot of the routes of a router. Registering
+// a route publishes a new snapshot, so that matching needs no locking.
+type routeTable struct {
+	routes []*Route
+
+	// Route index, built on demand unless disabled with RouteIndex.
+	index atomic.Pointer[routeIndex]
+
+	// Specificity order, computed on demand if enabled with
+	// EnableSpecificityOrder.
+	sorted atomic.Pointer[sortedRoutes]
+}
+
+// getRoutes returns the routes of the current snapshot.
+func (r *Router) getRoutes() []*Route {
+	if t := r.table.Load(); t != nil {
+		return t.routes
+	}
+	return nil
+}
+
+// addRoutes publishes a snapshot with routes appended.
+func (r *Router) addRoutes(routes ...*Route) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Snapshots never grow, so appending to the backing array of the
+	// current one does not change the routes seen by readers.
+	r.table.Store(&routeTable{routes: append(r.getRoutes(), routes...)})
+	r.generations().routes.Add(1)
 }
 
 // common route configuration shared between `Router` and `Route`
@@ -94,12 +191,40 @@ type routeConf struct {
 	// will not redirect
 	skipClean bool
 
+	// Cleans request paths instead of cleanPath, see SetPathCleaner.
+	pathCleaner func(path string) string
+
 	// If true, the http.Request context will not contain the Route.
 	omitRouteFromContext bool
 
 	// if true, the the http.Request context will not contain the router
 	omitRouterFromContext bool
 
+	// If true, routers try every route in turn instead of using the route
+	// index, see RouteIndex.
+	linearMatch bool
+
+	// If true, Compile reports conflicting routes, see Strict.
+	strict bool
+
+	// If true, routers match routes by specificity, see
+	// EnableSpecificityOrder.
+	specificityOrder bool
+
+	// If true, route regexps are compiled on first use, see LazyCompile.
+	lazyCompile bool
+
+	// If true, path templates accept ":name" and "*name" segments, see
+	// ColonPatterns.
+	colonPatterns bool
+
+	// If true, path templates accept extension variables, see
+	// ExtensionVars.
+	extensionVars bool
+
+	// Route regexps known to compile, see SetCompileCache.
+	compileCache *CompileCache
+
 	// Manager for the variables from host and path.
 	regexp routeRegexpGroup
 
@@ -110,6 +235,16 @@ type routeConf struct {
 	buildScheme string
 
 	buildVarsFunc BuildVarsFunc
+
+	// Receives diagnostics, see Router.SetLogger.
+	logger Logger
+
+	// Metadata inherited by new routes, see Router.Metadata. It is replaced,
+	// never modified.
+	routerMetadata map[any]any
+
+	// Generations of the router tree, shared by its routers and routes.
+	tree *treeGenerations
 }
 
 // returns an effective deep copy of `routeConf`
@@ -152,30 +287,41 @@ func copyRouteRegexp(r *routeRegexp) *routeRegexp {
 // (eg: not found) has a registered handler, the handler is assigned to the Handler
 // field of the match argument.
 func (r *Router) Match(req *http.Request, match *RouteMatch) bool {
-	for _, route := range r.routes {
-		if route.Match(req, match) {
-			// Build middleware chain if no error was found
-			if match.MatchErr == nil {
-				for i := len(r.middlewares) - 1; i >= 0; i-- {
-					match.Handler = r.middlewares[i].Middleware(HandlerToHandlerFunc(match.Handler))
-				}
-			}
+	if r.tenantOverlays != nil && r.matchTenantOverlay(req, match) {
+		return true
+	}
+	if ix := r.loadIndex(); ix != nil && match.matchTrace == nil {
+		if ix.match(r, req, match) {
 			return true
 		}
+	} else {
+		for _, route := range r.matchOrder(r.table.Load()) {
+			if r.matchRoute(route, req, match) {
+				return true
+			}
+		}
 	}
 
 	if match.MatchErr == ErrMethodMismatch {
 		if r.MethodNotAllowedHandler != nil {
 			match.Handler = r.MethodNotAllowedHandler
+			match.chain = nil
 			return true
 		}
 
 		return false
 	}
 
+	for _, route := range r.localeRedirects {
+		if r.matchRoute(route, req, match) {
+			return true
+		}
+	}
+
 	// Closest match for a router (includes sub-routers)
 	if r.NotFoundHandler != nil {
 		match.Handler = r.NotFoundHandler
+		match.chain = nil
 		match.MatchErr = ErrNotFound
 		return true
 	}
@@ -184,6 +330,29 @@ func (r *Router) Match(req *http.Request, match *RouteMatch) bool {
 	return false
 }
 
+// matchRoute matches a route of the router and wraps the handler of a match
+// with the router's middlewares. Handlers taken from a composed chain are
+// wrapped once and reused by later requests.
+func (r *Router) matchRoute(route *Route, req *http.Request, match *RouteMatch) bool {
+	if !route.Match(req, match) {
+		return false
+	}
+	// Build middleware chain if no error was found
+	if match.MatchErr == nil {
+		if match.chain != nil {
+			match.chain = match.chain.wrap(r)
+			match.Handler = match.chain.handler
+			return true
+		}
+		for i := len(r.middlewares) - 1; i >= 0; i-- {
+			if mw := r.middlewares[i]; appliesTo(mw, match.Route) {
+				match.Handler = applyMiddleware(match.trace, mw, match.Handler)
+			}
+		}
+	}
+	return true
+}
+
 // ServeHTTP dispatches the handler registered in the matched route.
 //
 // When there is a match, the route variables can be retrieved calling
@@ -194,43 +363,110 @@ func (r *Router) ServeHTTP(ctx context.Context, w http.ResponseWriter, req *http
 		if r.useEncodedPath {
 			path = req.URL.EscapedPath()
 		}
+		clean := cleanPath
+		if r.pathCleaner != nil {
+			clean = r.pathCleaner
+		}
 		// Clean path to canonical form and redirect.
-		if p := cleanPath(path); p != path {
+		if p := clean(path); p != path {
 			w.Header().Set("Location", replaceURLPath(req.URL, p))
 			w.WriteHeader(http.StatusMovedPermanently)
 			return nil
 		}
 	}
-	var match RouteMatch
+	if r.tenants != nil {
+		if err := r.tenants.Err(); err != nil {
+			return err
+		}
+		if tenant, ok := r.tenants.Resolve(req); ok {
+			resolved := resolvedTenant{id: tenant, label: r.tenantLabel(tenant)}
+			ctx = withRouterValue(ctx, tenantKey, resolved)
+			req = req.WithContext(withRouterValue(req.Context(), tenantKey, resolved))
+		}
+	}
+	state := getMatchState()
+	defer putMatchState(state)
+	match := &state.match
 	var handler Handler
-	if r.Match(req, &match) {
+	var start time.Time
+	if match.trace = r.startSample(req); match.trace != nil {
+		start = time.Now()
+	}
+	if r.matchTracing != nil {
+		match.matchTrace = r.startMatchTrace(req)
+	}
+	if r.Match(req, match) {
 		handler = match.Handler
 		if handler != nil {
 			// Populate context for custom handlers
-			if r.omitRouteFromContext {
-				// Only populate the match vars (if any) into the context.
-				req = requestWithVars(req, match.Vars)
-			} else {
-				req = requestWithRouteAndVars(req, match.Route, match.Vars)
-			}
-
-			if !r.omitRouterFromContext {
-				req = requestWithRouter(req, r)
-			}
+			req = r.requestWithMatch(req, match)
 		}
 	}
+	if match.matchTrace != nil {
+		r.finishMatchTrace(match)
+	}
 
-	if handler == nil && match.MatchErr == ErrMethodMismatch {
-		handler = methodNotAllowedHandler()
+	if match.MatchErr == ErrMethodMismatch {
+		if preflight := r.corsPreflightHandler(req); preflight != nil {
+			handler = preflight
+		} else {
+			if methods, ok := r.allowedMethods(match.mismatched, req); ok {
+				w.Header().Set("Allow", strings.Join(methods, ", "))
+			}
+			if handler == nil {
+				handler = methodNotAllowedHandler()
+			}
+		}
 	}
 
 	if handler == nil {
 		handler = NotFoundHandler()
 	}
 
+	if m := r.maintenance.Load(); m != nil && !m.allows(match) {
+		handler = m.handler
+		// The matched route does not serve the request: hooks, statistics
+		// and samples must not record it under the route.
+		match.Route, match.maintenance = nil, true
+	}
+
+	if match.trace != nil {
+		return r.serveSampled(ctx, w, req, binder, handler, match, start)
+	}
+
+	if r.instrumented() {
+		return r.serveInstrumented(ctx, w, req, binder, handler, match)
+	}
+
 	return handler.ServeHTTP(ctx, w, req, binder)
 }
 
+// Metadata sets metadata inherited by the routes created afterwards on the
+// router and on its subrouters created afterwards, so that attributes shared
+// by routes, such as the owning team, are set once:
+//
+//	admin := r.PathPrefix("/admin").Subrouter().Metadata("scope", "admin")
+//	admin.HandleFunc("/users", listUsers)                    // scope "admin"
+//	admin.HandleFunc("/status", status).Metadata("scope", "") // scope ""
+//
+// Metadata set on a route overrides inherited values. Subrouters also inherit
+// the metadata of their route, see Route.Subrouter.
+func (r *Router) Metadata(key any, value any) *Router {
+	// Routes created before share the current map.
+	m := maps.Clone(r.routerMetadata)
+	if m == nil {
+		m = make(map[any]any)
+	}
+	m[key] = value
+	r.routerMetadata = m
+	return r
+}
+
+// GetMetadata returns the metadata inherited by new routes of the router.
+func (r *Router) GetMetadata() map[any]any {
+	return r.routerMetadata
+}
+
 // Get returns a route registered with the given name.
 func (r *Router) Get(name string) *Route {
 	return r.namedRoutes[name]
@@ -279,6 +515,51 @@ func (r *Router) SkipClean(value bool) *Router {
 	return r
 }
 
+// SetPathCleaner sets the function returning the canonical form of request
+// paths; requests for other paths are redirected to the canonical path. A nil
+// function restores the default, which eliminates . and .. elements and
+// repeated slashes. Path cleaning is disabled by SkipClean.
+func (r *Router) SetPathCleaner(fn func(path string) string) *Router {
+	r.pathCleaner = fn
+	return r
+}
+
+// LazyCompile defines whether new routes compile their regular expressions
+// on the first match attempt instead of when they are registered. The
+// initial value is false. This cuts the startup time of routers with many
+// routes of which only some are requested.
+//
+// Invalid patterns are then reported by Compile, or logged when the route is
+// first matched, and such routes never match.
+func (r *Router) LazyCompile(value bool) *Router {
+	r.lazyCompile = value
+	return r
+}
+
+// Compile compiles the regular expressions of all routes, including the
+// routes of subrouters, and returns the errors of invalid routes. It also
+// groups the routes by template for computing the methods allowed for a
+// request, which is otherwise done on the first 405 Method Not Allowed
+// response. It is typically called at startup when LazyCompile or Strict is
+// set.
+func (r *Router) Compile() error {
+	r.loadAllowedMethods()
+	var errs []error
+	_ = r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		if err := route.Compile(); err != nil {
+			errs = append(errs, err)
+		}
+		return nil
+	})
+	if r.tenants != nil {
+		errs = append(errs, r.tenants.Err())
+	}
+	if r.strict {
+		errs = append(errs, r.conflicts()...)
+	}
+	return errors.Join(errs...)
+}
+
 // OmitRouteFromContext defines the behavior of omitting the Route from the
 //
 //	http.Request context.
@@ -315,12 +596,36 @@ func (r *Router) UseEncodedPath() *Router {
 
 // NewRoute registers an empty route.
 func (r *Router) NewRoute() *Route {
+	route := r.newRoute()
+	r.addRoutes(route)
+	return route
+}
+
+// newRoute returns an empty route of the router, not registered yet.
+func (r *Router) newRoute() *Route {
 	// initialize a route with a copy of the parent router's configuration
+	r.generations()
 	route := &Route{routeConf: copyRouteConf(r.routeConf), namedRoutes: r.namedRoutes}
-	r.routes = append(r.routes, route)
+	if r.routerMetadata != nil {
+		route.metadata = maps.Clone(r.routerMetadata)
+	}
 	return route
 }
 
+// Update registers routes while the router serves requests. fn registers the
+// routes on a staging router configured like r, and they are added to r at
+// once when fn returns. Routes are thus never matched while being configured.
+//
+// Registering routes directly on r is safe too, as matching reads an
+// immutable snapshot of the routes, but a route is matched as soon as it is
+// created. Named routes must not be looked up with Get concurrently.
+func (r *Router) Update(fn func(staging *Router)) {
+	r.generations()
+	staging := &Router{routeConf: copyRouteConf(r.routeConf), namedRoutes: r.namedRoutes}
+	fn(staging)
+	r.addRoutes(staging.getRoutes()...)
+}
+
 // Name registers a new route with a name.
 // See Route.Name().
 func (r *Router) Name(name string) *Route {
@@ -394,8 +699,9 @@ func (r *Router) BuildVarsFunc(f BuildVarsFunc) *Route {
 }
 
 // Walk walks the router and all its sub-routers, calling walkFn for each route
-// in the tree. The routes are walked in the order they were added. Sub-routers
-// are explored depth-first.
+// in the tree. The routes are walked in the order they were added, or in
+// specificity order if enabled with EnableSpecificityOrder. Sub-routers are
+// explored depth-first.
 func (r *Router) Walk(walkFn WalkFunc) error {
 	return r.walk(walkFn, []*Route{})
 }
@@ -410,7 +716,7 @@ var SkipRouter = errors.New("skip this router")
 type WalkFunc func(route *Route, router *Router, ancestors []*Route) error
 
 func (r *Router) walk(walkFn WalkFunc, ancestors []*Route) error {
-	for _, t := range r.routes {
+	for _, t := range r.matchOrder(r.table.Load()) {
 		err := walkFn(t, r, ancestors)
 		if err == SkipRouter {
 			continue
@@ -445,15 +751,76 @@ func (r *Router) walk(walkFn WalkFunc, ancestors []*Route) error {
 // ----------------------------------------------------------------------------
 
 // RouteMatch stores information about a matched route.
+//
+// Router.ServeHTTP reuses its RouteMatch for later requests. Matchers and
+// match hooks must not retain the *RouteMatch they are given after they
+// return; a copy of the struct remains valid.
 type RouteMatch struct {
 	Route   *Route
 	Handler Handler
-	Vars    map[string]string
+	// Vars holds the route variables. Router.ServeHTTP only sets it for
+	// match hooks, handlers use mux.Vars or mux.Var.
+	Vars map[string]string
 
 	// MatchErr is set to appropriate matching error
 	// It is set to ErrMethodMismatch if there is a mismatch in
 	// the request method and route method
 	MatchErr error
+
+	// mismatched is the first route rejecting the request method only.
+	mismatched *Route
+
+	// maintenance is true if the request is diverted to the maintenance
+	// handler, see Router.SetMaintenance.
+	maintenance bool
+
+	// matchTrace collects the rejected routes if the request is traced.
+	matchTrace *MatchTrace
+
+	// trace collects layer timings if the request is sampled.
+	trace *sampleTrace
+
+	// chain is the composed handler chain Handler was taken from, if any.
+	chain *middlewareChain
+
+	// If lazyVars is true, the variables are collected in vars instead of
+	// Vars.
+	lazyVars bool
+	vars     []routeVar
+
+	// host memoizes the request host for host matchers.
+	host matchHost
+}
+
+// routeVar is a route variable collected by a lazy match.
+type routeVar struct {
+	name, value string
+}
+
+// setVars stores the variables of a regexp match of input. Variables of
+// optional groups which did not participate in the match are skipped.
+func (m *RouteMatch) setVars(input string, matches []int, names []string) {
+	if !m.lazyVars && m.Vars == nil && len(names) > 0 {
+		m.Vars = make(map[string]string, len(names))
+	}
+	for i, name := range names {
+		if matches[2*i+2] < 0 {
+			continue
+		}
+		m.setVar(name, input[matches[2*i+2]:matches[2*i+3]])
+	}
+}
+
+// setVar stores a variable.
+func (m *RouteMatch) setVar(name, value string) {
+	if m.lazyVars {
+		m.vars = append(m.vars, routeVar{name: name, value: value})
+		return
+	}
+	if m.Vars == nil {
+		m.Vars = make(map[string]string, 1)
+	}
+	m.Vars[name] = value
 }
 
 type contextKey int
@@ -462,16 +829,42 @@ const (
 	varsKey contextKey = iota
 	routeKey
 	routerKey
+	sessionKey
+	auditKey
+	traceKey
+	tenantKey
+	localeKey
+	tenantLabelKey
 )
 
 // Vars returns the route variables for the current request, if any.
 func Vars(r *http.Request) map[string]string {
-	if rv := r.Context().Value(varsKey); rv != nil {
-		return rv.(map[string]string)
+	switch rv := r.Context().Value(varsKey).(type) {
+	case map[string]string:
+		return rv
+	case *matchContext:
+		return rv.varsMap()
 	}
 	return nil
 }
 
+// Var returns the route variable name for the current request, or "" if
+// there is no such variable. Unlike Vars it does not build a map.
+func Var(r *http.Request, name string) string {
+	switch rv := r.Context().Value(varsKey).(type) {
+	case map[string]string:
+		return rv[name]
+	case *matchContext:
+		// Later variables override earlier ones like in Vars.
+		for i := len(rv.routeVars) - 1; i >= 0; i-- {
+			if rv.routeVars[i].name == name {
+				return rv.routeVars[i].value
+			}
+		}
+	}
+	return ""
+}
+
 // CurrentRoute returns the matched route for the current request, if any.
 // This only works when called inside the handler of the matched route
 // because the matched route is stored in the request context which is cleared
@@ -500,21 +893,179 @@ func requestWithVars(r *http.Request, vars map[string]string) *http.Request {
 	return r.WithContext(ctx)
 }
 
-// requestWithRouteAndVars adds the matched route and vars to the request ctx.
-// It saves extra allocations in cloning the request once and skipping the
-//
-//	population of empty vars, which in turn mux.Vars can handle gracefully.
-func requestWithRouteAndVars(r *http.Request, route *Route, vars map[string]string) *http.Request {
-	ctx := context.WithValue(r.Context(), routeKey, route)
-	if len(vars) > 0 {
-		ctx = context.WithValue(ctx, varsKey, vars)
+// matchState is the matching state of Router.ServeHTTP. It is pooled, see
+// RouteMatch.
+type matchState struct {
+	match RouteMatch
+	vars  [4]routeVar
+}
+
+var matchStatePool = sync.Pool{
+	New: func() any { return new(matchState) },
+}
+
+func getMatchState() *matchState {
+	s := matchStatePool.Get().(*matchState)
+	s.match.vars = s.vars[:0]
+	s.match.lazyVars = true
+	return s
+}
+
+func putMatchState(s *matchState) {
+	s.match = RouteMatch{}
+	s.vars = [4]routeVar{}
+	matchStatePool.Put(s)
+}
+
+// requestWithMatch adds the matched route, router and variables to the
+// request context as configured. The context set by an enclosing router is
+// replaced, keeping the values not set by this match.
+func (r *Router) requestWithMatch(req *http.Request, match *RouteMatch) *http.Request {
+	var route *Route
+	var router *Router
+	if !r.omitRouteFromContext {
+		route = match.Route
 	}
-	return r.WithContext(ctx)
+	if !r.omitRouterFromContext {
+		router = r
+	}
+	if route == nil && router == nil && len(match.vars) == 0 {
+		return req
+	}
+
+	c := newMatchContext(req.Context())
+	if route != nil {
+		c.route = route
+	}
+	if router != nil {
+		c.router = router
+	}
+	if len(match.vars) > 0 {
+		c.routeVars = append(c.buf[:0], match.vars...)
+		c.vars.Store(nil)
+	}
+	return req.WithContext(c)
 }
 
-func requestWithRouter(r *http.Request, router *Router) *http.Request {
-	ctx := context.WithValue(r.Context(), routerKey, router)
-	return r.WithContext(ctx)
+// matchContext is the request context set by Router.ServeHTTP and the
+// middlewares of this package. It holds the router-owned values of a request
+// in a single context value, instead of a chain of context.WithValue layers.
+type matchContext struct {
+	context.Context
+	// route and router are nil if omitted from the context.
+	route     *Route
+	router    *Router
+	routeVars []routeVar
+	buf       [4]routeVar
+	vars      atomic.Pointer[map[string]string]
+
+	// Values set by middlewares, see withRouterValue.
+	session *SessionData
+	audit   *auditRecord
+	trace   *TraceContext
+	tenant  resolvedTenant
+	locale  string
+}
+
+// newMatchContext returns a matchContext for ctx. If ctx is a matchContext, the
+// new one replaces it and starts with a copy of its values, so that the
+// values stay in a single context layer.
+func newMatchContext(ctx context.Context) *matchContext {
+	parent, ok := ctx.(*matchContext)
+	if !ok {
+		return &matchContext{Context: ctx}
+	}
+	c := &matchContext{
+		Context: parent.Context,
+		route:   parent.route,
+		router:  parent.router,
+		session: parent.session,
+		audit:   parent.audit,
+		trace:   parent.trace,
+		tenant:  parent.tenant,
+		locale:  parent.locale,
+	}
+	c.routeVars = append(c.buf[:0], parent.routeVars...)
+	c.vars.Store(parent.vars.Load())
+	return c
+}
+
+// withRouterValue returns a copy of ctx holding the value of a middleware of
+// this package, like context.WithValue.
+func withRouterValue(ctx context.Context, key contextKey, value any) context.Context {
+	c := newMatchContext(ctx)
+	switch key {
+	case sessionKey:
+		c.session = value.(*SessionData)
+	case auditKey:
+		c.audit = value.(*auditRecord)
+	case traceKey:
+		c.trace = value.(*TraceContext)
+	case tenantKey:
+		c.tenant = value.(resolvedTenant)
+	case localeKey:
+		c.locale = value.(string)
+	default:
+		panic(fmt.Sprintf("mux: context key %d is not a router value", key))
+	}
+	return c
+}
+
+func (c *matchContext) Value(key any) any {
+	switch key {
+	case varsKey:
+		if len(c.routeVars) > 0 {
+			return c
+		}
+	case routeKey:
+		if c.route != nil {
+			return c.route
+		}
+	case routerKey:
+		if c.router != nil {
+			return c.router
+		}
+	case sessionKey:
+		if c.session != nil {
+			return c.session
+		}
+	case auditKey:
+		if c.audit != nil {
+			return c.audit
+		}
+	case traceKey:
+		if c.trace != nil {
+			return *c.trace
+		}
+	case tenantKey:
+		if c.tenant.id != "" {
+			return c.tenant.id
+		}
+	case tenantLabelKey:
+		if c.tenant.label != "" {
+			return c.tenant.label
+		}
+	case localeKey:
+		if c.locale != "" {
+			return c.locale
+		}
+	}
+	return c.Context.Value(key)
+}
+
+// varsMap builds the map returned by Vars once.
+func (c *matchContext) varsMap() map[string]string {
+	if vars := c.vars.Load(); vars != nil {
+		return *vars
+	}
+	vars := make(map[string]string, len(c.routeVars))
+	for _, v := range c.routeVars {
+		vars[v.name] = v.value
+	}
+	if !c.vars.CompareAndSwap(nil, &vars) {
+		return *c.vars.Load()
+	}
+	return vars
 }
 
 // ----------------------------------------------------------------------------
@@ -527,6 +1078,10 @@ func cleanPath(p string) string {
 	if p == "" {
 		return "/"
 	}
+	if isCleanPath(p) {
+		// Most paths are canonical, return them without allocating.
+		return p
+	}
 	if p[0] != '/' {
 		p = "/" + p
 	}
@@ -540,6 +1095,30 @@ func cleanPath(p string) string {
 	return np
 }
 
+// isCleanPath reports whether cleanPath returns p unchanged: p is rooted and
+// has no empty, . or .. elements except for a trailing slash.
+func isCleanPath(p string) bool {
+	if p == "" || p[0] != '/' {
+		return false
+	}
+	start := 1
+	for i := 1; i <= len(p); i++ {
+		if i < len(p) && p[i] != '/' {
+			continue
+		}
+		switch p[start:i] {
+		case "":
+			if i < len(p) {
+				return false
+			}
+		case ".", "..":
+			return false
+		}
+		start = i + 1
+	}
+	return true
+}
+
 // replaceURLPath prints an url.URL with a different path.
 func replaceURLPath(u *url.URL, p string) string {
 	// Operate on a copy of the request url.
@@ -613,61 +1192,6 @@ func matchInArray(arr []string, value string) bool {
 	return false
 }
 
-// matchMapWithString returns true if the given key/value pairs exist in a given map.
-func matchMapWithString(toCheck map[string]string, toMatch map[string][]string, canonicalKey bool) bool {
-	for k, v := range toCheck {
-		// Check if key exists.
-		if canonicalKey {
-			k = http.CanonicalHeaderKey(k)
-		}
-		if values := toMatch[k]; values == nil {
-			return false
-		} else if v != "" {
-			// If value was defined as an empty string we only check that the
-			// key exists. Otherwise we also check for equality.
-			valueExists := false
-			for _, value := range values {
-				if v == value {
-					valueExists = true
-					break
-				}
-			}
-			if !valueExists {
-				return false
-			}
-		}
-	}
-	return true
-}
-
-// matchMapWithRegex returns true if the given key/value pairs exist in a given map compiled against
-// the given regex
-func matchMapWithRegex(toCheck map[string]*regexp.Regexp, toMatch map[string][]string, canonicalKey bool) bool {
-	for k, v := range toCheck {
-		// Check if key exists.
-		if canonicalKey {
-			k = http.CanonicalHeaderKey(k)
-		}
-		if values := toMatch[k]; values == nil {
-			return false
-		} else if v != nil {
-			// If value was defined as an empty string we only check that the
-			// key exists. Otherwise we also check for equality.
-			valueExists := false
-			for _, value := range values {
-				if v.MatchString(value) {
-					valueExists = true
-					break
-				}
-			}
-			if !valueExists {
-				return false
-			}
-		}
-	}
-	return true
-}
-
 // methodNotAllowed replies to the request with an HTTP status code 405.
 func methodNotAllowed(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
 	w.WriteHeader(http.StatusMethodNotAllowed)
@@ -30,7 +30,7 @@ func (r *Route) GoString() string {
 }
 
 func (r *routeRegexp) GoString() string {
-	return fmt.Sprintf("&routeRegexp{template: %q, regexpType: %v, options: %v, regexp: regexp.MustCompile(%q), reverse: %q, varsN: %v, varsR: %v", r.template, r.regexpType, r.options, r.regexp.String(), r.reverse, r.varsN, r.varsR)
+	return fmt.Sprintf("&routeRegexp{template: %q, regexpType: %v, options: %v, regexp: regexp.MustCompile(%q), reverse: %q, varsN: %v, varsR: %v", r.template, r.regexpType, r.options, r.pattern, r.reverse, r.varsN, r.compiled.varsR)
 }
 
 type routeTest struct {
@@ -1879,6 +1879,8 @@ func testRoute(t *testing.T, test routeTest) {
 		uri.Scheme = "http"
 	}
 
+	testRouteIndexed(t, test)
+
 	var match RouteMatch
 	ok := route.Match(request, &match)
 	if ok != shouldMatch {
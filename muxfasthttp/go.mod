@@ -0,0 +1,20 @@
+module github.com/gorilla/mux/muxfasthttp
+
+go 1.21
+
+require (
+	github.com/gorilla/mux v0.0.0-20261016075547-52871cae1f3a
+	github.com/valyala/fasthttp v1.51.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.0.5 // indirect
+	github.com/klauspost/compress v1.17.0 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	golang.org/x/net v0.34.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
+
+// Development in the repository uses the router next to the module. The
+// required version is the one used by consumers, which ignore the replace.
+replace github.com/gorilla/mux => ../
@@ -0,0 +1,105 @@
+// Package muxfasthttp serves mux routers on fasthttp.
+//
+// Each fasthttp request is converted to a net/http request, and the response
+// written by the router is copied into the fasthttp response, so routes,
+// middlewares and handlers are used unchanged:
+//
+//	r := mux.NewRouter()
+//	r.HandleFunc("/users/{id}", getUser)
+//
+//	err := fasthttp.ListenAndServe(":8080", muxfasthttp.Handler(r))
+//
+// Responses are buffered by fasthttp: http.Flusher and http.Hijacker are not
+// supported.
+package muxfasthttp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// Option configures Handler.
+type Option func(*config)
+
+type config struct {
+	binder       mux.Binder
+	errorHandler mux.ServerErrorHandler
+}
+
+// WithBinder sets the binder passed to the router.
+func WithBinder(binder mux.Binder) Option {
+	return func(c *config) {
+		c.binder = binder
+	}
+}
+
+// WithErrorHandler sets the handler of the errors returned by the router. By
+// default a response with the status of mux.ErrorStatus is sent unless the
+// handler already sent a status.
+func WithErrorHandler(handler mux.ServerErrorHandler) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
+
+// Handler returns a fasthttp.RequestHandler serving router.
+func Handler(router *mux.Router, opts ...Option) fasthttp.RequestHandler {
+	c := &config{errorHandler: defaultErrorHandler}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return func(ctx *fasthttp.RequestCtx) {
+		var req http.Request
+		if err := fasthttpadaptor.ConvertRequest(ctx, &req, true); err != nil {
+			ctx.Error("Bad Request", fasthttp.StatusBadRequest)
+			return
+		}
+		r := req.WithContext(ctx)
+		w := &responseWriter{ctx: ctx, header: make(http.Header)}
+		if err := router.ServeHTTP(ctx, w, r, c.binder); err != nil {
+			c.errorHandler(ctx, w, r, err, w.wroteHeader)
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func defaultErrorHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, err error, wroteHeader bool) {
+	if !wroteHeader {
+		status := mux.ErrorStatus(err)
+		http.Error(w, http.StatusText(status), status)
+	}
+}
+
+// responseWriter is a http.ResponseWriter writing to the response of a
+// fasthttp request.
+type responseWriter struct {
+	ctx         *fasthttp.RequestCtx
+	header      http.Header
+	wroteHeader bool
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.ctx.SetStatusCode(code)
+	for name, values := range w.header {
+		for _, value := range values {
+			w.ctx.Response.Header.Add(name, value)
+		}
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.ctx.Write(p)
+}
@@ -0,0 +1,52 @@
+package muxfasthttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/valyala/fasthttp"
+)
+
+func serve(h fasthttp.RequestHandler, method, uri string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(uri)
+	h(ctx)
+	return ctx
+}
+
+func TestHandler(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/users/{id}", func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder mux.Binder) error {
+		w.Header().Set("X-Id", mux.Var(req, "id"))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "user %s", mux.Var(req, "id"))
+		return nil
+	}).Methods(http.MethodPost)
+	r.HandleFunc("/fail", func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder mux.Binder) error {
+		return errors.New("failed")
+	})
+	h := Handler(r)
+
+	ctx := serve(h, http.MethodPost, "http://localhost/users/42")
+	if ctx.Response.StatusCode() != http.StatusCreated || string(ctx.Response.Body()) != "user 42" {
+		t.Errorf("unexpected response %d %q", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	if got := string(ctx.Response.Header.Peek("X-Id")); got != "42" {
+		t.Errorf("expected header X-Id 42, got %q", got)
+	}
+
+	if ctx := serve(h, http.MethodGet, "http://localhost/users/42"); ctx.Response.StatusCode() != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", ctx.Response.StatusCode())
+	}
+	if ctx := serve(h, http.MethodGet, "http://localhost/missing"); ctx.Response.StatusCode() != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", ctx.Response.StatusCode())
+	}
+	if ctx := serve(h, http.MethodGet, "http://localhost/fail"); ctx.Response.StatusCode() != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", ctx.Response.StatusCode())
+	}
+}
@@ -0,0 +1,83 @@
+// Package muxhttp3 serves mux routers over HTTP/3 with quic-go, next to
+// HTTP/1.1 and HTTP/2:
+//
+//	err := muxhttp3.Serve(ctx, ":443", r, tlsConfig)
+//
+// Responses sent over HTTP/1.1 and HTTP/2 advertise the HTTP/3 endpoint in
+// the Alt-Svc header, so browsers switch to it for later requests.
+package muxhttp3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// Serve serves router over HTTPS on the TCP address addr with mux.Serve, and
+// over HTTP/3 on the UDP address addr, until ctx is cancelled or one of the
+// shutdown signals is received. An empty addr means ":https". Only
+// "host:port" addresses are supported; with port 0 the UDP port is chosen
+// independently of the TCP port and advertised in the Alt-Svc header.
+//
+// The options of mux.Serve apply, and the binder and error handler options
+// to HTTP/3 requests as well. The HTTP/3 server is closed once mux.Serve
+// returns, without waiting for its requests in flight.
+func Serve(ctx context.Context, addr string, router *mux.Router, tlsConfig *tls.Config, opts ...mux.ServerOption) error {
+	if addr == "" {
+		addr = ":https"
+	}
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	h3 := &http3.Server{
+		TLSConfig: http3.ConfigureTLSConfig(tlsConfig),
+		Port:      port,
+	}
+	errc := make(chan error, 1)
+	opts = append(opts, mux.WithTLS(tlsConfig), mux.WithHandlerWrapper(func(next http.Handler) http.Handler {
+		h3.Handler = next
+		go func() {
+			errc <- h3.Serve(conn)
+		}()
+		return advertise(port, next)
+	}))
+
+	errs := []error{mux.Serve(ctx, addr, router, opts...)}
+	if h3.Handler != nil {
+		errs = append(errs, h3.Close())
+		if err := <-errc; !isClosed(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// advertise returns next setting the Alt-Svc header of the HTTP/3 server on
+// the UDP port on responses to HTTP/1.1 and HTTP/2 requests. Unlike
+// http3.Server.SetQUICHeaders, it does not depend on the HTTP/3 server having
+// started serving, so the first responses advertise it too.
+func advertise(port int, next http.Handler) http.Handler {
+	altSvc := fmt.Sprintf(`h3=":%d"; ma=2592000`, port)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor < 3 {
+			w.Header().Add("Alt-Svc", altSvc)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isClosed reports whether err is the result of closing the HTTP/3 server.
+func isClosed(err error) bool {
+	return err == nil || errors.Is(err, http.ErrServerClosed) || errors.Is(err, quic.ErrServerClosed) || errors.Is(err, net.ErrClosed)
+}
@@ -0,0 +1,49 @@
+package muxhttp3
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestServeAdvertisesHTTP3(t *testing.T) {
+	// Borrow the test certificate and a client trusting it from httptest.
+	ts := httptest.NewTLSServer(nil)
+	cert := ts.TLS.Certificates[0]
+	client := ts.Client()
+	ts.Close()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	addrc := make(chan net.Addr, 1)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Serve(ctx, "127.0.0.1:0", router, &tls.Config{Certificates: []tls.Certificate{cert}},
+			mux.WithOnListen(func(addr net.Addr) { addrc <- addr }))
+	}()
+	addr := <-addrc
+
+	resp, err := client.Get("https://" + addr.String() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if altSvc := resp.Header.Get("Alt-Svc"); !strings.HasPrefix(altSvc, `h3=":`) {
+		t.Errorf("expected an HTTP/3 Alt-Svc header, got %q", altSvc)
+	}
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("expected a clean shutdown, got %v", err)
+	}
+}
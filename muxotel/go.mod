@@ -0,0 +1,24 @@
+module github.com/gorilla/mux/muxotel
+
+go 1.21
+
+require (
+	github.com/gorilla/mux v0.0.0-20261016075547-52871cae1f3a
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/metric v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/sdk/metric v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	golang.org/x/net v0.34.0 // indirect
+	golang.org/x/sys v0.29.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
+
+// Development in the repository uses the router next to the module. The
+// required version is the one used by consumers, which ignore the replace.
+replace github.com/gorilla/mux => ../
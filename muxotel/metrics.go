@@ -0,0 +1,126 @@
+package muxotel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// durationBuckets are the explicit bucket boundaries recommended by the HTTP
+// semantic conventions for http.server.request.duration.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+
+// TenantKey is the attribute holding the tenant of the request, see
+// mux.Router.Tenants, recorded by MetricsMiddleware. To bound the
+// cardinality of the metrics, it holds the tenant only if it is known to the
+// router and mux.UnknownTenant otherwise, see mux.TenantLabel.
+const TenantKey = attribute.Key("tenant.id")
+
+// WithMeterProvider sets the provider used to create meters. The global
+// provider is used by default.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// MetricsMiddleware returns a middleware recording the OpenTelemetry HTTP
+// server metrics for every matched request:
+//
+//   - http.server.request.duration
+//   - http.server.active_requests
+//   - http.server.request.body.size
+//   - http.server.response.body.size
+//
+// The metrics carry the method, the scheme and the tenant of the request, if
+// any, as tenant.id. Methods not defined by net/http are recorded as
+// "_OTHER". All but http.server.active_requests, for which the semantic
+// conventions do not define it, also carry the path template of the matched
+// route as http.route, so services exporting via OTLP get per-route metrics.
+// Like Middleware it has to be registered with Router.Use.
+func MetricsMiddleware(opts ...Option) (mux.MiddlewareFunc, error) {
+	cfg := newConfig(opts)
+	meter := cfg.meterProvider.Meter(ScopeName)
+
+	duration, err := meter.Float64Histogram("http.server.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of HTTP server requests."),
+		metric.WithExplicitBucketBoundaries(durationBuckets...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("muxotel: creating duration histogram: %w", err)
+	}
+
+	active, err := meter.Int64UpDownCounter("http.server.active_requests",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Number of active HTTP server requests."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("muxotel: creating active requests counter: %w", err)
+	}
+
+	requestSize, err := meter.Int64Histogram("http.server.request.body.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of HTTP server request bodies."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("muxotel: creating request size histogram: %w", err)
+	}
+
+	responseSize, err := meter.Int64Histogram("http.server.response.body.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of HTTP server response bodies."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("muxotel: creating response size histogram: %w", err)
+	}
+
+	return func(next mux.HandlerFunc) mux.HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+			if cfg.filter != nil && !cfg.filter(r) {
+				return next(ctx, w, r, binder)
+			}
+
+			base := []attribute.KeyValue{
+				methodAttribute(r.Method),
+				semconv.URLScheme(scheme(r)),
+			}
+			if tenant := mux.TenantLabel(r.Context()); tenant != "" {
+				base = append(base, TenantKey.String(tenant))
+			}
+
+			activeAttrs := metric.WithAttributes(base...)
+			active.Add(ctx, 1, activeAttrs)
+			defer active.Add(ctx, -1, activeAttrs)
+
+			rw := mux.NewResponseRecorderWriter(w)
+			start := time.Now()
+			err := next(ctx, rw, r, binder)
+			elapsed := time.Since(start).Seconds()
+
+			attrs := append(slices.Clip(base), semconv.HTTPResponseStatusCode(rw.StatusOrDefault(err)))
+			if route := routeTemplate(r); route != "" {
+				attrs = append(attrs, semconv.HTTPRoute(route))
+			}
+			if err != nil {
+				attrs = append(attrs, semconv.ErrorTypeKey.String(fmt.Sprintf("%T", err)))
+			}
+			set := metric.WithAttributes(attrs...)
+
+			duration.Record(ctx, elapsed, set)
+			if r.ContentLength > 0 {
+				requestSize.Record(ctx, r.ContentLength, set)
+			}
+			responseSize.Record(ctx, rw.BytesWritten(), set)
+
+			return err
+		}
+	}, nil
+}
@@ -0,0 +1,127 @@
+package muxotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	metrics, err := MetricsMiddleware(WithMeterProvider(provider))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := mux.NewRouter()
+	router.Use(metrics)
+	router.HandleFunc("/users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		_, err := w.Write([]byte("hello"))
+		return err
+	})
+
+	for _, path := range []string{"/users/1", "/users/2"} {
+		req := httptest.NewRequest("PROPFIND", path, strings.NewReader("body"))
+		if err := router.ServeHTTP(context.Background(), httptest.NewRecorder(), req, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]bool{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			found[m.Name] = true
+			if m.Name == "http.server.active_requests" {
+				sum, ok := m.Data.(metricdata.Sum[int64])
+				if !ok || len(sum.DataPoints) != 1 {
+					t.Fatalf("expected a single active requests series, got %+v", m.Data)
+				}
+				if _, ok := sum.DataPoints[0].Attributes.Value(semconv.HTTPRouteKey); ok {
+					t.Errorf("expected no route attribute on active requests, got %v", sum.DataPoints[0].Attributes)
+				}
+			}
+			if m.Name != "http.server.request.duration" {
+				continue
+			}
+			hist, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok || len(hist.DataPoints) != 1 {
+				t.Fatalf("expected a single duration series, got %+v", m.Data)
+			}
+			dp := hist.DataPoints[0]
+			if dp.Count != 2 {
+				t.Errorf("expected 2 recorded requests, got %d", dp.Count)
+			}
+			if route, ok := dp.Attributes.Value(semconv.HTTPRouteKey); !ok || route.AsString() != "/users/{id}" {
+				t.Errorf("expected route attribute, got %v", dp.Attributes)
+			}
+			if method, ok := dp.Attributes.Value(semconv.HTTPRequestMethodKey); !ok || method.AsString() != "_OTHER" {
+				t.Errorf("expected method attribute _OTHER, got %v", dp.Attributes)
+			}
+		}
+	}
+
+	for _, name := range []string{
+		"http.server.request.duration",
+		"http.server.active_requests",
+		"http.server.request.body.size",
+		"http.server.response.body.size",
+	} {
+		if !found[name] {
+			t.Errorf("metric %s was not recorded", name)
+		}
+	}
+}
+
+func TestMetricsMiddlewareTenant(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	metrics, err := MetricsMiddleware(WithMeterProvider(provider))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := mux.NewRouter().Tenants(mux.NewTenantResolver().Host("{tenant}.example.com", ""))
+	router.Use(metrics)
+	router.HandleFunc("/", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		return nil
+	})
+	router.TenantOverlay("acme")
+
+	req := httptest.NewRequest("GET", "http://acme.example.com/", nil)
+	if err := router.ServeHTTP(context.Background(), httptest.NewRecorder(), req, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "http.server.request.duration" {
+				continue
+			}
+			dp := m.Data.(metricdata.Histogram[float64]).DataPoints[0]
+			if tenant, ok := dp.Attributes.Value(TenantKey); !ok || tenant.AsString() != "acme" {
+				t.Errorf("expected tenant attribute, got %v", dp.Attributes)
+			}
+			return
+		}
+	}
+	t.Error("duration was not recorded")
+}
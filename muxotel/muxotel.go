@@ -0,0 +1,236 @@
+// Package muxotel provides OpenTelemetry instrumentation for mux routers.
+//
+// The tracing middleware starts a server span for every matched request,
+// named after the request method and the path template of the matched route.
+// The metrics middleware records the HTTP server metrics keyed on the same
+// route template:
+//
+//	metrics, err := muxotel.MetricsMiddleware()
+//	if err != nil {
+//	    return err
+//	}
+//
+//	r := mux.NewRouter()
+//	r.Use(muxotel.Middleware("my-service"), metrics)
+package muxotel
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name used for tracers and meters.
+const ScopeName = "github.com/gorilla/mux/muxotel"
+
+// config holds the settings applied through Options.
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagators    propagation.TextMapPropagator
+	filter         func(*http.Request) bool
+}
+
+// Option configures the instrumentation.
+type Option func(*config)
+
+// WithTracerProvider sets the provider used to create tracers. The global
+// provider is used by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithPropagators sets the propagators used to extract the parent span
+// context from incoming headers. The global propagators are used by default.
+func WithPropagators(propagators propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagators = propagators
+	}
+}
+
+// WithFilter sets a function deciding whether a request is instrumented.
+// Requests for which the filter returns false are passed on untouched.
+func WithFilter(filter func(*http.Request) bool) Option {
+	return func(c *config) {
+		c.filter = filter
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagators:    otel.GetTextMapPropagator(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Middleware returns a tracing middleware. It has to be registered with
+// Router.Use so the matched route is known when the span is started.
+//
+// As in otelmux, service names the (virtual) server handling the requests:
+// if not empty, it is recorded as server.address instead of the request
+// host. It is not recorded as service.name, which is an attribute of the
+// resource of the TracerProvider, see WithTracerProvider.
+//
+// As required by the semantic conventions, methods not defined by net/http
+// are recorded as "_OTHER", with the method in http.request.method_original,
+// and spans of such requests are named "HTTP" instead of after the method.
+//
+// The span context is stored in both the context passed to the next handler
+// and the request context. Errors returned by the handler are recorded on the
+// span and mark it as failed, as do responses with a 5xx status.
+func Middleware(service string, opts ...Option) mux.MiddlewareFunc {
+	cfg := newConfig(opts)
+	tracer := cfg.tracerProvider.Tracer(ScopeName)
+
+	return func(next mux.HandlerFunc) mux.HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+			if cfg.filter != nil && !cfg.filter(r) {
+				return next(ctx, w, r, binder)
+			}
+
+			ctx = cfg.propagators.Extract(ctx, propagation.HeaderCarrier(r.Header))
+
+			route := routeTemplate(r)
+			spanName := r.Method
+			if !knownMethod(r.Method) {
+				spanName = "HTTP"
+			}
+			if route != "" {
+				spanName += " " + route
+			}
+
+			attrs := requestAttributes(service, route, r)
+			ctx, span := tracer.Start(ctx, spanName,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			r = r.WithContext(trace.ContextWithSpan(r.Context(), span))
+			rw := mux.NewResponseRecorderWriter(w)
+
+			err := next(ctx, rw, r, binder)
+
+			status := rw.StatusOrDefault(err)
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			} else if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+
+			return err
+		}
+	}
+}
+
+// routeTemplate returns the path template of the route matched for r.
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return tpl
+}
+
+// requestAttributes returns the semantic convention attributes describing r.
+func requestAttributes(service, route string, r *http.Request) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		methodAttribute(r.Method),
+		semconv.URLPath(r.URL.Path),
+		semconv.URLScheme(scheme(r)),
+		semconv.NetworkProtocolVersion(strings.TrimPrefix(r.Proto, "HTTP/")),
+	}
+	if route != "" {
+		attrs = append(attrs, semconv.HTTPRoute(route))
+	}
+	if !knownMethod(r.Method) {
+		attrs = append(attrs, semconv.HTTPRequestMethodOriginal(r.Method))
+	}
+	if r.URL.RawQuery != "" {
+		attrs = append(attrs, semconv.URLQuery(r.URL.RawQuery))
+	}
+	server := service
+	if server == "" {
+		server = r.Host
+	}
+	if host, port := splitHostPort(server); host != "" {
+		attrs = append(attrs, semconv.ServerAddress(host))
+		if port > 0 {
+			attrs = append(attrs, semconv.ServerPort(port))
+		}
+	}
+	if ua := r.UserAgent(); ua != "" {
+		attrs = append(attrs, semconv.UserAgentOriginal(ua))
+	}
+	if host, _ := splitHostPort(r.RemoteAddr); host != "" {
+		attrs = append(attrs, semconv.ClientAddress(host))
+	}
+	return attrs
+}
+
+// knownMethod reports whether method is one of the methods defined by
+// net/http, which the semantic conventions know.
+func knownMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// methodAttribute returns the http.request.method attribute of method,
+// "_OTHER" for methods which are not known, so that clients cannot choose its
+// values freely.
+func methodAttribute(method string) attribute.KeyValue {
+	if !knownMethod(method) {
+		return semconv.HTTPRequestMethodOther
+	}
+	return semconv.HTTPRequestMethodKey.String(method)
+}
+
+func scheme(r *http.Request) string {
+	if r.URL.Scheme != "" {
+		return r.URL.Scheme
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func splitHostPort(hostport string) (string, int) {
+	host, portStr, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return hostport, 0
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return host, 0
+	}
+	return host, port
+}
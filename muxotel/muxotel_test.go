@@ -0,0 +1,135 @@
+package muxotel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	var handlerSpan trace.SpanContext
+
+	router := mux.NewRouter()
+	router.Use(Middleware("test", WithTracerProvider(provider), WithPropagators(propagation.TraceContext{})))
+	router.HandleFunc("/users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		return errors.New("boom")
+	})
+
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err := router.ServeHTTP(context.Background(), httptest.NewRecorder(), req, nil); err == nil {
+		t.Fatal("expected handler error to be returned")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+
+	if span.Name() != "GET /users/{id}" {
+		t.Errorf("unexpected span name %q", span.Name())
+	}
+	if span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("unexpected span kind %v", span.SpanKind())
+	}
+	if span.Parent().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("parent span context was not extracted: %v", span.Parent())
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("expected error status, got %v", span.Status())
+	}
+	if len(span.Events()) != 1 || span.Events()[0].Name != "exception" {
+		t.Errorf("expected recorded error event, got %v", span.Events())
+	}
+	if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("span was not propagated to the request context")
+	}
+
+	var route, status, server bool
+	for _, attr := range span.Attributes() {
+		switch attr.Key {
+		case semconv.HTTPRouteKey:
+			route = attr.Value.AsString() == "/users/{id}"
+		case semconv.HTTPResponseStatusCodeKey:
+			status = attr.Value.AsInt64() == http.StatusInternalServerError
+		case semconv.ServerAddressKey:
+			server = attr.Value.AsString() == "test"
+		case semconv.ServiceNameKey:
+			t.Errorf("expected service.name to be left to the resource, got %v", attr.Value.AsString())
+		}
+	}
+	if !route || !status || !server {
+		t.Errorf("missing route, status or server attribute: %v", span.Attributes())
+	}
+}
+
+func TestMiddlewareFilter(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	router := mux.NewRouter()
+	router.Use(Middleware("test", WithTracerProvider(provider), WithFilter(func(r *http.Request) bool {
+		return r.URL.Path != "/healthz"
+	})))
+	router.HandleFunc("/healthz", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		return nil
+	})
+
+	if err := router.ServeHTTP(context.Background(), httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil), nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.Ended()) != 0 {
+		t.Fatalf("expected filtered request not to be traced")
+	}
+}
+
+func TestMiddlewareOtherMethod(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	router := mux.NewRouter()
+	router.Use(Middleware("test", WithTracerProvider(provider)))
+	router.HandleFunc("/users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		return nil
+	})
+
+	req := httptest.NewRequest("PROPFIND", "/users/42", nil)
+	if err := router.ServeHTTP(context.Background(), httptest.NewRecorder(), req, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if name := spans[0].Name(); name != "HTTP /users/{id}" {
+		t.Errorf("unexpected span name %q", name)
+	}
+	var method, original string
+	for _, attr := range spans[0].Attributes() {
+		switch attr.Key {
+		case semconv.HTTPRequestMethodKey:
+			method = attr.Value.AsString()
+		case semconv.HTTPRequestMethodOriginalKey:
+			original = attr.Value.AsString()
+		}
+	}
+	if method != "_OTHER" || original != "PROPFIND" {
+		t.Errorf("expected method _OTHER with original PROPFIND, got %q and %q", method, original)
+	}
+}
@@ -0,0 +1,25 @@
+module github.com/gorilla/mux/muxprom
+
+go 1.21
+
+require (
+	github.com/gorilla/mux v0.0.0-20261016075547-52871cae1f3a
+	github.com/prometheus/client_golang v1.19.1
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/net v0.34.0 // indirect
+	golang.org/x/sys v0.29.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+)
+
+// Development in the repository uses the router next to the module. The
+// required version is the one used by consumers, which ignore the replace.
+replace github.com/gorilla/mux => ../
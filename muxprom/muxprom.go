@@ -0,0 +1,190 @@
+// Package muxprom provides a Prometheus metrics middleware for mux routers.
+//
+// Metrics are labeled by the path template of the matched route instead of
+// the request path, which keeps the label cardinality bounded:
+//
+//	metrics := muxprom.New(muxprom.Options{Namespace: "myservice"})
+//	prometheus.MustRegister(metrics)
+//
+//	r := mux.NewRouter()
+//	r.Use(metrics.Middleware)
+package muxprom
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unknownRoute is the route label used when the matched route has no path
+// template, e.g. for routes matching on host or headers only.
+const unknownRoute = "unknown"
+
+// otherMethod is the method label used for methods outside of the standard
+// set, which clients may choose freely.
+const otherMethod = "other"
+
+// Options configures the metrics collected by Metrics.
+type Options struct {
+	// Namespace and Subsystem prefix all metric names.
+	Namespace string
+	Subsystem string
+	// DurationBuckets are the buckets of the request duration histogram in
+	// seconds. Defaults to prometheus.DefBuckets.
+	DurationBuckets []float64
+	// SizeBuckets are the buckets of the response size histogram in bytes.
+	// Defaults to exponential buckets from 100B to 100MB.
+	SizeBuckets []float64
+	// ConstLabels are added to every metric.
+	ConstLabels prometheus.Labels
+	// TenantLabel adds the label "tenant" holding the tenant of the request,
+	// see mux.Router.Tenants, to every metric. Each tenant multiplies the
+	// number of series, so only the tenants known to the router are used,
+	// see mux.TenantLabel; other tenants, e.g. from a host variable or
+	// header, are labeled mux.UnknownTenant. It is empty for requests
+	// without a tenant.
+	TenantLabel bool
+}
+
+// Metrics is a prometheus.Collector recording request metrics per route. It
+// has to be registered with a prometheus.Registerer by the caller.
+type Metrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	size     *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+	tenant   bool
+}
+
+// New creates the metrics described by options.
+func New(options Options) *Metrics {
+	if options.DurationBuckets == nil {
+		options.DurationBuckets = prometheus.DefBuckets
+	}
+	if options.SizeBuckets == nil {
+		options.SizeBuckets = prometheus.ExponentialBuckets(100, 10, 7)
+	}
+
+	labels := []string{"route", "method", "code"}
+	inFlightLabels := []string{"route"}
+	if options.TenantLabel {
+		labels = append(labels, "tenant")
+		inFlightLabels = append(inFlightLabels, "tenant")
+	}
+
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   options.Namespace,
+			Subsystem:   options.Subsystem,
+			Name:        "http_requests_total",
+			Help:        "Number of HTTP requests handled, by route template, method and status class.",
+			ConstLabels: options.ConstLabels,
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   options.Namespace,
+			Subsystem:   options.Subsystem,
+			Name:        "http_request_duration_seconds",
+			Help:        "Duration of HTTP requests, by route template, method and status class.",
+			Buckets:     options.DurationBuckets,
+			ConstLabels: options.ConstLabels,
+		}, labels),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   options.Namespace,
+			Subsystem:   options.Subsystem,
+			Name:        "http_response_size_bytes",
+			Help:        "Size of HTTP response bodies, by route template, method and status class.",
+			Buckets:     options.SizeBuckets,
+			ConstLabels: options.ConstLabels,
+		}, labels),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   options.Namespace,
+			Subsystem:   options.Subsystem,
+			Name:        "http_requests_in_flight",
+			Help:        "Number of HTTP requests currently being handled, by route template.",
+			ConstLabels: options.ConstLabels,
+		}, inFlightLabels),
+		tenant: options.TenantLabel,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.duration.Describe(ch)
+	m.size.Describe(ch)
+	m.inFlight.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.duration.Collect(ch)
+	m.size.Collect(ch)
+	m.inFlight.Collect(ch)
+}
+
+// Middleware records the metrics of every request handled by the router.
+// It has to be registered with Router.Use so the matched route is known.
+func (m *Metrics) Middleware(next mux.HandlerFunc) mux.HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		route := RouteLabel(r)
+		var tenant []string
+		if m.tenant {
+			tenant = []string{mux.TenantLabel(r.Context())}
+		}
+
+		inFlight := m.inFlight.WithLabelValues(append([]string{route}, tenant...)...)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		rw := mux.NewResponseRecorderWriter(w)
+		start := time.Now()
+		err := next(ctx, rw, r, binder)
+
+		code := StatusClass(rw.StatusOrDefault(err))
+		labels := append([]string{route, MethodLabel(r.Method), code}, tenant...)
+		m.requests.WithLabelValues(labels...).Inc()
+		m.duration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		m.size.WithLabelValues(labels...).Observe(float64(rw.BytesWritten()))
+
+		return err
+	}
+}
+
+// RouteLabel returns the path template of the route matched for r, or
+// "unknown" if there is none.
+func RouteLabel(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return unknownRoute
+	}
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return unknownRoute
+	}
+	return tpl
+}
+
+// MethodLabel returns method if it is one of the methods defined by net/http,
+// such as GET or POST, and "other" otherwise, which keeps the label
+// cardinality bounded.
+func MethodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return otherMethod
+}
+
+// StatusClass maps a status code to its class, e.g. 404 to "4xx".
+func StatusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
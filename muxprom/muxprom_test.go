@@ -0,0 +1,96 @@
+package muxprom
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware(t *testing.T) {
+	metrics := New(Options{Namespace: "test"})
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(metrics)
+
+	router := mux.NewRouter()
+	router.Use(metrics.Middleware)
+	router.HandleFunc("/users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		_, err := w.Write([]byte("hello"))
+		return err
+	}).Methods("GET")
+	router.HandleFunc("/fail", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		return errors.New("boom")
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/fail"} {
+		_ = router.ServeHTTP(context.Background(), httptest.NewRecorder(), httptest.NewRequest("GET", path, nil), nil)
+	}
+
+	expected := `
+# HELP test_http_requests_total Number of HTTP requests handled, by route template, method and status class.
+# TYPE test_http_requests_total counter
+test_http_requests_total{code="2xx",method="GET",route="/users/{id}"} 2
+test_http_requests_total{code="5xx",method="GET",route="/fail"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "test_http_requests_total"); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := testutil.CollectAndCount(metrics, "test_http_request_duration_seconds"); count != 2 {
+		t.Fatalf("expected 2 duration series, got %d", count)
+	}
+}
+
+func TestMiddlewareTenantLabel(t *testing.T) {
+	metrics := New(Options{Namespace: "test", TenantLabel: true})
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(metrics)
+
+	router := mux.NewRouter().Tenants(mux.NewTenantResolver().Header("X-Tenant", func(*http.Request) bool { return true }))
+	router.Use(metrics.Middleware)
+	router.HandleFunc("/users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		return nil
+	}).Methods("GET")
+	router.TenantOverlay("acme")
+
+	for _, tenant := range []string{"acme", "acme", "", "random-1", "random-2"} {
+		req := httptest.NewRequest("GET", "/users/1", nil)
+		if tenant != "" {
+			req.Header.Set("X-Tenant", tenant)
+		}
+		_ = router.ServeHTTP(context.Background(), httptest.NewRecorder(), req, nil)
+	}
+
+	expected := `
+# HELP test_http_requests_total Number of HTTP requests handled, by route template, method and status class.
+# TYPE test_http_requests_total counter
+test_http_requests_total{code="2xx",method="GET",route="/users/{id}",tenant=""} 1
+test_http_requests_total{code="2xx",method="GET",route="/users/{id}",tenant="acme"} 2
+test_http_requests_total{code="2xx",method="GET",route="/users/{id}",tenant="unknown"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "test_http_requests_total"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	for code, class := range map[int]string{200: "2xx", 302: "3xx", 404: "4xx", 503: "5xx", 0: "unknown"} {
+		if got := StatusClass(code); got != class {
+			t.Errorf("StatusClass(%d) = %q, want %q", code, got, class)
+		}
+	}
+}
+
+func TestMethodLabel(t *testing.T) {
+	for method, label := range map[string]string{"GET": "GET", "PATCH": "PATCH", "PROPFIND": "other", "get": "other", "": "other"} {
+		if got := MethodLabel(method); got != label {
+			t.Errorf("MethodLabel(%q) = %q, want %q", method, got, label)
+		}
+	}
+}
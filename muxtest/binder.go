@@ -0,0 +1,117 @@
+package muxtest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// MockBinder is a mux.BodyBinder for handler tests, returning canned results
+// in the order they were programmed and recording the calls:
+//
+//	binder := muxtest.NewMockBinder(t).
+//	    Return(CreateUser{Name: "ann"}).
+//	    ReturnError(CreateUser{}, errInvalid)
+//	client := muxtest.NewClient(t, router).WithBinder(binder)
+//
+// Each result expects the destination of its call to be a pointer to the type
+// of its value, and reports other destinations to t. Unexpected calls and
+// results left when the test ends are reported as well.
+type MockBinder struct {
+	t       testing.TB
+	mu      sync.Mutex
+	results []bindResult
+	calls   []BindCall
+}
+
+// BindCall is a call of MockBinder.Bind.
+type BindCall struct {
+	Request *http.Request
+	// Dest is the destination passed to Bind.
+	Dest any
+	// Err is the error returned by Bind.
+	Err error
+}
+
+// bindResult is a canned result of a MockBinder.
+type bindResult struct {
+	value reflect.Value
+	err   error
+}
+
+// NewMockBinder returns a MockBinder without results, reporting failures to t.
+func NewMockBinder(t testing.TB) *MockBinder {
+	b := &MockBinder{t: t}
+	t.Cleanup(b.assertConsumed)
+	return b
+}
+
+// Return adds a result storing v in the destination, which must be a pointer
+// to the type of v.
+func (b *MockBinder) Return(v any) *MockBinder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.results = append(b.results, bindResult{value: reflect.ValueOf(v)})
+	return b
+}
+
+// ReturnError adds a result returning err. Unless v is nil, the destination
+// must be a pointer to the type of v; it is left unchanged.
+func (b *MockBinder) ReturnError(v any, err error) *MockBinder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.results = append(b.results, bindResult{value: reflect.ValueOf(v), err: err})
+	return b
+}
+
+// Bind implements mux.BodyBinder with the next result.
+func (b *MockBinder) Bind(r *http.Request, v any) error {
+	b.t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	call := BindCall{Request: r, Dest: v}
+	call.Err = b.bind(v)
+	b.calls = append(b.calls, call)
+	return call.Err
+}
+
+func (b *MockBinder) bind(v any) error {
+	b.t.Helper()
+	if len(b.results) == 0 {
+		b.t.Errorf("muxtest: unexpected Bind call with %T", v)
+		return errors.New("muxtest: unexpected Bind call")
+	}
+	result := b.results[0]
+	b.results = b.results[1:]
+	if !result.value.IsValid() {
+		return result.err
+	}
+	dest := reflect.ValueOf(v)
+	if dest.Kind() != reflect.Pointer || dest.IsNil() || dest.Type().Elem() != result.value.Type() {
+		b.t.Errorf("muxtest: expected Bind with *%s, got %T", result.value.Type(), v)
+		return fmt.Errorf("muxtest: unexpected Bind destination %T", v)
+	}
+	if result.err == nil {
+		dest.Elem().Set(result.value)
+	}
+	return result.err
+}
+
+// Calls returns the calls of Bind.
+func (b *MockBinder) Calls() []BindCall {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]BindCall(nil), b.calls...)
+}
+
+// assertConsumed reports results which weren't returned.
+func (b *MockBinder) assertConsumed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.results) > 0 {
+		b.t.Errorf("muxtest: %d Bind results not consumed", len(b.results))
+	}
+}
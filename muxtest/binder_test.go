@@ -0,0 +1,70 @@
+package muxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type createUser struct {
+	Name string
+}
+
+func binderTestRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/users", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		var in createUser
+		if err := binder.(mux.BodyBinder).Bind(r, &in); err != nil {
+			return err
+		}
+		fmt.Fprint(w, in.Name)
+		return nil
+	}).Methods(http.MethodPost)
+	return router
+}
+
+func TestMockBinder(t *testing.T) {
+	errInvalid := errors.New("invalid")
+	binder := NewMockBinder(t).
+		Return(createUser{Name: "ann"}).
+		ReturnError(createUser{}, errInvalid).
+		ReturnError(nil, errInvalid)
+	client := NewClient(t, binderTestRouter()).WithBinder(binder)
+
+	client.Post("/users").WithBody(`{}`).ExpectNoError().ExpectBody("ann")
+	if err := client.Post("/users").ExpectError(); err != errInvalid {
+		t.Errorf("expected %v, got %v", errInvalid, err)
+	}
+	if err := client.Post("/users").ExpectError(); err != errInvalid {
+		t.Errorf("expected %v, got %v", errInvalid, err)
+	}
+
+	calls := binder.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 calls, got %d", len(calls))
+	}
+	if calls[0].Request.URL.Path != "/users" || calls[0].Dest.(*createUser).Name != "ann" || calls[0].Err != nil {
+		t.Errorf("unexpected call %+v", calls[0])
+	}
+	if calls[1].Err != errInvalid {
+		t.Errorf("unexpected call %+v", calls[1])
+	}
+}
+
+func TestMockBinderFailures(t *testing.T) {
+	rt := &recordingT{T: t}
+	binder := NewMockBinder(rt).Return("wrong type")
+	router := binderTestRouter()
+	NewClient(t, router).WithBinder(binder).Post("/users").ExpectError()
+	NewClient(t, router).WithBinder(binder).Post("/users").ExpectError()
+	binder.Return(createUser{})
+	binder.assertConsumed()
+	if len(rt.failures) != 3 {
+		t.Errorf("expected 3 failures, got %q", rt.failures)
+	}
+	binder.results = nil
+}
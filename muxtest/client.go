@@ -0,0 +1,224 @@
+// Package muxtest provides helpers for testing mux routers and handlers.
+package muxtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// Client sends requests to a router in tests, without a server, and
+// asserts on the responses:
+//
+//	client := muxtest.NewClient(t, router)
+//	var user User
+//	client.Get("/users/42").WithHeader("Authorization", token).
+//	    ExpectStatus(http.StatusOK).
+//	    ExpectJSON(&user)
+//
+// Requests are sent by the first Expect method or by Do, with the context
+// and binder of the client.
+type Client struct {
+	t       testing.TB
+	router  *mux.Router
+	ctx     context.Context
+	binder  mux.Binder
+	headers http.Header
+}
+
+// NewClient returns a client sending requests to router, reporting failed
+// expectations to t.
+func NewClient(t testing.TB, router *mux.Router) *Client {
+	return &Client{t: t, router: router, ctx: context.Background(), headers: make(http.Header)}
+}
+
+// WithContext sets the context passed to the router. It defaults to
+// context.Background.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	c.ctx = ctx
+	return c
+}
+
+// WithBinder sets the binder passed to the router.
+func (c *Client) WithBinder(binder mux.Binder) *Client {
+	c.binder = binder
+	return c
+}
+
+// WithHeader adds a header sent with all requests of the client.
+func (c *Client) WithHeader(name, value string) *Client {
+	c.headers.Add(name, value)
+	return c
+}
+
+// Get returns a GET request for target, a path or an absolute URL.
+func (c *Client) Get(target string) *Request {
+	return c.NewRequest(http.MethodGet, target)
+}
+
+// Head returns a HEAD request for target.
+func (c *Client) Head(target string) *Request {
+	return c.NewRequest(http.MethodHead, target)
+}
+
+// Post returns a POST request for target.
+func (c *Client) Post(target string) *Request {
+	return c.NewRequest(http.MethodPost, target)
+}
+
+// Put returns a PUT request for target.
+func (c *Client) Put(target string) *Request {
+	return c.NewRequest(http.MethodPut, target)
+}
+
+// Patch returns a PATCH request for target.
+func (c *Client) Patch(target string) *Request {
+	return c.NewRequest(http.MethodPatch, target)
+}
+
+// Delete returns a DELETE request for target.
+func (c *Client) Delete(target string) *Request {
+	return c.NewRequest(http.MethodDelete, target)
+}
+
+// NewRequest returns a request with method for target.
+func (c *Client) NewRequest(method, target string) *Request {
+	req := httptest.NewRequest(method, target, nil)
+	for name, values := range c.headers {
+		req.Header[name] = append([]string(nil), values...)
+	}
+	return &Request{c: c, req: req}
+}
+
+// Request is a request of a Client. Its With methods configure the request
+// and must be called before it is sent.
+type Request struct {
+	c    *Client
+	req  *http.Request
+	sent bool
+	rec  *httptest.ResponseRecorder
+	err  error
+}
+
+// WithHeader adds a request header.
+func (r *Request) WithHeader(name, value string) *Request {
+	r.req.Header.Add(name, value)
+	return r
+}
+
+// WithQuery adds a query parameter.
+func (r *Request) WithQuery(name, value string) *Request {
+	q := r.req.URL.Query()
+	q.Add(name, value)
+	r.req.URL.RawQuery = q.Encode()
+	r.req.RequestURI = r.req.URL.RequestURI()
+	return r
+}
+
+// WithBody sets the request body.
+func (r *Request) WithBody(body string) *Request {
+	r.req.Body = io.NopCloser(strings.NewReader(body))
+	r.req.ContentLength = int64(len(body))
+	return r
+}
+
+// WithJSON sets the request body to v encoded as JSON, and the Content-Type
+// header.
+func (r *Request) WithJSON(v any) *Request {
+	r.c.t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		r.c.t.Fatalf("muxtest: encoding JSON body: %v", err)
+	}
+	r.req.Header.Set("Content-Type", "application/json")
+	r.req.Body = io.NopCloser(bytes.NewReader(b))
+	r.req.ContentLength = int64(len(b))
+	return r
+}
+
+// WithForm sets the request body to the URL encoded form values, and the
+// Content-Type header.
+func (r *Request) WithForm(values url.Values) *Request {
+	r.req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r.WithBody(values.Encode())
+}
+
+// Do sends the request, unless it was sent already, and returns the recorded
+// response and the error returned by the router.
+func (r *Request) Do() (*httptest.ResponseRecorder, error) {
+	if !r.sent {
+		r.sent = true
+		r.rec = httptest.NewRecorder()
+		r.err = r.c.router.ServeHTTP(r.c.ctx, r.rec, r.req, r.c.binder)
+	}
+	return r.rec, r.err
+}
+
+// ExpectStatus expects the response status code.
+func (r *Request) ExpectStatus(code int) *Request {
+	r.c.t.Helper()
+	if rec, _ := r.Do(); rec.Code != code {
+		r.c.t.Errorf("%s: expected status %d, got %d", r, code, rec.Code)
+	}
+	return r
+}
+
+// ExpectHeader expects the value of a response header.
+func (r *Request) ExpectHeader(name, value string) *Request {
+	r.c.t.Helper()
+	if rec, _ := r.Do(); rec.Header().Get(name) != value {
+		r.c.t.Errorf("%s: expected header %s %q, got %q", r, name, value, rec.Header().Get(name))
+	}
+	return r
+}
+
+// ExpectBody expects the response body.
+func (r *Request) ExpectBody(body string) *Request {
+	r.c.t.Helper()
+	if rec, _ := r.Do(); rec.Body.String() != body {
+		r.c.t.Errorf("%s: expected body %q, got %q", r, body, rec.Body.String())
+	}
+	return r
+}
+
+// ExpectJSON decodes the JSON response body into out.
+func (r *Request) ExpectJSON(out any) *Request {
+	r.c.t.Helper()
+	rec, _ := r.Do()
+	if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+		r.c.t.Errorf("%s: decoding JSON body %q: %v", r, rec.Body.String(), err)
+	}
+	return r
+}
+
+// ExpectNoError expects the router to return no error.
+func (r *Request) ExpectNoError() *Request {
+	r.c.t.Helper()
+	if _, err := r.Do(); err != nil {
+		r.c.t.Errorf("%s: unexpected error: %v", r, err)
+	}
+	return r
+}
+
+// ExpectError expects the router to return an error, and returns it.
+func (r *Request) ExpectError() error {
+	r.c.t.Helper()
+	_, err := r.Do()
+	if err == nil {
+		r.c.t.Errorf("%s: expected an error", r)
+	}
+	return err
+}
+
+// String returns the method and URL of the request.
+func (r *Request) String() string {
+	return r.req.Method + " " + r.req.URL.String()
+}
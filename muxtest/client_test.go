@@ -0,0 +1,87 @@
+package muxtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// recordingT records the failures reported by the helpers.
+type recordingT struct {
+	*testing.T
+	failures []string
+}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+type ctxKey struct{}
+
+func testRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		w.Header().Set("Content-Type", "application/json")
+		return json.NewEncoder(w).Encode(map[string]any{
+			"id":    mux.Var(r, "id"),
+			"token": r.Header.Get("Authorization"),
+			"ctx":   ctx.Value(ctxKey{}),
+			"q":     r.URL.Query().Get("q"),
+		})
+	}).Methods(http.MethodGet)
+	router.HandleFunc("/echo", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		if r.Form.Get("fail") != "" {
+			return errors.New("failed")
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, r.Header.Get("Content-Type"), " ", r.Form.Encode())
+		return nil
+	}).Methods(http.MethodPost)
+	return router
+}
+
+func TestClient(t *testing.T) {
+	client := NewClient(t, testRouter()).
+		WithHeader("Authorization", "secret").
+		WithContext(context.WithValue(context.Background(), ctxKey{}, "value"))
+
+	var user map[string]string
+	client.Get("/users/42").WithQuery("q", "x").
+		ExpectNoError().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Content-Type", "application/json").
+		ExpectJSON(&user)
+	if user["id"] != "42" || user["token"] != "secret" || user["ctx"] != "value" || user["q"] != "x" {
+		t.Errorf("unexpected response %v", user)
+	}
+
+	client.Post("/echo").WithForm(url.Values{"a": {"1"}}).
+		ExpectStatus(http.StatusCreated).
+		ExpectBody("application/x-www-form-urlencoded a=1")
+	if err := client.Post("/echo").WithForm(url.Values{"fail": {"1"}}).ExpectError(); err == nil || err.Error() != "failed" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestClientFailures(t *testing.T) {
+	rt := &recordingT{T: t}
+	client := NewClient(rt, testRouter())
+	client.Get("/users/1").
+		ExpectStatus(http.StatusNotFound).
+		ExpectHeader("Content-Type", "text/plain").
+		ExpectBody("").
+		ExpectError()
+	client.Post("/echo").WithJSON(map[string]int{"a": 1}).ExpectJSON(&struct{}{})
+	if len(rt.failures) != 5 {
+		t.Errorf("expected 5 failures, got %q", rt.failures)
+	}
+}
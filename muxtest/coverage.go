@@ -0,0 +1,49 @@
+package muxtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// AssertAllRoutesCovered reports the routes of router which served no
+// request to t. The router must record coverage, see
+// mux.Router.RecordCoverage; requests sent with Client, Server or
+// Router.ServeHTTP all count. It is typically called after the tests ran,
+// from TestMain or a cleanup of a shared router:
+//
+//	router := newRouter().RecordCoverage()
+//	t.Cleanup(func() { muxtest.AssertAllRoutesCovered(t, router) })
+func AssertAllRoutesCovered(t testing.TB, router *mux.Router) {
+	t.Helper()
+	if !router.RecordsCoverage() {
+		t.Fatalf("muxtest: the router does not record coverage, call RecordCoverage before serving requests")
+	}
+	uncovered := router.UncoveredRoutes()
+	if len(uncovered) == 0 {
+		return
+	}
+	var b strings.Builder
+	for _, route := range uncovered {
+		b.WriteString("\n\t")
+		b.WriteString(coverageLabel(route))
+	}
+	t.Errorf("%d routes served no request:%s", len(uncovered), b.String())
+}
+
+// coverageLabel describes route by its methods, path template and name.
+func coverageLabel(route *mux.Route) string {
+	methods, _ := route.GetMethods()
+	label := "*"
+	if len(methods) > 0 {
+		label = strings.Join(methods, ",")
+	}
+	if tpl, err := route.GetPathTemplate(); err == nil {
+		label += " " + tpl
+	}
+	if name := route.GetName(); name != "" {
+		label += " (" + name + ")"
+	}
+	return label
+}
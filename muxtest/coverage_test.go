@@ -0,0 +1,26 @@
+package muxtest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAssertAllRoutesCovered(t *testing.T) {
+	router := testRouter().RecordCoverage()
+	client := NewClient(t, router)
+	client.Get("/users/1").ExpectStatus(http.StatusOK)
+
+	rt := &recordingT{T: t}
+	AssertAllRoutesCovered(rt, router)
+	if len(rt.failures) != 1 || !strings.Contains(rt.failures[0], "1 routes served no request:\n\tPOST /echo") {
+		t.Errorf("unexpected failures %q", rt.failures)
+	}
+
+	client.Post("/echo").ExpectStatus(http.StatusCreated)
+	rt.failures = nil
+	AssertAllRoutesCovered(rt, router)
+	if len(rt.failures) != 0 {
+		t.Errorf("unexpected failures %q", rt.failures)
+	}
+}
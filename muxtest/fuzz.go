@@ -0,0 +1,154 @@
+package muxtest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// templateSeeds are the seeds of FuzzTemplates.
+var templateSeeds = []string{
+	"/",
+	"/users/{id}",
+	"/users/{id:[0-9]+}/posts/{slug}",
+	"/files/{path:.*}",
+	"/{a}/{b:[a-z]{2,3}}/",
+	"/{category}-{id:[0-9]+}.html",
+	"/x/{y:(?:a|b)}",
+	"/{",
+	"/}",
+	"/{}",
+	"/{a:}",
+	"/{a:[}",
+	"/{a}{b}",
+	"/{a:[0-9]{1,}}",
+}
+
+// FuzzTemplates fuzzes the parsing of path templates and URL building:
+// templates must be parsed or rejected without panicking, and paths built
+// by a route must be matched by it. Run it from a fuzz test:
+//
+//	func FuzzTemplates(f *testing.F) {
+//	    muxtest.FuzzTemplates(f)
+//	}
+func FuzzTemplates(f *testing.F) {
+	for _, tpl := range templateSeeds {
+		f.Add(tpl, "value")
+	}
+	f.Fuzz(func(t *testing.T, tpl, value string) {
+		route := newFuzzRoute(tpl)
+		if route == nil || route.GetError() != nil {
+			return
+		}
+		names, err := route.GetVarNames()
+		if err != nil {
+			t.Fatalf("%q: %v", tpl, err)
+		}
+		pairs := make([]string, 0, 2*len(names))
+		for _, name := range names {
+			pairs = append(pairs, name, value)
+		}
+		u, err := route.URLPath(pairs...)
+		if err != nil {
+			return
+		}
+		if !validFuzzPath(u.Path) {
+			return
+		}
+		req := &http.Request{Method: http.MethodGet, URL: u, Host: "localhost", Header: http.Header{}}
+		var match mux.RouteMatch
+		if !route.Match(req, &match) {
+			t.Errorf("%q: built path %q for %q is not matched", tpl, u.Path, value)
+		}
+	})
+}
+
+// newFuzzRoute returns a route with the path template tpl, or nil if tpl
+// contains capturing groups, for which Route.Path panics by design.
+func newFuzzRoute(tpl string) (route *mux.Route) {
+	defer func() {
+		if err := recover(); err != nil {
+			if msg, ok := err.(string); !ok || !strings.Contains(msg, "capture groups") {
+				panic(err)
+			}
+			route = nil
+		}
+	}()
+	return mux.NewRouter().NewRoute().Path(tpl)
+}
+
+// FuzzRouter fuzzes the matching of request paths by router: matching must
+// not panic, and the URL of a matched route must be buildable from the
+// matched variables. The seeds are paths of the routes of router, with the
+// variables replaced by sample values, and hostile paths. Run it from a fuzz
+// test of the router of an application:
+//
+//	func FuzzRouter(f *testing.F) {
+//	    muxtest.FuzzRouter(f, newRouter())
+//	}
+func FuzzRouter(f *testing.F, router *mux.Router) {
+	for _, path := range routerSeeds(router) {
+		f.Add(http.MethodGet, path)
+	}
+	for _, path := range []string{"", "/", "//", "/..", "/a/../b", "/%", "/%zz", "/%2F", "/a%00b", "/" + strings.Repeat("a/", 100)} {
+		f.Add(http.MethodGet, path)
+	}
+	f.Add(http.MethodPost, "/")
+	f.Add("", "/")
+	f.Fuzz(func(t *testing.T, method, target string) {
+		u, err := url.Parse(target)
+		if err != nil {
+			u = &url.URL{Path: target}
+		}
+		req := &http.Request{Method: method, URL: u, Host: "localhost", Header: http.Header{}}
+		req = req.WithContext(context.Background())
+		result, err := router.MatchRequest(req)
+		if err != nil || result.Route == nil {
+			return
+		}
+		if _, err := result.Route.GetPathTemplate(); err != nil {
+			return
+		}
+		pairs := make([]string, 0, 2*len(result.Vars))
+		for name, value := range result.Vars {
+			pairs = append(pairs, name, value)
+		}
+		// Building may fail for missing host or query variables, but must
+		// not panic.
+		_, _ = result.Route.URLPath(pairs...)
+	})
+}
+
+var templateVar = regexp.MustCompile(`\{[^{}]*(\{[^{}]*\}[^{}]*)*\}`)
+
+// routerSeeds returns the path templates of router with the variables
+// replaced by a sample value.
+func routerSeeds(router *mux.Router) []string {
+	var seeds []string
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			seeds = append(seeds, templateVar.ReplaceAllString(tpl, "1"))
+		}
+		return nil
+	})
+	return seeds
+}
+
+// validFuzzPath reports whether path is a path a client could request:
+// valid UTF-8 without control characters.
+func validFuzzPath(path string) bool {
+	if !strings.HasPrefix(path, "/") {
+		return false
+	}
+	for _, c := range path {
+		if c < 0x20 || c == 0x7f || c == '�' || c == '?' || c == '#' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,11 @@
+package muxtest
+
+import "testing"
+
+func FuzzTemplatesSeeds(f *testing.F) {
+	FuzzTemplates(f)
+}
+
+func FuzzRouterSeeds(f *testing.F) {
+	FuzzRouter(f, routeTestRouter())
+}
@@ -0,0 +1,95 @@
+package muxtest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+var update = flag.Bool("muxtest.update", false, "update the golden files of muxtest.AssertRouteTable")
+
+// AssertRouteTable compares the route table of router, see
+// Router.WriteRouteTable, with the golden file at path, reporting added and
+// removed routes to t:
+//
+//	func TestRoutes(t *testing.T) {
+//	    muxtest.AssertRouteTable(t, newRouter(), "testdata/routes.golden")
+//	}
+//
+// Running the tests with -muxtest.update writes the golden file instead.
+func AssertRouteTable(t testing.TB, router *mux.Router, path string) {
+	t.Helper()
+	var got bytes.Buffer
+	if err := router.WriteRouteTable(&got); err != nil {
+		t.Fatalf("muxtest: writing route table: %v", err)
+	}
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("muxtest: %v", err)
+		}
+		if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+			t.Fatalf("muxtest: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("muxtest: %v (run the tests with -muxtest.update to create it)", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("route table differs from %s (run the tests with -muxtest.update to accept it):\n%s", path, diffLines(splitLines(want), splitLines(got.Bytes())))
+	}
+}
+
+// splitLines splits b into lines without their line endings.
+func splitLines(b []byte) []string {
+	var lines []string
+	for _, line := range bytes.SplitAfter(b, []byte("\n")) {
+		if len(line) > 0 {
+			lines = append(lines, string(bytes.TrimRight(line, "\r\n")))
+		}
+	}
+	return lines
+}
+
+// diffLines returns a line diff of want and got, the lines of the longest
+// common subsequence prefixed with "  ", removed lines with "- " and added
+// lines with "+ ".
+func diffLines(want, got []string) string {
+	// lcs[i][j] is the length of the longest common subsequence of want[i:]
+	// and got[j:].
+	lcs := make([][]int, len(want)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var b bytes.Buffer
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case i < len(want) && j < len(got) && want[i] == got[j]:
+			b.WriteString("  " + want[i] + "\n")
+			i++
+			j++
+		case j < len(got) && (i == len(want) || lcs[i][j+1] >= lcs[i+1][j]):
+			b.WriteString("+ " + got[j] + "\n")
+			j++
+		default:
+			b.WriteString("- " + want[i] + "\n")
+			i++
+		}
+	}
+	return b.String()
+}
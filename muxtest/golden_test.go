@@ -0,0 +1,37 @@
+package muxtest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAssertRouteTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.golden")
+	golden := "GET /users/{id} name=user\nGET /old\nPOST /echo name=echo\n"
+	if err := os.WriteFile(path, []byte(golden), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rt := &recordingT{T: t}
+	AssertRouteTable(rt, routeTestRouter(), path)
+	if len(rt.failures) != 1 {
+		t.Fatalf("expected 1 failure, got %q", rt.failures)
+	}
+	want := `  GET /users/{id} name=user
+- GET /old
+  POST /echo name=echo
++ * /api/items header=Accept:application/vnd.v2+json name=items.v2
++ * /api/items name=items
+`
+	if !strings.HasSuffix(rt.failures[0], want) {
+		t.Errorf("unexpected diff:\n%s", rt.failures[0])
+	}
+
+	*update = true
+	defer func() { *update = false }()
+	AssertRouteTable(t, routeTestRouter(), path)
+	*update = false
+	AssertRouteTable(t, routeTestRouter(), path)
+}
@@ -0,0 +1,133 @@
+package muxtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// openAPIMethods are the operations of an OpenAPI path item.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// OpenAPIOptions configures AssertOpenAPI.
+type OpenAPIOptions struct {
+	// BasePath is the path of the server URL of the document, which the
+	// route paths start with but the document paths don't.
+	BasePath string
+
+	// Ignore, if set, excludes the routes it returns true for, such as
+	// health checks or file servers.
+	Ignore func(route *mux.Route) bool
+}
+
+// AssertOpenAPI checks the router against the OpenAPI document spec, in
+// JSON, reporting to t the routes without an operation and the operations
+// without a route:
+//
+//	spec, _ := os.ReadFile("openapi.json")
+//	muxtest.AssertOpenAPI(t, newRouter(), spec, muxtest.OpenAPIOptions{BasePath: "/v1"})
+//
+// Routes and paths are compared without the names and patterns of their
+// variables. A route without methods implements all operations of its path.
+// HEAD and OPTIONS routes don't need an operation. Routes without a path
+// template are not checked.
+func AssertOpenAPI(t testing.TB, router *mux.Router, spec []byte, options OpenAPIOptions) {
+	t.Helper()
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		t.Fatalf("muxtest: parsing OpenAPI document: %v", err)
+	}
+
+	// operations maps normalized paths to their operations, and the
+	// operations to whether a route implements them.
+	operations := make(map[string]map[string]bool)
+	paths := make(map[string]string)
+	for path, item := range doc.Paths {
+		key := normalizeOpenAPIPath(path)
+		paths[key] = path
+		operations[key] = make(map[string]bool)
+		for method := range item {
+			if slices.Contains(openAPIMethods, method) {
+				operations[key][strings.ToUpper(method)] = false
+			}
+		}
+	}
+
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+		if _, ok := route.GetHandler().(*mux.Router); ok {
+			return nil
+		}
+		tpl, err := route.GetPathTemplate()
+		if err != nil || options.Ignore != nil && options.Ignore(route) {
+			return nil
+		}
+		path, ok := strings.CutPrefix(tpl, options.BasePath)
+		if !ok {
+			t.Errorf("muxtest: route %s is outside of the base path %s", tpl, options.BasePath)
+			return nil
+		}
+		ops := operations[normalizeOpenAPIPath(path)]
+		methods, err := route.GetMethods()
+		if err != nil {
+			// The route implements all operations of the path.
+			if len(ops) == 0 {
+				t.Errorf("muxtest: route %s has no OpenAPI operation", tpl)
+			}
+			for method := range ops {
+				ops[method] = true
+			}
+			return nil
+		}
+		for _, method := range methods {
+			if _, ok := ops[method]; ok {
+				ops[method] = true
+			} else if method != http.MethodHead && method != http.MethodOptions {
+				t.Errorf("muxtest: route %s %s has no OpenAPI operation", method, tpl)
+			}
+		}
+		return nil
+	})
+
+	var missing []string
+	for key, ops := range operations {
+		for method, implemented := range ops {
+			if !implemented {
+				missing = append(missing, method+" "+paths[key])
+			}
+		}
+	}
+	slices.Sort(missing)
+	for _, op := range missing {
+		t.Errorf("muxtest: OpenAPI operation %s has no route", op)
+	}
+}
+
+// normalizeOpenAPIPath returns path with the variables replaced by "{}", for
+// comparing route templates and OpenAPI paths.
+func normalizeOpenAPIPath(path string) string {
+	var b strings.Builder
+	depth := 0
+	for _, c := range path {
+		switch {
+		case c == '{':
+			if depth == 0 {
+				b.WriteString("{}")
+			}
+			depth++
+		case c == '}' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
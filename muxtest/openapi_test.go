@@ -0,0 +1,73 @@
+package muxtest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+const openAPITestSpec = `{
+  "openapi": "3.0.3",
+  "paths": {
+    "/users": {"get": {}, "post": {}, "parameters": []},
+    "/users/{userId}": {"get": {}, "delete": {}},
+    "/files/{name}": {"get": {}, "put": {}},
+    "/orders": {"get": {}}
+  }
+}`
+
+func openAPITestRouter() *mux.Router {
+	handler := mux.HandlerFunc(mux.NotFound)
+	router := mux.NewRouter()
+	api := router.PathPrefix("/v1").Subrouter()
+	api.Handle("/users", handler).Methods(http.MethodGet, http.MethodHead, http.MethodPost)
+	api.Handle("/users/{id:[0-9]+}", handler).Methods(http.MethodGet, http.MethodDelete)
+	api.Handle("/files/{name}", handler)
+	router.Handle("/health", handler).Name("health")
+	return router
+}
+
+func TestAssertOpenAPI(t *testing.T) {
+	router := openAPITestRouter()
+	router.Handle("/v1/orders", mux.HandlerFunc(mux.NotFound)).Methods(http.MethodGet)
+	AssertOpenAPI(t, router, []byte(openAPITestSpec), OpenAPIOptions{
+		BasePath: "/v1",
+		Ignore:   func(route *mux.Route) bool { return route.GetName() == "health" },
+	})
+}
+
+func TestAssertOpenAPIDrift(t *testing.T) {
+	rt := &recordingT{T: t}
+	router := openAPITestRouter()
+	router.Handle("/v1/users/{id}", mux.HandlerFunc(mux.NotFound)).Methods(http.MethodPatch)
+	router.Handle("/v1/admin", mux.HandlerFunc(mux.NotFound))
+	AssertOpenAPI(rt, router, []byte(openAPITestSpec), OpenAPIOptions{BasePath: "/v1"})
+	want := []string{
+		"muxtest: route /health is outside of the base path /v1",
+		"muxtest: route PATCH /v1/users/{id} has no OpenAPI operation",
+		"muxtest: route /v1/admin has no OpenAPI operation",
+		"muxtest: OpenAPI operation GET /orders has no route",
+	}
+	if len(rt.failures) != len(want) {
+		t.Fatalf("expected failures %q, got %q", want, rt.failures)
+	}
+	for i := range want {
+		if rt.failures[i] != want[i] {
+			t.Errorf("expected %q, got %q", want[i], rt.failures[i])
+		}
+	}
+}
+
+func TestNormalizeOpenAPIPath(t *testing.T) {
+	for in, want := range map[string]string{
+		"/users/{id}":              "/users/{}",
+		"/users/{id:[0-9]{1,3}}/x": "/users/{}/x",
+		"/{a}-{b}.json":            "/{}-{}.json",
+		"/static":                  "/static",
+	} {
+		if got := normalizeOpenAPIPath(in); got != want {
+			t.Errorf("%s: expected %s, got %s", in, want, got)
+		}
+	}
+}
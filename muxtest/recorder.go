@@ -0,0 +1,197 @@
+package muxtest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Recorder is a ResponseWriter recording the response like
+// httptest.ResponseRecorder, which it embeds, and in addition the time of
+// each write and the chunks sent by flushes, for testing streaming handlers
+// such as server-sent events:
+//
+//	rec := muxtest.NewRecorder()
+//	go router.ServeHTTP(ctx, rec, req, nil)
+//	chunk, err := rec.NextChunk(ctx)
+//
+// It implements http.Flusher, http.Hijacker and io.ReaderFrom. It is safe
+// to wait for chunks while the handler writes.
+type Recorder struct {
+	*httptest.ResponseRecorder
+
+	start time.Time
+
+	mu       sync.Mutex
+	writes   []Write
+	chunks   []Chunk
+	pending  []byte
+	next     int
+	notify   chan struct{}
+	hijacked net.Conn
+}
+
+// Write is a write of the response body.
+type Write struct {
+	Data []byte
+	// At is the time of the write since the recorder was created.
+	At time.Duration
+}
+
+// Chunk is the data written between two flushes.
+type Chunk struct {
+	Data []byte
+	// At is the time of the flush since the recorder was created.
+	At time.Duration
+}
+
+// NewRecorder returns a new Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		ResponseRecorder: httptest.NewRecorder(),
+		start:            time.Now(),
+		notify:           make(chan struct{}),
+	}
+}
+
+// Write records b.
+func (r *Recorder) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.hijacked != nil {
+		return 0, http.ErrHijacked
+	}
+	r.record(b)
+	return r.ResponseRecorder.Write(b)
+}
+
+// WriteString records s.
+func (r *Recorder) WriteString(s string) (int, error) {
+	return r.Write([]byte(s))
+}
+
+// ReadFrom records the data read from src as a single write.
+func (r *Recorder) ReadFrom(src io.Reader) (int64, error) {
+	b, err := io.ReadAll(src)
+	if len(b) > 0 {
+		if _, werr := r.Write(b); werr != nil {
+			return 0, werr
+		}
+	}
+	return int64(len(b)), err
+}
+
+// WriteHeader records the status code.
+func (r *Recorder) WriteHeader(code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ResponseRecorder.WriteHeader(code)
+}
+
+// record records the write of b; r.mu is held.
+func (r *Recorder) record(b []byte) {
+	data := slices.Clone(b)
+	r.writes = append(r.writes, Write{Data: data, At: time.Since(r.start)})
+	r.pending = append(r.pending, data...)
+}
+
+// Flush ends the current chunk, unless no data was written since the last
+// flush.
+func (r *Recorder) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ResponseRecorder.Flush()
+	if len(r.pending) == 0 {
+		return
+	}
+	r.chunks = append(r.chunks, Chunk{Data: r.pending, At: time.Since(r.start)})
+	r.pending = nil
+	close(r.notify)
+	r.notify = make(chan struct{})
+}
+
+// Hijack takes over the connection, returning the server end of an
+// in-memory connection whose client end is returned by Conn.
+func (r *Recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.hijacked != nil {
+		return nil, nil, http.ErrHijacked
+	}
+	server, client := net.Pipe()
+	r.hijacked = client
+	close(r.notify)
+	r.notify = make(chan struct{})
+	return server, bufio.NewReadWriter(bufio.NewReader(server), bufio.NewWriter(server)), nil
+}
+
+// Conn returns the client end of the hijacked connection, or nil if the
+// connection wasn't hijacked.
+func (r *Recorder) Conn() net.Conn {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.hijacked
+}
+
+// Writes returns the writes of the response body.
+func (r *Recorder) Writes() []Write {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.writes)
+}
+
+// Chunks returns the flushed chunks. Data written after the last flush is
+// not included.
+func (r *Recorder) Chunks() []Chunk {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.chunks)
+}
+
+// NextChunk waits for the chunk after the one it returned last, returning
+// the error of ctx if it is done first.
+func (r *Recorder) NextChunk(ctx context.Context) (Chunk, error) {
+	for {
+		r.mu.Lock()
+		if r.next < len(r.chunks) {
+			chunk := r.chunks[r.next]
+			r.next++
+			r.mu.Unlock()
+			return chunk, nil
+		}
+		if r.hijacked != nil {
+			r.mu.Unlock()
+			return Chunk{}, errors.New("muxtest: connection hijacked")
+		}
+		notify := r.notify
+		r.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return Chunk{}, ctx.Err()
+		}
+	}
+}
+
+// ExpectChunks expects the flushed chunks to be chunks, reporting
+// differences to t.
+func (r *Recorder) ExpectChunks(t testing.TB, chunks ...string) {
+	t.Helper()
+	got := r.Chunks()
+	data := make([]string, len(got))
+	for i, chunk := range got {
+		data[i] = string(chunk.Data)
+	}
+	if !slices.Equal(data, chunks) {
+		t.Errorf("expected chunks %q, got %q", chunks, data)
+	}
+}
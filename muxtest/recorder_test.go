@@ -0,0 +1,102 @@
+package muxtest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRecorderChunks(t *testing.T) {
+	release := make(chan struct{})
+	router := mux.NewRouter()
+	router.HandleFunc("/events", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 2; i++ {
+			fmt.Fprintf(w, "data: %d\n", i)
+			fmt.Fprint(w, "\n")
+			if err := rc.Flush(); err != nil {
+				return err
+			}
+			<-release
+		}
+		_, err := io.Copy(w, strings.NewReader("tail"))
+		return err
+	})
+
+	rec := NewRecorder()
+	done := make(chan error)
+	go func() {
+		done <- router.ServeHTTP(context.Background(), rec, httptest.NewRequest(http.MethodGet, "/events", nil), nil)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		chunk, err := rec.NextChunk(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("data: %d\n\n", i); string(chunk.Data) != want {
+			t.Errorf("expected chunk %q, got %q", want, chunk.Data)
+		}
+		release <- struct{}{}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	rec.ExpectChunks(t, "data: 0\n\n", "data: 1\n\n")
+	if rec.Body.String() != "data: 0\n\ndata: 1\n\ntail" || !rec.Flushed {
+		t.Errorf("unexpected body %q, flushed %v", rec.Body.String(), rec.Flushed)
+	}
+	writes := rec.Writes()
+	if len(writes) != 5 || string(writes[4].Data) != "tail" || writes[4].At < writes[0].At {
+		t.Errorf("unexpected writes %+v", writes)
+	}
+
+	expired, cancelExpired := context.WithCancel(context.Background())
+	cancelExpired()
+	if _, err := rec.NextChunk(expired); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestRecorderHijack(t *testing.T) {
+	rec := NewRecorder()
+	if rec.Conn() != nil {
+		t.Fatal("expected no connection before hijacking")
+	}
+	go func() {
+		conn, rw, err := http.NewResponseController(rec).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		line, _ := rw.ReadString('\n')
+		rw.WriteString("echo " + line)
+		rw.Flush()
+	}()
+
+	var conn = rec.Conn()
+	for conn == nil {
+		time.Sleep(time.Millisecond)
+		conn = rec.Conn()
+	}
+	fmt.Fprint(conn, "hi\n")
+	if reply, err := bufio.NewReader(conn).ReadString('\n'); err != nil || reply != "echo hi\n" {
+		t.Errorf("unexpected reply %q, %v", reply, err)
+	}
+	if _, err := rec.Write([]byte("x")); err != http.ErrHijacked {
+		t.Errorf("expected %v, got %v", http.ErrHijacked, err)
+	}
+}
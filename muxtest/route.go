@@ -0,0 +1,96 @@
+package muxtest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteCase is a request and the route it is expected to match, see
+// AssertRoutes.
+type RouteCase struct {
+	// Method and URL of the request; the URL is a path or an absolute URL.
+	// Method defaults to GET.
+	Method string
+	URL    string
+	// Header holds the request headers.
+	Header http.Header
+
+	// Name is the name of the expected route.
+	Name string
+	// Vars, if not nil, are the expected route variables.
+	Vars map[string]string
+	// Err, if not nil, is the expected match error, mux.ErrNotFound or
+	// mux.ErrMethodMismatch. Name and Vars are ignored then.
+	Err error
+}
+
+// String returns the method and URL of the case.
+func (c RouteCase) String() string {
+	method := c.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	return method + " " + c.URL
+}
+
+// AssertRoutes checks the route each case matches with Router.MatchRequest,
+// reporting mismatches to t:
+//
+//	muxtest.AssertRoutes(t, router, []muxtest.RouteCase{
+//	    {URL: "/users/42", Name: "user", Vars: map[string]string{"id": "42"}},
+//	    {Method: http.MethodDelete, URL: "/users/42", Err: mux.ErrMethodMismatch},
+//	    {URL: "/unknown", Err: mux.ErrNotFound},
+//	})
+func AssertRoutes(t testing.TB, router *mux.Router, cases []RouteCase) {
+	t.Helper()
+	for _, c := range cases {
+		AssertRoute(t, router, c)
+	}
+}
+
+// AssertRoute checks the route c matches, see AssertRoutes.
+func AssertRoute(t testing.TB, router *mux.Router, c RouteCase) {
+	t.Helper()
+	method := c.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req := httptest.NewRequest(method, c.URL, nil)
+	for name, values := range c.Header {
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
+
+	result, err := router.MatchRequest(req)
+	if c.Err != nil {
+		if !errors.Is(err, c.Err) {
+			t.Errorf("%s: expected error %v, got %v", c, c.Err, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Errorf("%s: expected route %q, got error %v", c, c.Name, err)
+		return
+	}
+	if result.Name != c.Name {
+		t.Errorf("%s: expected route %q, got %q (%s)", c, c.Name, result.Name, result.PathTemplate)
+	}
+	if c.Vars != nil && !equalVars(result.Vars, c.Vars) {
+		t.Errorf("%s: expected vars %v, got %v", c, c.Vars, result.Vars)
+	}
+}
+
+func equalVars(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package muxtest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func routeTestRouter() *mux.Router {
+	handler := mux.HandlerFunc(mux.NotFound)
+	router := mux.NewRouter()
+	router.Handle("/users/{id}", handler).Methods(http.MethodGet).Name("user")
+	router.Handle("/echo", handler).Methods(http.MethodPost).Name("echo")
+	api := router.PathPrefix("/api").Subrouter()
+	api.Handle("/items", handler).Headers("Accept", "application/vnd.v2+json").Name("items.v2")
+	api.Handle("/items", handler).Name("items")
+	return router
+}
+
+func TestAssertRoutes(t *testing.T) {
+	AssertRoutes(t, routeTestRouter(), []RouteCase{
+		{URL: "/users/42", Name: "user", Vars: map[string]string{"id": "42"}},
+		{Method: http.MethodPost, URL: "/echo", Name: "echo"},
+		{URL: "/api/items", Name: "items"},
+		{URL: "/api/items", Header: http.Header{"Accept": {"application/vnd.v2+json"}}, Name: "items.v2"},
+		{Method: http.MethodDelete, URL: "/users/42", Err: mux.ErrMethodMismatch},
+		{URL: "/unknown", Err: mux.ErrNotFound},
+	})
+}
+
+func TestAssertRoutesFailures(t *testing.T) {
+	rt := &recordingT{T: t}
+	AssertRoutes(rt, routeTestRouter(), []RouteCase{
+		{URL: "/users/42", Name: "echo"},
+		{URL: "/users/42", Name: "user", Vars: map[string]string{"id": "1"}},
+		{URL: "/unknown", Name: "user"},
+		{URL: "/users/42", Err: mux.ErrNotFound},
+	})
+	if len(rt.failures) != 4 {
+		t.Errorf("expected 4 failures, got %q", rt.failures)
+	}
+}
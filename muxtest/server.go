@@ -0,0 +1,87 @@
+package muxtest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+)
+
+// serverErrorBuffer is the number of handler errors a Server buffers.
+const serverErrorBuffer = 64
+
+// Server is an httptest.Server serving a router, for end to end tests:
+//
+//	srv := muxtest.NewServer(router, binder)
+//	defer srv.Close()
+//	resp, err := srv.Client().Get(srv.URL + "/users/42")
+//
+// The requests are served with the base context and binder of the server.
+// Errors returned by the router are answered like by mux.Serve, with the
+// status of mux.ErrorStatus unless the handler wrote a response, and sent to
+// the Errors channel.
+type Server struct {
+	*httptest.Server
+	errors chan error
+}
+
+// ServerOption configures NewServer.
+type ServerOption func(*serverConfig)
+
+type serverConfig struct {
+	ctx context.Context
+	tls bool
+}
+
+// WithBaseContext sets the context the request contexts are derived from.
+// It defaults to context.Background.
+func WithBaseContext(ctx context.Context) ServerOption {
+	return func(c *serverConfig) {
+		c.ctx = ctx
+	}
+}
+
+// WithServerTLS serves HTTPS, see httptest.Server.StartTLS.
+func WithServerTLS() ServerOption {
+	return func(c *serverConfig) {
+		c.tls = true
+	}
+}
+
+// NewServer starts a server serving router with binder. It must be closed
+// with Close.
+func NewServer(router *mux.Router, binder mux.Binder, opts ...ServerOption) *Server {
+	config := &serverConfig{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(config)
+	}
+	s := &Server{errors: make(chan error, serverErrorBuffer)}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := mux.NewResponseRecorderWriter(w)
+		if err := router.ServeHTTP(r.Context(), rw, r, binder); err != nil {
+			if !rw.WroteHeader() {
+				status := mux.ErrorStatus(err)
+				http.Error(rw, http.StatusText(status), status)
+			}
+			select {
+			case s.errors <- err:
+			default:
+			}
+		}
+	}))
+	s.Config.BaseContext = func(net.Listener) context.Context { return config.ctx }
+	if config.tls {
+		s.StartTLS()
+	} else {
+		s.Start()
+	}
+	return s
+}
+
+// Errors returns the channel receiving the errors returned by the router.
+// Errors are dropped while the channel holds 64 unreceived errors.
+func (s *Server) Errors() <-chan error {
+	return s.errors
+}
@@ -0,0 +1,62 @@
+package muxtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestServer(t *testing.T) {
+	errFailed := errors.New("failed")
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		var in createUser
+		if err := binder.(mux.BodyBinder).Bind(r, &in); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, mux.Var(r, "id")+" "+in.Name+" "+ctx.Value(ctxKey{}).(string))
+		return err
+	})
+	router.HandleFunc("/fail", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder mux.Binder) error {
+		return errFailed
+	})
+
+	binder := NewMockBinder(t).Return(createUser{Name: "ann"})
+	for _, tls := range []bool{false, true} {
+		opts := []ServerOption{WithBaseContext(context.WithValue(context.Background(), ctxKey{}, "base"))}
+		if tls {
+			opts = append(opts, WithServerTLS())
+			binder.Return(createUser{Name: "bob"})
+		}
+		srv := NewServer(router, binder, opts...)
+
+		resp, err := srv.Client().Get(srv.URL + "/users/42")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if want := map[bool]string{false: "42 ann base", true: "42 bob base"}[tls]; string(body) != want {
+			t.Errorf("expected %q, got %q", want, body)
+		}
+
+		resp, err = srv.Client().Get(srv.URL + "/fail")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusInternalServerError || strings.TrimSpace(string(body)) != "Internal Server Error" {
+			t.Errorf("unexpected response %d %q", resp.StatusCode, body)
+		}
+		if err := <-srv.Errors(); err != errFailed {
+			t.Errorf("expected %v, got %v", errFailed, err)
+		}
+		srv.Close()
+	}
+}
@@ -231,17 +231,17 @@ type headerMatcherTest struct {
 
 var headerMatcherTests = []headerMatcherTest{
 	{
-		matcher: headerMatcher(map[string]string{"x-requested-with": "XMLHttpRequest"}),
+		matcher: newHeaderMatcher(map[string]string{"x-requested-with": "XMLHttpRequest"}),
 		headers: map[string]string{"X-Requested-With": "XMLHttpRequest"},
 		result:  true,
 	},
 	{
-		matcher: headerMatcher(map[string]string{"x-requested-with": ""}),
+		matcher: newHeaderMatcher(map[string]string{"x-requested-with": ""}),
 		headers: map[string]string{"X-Requested-With": "anything"},
 		result:  true,
 	},
 	{
-		matcher: headerMatcher(map[string]string{"x-requested-with": "XMLHttpRequest"}),
+		matcher: newHeaderMatcher(map[string]string{"x-requested-with": "XMLHttpRequest"}),
 		headers: map[string]string{},
 		result:  false,
 	},
@@ -699,7 +699,7 @@ func TestNewRegexp(t *testing.T) {
 	for pattern, paths := range tests {
 		p, _ = newRouteRegexp(pattern, regexpTypePath, routeRegexpOptions{})
 		for path, result := range paths {
-			matches = p.regexp.FindStringSubmatch(path)
+			matches = p.compiled.regexp.FindStringSubmatch(path)
 			if result == nil {
 				if matches != nil {
 					t.Errorf("%v should not match %v.", pattern, path)
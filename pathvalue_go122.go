@@ -0,0 +1,19 @@
+//go:build go1.22
+
+package mux
+
+import (
+	"context"
+	"net/http"
+)
+
+// withPathValues returns h setting the route variables as path values of the
+// request.
+func withPathValues(h Handler) Handler {
+	return HandlerFunc(func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		for name, value := range Vars(req) {
+			req.SetPathValue(name, value)
+		}
+		return h.ServeHTTP(ctx, w, req, binder)
+	})
+}
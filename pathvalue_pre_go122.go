@@ -0,0 +1,8 @@
+//go:build !go1.22
+
+package mux
+
+// withPathValues returns h, Request.PathValue requires Go 1.22.
+func withPathValues(h Handler) Handler {
+	return h
+}
@@ -0,0 +1,48 @@
+package mux
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+// AttachProfiler registers the net/http/pprof and expvar handlers on router
+// below prefix and returns the subrouter holding them:
+//
+//	{prefix}/pprof/            profile index
+//	{prefix}/pprof/cmdline     pprof.Cmdline
+//	{prefix}/pprof/profile     pprof.Profile
+//	{prefix}/pprof/symbol      pprof.Symbol
+//	{prefix}/pprof/trace       pprof.Trace
+//	{prefix}/pprof/{profile}   named profiles like heap or goroutine
+//	{prefix}/vars              expvar.Handler
+//
+// The given middlewares are applied to all of these routes only, which is the
+// place to add authentication:
+//
+//	mux.AttachProfiler(r, "/debug", adminOnly)
+//
+// Note that importing net/http/pprof and expvar registers their handlers on
+// http.DefaultServeMux as a side effect. Do not serve http.DefaultServeMux
+// publicly.
+func AttachProfiler(router *Router, prefix string, mwf ...MiddlewareFunc) *Router {
+	prefix = strings.TrimRight(prefix, "/")
+
+	sub := router.PathPrefix(prefix + "/").Subrouter()
+	sub.Use(mwf...)
+
+	sub.Handle("/pprof/", WrapHTTPHandler(http.HandlerFunc(pprof.Index)))
+	sub.Handle("/pprof/cmdline", WrapHTTPHandler(http.HandlerFunc(pprof.Cmdline)))
+	sub.Handle("/pprof/profile", WrapHTTPHandler(http.HandlerFunc(pprof.Profile)))
+	sub.Handle("/pprof/symbol", WrapHTTPHandler(http.HandlerFunc(pprof.Symbol)))
+	sub.Handle("/pprof/trace", WrapHTTPHandler(http.HandlerFunc(pprof.Trace)))
+	sub.HandleFunc("/pprof/{profile}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		pprof.Handler(Vars(r)["profile"]).ServeHTTP(w, r)
+		return nil
+	})
+	sub.Handle("/vars", WrapHTTPHandler(expvar.Handler()))
+
+	return sub
+}
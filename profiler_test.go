@@ -0,0 +1,49 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAttachProfiler(t *testing.T) {
+	router := NewRouter()
+	guard := &testMiddleware{}
+	AttachProfiler(router, "/debug/", guard.Middleware)
+	router.HandleFunc("/", dummyHandler)
+
+	tests := []struct {
+		path     string
+		contains string
+	}{
+		{"/debug/pprof/", "goroutine"},
+		{"/debug/pprof/goroutine?debug=1", "goroutine profile"},
+		{"/debug/pprof/cmdline", ""},
+		{"/debug/vars", "memstats"},
+	}
+
+	for _, tt := range tests {
+		rw := NewRecorder()
+		if err := router.ServeHTTP(context.Background(), rw, newRequest("GET", "http://localhost"+tt.path), nil); err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if rw.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d", tt.path, rw.Code)
+		}
+		if !strings.Contains(rw.Body.String(), tt.contains) {
+			t.Errorf("%s: expected body to contain %q", tt.path, tt.contains)
+		}
+	}
+
+	if guard.timesCalled != uint(len(tests)) {
+		t.Errorf("expected middleware to guard %d requests, got %d", len(tests), guard.timesCalled)
+	}
+
+	if err := router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if guard.timesCalled != uint(len(tests)) {
+		t.Error("profiler middleware must not apply to other routes")
+	}
+}
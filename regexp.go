@@ -5,19 +5,29 @@
 package mux
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type routeRegexpOptions struct {
 	strictSlash    bool
 	useEncodedPath bool
+	// If true, the template may end with an extension variable.
+	extensionVars bool
+	// If true, the regexps are compiled on first use and compile errors are
+	// logged to logger.
+	lazy   bool
+	logger Logger
+	// Regexps known to compile, which are compiled on first use.
+	compileCache *CompileCache
 }
 
 type regexpType int
@@ -65,13 +75,16 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 		endSlash = true
 	}
 	varsN := make([]string, len(idxs)/2)
-	varsR := make([]*regexp.Regexp, len(idxs)/2)
+	varsP := make([]string, len(idxs)/2)
 
 	var pattern, reverse strings.Builder
+	if typ == regexpTypeHost {
+		// Host names are case-insensitive, and requestHost lowercases them.
+		pattern.WriteString("(?i)")
+	}
 	pattern.WriteByte('^')
 
 	var end, colonIdx, groupIdx int
-	var err error
 	var patt, param, name string
 	for i := 0; i < len(idxs); i += 2 {
 		// Set all values we are interested in.
@@ -92,25 +105,42 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 			name = param[0:colonIdx]
 			patt = param[colonIdx+1:]
 		}
+		// A path variable followed by an extension variable matches as
+		// little as possible, leaving the extension to the latter.
+		if typ == regexpTypePath && options.extensionVars && colonIdx == -1 && i+2 < len(idxs) && idxs[i+2] == end && isExtensionTag(tpl[end:idxs[i+3]]) {
+			patt = defaultPattern + "?"
+		}
+		extension := typ == regexpTypePath && options.extensionVars && isExtensionTag(tag)
+		if extension {
+			name = name[1:]
+			if colonIdx == -1 {
+				patt = extensionPattern
+			}
+		}
 
 		// Name or pattern can't be empty.
 		if name == "" || patt == "" {
-			return nil, fmt.Errorf("mux: missing name or pattern in %q", tag)
+			return nil, &TemplateError{Template: template, Pos: idxs[i], Segment: tag, Err: fmt.Errorf("mux: missing name or pattern in %q", tag)}
+		}
+		if extension && end != len(tpl) {
+			return nil, &TemplateError{Template: template, Pos: idxs[i], Segment: tag, Err: fmt.Errorf("mux: extension variable %q must end the template", tag)}
 		}
 		// Build the regexp pattern.
 		groupName := varGroupName(groupIdx)
 
-		pattern.WriteString(regexp.QuoteMeta(raw) + "(?P<" + groupName + ">" + patt + ")")
+		if extension {
+			pattern.WriteString(regexp.QuoteMeta(raw) + `(?:\.(?P<` + groupName + ">" + patt + "))?")
+		} else {
+			pattern.WriteString(regexp.QuoteMeta(raw) + "(?P<" + groupName + ">" + patt + ")")
+		}
 
 		// Build the reverse template.
-		reverse.WriteString(raw + "%s")
+		// Percent signs in the template are literal.
+		reverse.WriteString(strings.ReplaceAll(raw, "%", "%%") + "%s")
 
-		// Append variable name and compiled pattern.
+		// Append variable name and pattern.
 		varsN[groupIdx] = name
-		varsR[groupIdx], err = RegexpCompileFunc("^" + patt + "$")
-		if err != nil {
-			return nil, fmt.Errorf("mux: error compiling regex for %q: %w", tag, err)
-		}
+		varsP[groupIdx] = patt
 	}
 	// Add the remaining.
 	raw := tpl[end:]
@@ -128,41 +158,120 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 		pattern.WriteByte('$')
 	}
 
-	// Compile full regexp.
-	patternStr := pattern.String()
-	reg, errCompile := RegexpCompileFunc(patternStr)
-	if errCompile != nil {
-		return nil, errCompile
-	}
-
-	// Check for capturing groups which used to work in older versions
-	if reg.NumSubexp() != len(idxs)/2 {
-		panic(fmt.Sprintf("route %s contains capture groups in its regexp. ", template) +
-			"Only non-capturing groups are accepted: e.g. (?:pattern) instead of (pattern)")
-	}
-
 	var wildcardHostPort bool
 	if typ == regexpTypeHost {
-		if !strings.Contains(patternStr, ":") {
+		if !strings.Contains(pattern.String(), ":") {
 			wildcardHostPort = true
 		}
 	}
-	reverse.WriteString(raw)
+	reverse.WriteString(strings.ReplaceAll(raw, "%", "%%"))
 	if endSlash {
 		reverse.WriteByte('/')
 	}
 
 	// Done!
-	return &routeRegexp{
+	static := (typ == regexpTypePath || typ == regexpTypePrefix) && len(idxs) == 0
+	template = intern(template)
+	literal := template[:len(tpl)]
+	var queryKey string
+	if typ == regexpTypeQuery {
+		queryKey, literal, _ = strings.Cut(template, "=")
+		// Without a value any value matches, like the default pattern.
+		static = len(idxs) == 0 && literal != ""
+	}
+	for i := range varsN {
+		varsN[i], varsP[i] = intern(varsN[i]), intern(varsP[i])
+	}
+	rr := &routeRegexp{
 		template:         template,
 		regexpType:       typ,
 		options:          options,
-		regexp:           reg,
-		reverse:          reverse.String(),
+		pattern:          intern(pattern.String()),
+		static:           static,
+		literal:          literal,
+		queryKey:         queryKey,
+		reverse:          intern(reverse.String()),
 		varsN:            varsN,
-		varsR:            varsR,
+		varsP:            varsP,
+		extension:        typ == regexpTypePath && options.extensionVars && len(idxs) > 0 && isExtensionTag(template[idxs[len(idxs)-2]:idxs[len(idxs)-1]]),
 		wildcardHostPort: wildcardHostPort,
-	}, nil
+		compiled:         new(compiledRegexp),
+	}
+	if !static && options.compileCache.contains(rr) {
+		rr.options.lazy = true
+	}
+	if !rr.options.lazy {
+		if err := rr.compile(); err != nil {
+			return nil, err
+		}
+	}
+	return rr, nil
+}
+
+// compiledRegexp holds the compiled regexps of a routeRegexp. It is shared
+// by the copies of the routeRegexp.
+type compiledRegexp struct {
+	once sync.Once
+	err  error
+	// Expanded regexp.
+	regexp *regexp.Regexp
+	// Variable regexps (validators).
+	varsR []*regexp.Regexp
+}
+
+// errCapturingGroups is returned by compileRegexps if the template contains
+// capturing groups.
+var errCapturingGroups = errors.New("mux: route regexp contains capturing groups")
+
+// compile compiles the regexps once and returns the compile error, if any.
+// It panics if the template contains capturing groups, unless the regexps
+// are compiled lazily: the error is then returned like other compile errors,
+// so that the route never matches instead of failing a request.
+func (r *routeRegexp) compile() error {
+	c := r.compiled
+	c.once.Do(func() {
+		if r.static {
+			// Matched by string comparison.
+			return
+		}
+		c.regexp, c.varsR, c.err = r.compileRegexps()
+		if c.err == errCapturingGroups {
+			if !r.options.lazy {
+				panic(fmt.Sprintf("route %s contains capture groups in its regexp. ", r.template) +
+					"Only non-capturing groups are accepted: e.g. (?:pattern) instead of (pattern)")
+			}
+			c.err = fmt.Errorf("%w in %q, only non-capturing groups are accepted: e.g. (?:pattern) instead of (pattern)", errCapturingGroups, r.template)
+		}
+		if c.err != nil && r.options.lazy {
+			r.options.logger.Log(context.Background(), slog.LevelError, "mux: invalid route", "template", r.template, "error", c.err)
+		}
+	})
+	return c.err
+}
+
+func (r *routeRegexp) compileRegexps() (*regexp.Regexp, []*regexp.Regexp, error) {
+	varsR := make([]*regexp.Regexp, len(r.varsP))
+	for i, patt := range r.varsP {
+		var err error
+		// The pattern is grouped so that alternations are anchored too.
+		if varsR[i], err = RegexpCompileFunc("^(?:" + patt + ")$"); err != nil {
+			tag := "{" + r.varsN[i] + ":" + patt + "}"
+			idxs, _ := braceIndices(r.template)
+			return nil, nil, &TemplateError{Template: r.template, Pos: idxs[2*i], Segment: tag, Err: fmt.Errorf("mux: error compiling regex for %q: %w", tag, err)}
+		}
+	}
+
+	// Compile full regexp.
+	reg, err := RegexpCompileFunc(r.pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Check for capturing groups which used to work in older versions
+	if reg.NumSubexp() != len(r.varsN) {
+		return nil, nil, errCapturingGroups
+	}
+	return reg, varsR, nil
 }
 
 // routeRegexp stores a regexp to match a host or path and information to
@@ -174,14 +283,27 @@ type routeRegexp struct {
 	regexpType regexpType
 	// Options for matching
 	options routeRegexpOptions
-	// Expanded regexp.
-	regexp *regexp.Regexp
+	// Expanded regexp pattern.
+	pattern string
+	// If static is true, the path template has no variables and the path is
+	// compared with literal: the template, without its trailing slash with
+	// strict slash. The query template of a static query matcher has no
+	// variables and the query value is compared with literal, the value of
+	// the template.
+	static  bool
+	literal string
+	// The query key of a query matcher.
+	queryKey string
 	// Reverse template.
 	reverse string
 	// Variable names.
 	varsN []string
-	// Variable regexps (validators).
-	varsR []*regexp.Regexp
+	// Variable patterns.
+	varsP []string
+	// If true, the last variable is an extension variable, see Route.Path.
+	extension bool
+	// Compiled regexps, see compile.
+	compiled *compiledRegexp
 	// Wildcard host-port (no strict port match in hostname)
 	wildcardHostPort bool
 }
@@ -189,14 +311,8 @@ type routeRegexp struct {
 // Match matches the regexp against the URL host or path.
 func (r *routeRegexp) Match(req *http.Request, match *RouteMatch) bool {
 	if r.regexpType == regexpTypeHost {
-		host := getHost(req)
-		if r.wildcardHostPort {
-			// Don't be strict on the port match
-			if i := strings.Index(host, ":"); i != -1 {
-				host = host[:i]
-			}
-		}
-		return r.regexp.MatchString(host)
+		host := match.requestHost(req, r.wildcardHostPort)
+		return r.compile() == nil && r.compiled.regexp.MatchString(host)
 	}
 
 	if r.regexpType == regexpTypeQuery {
@@ -206,16 +322,43 @@ func (r *routeRegexp) Match(req *http.Request, match *RouteMatch) bool {
 	if r.options.useEncodedPath {
 		path = req.URL.EscapedPath()
 	}
-	return r.regexp.MatchString(path)
+	if r.static {
+		return r.matchStatic(path)
+	}
+	return r.compile() == nil && r.compiled.regexp.MatchString(path)
+}
+
+// matchStatic matches a path against a template without variables like the
+// expanded regexp would.
+func (r *routeRegexp) matchStatic(path string) bool {
+	if r.regexpType == regexpTypePrefix {
+		return strings.HasPrefix(path, r.literal)
+	}
+	if r.options.strictSlash && len(path) == len(r.literal)+1 && path[len(path)-1] == '/' {
+		path = path[:len(path)-1]
+	}
+	return path == r.literal
 }
 
 // url builds a URL part using the given values.
 func (r *routeRegexp) url(values map[string]string) (string, error) {
+	if err := r.compile(); err != nil {
+		return "", err
+	}
 	urlValues := make([]interface{}, len(r.varsN))
 	for k, v := range r.varsN {
 		value, ok := values[v]
-		if !ok {
-			return "", fmt.Errorf("mux: missing route variable %q", v)
+		if r.isExtensionVar(k) {
+			// The extension is optional. It is checked on its own as the
+			// previous variable may match it too.
+			if value != "" {
+				if !r.compiled.varsR[k].MatchString(value) {
+					return "", &URLError{Template: r.template, Variable: v, Err: fmt.Errorf("value %q doesn't match %q", value, r.varsP[k])}
+				}
+				value = "." + value
+			}
+		} else if !ok {
+			return "", &URLError{Template: r.template, Variable: v, Err: errMissingVariable}
 		}
 		if r.regexpType == regexpTypeQuery {
 			value = url.QueryEscape(value)
@@ -223,15 +366,16 @@ func (r *routeRegexp) url(values map[string]string) (string, error) {
 		urlValues[k] = value
 	}
 	rv := fmt.Sprintf(r.reverse, urlValues...)
-	if !r.regexp.MatchString(rv) {
+	if !r.static && !r.compiled.regexp.MatchString(rv) {
 		// The URL is checked against the full regexp, instead of checking
 		// individual variables. This is faster but to provide a good error
 		// message, we check individual regexps if the URL doesn't match.
 		for k, v := range r.varsN {
-			if !r.varsR[k].MatchString(values[v]) {
-				return "", fmt.Errorf(
-					"mux: variable %q doesn't match, expected %q", values[v],
-					r.varsR[k].String())
+			if r.isExtensionVar(k) && values[v] == "" {
+				continue
+			}
+			if !r.compiled.varsR[k].MatchString(values[v]) {
+				return "", &URLError{Template: r.template, Variable: v, Err: fmt.Errorf("value %q doesn't match %q", values[v], r.varsP[k])}
 			}
 		}
 	}
@@ -245,44 +389,42 @@ func (r *routeRegexp) getURLQuery(req *http.Request) string {
 	if r.regexpType != regexpTypeQuery {
 		return ""
 	}
-	templateKey := strings.SplitN(r.template, "=", 2)[0]
-	val, ok := findFirstQueryKey(req.URL.RawQuery, templateKey)
+	val, ok := findFirstQueryKey(req.URL.RawQuery, r.queryKey)
 	if ok {
-		return templateKey + "=" + val
+		return r.queryKey + "=" + val
 	}
 	return ""
 }
 
 // findFirstQueryKey returns the same result as (*url.URL).Query()[key][0].
-// If key was not found, empty string and false is returned.
+// If key was not found, empty string and false is returned. It only
+// allocates to unescape the value of key.
 func findFirstQueryKey(rawQuery, key string) (value string, ok bool) {
-	query := []byte(rawQuery)
+	query := rawQuery
 	for len(query) > 0 {
 		foundKey := query
-		if i := bytes.IndexAny(foundKey, "&;"); i >= 0 {
+		if i := strings.IndexAny(foundKey, "&;"); i >= 0 {
 			foundKey, query = foundKey[:i], foundKey[i+1:]
 		} else {
-			query = query[:0]
+			query = ""
 		}
 		if len(foundKey) == 0 {
 			continue
 		}
-		var value []byte
-		if i := bytes.IndexByte(foundKey, '='); i >= 0 {
-			foundKey, value = foundKey[:i], foundKey[i+1:]
-		}
+		foundKey, value, _ := strings.Cut(foundKey, "=")
 		if len(foundKey) < len(key) {
 			// Cannot possibly be key.
 			continue
 		}
-		keyString, err := url.QueryUnescape(string(foundKey))
+		// Unescaping a string without escapes does not allocate.
+		keyString, err := url.QueryUnescape(foundKey)
 		if err != nil {
 			continue
 		}
 		if keyString != key {
 			continue
 		}
-		valueString, err := url.QueryUnescape(string(value))
+		valueString, err := url.QueryUnescape(value)
 		if err != nil {
 			continue
 		}
@@ -291,10 +433,26 @@ func findFirstQueryKey(rawQuery, key string) (value string, ok bool) {
 	return "", false
 }
 
+// matchQueryString matches the query value of the request. Only variable
+// values are matched with the regexp, which requires building the key/value
+// pair.
 func (r *routeRegexp) matchQueryString(req *http.Request) bool {
-	return r.regexp.MatchString(r.getURLQuery(req))
+	val, ok := findFirstQueryKey(req.URL.RawQuery, r.queryKey)
+	switch {
+	case !ok:
+		return false
+	case r.static:
+		return val == r.literal
+	case len(r.varsN) == 0:
+		// The default pattern ".*" matches anything but newlines.
+		return !strings.Contains(val, "\n")
+	}
+	return r.compile() == nil && r.compiled.regexp.MatchString(r.queryKey+"="+val)
 }
 
+// errUnbalancedBraces is the error of templates with unbalanced braces.
+var errUnbalancedBraces = errors.New("mux: unbalanced braces")
+
 // braceIndices returns the first level curly brace indices from a string.
 // It returns an error in case of unbalanced braces.
 func braceIndices(s string) ([]int, error) {
@@ -310,12 +468,12 @@ func braceIndices(s string) ([]int, error) {
 			if level--; level == 0 {
 				idxs = append(idxs, idx, i+1)
 			} else if level < 0 {
-				return nil, fmt.Errorf("mux: unbalanced braces in %q", s)
+				return nil, newTemplateError(s, i, errUnbalancedBraces)
 			}
 		}
 	}
 	if level != 0 {
-		return nil, fmt.Errorf("mux: unbalanced braces in %q", s)
+		return nil, newTemplateError(s, idx, errUnbalancedBraces)
 	}
 	return idxs, nil
 }
@@ -341,16 +499,10 @@ func (v routeRegexpGroup) setMatch(req *http.Request, m *RouteMatch, r *Route) {
 	// Store host variables.
 	if v.host != nil {
 		if len(v.host.varsN) > 0 {
-			host := getHost(req)
-			if v.host.wildcardHostPort {
-				// Don't be strict on the port match
-				if i := strings.Index(host, ":"); i != -1 {
-					host = host[:i]
-				}
-			}
-			matches := v.host.regexp.FindStringSubmatchIndex(host)
+			host := m.requestHost(req, v.host.wildcardHostPort)
+			matches := v.host.compiled.regexp.FindStringSubmatchIndex(host)
 			if len(matches) > 0 {
-				m.Vars = extractVars(host, matches, v.host.varsN, m.Vars)
+				m.setVars(host, matches, v.host.varsN)
 			}
 		}
 	}
@@ -361,9 +513,14 @@ func (v routeRegexpGroup) setMatch(req *http.Request, m *RouteMatch, r *Route) {
 	// Store path variables.
 	if v.path != nil {
 		if len(v.path.varsN) > 0 {
-			matches := v.path.regexp.FindStringSubmatchIndex(path)
+			matches := v.path.compiled.regexp.FindStringSubmatchIndex(path)
 			if len(matches) > 0 {
-				m.Vars = extractVars(path, matches, v.path.varsN, m.Vars)
+				m.setVars(path, matches, v.path.varsN)
+				if v.path.extension && matches[len(matches)-1] < 0 {
+					if format := v.path.negotiateExtension(req); format != "" {
+						m.setVar(v.path.varsN[len(v.path.varsN)-1], format)
+					}
+				}
 			}
 		}
 		// Check if we should redirect.
@@ -383,6 +540,7 @@ func (v routeRegexpGroup) setMatch(req *http.Request, m *RouteMatch, r *Route) {
 					handler.ServeHTTP(writer, request)
 					return nil
 				})
+				m.chain = nil
 			}
 		}
 	}
@@ -390,14 +548,42 @@ func (v routeRegexpGroup) setMatch(req *http.Request, m *RouteMatch, r *Route) {
 	for _, q := range v.queries {
 		if len(q.varsN) > 0 {
 			queryURL := q.getURLQuery(req)
-			matches := q.regexp.FindStringSubmatchIndex(queryURL)
+			matches := q.compiled.regexp.FindStringSubmatchIndex(queryURL)
 			if len(matches) > 0 {
-				m.Vars = extractVars(queryURL, matches, q.varsN, m.Vars)
+				m.setVars(queryURL, matches, q.varsN)
 			}
 		}
 	}
 }
 
+// matchHost is the request host seen by host matchers. It is parsed once per
+// request and match.
+type matchHost struct {
+	req  *http.Request
+	host string
+	// name is host without the port.
+	name string
+}
+
+// requestHost returns the lowercased host of req, without the port if
+// stripPort is true.
+func (m *RouteMatch) requestHost(req *http.Request, stripPort bool) string {
+	h := &m.host
+	if h.req != req {
+		h.req = req
+		h.host = strings.ToLower(getHost(req))
+		h.name = h.host
+		// Don't be strict on the port match
+		if i := strings.Index(h.host, ":"); i != -1 {
+			h.name = h.host[:i]
+		}
+	}
+	if stripPort {
+		return h.name
+	}
+	return h.host
+}
+
 // getHost tries its best to return the request host.
 // According to section 14.23 of RFC 2616 the Host header
 // can include the port number if the default value of 80 is not used.
@@ -407,13 +593,3 @@ func getHost(r *http.Request) string {
 	}
 	return r.Host
 }
-
-func extractVars(input string, matches []int, names []string, output map[string]string) map[string]string {
-	for i, name := range names {
-		if output == nil {
-			output = make(map[string]string, len(names))
-		}
-		output[name] = input[matches[2*i+2]:matches[2*i+3]]
-	}
-	return output
-}
@@ -3,6 +3,7 @@ package mux
 import (
 	"net/url"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"testing"
@@ -33,6 +34,67 @@ func Test_newRouteRegexp_Errors(t *testing.T) {
 	}
 }
 
+func Test_routeRegexp_matchStatic(t *testing.T) {
+	templates := []string{"/", "/a", "/a/", "/a.b", "/a//", "/a+b"}
+	paths := []string{"", "/", "//", "/a", "/a/", "/a//", "/a///", "/ab", "/a.b", "/axb", "/a+b", "/aab"}
+
+	for _, typ := range []regexpType{regexpTypePath, regexpTypePrefix} {
+		for _, strictSlash := range []bool{false, true} {
+			for _, tpl := range templates {
+				rr, err := newRouteRegexp(tpl, typ, routeRegexpOptions{strictSlash: strictSlash})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !rr.static {
+					t.Fatalf("expected %q to be static", tpl)
+				}
+				for _, path := range paths {
+					if got, expected := rr.matchStatic(path), regexp.MustCompile(rr.pattern).MatchString(path); got != expected {
+						t.Errorf("%q (type %v, strictSlash %v) matching %q: expected %v, got %v", tpl, typ, strictSlash, path, expected, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_routeRegexp_matchQueryString(t *testing.T) {
+	templates := []string{"a=", "a=b", "a=b.c", "a={v}", "a={v:[0-9]+}", "a+b=c", "a%20b="}
+	queries := []string{"", "a", "a=", "a=b", "a=bb", "a=bxc", "a=b.c", "a=1", "a=%0A", "b=b", "a=x&a=b", "a+b=c", "a%20b=1", "a=b;c=d"}
+
+	for _, tpl := range templates {
+		rr, err := newRouteRegexp(tpl, regexpTypeQuery, routeRegexpOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		re := regexp.MustCompile(rr.pattern)
+		for _, query := range queries {
+			req := newRequest("GET", "http://localhost/?"+query)
+			// The key/value pair was matched before the fast paths were added.
+			var pair string
+			if val, ok := findFirstQueryKey(query, rr.queryKey); ok {
+				pair = rr.queryKey + "=" + val
+			}
+			if got, expected := rr.matchQueryString(req), re.MatchString(pair); got != expected {
+				t.Errorf("%q matching %q: expected %v, got %v", tpl, query, expected, got)
+			}
+		}
+	}
+}
+
+func Test_routeRegexp_matchQueryStringAllocs(t *testing.T) {
+	for _, tpl := range []string{"format=json", "format="} {
+		rr, err := newRouteRegexp(tpl, regexpTypeQuery, routeRegexpOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := newRequest("GET", "http://localhost/?page=2&format=json")
+		if n := testing.AllocsPerRun(100, func() { rr.matchQueryString(req) }); n != 0 {
+			t.Errorf("%q: expected no allocations, got %v", tpl, n)
+		}
+	}
+}
+
 func Test_findFirstQueryKey(t *testing.T) {
 	tests := []string{
 		"a=1&b=2",
@@ -115,3 +177,74 @@ func Benchmark_findQueryKeyGoLib(b *testing.B) {
 		})
 	}
 }
+
+func Test_RouteMatch_requestHost(t *testing.T) {
+	var match RouteMatch
+	req := newRequest("GET", "http://example.com:8080/")
+	if host := match.requestHost(req, false); host != "example.com:8080" {
+		t.Errorf("unexpected host %q", host)
+	}
+	if host := match.requestHost(req, true); host != "example.com" {
+		t.Errorf("unexpected host without port %q", host)
+	}
+
+	// The memoized host must not leak into the match of another request.
+	req = newRequest("GET", "http://other.com/")
+	if host := match.requestHost(req, true); host != "other.com" {
+		t.Errorf("expected the host of the new request, got %q", host)
+	}
+
+	req = newRequest("GET", "http://ACME.Example.com:8080/")
+	if host := match.requestHost(req, true); host != "acme.example.com" {
+		t.Errorf("expected the lowercased host, got %q", host)
+	}
+}
+
+func Test_routeRegexp_hostCase(t *testing.T) {
+	router := NewRouter()
+	router.Host("API.example.com").Path("/api").HandlerFunc(dummyHandler)
+	router.Host("{tenant}.example.com").Path("/dashboard").HandlerFunc(dummyHandler)
+	router.Host("{tenant:[a-z]+}.example.org").HandlerFunc(dummyHandler)
+
+	tests := []struct {
+		url    string
+		tenant string
+	}{
+		{url: "http://api.Example.COM/api"},
+		{url: "http://ACME.Example.com/dashboard", tenant: "acme"},
+		{url: "http://ACME.example.org/", tenant: "acme"},
+	}
+	for _, tt := range tests {
+		var match RouteMatch
+		if !router.Match(newRequest("GET", tt.url), &match) {
+			t.Errorf("%s: expected a match", tt.url)
+			continue
+		}
+		if match.Vars["tenant"] != tt.tenant {
+			t.Errorf("%s: expected tenant %q, got %q", tt.url, tt.tenant, match.Vars["tenant"])
+		}
+	}
+}
+
+func Test_routeRegexp_urlPercent(t *testing.T) {
+	for _, tpl := range []string{"/100%", "/100%/{id}", "/{id}/%s"} {
+		route := new(Route).Path(tpl)
+		u, err := route.URLPath("id", "1")
+		if err != nil {
+			t.Fatalf("%s: %v", tpl, err)
+		}
+		if want := strings.ReplaceAll(tpl, "{id}", "1"); u.Path != want {
+			t.Errorf("%s: expected %q, got %q", tpl, want, u.Path)
+		}
+	}
+}
+
+func Test_routeRegexp_urlAlternation(t *testing.T) {
+	route := new(Route).Path("/{v:a|b}")
+	if _, err := route.URLPath("v", "ax"); err == nil {
+		t.Error("expected an error building a URL with a value matching neither alternative")
+	}
+	if u, err := route.URLPath("v", "b"); err != nil || u.Path != "/b" {
+		t.Errorf("expected /b, got %v, %v", u, err)
+	}
+}
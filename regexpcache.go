@@ -0,0 +1,118 @@
+package mux
+
+import (
+	"container/list"
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultRegexpCacheSize is the number of expressions kept by
+// DefaultRegexpCache.
+const DefaultRegexpCacheSize = 1024
+
+// DefaultRegexpCache is the cache used by the default RegexpCompileFunc.
+var DefaultRegexpCache = NewRegexpCache(DefaultRegexpCacheSize)
+
+// RegexpCache is a size-bounded cache of compiled regular expressions, safe
+// for concurrent use. Its Compile method can be used as RegexpCompileFunc, so
+// that routes sharing patterns share the compiled regexp. The least recently
+// used expressions are evicted first.
+type RegexpCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// RegexpCacheStats describes the use of a RegexpCache.
+type RegexpCacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Size is the number of cached expressions.
+	Size int
+}
+
+// HitRate returns the share of lookups served from the cache, or zero if
+// there were none.
+func (s RegexpCacheStats) HitRate() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+type regexpCacheEntry struct {
+	expr   string
+	regexp *regexp.Regexp
+}
+
+// NewRegexpCache returns a cache holding up to size expressions. A size of
+// zero or less disables caching.
+func NewRegexpCache(size int) *RegexpCache {
+	return &RegexpCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Compile returns the compiled expr like regexp.Compile. Errors are not
+// cached.
+func (c *RegexpCache) Compile(expr string) (*regexp.Regexp, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[expr]; ok {
+		c.lru.MoveToFront(e)
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return e.Value.(*regexpCacheEntry).regexp, nil
+	}
+	c.mu.Unlock()
+	c.misses.Add(1)
+
+	// Compile outside the lock, a concurrent miss compiles the same
+	// expression at worst.
+	re, err := regexp.Compile(expr)
+	if err != nil || c.size <= 0 {
+		return re, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[expr]; ok {
+		c.lru.MoveToFront(e)
+		return e.Value.(*regexpCacheEntry).regexp, nil
+	}
+	c.entries[expr] = c.lru.PushFront(&regexpCacheEntry{expr: expr, regexp: re})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*regexpCacheEntry).expr)
+	}
+	return re, nil
+}
+
+// Stats returns the cache statistics.
+func (c *RegexpCache) Stats() RegexpCacheStats {
+	c.mu.Lock()
+	size := c.lru.Len()
+	c.mu.Unlock()
+	return RegexpCacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Size:   size,
+	}
+}
+
+// Reset removes all expressions and clears the statistics.
+func (c *RegexpCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.lru.Init()
+	c.hits.Store(0)
+	c.misses.Store(0)
+}
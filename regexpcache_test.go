@@ -0,0 +1,73 @@
+package mux
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestRegexpCache(t *testing.T) {
+	cache := NewRegexpCache(2)
+
+	a1, err := cache.Compile("^a$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, _ := cache.Compile("^a$")
+	if a1 != a2 {
+		t.Error("expected the cached regexp to be reused")
+	}
+	if _, err := cache.Compile("("); err == nil {
+		t.Error("expected a compile error")
+	}
+
+	// Evicts the least recently used "^b$".
+	_, _ = cache.Compile("^b$")
+	_, _ = cache.Compile("^a$")
+	_, _ = cache.Compile("^c$")
+	if b, _ := cache.Compile("^b$"); b == nil {
+		t.Fatal("expected regexp")
+	}
+
+	expected := RegexpCacheStats{Hits: 2, Misses: 5, Size: 2}
+	if stats := cache.Stats(); stats != expected {
+		t.Errorf("expected %+v, got %+v", expected, stats)
+	}
+	if rate := expected.HitRate(); rate != 2.0/7 {
+		t.Errorf("unexpected hit rate %v", rate)
+	}
+
+	cache.Reset()
+	if stats := cache.Stats(); stats != (RegexpCacheStats{}) {
+		t.Errorf("expected empty stats after reset, got %+v", stats)
+	}
+}
+
+func TestRegexpCacheConcurrent(t *testing.T) {
+	cache := NewRegexpCache(8)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := cache.Compile(fmt.Sprintf("^%d$", (i+j)%16)); err != nil {
+					t.Error(err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if stats := cache.Stats(); stats.Size != 8 || stats.Hits+stats.Misses != 800 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestRegexpCacheDisabled(t *testing.T) {
+	cache := NewRegexpCache(0)
+	a1, _ := cache.Compile("^a$")
+	a2, _ := cache.Compile("^a$")
+	if a1 == a2 || cache.Stats().Size != 0 {
+		t.Error("expected a cache of size zero not to cache")
+	}
+}
@@ -0,0 +1,284 @@
+package mux
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RecordedRequest is a request captured by a RequestRecorder, which can be
+// replayed with Replay.
+type RecordedRequest struct {
+	Time   time.Time   `json:"time"`
+	Method string      `json:"method"`
+	Host   string      `json:"host"`
+	URI    string      `json:"uri"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+	// Truncated is true if the body was longer than the recorded part.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// NewRequest returns a request equal to the recorded one, up to the redacted
+// values and the truncated body.
+func (rr *RecordedRequest) NewRequest(ctx context.Context) (*http.Request, error) {
+	u, err := url.ParseRequestURI(rr.URI)
+	if err != nil {
+		return nil, fmt.Errorf("mux: recorded request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, rr.Method, u.String(), bytes.NewReader(rr.Body))
+	if err != nil {
+		return nil, fmt.Errorf("mux: recorded request: %w", err)
+	}
+	req.Host = rr.Host
+	req.RequestURI = rr.URI
+	req.Header = rr.Header.Clone()
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	return req, nil
+}
+
+// RecordOptions configures a RequestRecorder.
+type RecordOptions struct {
+	// Filter selects the requests to record. Nil records all requests.
+	Filter func(r *http.Request) bool
+	// Output receives every recorded request as a line of JSON, which
+	// ReadRecordedRequests reads. It may be nil if Callback is set. Writes to
+	// Output are serialized.
+	Output io.Writer
+	// Callback is invoked with every recorded request.
+	Callback func(ctx context.Context, req *RecordedRequest)
+	// MaxBodySize caps the number of body bytes recorded. Zero selects a
+	// default of 64KiB, a negative value disables body recording.
+	MaxBodySize int64
+	// RedactHeaders lists header names whose values are replaced.
+	// Authorization, Cookie and Proxy-Authorization are always redacted.
+	RedactHeaders []string
+	// RedactQuery lists query parameters whose values are replaced.
+	RedactQuery []string
+	// Enabled sets the initial state of the recorder.
+	Enabled bool
+}
+
+// RequestRecorder records sanitized requests, to reproduce routing and
+// binding issues of production traffic locally with Replay. Like the
+// DumpMiddleware it can be switched on and off at runtime and costs a single
+// atomic load per request while disabled.
+//
+// As a middleware it records the requests matching a route. To record all
+// requests, including the ones no route matches, wrap the router:
+//
+//	rec := mux.NewRequestRecorder(mux.RecordOptions{
+//	    Output:      f,
+//	    Filter:      func(r *http.Request) bool { return strings.HasPrefix(r.URL.Path, "/orders") },
+//	    RedactQuery: []string{"token"},
+//	    Enabled:     true,
+//	})
+//	root := mux.NewRouter()
+//	root.NewRoute().Handler(rec.Middleware(router.ServeHTTP))
+//
+// and replay them later:
+//
+//	requests, err := mux.ReadRecordedRequests(f)
+//	results, err := mux.Replay(ctx, router, requests, binder)
+type RequestRecorder struct {
+	enabled atomic.Bool
+	options RecordOptions
+	redact  map[string]bool
+	mu      sync.Mutex
+}
+
+// NewRequestRecorder returns a RequestRecorder configured with options.
+func NewRequestRecorder(options RecordOptions) *RequestRecorder {
+	if options.MaxBodySize == 0 {
+		options.MaxBodySize = defaultDumpBodySize
+	}
+
+	rec := &RequestRecorder{
+		options: options,
+		redact: map[string]bool{
+			"Authorization":       true,
+			"Cookie":              true,
+			"Proxy-Authorization": true,
+		},
+	}
+	for _, h := range options.RedactHeaders {
+		rec.redact[http.CanonicalHeaderKey(h)] = true
+	}
+	rec.enabled.Store(options.Enabled)
+
+	return rec
+}
+
+// SetEnabled switches recording on or off.
+func (rec *RequestRecorder) SetEnabled(enabled bool) {
+	rec.enabled.Store(enabled)
+}
+
+// Enabled reports whether recording is switched on.
+func (rec *RequestRecorder) Enabled() bool {
+	return rec.enabled.Load()
+}
+
+// Middleware implements the middleware interface.
+func (rec *RequestRecorder) Middleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		if rec.enabled.Load() && (rec.options.Filter == nil || rec.options.Filter(req)) {
+			rec.emit(ctx, req, rec.record(req))
+		}
+		return next(ctx, w, req, binder)
+	}
+}
+
+// record captures req and restores its body so the handler can still
+// consume it in full.
+func (rec *RequestRecorder) record(req *http.Request) *RecordedRequest {
+	rr := &RecordedRequest{
+		Time:   time.Now(),
+		Method: req.Method,
+		Host:   req.Host,
+		URI:    rec.redactURI(req.URL),
+		Header: req.Header.Clone(),
+	}
+	for k := range rr.Header {
+		if rec.redact[k] {
+			rr.Header[k] = []string{redactedValue}
+		}
+	}
+
+	if rec.options.MaxBodySize > 0 && req.Body != nil && req.Body != http.NoBody {
+		buf := new(bytes.Buffer)
+		_, _ = io.CopyN(buf, req.Body, rec.options.MaxBodySize+1)
+		rr.Body = buf.Bytes()
+		if int64(len(rr.Body)) > rec.options.MaxBodySize {
+			rr.Body, rr.Truncated = rr.Body[:rec.options.MaxBodySize], true
+		}
+		req.Body = &multiReadCloser{
+			Reader: io.MultiReader(bytes.NewReader(buf.Bytes()), req.Body),
+			Closer: req.Body,
+		}
+	}
+	return rr
+}
+
+// redactURI returns the request URI of u with the values of the redacted
+// query parameters replaced.
+func (rec *RequestRecorder) redactURI(u *url.URL) string {
+	if len(rec.options.RedactQuery) == 0 || u.RawQuery == "" {
+		return u.RequestURI()
+	}
+	query := u.Query()
+	for _, name := range rec.options.RedactQuery {
+		if values, ok := query[name]; ok {
+			for i := range values {
+				values[i] = redactedValue
+			}
+		}
+	}
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.RequestURI()
+}
+
+func (rec *RequestRecorder) emit(ctx context.Context, req *http.Request, rr *RecordedRequest) {
+	if rec.options.Output != nil {
+		line, err := json.Marshal(rr)
+		if err == nil {
+			line = append(line, '\n')
+			rec.mu.Lock()
+			_, err = rec.options.Output.Write(line)
+			rec.mu.Unlock()
+		}
+		if err != nil {
+			requestLogger(req).Log(ctx, slog.LevelError, "mux: writing recorded request failed", "error", err)
+		}
+	}
+
+	if rec.options.Callback != nil {
+		rec.options.Callback(ctx, rr)
+	}
+}
+
+// ReadRecordedRequests reads the requests written by a RequestRecorder to
+// its Output.
+func ReadRecordedRequests(r io.Reader) ([]*RecordedRequest, error) {
+	var requests []*RecordedRequest
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var rr RecordedRequest
+		if err := json.Unmarshal(scanner.Bytes(), &rr); err != nil {
+			return nil, fmt.Errorf("mux: recorded request on line %d: %w", line, err)
+		}
+		requests = append(requests, &rr)
+	}
+	return requests, scanner.Err()
+}
+
+// ReplayResult is the outcome of a replayed request.
+type ReplayResult struct {
+	Request *RecordedRequest
+	// Match is the route the request matched, nil if it matched none.
+	Match *MatchResult
+	// MatchErr is the error of Router.MatchRequest, ErrNotFound or
+	// ErrMethodMismatch.
+	MatchErr error
+	// Status is the response status, as sent by Serve, and Err the error
+	// returned by the router.
+	Status int
+	Err    error
+}
+
+// Replay serves the recorded requests with router and binder, discarding
+// the responses, and returns the route matched by each request and the
+// outcome. It returns an error only for requests which can't be rebuilt.
+func Replay(ctx context.Context, router *Router, requests []*RecordedRequest, binder Binder) ([]ReplayResult, error) {
+	results := make([]ReplayResult, 0, len(requests))
+	for _, rr := range requests {
+		req, err := rr.NewRequest(ctx)
+		if err != nil {
+			return results, err
+		}
+		result := ReplayResult{Request: rr}
+		result.Match, result.MatchErr = router.MatchRequest(req)
+
+		req, _ = rr.NewRequest(ctx)
+		rw := NewResponseRecorderWriter(&discardResponseWriter{header: make(http.Header)})
+		result.Err = router.ServeHTTP(ctx, rw, req, binder)
+		result.Status = rw.StatusOrDefault(result.Err)
+		if result.Err != nil && !rw.WroteHeader() {
+			result.Status = ErrorStatus(result.Err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// discardResponseWriter is a ResponseWriter discarding the response.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(int) {}
@@ -0,0 +1,96 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestRecorder(t *testing.T) {
+	var out bytes.Buffer
+	rec := NewRequestRecorder(RecordOptions{
+		Output:        &out,
+		Filter:        func(r *http.Request) bool { return r.URL.Path != "/health" },
+		MaxBodySize:   5,
+		RedactHeaders: []string{"x-api-key"},
+		RedactQuery:   []string{"token"},
+	})
+
+	var bodies []string
+	router := NewRouter()
+	router.HandleFunc("/orders/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		body, err := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		return err
+	}).Methods(http.MethodPost).Name("order")
+	router.HandleFunc("/health", dummyHandler)
+	root := NewRouter().SkipClean(true)
+	root.NewRoute().Handler(rec.Middleware(router.ServeHTTP))
+
+	serve := func(method, target, body string) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("X-Api-Key", "secret")
+		req.Header.Set("Content-Type", "application/json")
+		if err := root.ServeHTTP(context.Background(), NewRecorder(), req, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	serve(http.MethodPost, "/orders/1", `{"a":1}`)
+	if out.Len() != 0 {
+		t.Fatalf("disabled recorder recorded %q", out.String())
+	}
+	rec.SetEnabled(true)
+	serve(http.MethodPost, "/orders/1?token=secret&x=1", `{"a":1}`)
+	serve(http.MethodGet, "/health", "")
+	serve(http.MethodGet, "/missing", "")
+	if len(bodies) != 2 || bodies[1] != `{"a":1}` {
+		t.Errorf("handler did not receive the full body, got %q", bodies)
+	}
+
+	requests, err := ReadRecordedRequests(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 recorded requests, got %d", len(requests))
+	}
+	rr := requests[0]
+	if rr.Method != http.MethodPost || rr.URI != "/orders/1?token=%5BREDACTED%5D&x=1" || rr.Host != "example.com" {
+		t.Errorf("unexpected request %+v", rr)
+	}
+	if rr.Header.Get("Authorization") != redactedValue || rr.Header.Get("X-Api-Key") != redactedValue || rr.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers %v", rr.Header)
+	}
+	if string(rr.Body) != `{"a":` || !rr.Truncated {
+		t.Errorf("unexpected body %q, truncated %v", rr.Body, rr.Truncated)
+	}
+
+	results, err := Replay(context.Background(), router, requests, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results[0]; r.Match == nil || r.Match.Name != "order" || r.Match.Vars["id"] != "1" || r.Status != http.StatusOK || r.Err != nil {
+		t.Errorf("unexpected result %+v", r)
+	}
+	if r := results[1]; r.Match != nil || r.MatchErr != ErrNotFound || r.Status != http.StatusNotFound {
+		t.Errorf("unexpected result %+v", r)
+	}
+	if bodies[len(bodies)-1] != `{"a":` {
+		t.Errorf("expected the recorded body to be replayed, got %q", bodies[len(bodies)-1])
+	}
+}
+
+func TestReadRecordedRequestsError(t *testing.T) {
+	line, _ := json.Marshal(&RecordedRequest{Method: http.MethodGet, URI: "/"})
+	_, err := ReadRecordedRequests(strings.NewReader(string(line) + "\n\n{"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected an error on line 3, got %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package mux
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ResponseRecorderWriter wraps an http.ResponseWriter and records the status
+// code and the number of body bytes written, while passing http.Flusher,
+// http.Hijacker, http.Pusher and io.ReaderFrom through to the wrapped writer.
+// Flushing and hijacking reach writers wrapped in turn by writers which only
+// implement Unwrap. It implements Unwrap itself, so http.NewResponseController
+// sees the original writer as well, e.g. for deadlines and full duplex. The
+// header map is the one of the wrapped writer, so trailers declared in the
+// Trailer header or set with the http.TrailerPrefix are sent.
+//
+// All middlewares shipped with this package share a single recorder per
+// request: NewResponseRecorderWriter returns the given writer unchanged if it
+// already is a *ResponseRecorderWriter. Custom middlewares should do the same
+// instead of defining their own wrappers, which would hide the optional
+// interfaces of the underlying writer.
+type ResponseRecorderWriter struct {
+	http.ResponseWriter
+
+	status  int
+	written int64
+
+	beforeWriteHeader []func(status int)
+	onWrite           []func(b []byte)
+}
+
+// NewResponseRecorderWriter wraps w, unless it already is a
+// *ResponseRecorderWriter, in which case it is returned as is.
+func NewResponseRecorderWriter(w http.ResponseWriter) *ResponseRecorderWriter {
+	if rw, ok := w.(*ResponseRecorderWriter); ok {
+		return rw
+	}
+	return &ResponseRecorderWriter{ResponseWriter: w}
+}
+
+// Status returns the status code written so far, or 0 if the header has not
+// been written yet.
+func (w *ResponseRecorderWriter) Status() int {
+	return w.status
+}
+
+// StatusOrDefault returns the status code written so far. If nothing was
+// written it returns 500 if err is not nil and 200 otherwise, which is what
+// the client receives for a handler returning err without writing anything.
+func (w *ResponseRecorderWriter) StatusOrDefault(err error) int {
+	if w.status != 0 {
+		return w.status
+	}
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return http.StatusOK
+}
+
+// WroteHeader reports whether the header has been written.
+func (w *ResponseRecorderWriter) WroteHeader() bool {
+	return w.status != 0
+}
+
+// BytesWritten returns the number of body bytes written so far.
+func (w *ResponseRecorderWriter) BytesWritten() int64 {
+	return w.written
+}
+
+// BeforeWriteHeader registers fn to be called once right before the header is
+// written, which is the last chance to modify it. If the header has already
+// been written, fn is not called.
+func (w *ResponseRecorderWriter) BeforeWriteHeader(fn func(status int)) {
+	w.beforeWriteHeader = append(w.beforeWriteHeader, fn)
+}
+
+// OnWrite registers fn to be called with every chunk of the body before it is
+// written. fn must not retain b.
+func (w *ResponseRecorderWriter) OnWrite(fn func(b []byte)) {
+	w.onWrite = append(w.onWrite, fn)
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *ResponseRecorderWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		// Informational responses may be followed by the final header.
+		if code >= 200 || code == http.StatusSwitchingProtocols {
+			w.writeHeader(code)
+			return
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *ResponseRecorderWriter) writeHeader(code int) {
+	hooks := w.beforeWriteHeader
+	w.beforeWriteHeader = nil
+	for _, fn := range hooks {
+		fn(code)
+	}
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter.
+func (w *ResponseRecorderWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.writeHeader(http.StatusOK)
+	}
+	for _, fn := range w.onWrite {
+		fn(b)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// ReadFrom implements io.ReaderFrom, using the wrapped writer's ReadFrom
+// if possible.
+func (w *ResponseRecorderWriter) ReadFrom(src io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.writeHeader(http.StatusOK)
+	}
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok && len(w.onWrite) == 0 {
+		n, err := rf.ReadFrom(src)
+		w.written += n
+		return n, err
+	}
+	return io.Copy(writerOnly{w}, src)
+}
+
+// Flush implements http.Flusher. It is a no-op if the wrapped writer does not
+// support flushing.
+func (w *ResponseRecorderWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// FlushError flushes the response like Flush, returning the error of the
+// wrapped writer. It returns http.ErrNotSupported if the wrapped writer does
+// not support flushing. http.ResponseController prefers it over Flush.
+func (w *ResponseRecorderWriter) FlushError() error {
+	if w.status == 0 {
+		w.writeHeader(http.StatusOK)
+	}
+	return unwrapNotSupported(http.NewResponseController(w.ResponseWriter).Flush())
+}
+
+// Hijack implements http.Hijacker. It returns http.ErrNotSupported if the
+// wrapped writer can't be hijacked.
+func (w *ResponseRecorderWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, unwrapNotSupported(err)
+}
+
+// unwrapNotSupported returns http.ErrNotSupported itself for the errors of
+// http.ResponseController wrapping it, and err otherwise.
+func unwrapNotSupported(err error) error {
+	if errors.Is(err, http.ErrNotSupported) {
+		return http.ErrNotSupported
+	}
+	return err
+}
+
+// Push implements http.Pusher. It returns http.ErrNotSupported if the wrapped
+// writer does not support server push.
+func (w *ResponseRecorderWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (w *ResponseRecorderWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writerOnly hides all methods but Write, to keep io.Copy from calling back
+// into ReadFrom.
+type writerOnly struct {
+	io.Writer
+}
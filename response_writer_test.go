@@ -0,0 +1,100 @@
+package mux
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+func TestResponseRecorderWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := NewResponseRecorderWriter(rec)
+
+	if NewResponseRecorderWriter(rw) != rw {
+		t.Fatal("expected an existing recorder to be reused")
+	}
+
+	var order []string
+	rw.BeforeWriteHeader(func(status int) {
+		order = append(order, "before")
+		rw.Header().Set("X-Status", http.StatusText(status))
+	})
+	var chunks []string
+	rw.OnWrite(func(b []byte) { chunks = append(chunks, string(b)) })
+
+	if rw.WroteHeader() || rw.StatusOrDefault(nil) != http.StatusOK || rw.StatusOrDefault(errors.New("x")) != http.StatusInternalServerError {
+		t.Fatal("unexpected defaults before writing")
+	}
+
+	rw.WriteHeader(http.StatusAccepted)
+	rw.WriteHeader(http.StatusTeapot)
+	if _, err := rw.Write([]byte("hello ")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rw.ReadFrom(strings.NewReader("world")); err != nil {
+		t.Fatal(err)
+	}
+	rw.Flush()
+
+	if rw.Status() != http.StatusAccepted {
+		t.Errorf("expected first status to be recorded, got %d", rw.Status())
+	}
+	if rw.BytesWritten() != 11 || rec.Body.String() != "hello world" {
+		t.Errorf("unexpected body %q (%d bytes recorded)", rec.Body.String(), rw.BytesWritten())
+	}
+	if len(order) != 1 || rec.Header().Get("X-Status") != "Accepted" {
+		t.Errorf("before write header hook did not run exactly once: %v", order)
+	}
+	if strings.Join(chunks, "") != "hello world" {
+		t.Errorf("unexpected chunks %q", chunks)
+	}
+	if !rec.Flushed {
+		t.Error("expected flush to be passed through")
+	}
+	if err := rw.Push("/style.css", nil); err != http.ErrNotSupported {
+		t.Errorf("expected ErrNotSupported from Push, got %v", err)
+	}
+	if _, _, err := rw.Hijack(); err != http.ErrNotSupported {
+		t.Errorf("expected ErrNotSupported from Hijack, got %v", err)
+	}
+}
+
+func TestResponseRecorderWriterHijack(t *testing.T) {
+	inner := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rw := NewResponseRecorderWriter(inner)
+
+	if _, _, err := http.NewResponseController(rw).Hijack(); err != nil {
+		t.Fatal(err)
+	}
+	if !inner.hijacked || rw.Status() != http.StatusSwitchingProtocols {
+		t.Errorf("expected hijack to be passed through, status %d", rw.Status())
+	}
+}
+
+func TestResponseRecorderWriterUnwrapsWrappers(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := NewResponseRecorderWriter(opaqueWriter{rec})
+	if err := rw.FlushError(); err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Flushed || rw.Status() != http.StatusOK {
+		t.Errorf("expected the wrapped recorder to be flushed, got %v %d", rec.Flushed, rw.Status())
+	}
+	if _, _, err := rw.Hijack(); err != http.ErrNotSupported {
+		t.Errorf("expected ErrNotSupported from Hijack, got %v", err)
+	}
+}
@@ -8,10 +8,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // Route stores information to match a request and build URLs.
@@ -20,10 +25,14 @@ type Route struct {
 	handler Handler
 	// If true, this route never matches: it is only used to build URLs.
 	buildOnly bool
+	// If set, the route only matches while it returns true.
+	enabled EnabledFunc
 	// The name used to build URLs.
 	name string
 	// Error resulted from building a route.
 	err error
+	// All errors resulted from building the route, see Validate.
+	errs []error
 
 	// The meta data associated with this route
 	metadata map[any]any
@@ -34,6 +43,19 @@ type Route struct {
 	// route specific middleware
 	middlewares []middleware
 
+	// latency histogram, populated when the serving router has histograms enabled
+	latency atomic.Pointer[latencyHistogram]
+
+	// number of requests served, counted when the serving router records
+	// coverage
+	hits atomic.Uint64
+
+	// handler composed with the route middlewares, see handlerChain
+	chain atomic.Pointer[middlewareChain]
+
+	// pattern of a route registered by Router.HandlePattern
+	servePattern *servePattern
+
 	// config possibly passed in from `Router`
 	routeConf
 }
@@ -46,16 +68,40 @@ func (r *Route) SkipClean() bool {
 
 // Match matches the route against the request.
 func (r *Route) Match(req *http.Request, match *RouteMatch) bool {
-	if r.buildOnly || r.err != nil {
+	var start time.Time
+	if match.matchTrace != nil {
+		start = time.Now()
+	}
+	if r.buildOnly {
+		return false
+	}
+	if r.err != nil {
+		if match.matchTrace != nil {
+			match.matchTrace.reject(r, nil, "invalid", start)
+		}
+		return false
+	}
+	if r.enabled != nil && !r.enabled(req.Context(), req) {
+		if match.matchTrace != nil {
+			match.matchTrace.reject(r, nil, "disabled", start)
+		}
 		return false
 	}
 
 	var matchErr error
 
 	// Match everything.
-	for _, m := range r.matchers {
+	for i, m := range r.matchers {
 		if matched := m.Match(req, match); !matched {
 			if _, ok := m.(methodMatcher); ok {
+				if match.MatchErr == ErrMethodMismatch && failsLikePath(r.matchers[i+1:]) {
+					// The route cannot change the result: a previous route
+					// already matched all but the method.
+					if match.matchTrace != nil {
+						match.matchTrace.reject(r, m, "", start)
+					}
+					return false
+				}
 				matchErr = ErrMethodMismatch
 				continue
 			}
@@ -69,6 +115,9 @@ func (r *Route) Match(req *http.Request, match *RouteMatch) bool {
 			if rr, ok := m.(*routeRegexp); ok {
 				if rr.regexpType == regexpTypeQuery {
 					matchErr = ErrNotFound
+					if match.matchTrace != nil {
+						match.matchTrace.reject(r, m, "", start)
+					}
 					break
 				}
 			}
@@ -84,6 +133,9 @@ func (r *Route) Match(req *http.Request, match *RouteMatch) bool {
 				match.MatchErr = nil
 			}
 
+			if match.matchTrace != nil {
+				match.matchTrace.reject(r, m, "", start)
+			}
 			matchErr = nil // nolint:ineffassign
 			return false
 		}
@@ -91,6 +143,12 @@ func (r *Route) Match(req *http.Request, match *RouteMatch) bool {
 
 	if matchErr != nil {
 		match.MatchErr = matchErr
+		if match.mismatched == nil {
+			match.mismatched = r
+		}
+		if matchErr == ErrMethodMismatch && match.matchTrace != nil {
+			match.matchTrace.reject(r, nil, "method", start)
+		}
 		return false
 	}
 
@@ -99,6 +157,7 @@ func (r *Route) Match(req *http.Request, match *RouteMatch) bool {
 		match.MatchErr = nil
 		// Then override the mis-matched handler
 		match.Handler = r.handler
+		match.chain = nil
 	}
 
 	// Yay, we have a match. Let's collect some info about it.
@@ -106,7 +165,15 @@ func (r *Route) Match(req *http.Request, match *RouteMatch) bool {
 		match.Route = r
 	}
 	if isNil(match.Handler) {
-		match.Handler = r.GetHandlerWithMiddlewares()
+		if match.trace != nil {
+			match.Handler = r.tracedHandler(match.trace)
+		} else {
+			c := r.handlerChain()
+			match.Handler = c.handler
+			if c.handler != nil {
+				match.chain = c
+			}
+		}
 	}
 
 	// Set variables.
@@ -123,12 +190,45 @@ func (r *Route) GetError() error {
 	return r.err
 }
 
+// Compile compiles the regular expressions of the route if it was created by
+// a router with LazyCompile set, and returns the error of an invalid route.
+func (r *Route) Compile() error {
+	if r.err != nil {
+		return r.err
+	}
+	for _, m := range r.matchers {
+		if rr, ok := m.(*routeRegexp); ok {
+			if err := rr.compile(); err != nil {
+				// Logged by compile if the route is lazy.
+				r.err = err
+				r.errs = append(r.errs, err)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // BuildOnly sets the route to never match: it is only used to build URLs.
 func (r *Route) BuildOnly() *Route {
 	r.buildOnly = true
 	return r
 }
 
+// EnabledFunc reports whether a route is switched on for a request.
+type EnabledFunc func(ctx context.Context, r *http.Request) bool
+
+// Enabled makes the route conditional on fn, which is evaluated for every
+// request before any other matcher, e.g. to dark launch a route behind a
+// feature flag. While fn returns false the route behaves as if it was not
+// registered: matching continues with the next route and the request does
+// not count towards a 405 Method Not Allowed response. Disabling a route
+// with a subrouter disables all its routes.
+func (r *Route) Enabled(fn EnabledFunc) *Route {
+	r.enabled = fn
+	return r
+}
+
 // MetaData -------------------------------------------------------------------
 
 // Metadata is used to set metadata on a route
@@ -138,6 +238,8 @@ func (r *Route) Metadata(key any, value any) *Route {
 	}
 
 	r.metadata[key] = value
+	// Middlewares may depend on the metadata, see Router.UseWhen.
+	r.generations().middlewares.Add(1)
 	return r
 }
 
@@ -172,12 +274,36 @@ func (r *Route) GetMetadataValueOr(key any, fallbackValue any) any {
 	return value
 }
 
+// MetadataValue returns the value of a specific key in the metadata map of route as a T. If the key is not present mux.ErrMetadataKeyNotFound is returned, and if the value is not a T an error wrapping mux.ErrMetadataTypeMismatch
+func MetadataValue[T any](route *Route, key any) (T, error) {
+	var zero T
+	value, ok := route.metadata[key]
+	if !ok {
+		return zero, ErrMetadataKeyNotFound
+	}
+	typed, ok := value.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %T is not %T", ErrMetadataTypeMismatch, value, zero)
+	}
+	return typed, nil
+}
+
+// MetadataValueOr returns the value of a specific key in the metadata map of route as a T. If the key is not present or the value is not a T the fallback value is returned
+func MetadataValueOr[T any](route *Route, key any, fallbackValue T) T {
+	value, err := MetadataValue[T](route, key)
+	if err != nil {
+		return fallbackValue
+	}
+	return value
+}
+
 // Handler --------------------------------------------------------------------
 
 // Handler sets a handler for the route.
 func (r *Route) Handler(handler Handler) *Route {
 	if r.err == nil {
 		r.handler = handler
+		r.generations().middlewares.Add(1)
 	}
 	return r
 }
@@ -210,14 +336,27 @@ func (r *Route) GetHandlerWithMiddlewares() HandlerFunc {
 	return HandlerToHandlerFunc(handler)
 }
 
+// setErr records a build error of the route, logging the first one, which
+// turns the route invalid. Invalid routes never match.
+func (r *Route) setErr(err error) {
+	if err == nil {
+		return
+	}
+	r.errs = append(r.errs, err)
+	if r.err == nil {
+		r.getLogger().Log(context.Background(), slog.LevelError, "mux: invalid route", "error", err)
+		r.err = err
+	}
+}
+
 // Name -----------------------------------------------------------------------
 
 // Name sets the name for the route, used to build URLs.
 // It is an error to call Name more than once on a route.
 func (r *Route) Name(name string) *Route {
 	if r.name != "" {
-		r.err = fmt.Errorf("mux: route already has name %q, can't set %q",
-			r.name, name)
+		r.setErr(fmt.Errorf("mux: route already has name %q, can't set %q",
+			r.name, name))
 	}
 	if r.err == nil {
 		r.name = name
@@ -240,22 +379,56 @@ type matcher interface {
 	Match(*http.Request, *RouteMatch) bool
 }
 
-// addMatcher adds a matcher to the route.
+// addMatcher adds a matcher to the route. Method and scheme matchers are
+// added before the host and path matchers they follow, so that they are
+// checked before the regexps; this does not change the result of Match.
 func (r *Route) addMatcher(m matcher) *Route {
 	if r.err == nil {
-		r.matchers = append(r.matchers, m)
+		i := len(r.matchers)
+		switch m.(type) {
+		case methodMatcher, schemeMatcher:
+			for i > 0 && isHostOrPathMatcher(r.matchers[i-1]) {
+				i--
+			}
+		}
+		r.matchers = slices.Insert(r.matchers, i, m)
+		r.generations().routes.Add(1)
 	}
 	return r
 }
 
+// isHostOrPathMatcher reports whether m matches the host or path regexp.
+func isHostOrPathMatcher(m matcher) bool {
+	rr, ok := m.(*routeRegexp)
+	return ok && rr.regexpType != regexpTypeQuery
+}
+
+// failsLikePath reports whether the matchers only fail like a path matcher,
+// without side effects on the RouteMatch.
+func failsLikePath(matchers []matcher) bool {
+	for _, m := range matchers {
+		switch m.(type) {
+		case methodMatcher, schemeMatcher, headerMatcher:
+			continue
+		}
+		if !isHostOrPathMatcher(m) {
+			return false
+		}
+	}
+	return true
+}
+
 // addRegexpMatcher adds a host or path matcher and builder to a route.
+// If the route has an error already, the template is only checked, see
+// Validate.
 func (r *Route) addRegexpMatcher(tpl string, typ regexpType) error {
-	if r.err != nil {
-		return r.err
-	}
+	failed := r.err != nil
 	if typ == regexpTypePath || typ == regexpTypePrefix {
 		if len(tpl) > 0 && tpl[0] != '/' {
-			return fmt.Errorf("mux: path must start with a slash, got %q", tpl)
+			return newTemplateError(tpl, 0, fmt.Errorf("mux: path must start with a slash, got %q", tpl))
+		}
+		if r.colonPatterns {
+			tpl = translateColonPattern(tpl)
 		}
 		if r.regexp.path != nil {
 			tpl = strings.TrimRight(r.regexp.path.template, "/") + tpl
@@ -264,10 +437,17 @@ func (r *Route) addRegexpMatcher(tpl string, typ regexpType) error {
 	rr, err := newRouteRegexp(tpl, typ, routeRegexpOptions{
 		strictSlash:    r.strictSlash,
 		useEncodedPath: r.useEncodedPath,
+		extensionVars:  r.extensionVars,
+		lazy:           r.lazyCompile,
+		compileCache:   r.compileCache,
+		logger:         r.getLogger(),
 	})
 	if err != nil {
 		return err
 	}
+	if failed {
+		return rr.compile()
+	}
 	for _, q := range r.regexp.queries {
 		if err = uniqueVars(rr.varsN, q.varsN); err != nil {
 			return err
@@ -298,11 +478,70 @@ func (r *Route) addRegexpMatcher(tpl string, typ regexpType) error {
 
 // Headers --------------------------------------------------------------------
 
-// headerMatcher matches the request against header values.
-type headerMatcher map[string]string
+// headerMatcher matches the request against header values. It is built at
+// registration with canonical header keys, so that matching neither
+// canonicalizes keys nor iterates a map.
+type headerMatcher []headerMatch
+
+// headerMatch matches the values of a header. An empty value and a nil
+// regexp match any value.
+type headerMatch struct {
+	key    string
+	value  string
+	regexp *regexp.Regexp
+}
+
+// newHeaderMatcher returns a matcher for the header values in headers.
+func newHeaderMatcher(headers map[string]string) headerMatcher {
+	m := make(headerMatcher, 0, len(headers))
+	for k, v := range headers {
+		m = append(m, headerMatch{key: intern(http.CanonicalHeaderKey(k)), value: v})
+	}
+	return m.sorted()
+}
+
+// newHeaderRegexMatcher returns a matcher for the header values matching the
+// regexps in headers.
+func newHeaderRegexMatcher(headers map[string]*regexp.Regexp) headerMatcher {
+	m := make(headerMatcher, 0, len(headers))
+	for k, v := range headers {
+		m = append(m, headerMatch{key: intern(http.CanonicalHeaderKey(k)), regexp: v})
+	}
+	return m.sorted()
+}
+
+// sorted sorts m by key, so that routes check headers in a stable order.
+func (m headerMatcher) sorted() headerMatcher {
+	slices.SortFunc(m, func(a, b headerMatch) int { return strings.Compare(a.key, b.key) })
+	return m
+}
 
 func (m headerMatcher) Match(r *http.Request, match *RouteMatch) bool {
-	return matchMapWithString(m, r.Header, true)
+	for i := range m {
+		if !m[i].match(r.Header[m[i].key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// match reports whether one of values matches, given that the header is set.
+func (h *headerMatch) match(values []string) bool {
+	if values == nil {
+		return false
+	}
+	switch {
+	case h.regexp != nil:
+		for _, value := range values {
+			if h.regexp.MatchString(value) {
+				return true
+			}
+		}
+		return false
+	case h.value != "":
+		return slices.Contains(values, h.value)
+	}
+	return true
 }
 
 // Headers adds a matcher for request header values.
@@ -316,20 +555,13 @@ func (m headerMatcher) Match(r *http.Request, match *RouteMatch) bool {
 // If the value is an empty string, it will match any value if the key is set.
 func (r *Route) Headers(pairs ...string) *Route {
 	if r.err == nil {
-		var headers map[string]string
-		headers, r.err = mapFromPairsToString(pairs...)
-		return r.addMatcher(headerMatcher(headers))
+		headers, err := mapFromPairsToString(pairs...)
+		r.setErr(err)
+		return r.addMatcher(newHeaderMatcher(headers))
 	}
 	return r
 }
 
-// headerRegexMatcher matches the request against the route given a regex for the header
-type headerRegexMatcher map[string]*regexp.Regexp
-
-func (m headerRegexMatcher) Match(r *http.Request, match *RouteMatch) bool {
-	return matchMapWithRegex(m, r.Header, true)
-}
-
 // HeadersRegexp accepts a sequence of key/value pairs, where the value has regex
 // support. For example:
 //
@@ -342,9 +574,9 @@ func (m headerRegexMatcher) Match(r *http.Request, match *RouteMatch) bool {
 // Use the start and end of string anchors (^ and $) to match an exact value.
 func (r *Route) HeadersRegexp(pairs ...string) *Route {
 	if r.err == nil {
-		var headers map[string]*regexp.Regexp
-		headers, r.err = mapFromPairsToRegex(pairs...)
-		return r.addMatcher(headerRegexMatcher(headers))
+		headers, err := mapFromPairsToRegex(pairs...)
+		r.setErr(err)
+		return r.addMatcher(newHeaderRegexMatcher(headers))
 	}
 	return r
 }
@@ -366,10 +598,13 @@ func (r *Route) HeadersRegexp(pairs ...string) *Route {
 //	r.Host("{subdomain}.domain.com")
 //	r.Host("{subdomain:[a-z]+}.domain.com")
 //
+// Host names match case-insensitively: the host of the request is
+// lowercased, and so are the values of the host variables.
+//
 // Variable names must be unique in a given route. They can be retrieved
 // calling mux.Vars(request).
 func (r *Route) Host(tpl string) *Route {
-	r.err = r.addRegexpMatcher(tpl, regexpTypeHost)
+	r.setErr(r.addRegexpMatcher(tpl, regexpTypeHost))
 	return r
 }
 
@@ -404,7 +639,7 @@ func (r *Route) Methods(methods ...string) *Route {
 	for k, v := range methods {
 		methods[k] = strings.ToUpper(v)
 	}
-	return r.addMatcher(methodMatcher(methods))
+	return r.addMatcher(internMethods(methods))
 }
 
 // Path -----------------------------------------------------------------------
@@ -418,6 +653,16 @@ func (r *Route) Methods(methods ...string) *Route {
 //
 // - {name:pattern} matches the given regexp pattern.
 //
+// - With Router.ExtensionVars, {.name} and {.name:pattern} at the end of the
+// template match an optional extension selecting the response format, such
+// as ".json". The extension defaults to letters and digits. Paths without an
+// extension match as well: if the pattern lists the formats, such as
+// "json|csv", the variable is set to the one preferred by the Accept header
+// of the request, or to the first one without an Accept header; otherwise it
+// is not set. URLs are built without an extension if the variable is empty
+// or not given. Without Router.ExtensionVars, they are ordinary variables
+// whose names start with a dot.
+//
 // For example:
 //
 //	r := mux.NewRouter().NewRoute()
@@ -429,7 +674,7 @@ func (r *Route) Methods(methods ...string) *Route {
 // Variable names must be unique in a given route. They can be retrieved
 // calling mux.Vars(request).
 func (r *Route) Path(tpl string) *Route {
-	r.err = r.addRegexpMatcher(tpl, regexpTypePath)
+	r.setErr(r.addRegexpMatcher(tpl, regexpTypePath))
 	return r
 }
 
@@ -445,7 +690,7 @@ func (r *Route) Path(tpl string) *Route {
 // Also note that the setting of Router.StrictSlash() has no effect on routes
 // with a PathPrefix matcher.
 func (r *Route) PathPrefix(tpl string) *Route {
-	r.err = r.addRegexpMatcher(tpl, regexpTypePrefix)
+	r.setErr(r.addRegexpMatcher(tpl, regexpTypePrefix))
 	return r
 }
 
@@ -471,14 +716,12 @@ func (r *Route) PathPrefix(tpl string) *Route {
 func (r *Route) Queries(pairs ...string) *Route {
 	length := len(pairs)
 	if length%2 != 0 {
-		r.err = fmt.Errorf(
-			"mux: number of parameters must be multiple of 2, got %v", pairs)
+		r.setErr(fmt.Errorf(
+			"mux: number of parameters must be multiple of 2, got %v", pairs))
 		return nil
 	}
 	for i := 0; i < length; i += 2 {
-		if r.err = r.addRegexpMatcher(pairs[i]+"="+pairs[i+1], regexpTypeQuery); r.err != nil {
-			return r
-		}
+		r.setErr(r.addRegexpMatcher(pairs[i]+"="+pairs[i+1], regexpTypeQuery))
 	}
 
 	return r
@@ -559,9 +802,16 @@ func (r *Route) BuildVarsFunc(f BuildVarsFunc) *Route {
 //
 // Here, the routes registered in the subrouter won't be tested if the host
 // doesn't match.
+//
+// The routes of the subrouter inherit the metadata of the route, as set when
+// Subrouter is called, see Router.Metadata.
 func (r *Route) Subrouter() *Router {
 	// initialize a subrouter with a copy of the parent route's configuration
+	r.generations()
 	router := &Router{routeConf: copyRouteConf(r.routeConf), namedRoutes: r.namedRoutes}
+	if r.metadata != nil {
+		router.routerMetadata = maps.Clone(r.metadata)
+	}
 	r.addMatcher(router)
 	return router
 }
@@ -607,7 +857,8 @@ func (r *Route) Subrouter() *Router {
 //	             .Schemes("https", "http").URL()
 //
 // All variables defined in the route are required, and their values must
-// conform to the corresponding patterns.
+// conform to the corresponding patterns. Otherwise the error is a *URLError
+// telling the route, template and variable.
 func (r *Route) URL(pairs ...string) (*url.URL, error) {
 	if r.err != nil {
 		return nil, r.err
@@ -620,7 +871,7 @@ func (r *Route) URL(pairs ...string) (*url.URL, error) {
 	queries := make([]string, 0, len(r.regexp.queries))
 	if r.regexp.host != nil {
 		if host, err = r.regexp.host.url(values); err != nil {
-			return nil, err
+			return nil, r.urlError(err)
 		}
 		scheme = "http"
 		if r.buildScheme != "" {
@@ -629,13 +880,13 @@ func (r *Route) URL(pairs ...string) (*url.URL, error) {
 	}
 	if r.regexp.path != nil {
 		if path, err = r.regexp.path.url(values); err != nil {
-			return nil, err
+			return nil, r.urlError(err)
 		}
 	}
 	for _, q := range r.regexp.queries {
 		var query string
 		if query, err = q.url(values); err != nil {
-			return nil, err
+			return nil, r.urlError(err)
 		}
 		queries = append(queries, query)
 	}
@@ -655,7 +906,7 @@ func (r *Route) URLHost(pairs ...string) (*url.URL, error) {
 		return nil, r.err
 	}
 	if r.regexp.host == nil {
-		return nil, errors.New("mux: route doesn't have a host")
+		return nil, r.urlError(errors.New("route doesn't have a host"))
 	}
 	values, err := r.prepareVars(pairs...)
 	if err != nil {
@@ -663,7 +914,7 @@ func (r *Route) URLHost(pairs ...string) (*url.URL, error) {
 	}
 	host, err := r.regexp.host.url(values)
 	if err != nil {
-		return nil, err
+		return nil, r.urlError(err)
 	}
 	u := &url.URL{
 		Scheme: "http",
@@ -683,7 +934,7 @@ func (r *Route) URLPath(pairs ...string) (*url.URL, error) {
 		return nil, r.err
 	}
 	if r.regexp.path == nil {
-		return nil, errors.New("mux: route doesn't have a path")
+		return nil, r.urlError(errors.New("route doesn't have a path"))
 	}
 	values, err := r.prepareVars(pairs...)
 	if err != nil {
@@ -691,7 +942,7 @@ func (r *Route) URLPath(pairs ...string) (*url.URL, error) {
 	}
 	path, err := r.regexp.path.url(values)
 	if err != nil {
-		return nil, err
+		return nil, r.urlError(err)
 	}
 	return &url.URL{
 		Path: path,
@@ -724,7 +975,7 @@ func (r *Route) GetPathRegexp() (string, error) {
 	if r.regexp.path == nil {
 		return "", errors.New("mux: route does not have a path")
 	}
-	return r.regexp.path.regexp.String(), nil
+	return r.regexp.path.pattern, nil
 }
 
 // GetQueriesRegexp returns the expanded regular expressions used to match the
@@ -741,7 +992,7 @@ func (r *Route) GetQueriesRegexp() ([]string, error) {
 	}
 	queries := make([]string, 0, len(r.regexp.queries))
 	for _, query := range r.regexp.queries {
-		queries = append(queries, query.regexp.String())
+		queries = append(queries, query.pattern)
 	}
 	return queries, nil
 }
@@ -775,7 +1026,8 @@ func (r *Route) GetMethods() ([]string, error) {
 	}
 	for _, m := range r.matchers {
 		if methods, ok := m.(methodMatcher); ok {
-			return []string(methods), nil
+			// Method matchers are shared between routes.
+			return slices.Clone(methods), nil
 		}
 	}
 	return nil, errors.New("mux: route doesn't have methods")
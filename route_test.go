@@ -60,6 +60,21 @@ func BenchmarkNewRouterRegexpFunc(b *testing.B) {
 	}
 }
 
+func BenchmarkNewRouterRegexpCache(b *testing.B) {
+	testNewRouterMu.Lock()
+	defer testNewRouterMu.Unlock()
+
+	RegexpCompileFunc = NewRegexpCache(DefaultRegexpCacheSize).Compile
+	defer func() { RegexpCompileFunc = DefaultRegexpCache.Compile }()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		testNewRouter(b, testHandler)
+	}
+}
+
 func testNewRouter(_ testing.TB, handler Handler) {
 	r := NewRouter()
 	// A route with a route variable:
@@ -156,3 +171,158 @@ func TestRouteMetadata(t *testing.T) {
 		router.ServeHTTP(context.Background(), rw, req, nil)
 	})
 }
+
+func TestMetadataValue(t *testing.T) {
+	type limit struct{ rps int }
+	route := NewRouter().HandleFunc("/", dummyHandler).
+		Metadata("owner", "payments").
+		Metadata("limit", limit{rps: 10})
+
+	owner, err := MetadataValue[string](route, "owner")
+	if err != nil || owner != "payments" {
+		t.Fatalf("Expected metadata value 'payments', got %q, %v", owner, err)
+	}
+	if l, err := MetadataValue[limit](route, "limit"); err != nil || l.rps != 10 {
+		t.Fatalf("Expected metadata value limit{10}, got %v, %v", l, err)
+	}
+
+	if _, err := MetadataValue[string](route, "missing"); !errors.Is(err, ErrMetadataKeyNotFound) {
+		t.Fatalf("Expected error to be ErrMetadataKeyNotFound but got: %v", err)
+	}
+	_, err = MetadataValue[int](route, "owner")
+	if !errors.Is(err, ErrMetadataTypeMismatch) || errors.Is(err, ErrMetadataKeyNotFound) {
+		t.Fatalf("Expected error to be ErrMetadataTypeMismatch but got: %v", err)
+	}
+	if err.Error() != "metadata value has a different type: string is not int" {
+		t.Fatalf("Unexpected error message: %s", err)
+	}
+
+	if value := MetadataValueOr(route, "owner", "fallback"); value != "payments" {
+		t.Fatalf("Expected metadata value 'payments', got %q", value)
+	}
+	if value := MetadataValueOr(route, "missing", "fallback"); value != "fallback" {
+		t.Fatalf("Expected fallback value, got %q", value)
+	}
+	if value := MetadataValueOr(route, "owner", 5); value != 5 {
+		t.Fatalf("Expected fallback value for a mismatched type, got %d", value)
+	}
+}
+
+func TestHeaderMatcherCanonicalKeys(t *testing.T) {
+	route := new(Route).
+		Headers("content-type", "application/json", "x-requested-with", "").
+		HeadersRegexp("accept", "^text/")
+	for _, m := range route.matchers {
+		for _, h := range m.(headerMatcher) {
+			if h.key != http.CanonicalHeaderKey(h.key) {
+				t.Errorf("expected canonical key, got %q", h.key)
+			}
+		}
+	}
+
+	req := newRequestWithHeaders("GET", "http://localhost/",
+		"Content-Type", "application/json", "X-Requested-With", "XMLHttpRequest", "Accept", "text/html")
+	if !route.Match(req, &RouteMatch{}) {
+		t.Error("expected the route to match")
+	}
+	req.Header.Set("Accept", "application/json")
+	if route.Match(req, &RouteMatch{}) {
+		t.Error("expected the route not to match")
+	}
+}
+
+func TestMatcherOrder(t *testing.T) {
+	route := new(Route).Host("example.com").Path("/a").Methods("GET").Schemes("https")
+	if _, ok := route.matchers[0].(methodMatcher); !ok {
+		t.Errorf("expected the method matcher first, got %T", route.matchers[0])
+	}
+	if _, ok := route.matchers[1].(schemeMatcher); !ok {
+		t.Errorf("expected the scheme matcher second, got %T", route.matchers[1])
+	}
+
+	// Methods are not checked before query or custom matchers, which fail
+	// differently.
+	route = new(Route).Path("/a").Queries("q", "1").Methods("GET")
+	if _, ok := route.matchers[2].(methodMatcher); !ok {
+		t.Errorf("expected the method matcher after the query matcher, got %T", route.matchers[2])
+	}
+}
+
+func TestMethodMismatchAfterMethodMismatch(t *testing.T) {
+	for _, tc := range []struct {
+		title    string
+		register func(r *Router)
+		expected error
+	}{
+		{
+			title: "path",
+			register: func(r *Router) {
+				r.HandleFunc("/a", dummyHandler).Methods("GET")
+				r.HandleFunc("/a", dummyHandler).Methods("PUT")
+				r.HandleFunc("/b", dummyHandler).Methods("PUT")
+			},
+			expected: ErrMethodMismatch,
+		},
+		{
+			title: "query",
+			register: func(r *Router) {
+				r.HandleFunc("/a", dummyHandler).Methods("GET")
+				r.HandleFunc("/a", dummyHandler).Methods("PUT").Queries("q", "1")
+			},
+			expected: ErrNotFound,
+		},
+		{
+			title: "match",
+			register: func(r *Router) {
+				r.HandleFunc("/a", dummyHandler).Methods("GET")
+				r.HandleFunc("/a", dummyHandler).Methods("PUT")
+				r.HandleFunc("/a", dummyHandler).Methods("POST")
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			r := NewRouter()
+			tc.register(r)
+			var match RouteMatch
+			matched := r.Match(newRequest("POST", "http://localhost/a"), &match)
+			if matched != (tc.expected == nil) || match.MatchErr != tc.expected {
+				t.Errorf("expected %v, got %v (matched %v)", tc.expected, match.MatchErr, matched)
+			}
+		})
+	}
+}
+
+func TestRouterMetadata(t *testing.T) {
+	router := NewRouter()
+	before := router.HandleFunc("/before", dummyHandler)
+	router.Metadata("team", "platform").Metadata("scope", "read")
+
+	users := router.HandleFunc("/users", dummyHandler)
+	override := router.HandleFunc("/users/{id}", dummyHandler).Metadata("scope", "write")
+	admin := router.PathPrefix("/admin").Metadata("scope", "admin").Subrouter()
+	admin.Metadata("audit", true)
+	adminUsers := admin.HandleFunc("/users", dummyHandler)
+	router.Metadata("team", "identity")
+
+	tests := []struct {
+		route *Route
+		want  map[any]any
+	}{
+		{before, nil},
+		{users, map[any]any{"team": "platform", "scope": "read"}},
+		{override, map[any]any{"team": "platform", "scope": "write"}},
+		{adminUsers, map[any]any{"team": "platform", "scope": "admin", "audit": true}},
+		{router.HandleFunc("/after", dummyHandler), map[any]any{"team": "identity", "scope": "read"}},
+	}
+	for i, test := range tests {
+		if got := test.route.GetMetadata(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("route %d: expected metadata %v, got %v", i, test.want, got)
+		}
+	}
+	if scope := MetadataValueOr(users, "scope", ""); scope != "read" {
+		t.Errorf("expected the inherited scope, got %q", scope)
+	}
+	if got := router.GetMetadata(); !reflect.DeepEqual(got, map[any]any{"team": "identity", "scope": "read"}) {
+		t.Errorf("unexpected router metadata %v", got)
+	}
+}
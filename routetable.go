@@ -0,0 +1,102 @@
+package mux
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"strings"
+)
+
+// WriteRouteTable writes a canonical description of the routes with a
+// handler to w, one line per route in the order they are matched, meant for
+// golden file tests of the route set, see muxtest.AssertRouteTable:
+//
+//	GET,HEAD /users/{id} name=user
+//	POST /users header=Content-Type:application/json
+//	* /static/ prefix
+//
+// A line holds the sorted methods, "*" for any, the path template, "-" for
+// none, and the other matchers in a fixed order: "prefix" for path prefixes,
+// host, query, scheme and header matchers, "header~" for regexp header
+// matchers, "custom" for other matchers, and the route name. The matchers of
+// enclosing routes of subrouters are included. The description doesn't
+// depend on the handlers, middlewares or metadata of the routes.
+func (r *Router) WriteRouteTable(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	err := r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		if route.handler == nil {
+			return nil
+		}
+		if _, ok := route.handler.(*Router); ok {
+			return nil
+		}
+		bw.WriteString(describeRoute(route, ancestors))
+		return bw.WriteByte('\n')
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// describeRoute returns the line of route in the route table.
+func describeRoute(route *Route, ancestors []*Route) string {
+	var methods, schemes []string
+	var headers []string
+	custom := false
+	for _, r := range append(slices.Clone(ancestors), route) {
+		for _, m := range r.matchers {
+			switch m := m.(type) {
+			case methodMatcher:
+				// The methods of a route narrow the ones of its ancestors.
+				methods = slices.Clone(m)
+			case schemeMatcher:
+				schemes = slices.Clone(m)
+			case headerMatcher:
+				for _, h := range m {
+					if h.regexp != nil {
+						headers = append(headers, "header~"+h.key+":"+h.regexp.String())
+					} else {
+						headers = append(headers, "header="+h.key+":"+h.value)
+					}
+				}
+			case *routeRegexp, *Router:
+			default:
+				custom = true
+			}
+		}
+	}
+
+	fields := []string{"*", "-"}
+	if len(methods) > 0 {
+		slices.Sort(methods)
+		fields[0] = strings.Join(slices.Compact(methods), ",")
+	}
+	if path := route.regexp.path; path != nil {
+		fields[1] = path.template
+		if path.regexpType == regexpTypePrefix {
+			fields = append(fields, "prefix")
+		}
+	}
+	if host, err := route.GetHostTemplate(); err == nil {
+		fields = append(fields, "host="+host)
+	}
+	if queries, err := route.GetQueriesTemplates(); err == nil {
+		for _, q := range queries {
+			fields = append(fields, "query="+q)
+		}
+	}
+	if len(schemes) > 0 {
+		slices.Sort(schemes)
+		fields = append(fields, "scheme="+strings.Join(schemes, ","))
+	}
+	slices.Sort(headers)
+	fields = append(fields, slices.Compact(headers)...)
+	if custom {
+		fields = append(fields, "custom")
+	}
+	if name := route.GetName(); name != "" {
+		fields = append(fields, "name="+name)
+	}
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,35 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWriteRouteTable(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodHead, http.MethodGet).Name("user")
+	router.HandleFunc("/users", dummyHandler).Methods(http.MethodPost).Headers("content-type", "application/json")
+	router.PathPrefix("/static/").HandlerFunc(dummyHandler)
+	router.HandleFunc("/search", dummyHandler).Queries("q", "{q}").Schemes("https", "http")
+	api := router.Host("{tenant}.example.com").PathPrefix("/api").Methods(http.MethodGet).Subrouter()
+	api.HandleFunc("/items", dummyHandler).HeadersRegexp("Accept", "json$").Name("items")
+	api.HandleFunc("/items", dummyHandler).Methods(http.MethodPut).MatcherFunc(func(*http.Request, *RouteMatch) bool { return true })
+	router.NewRoute().HandlerFunc(dummyHandler)
+
+	want := `GET,HEAD /users/{id} name=user
+POST /users header=Content-Type:application/json
+* /static/ prefix
+* /search query=q={q} scheme=http,https
+GET /api/items host={tenant}.example.com header~Accept:json$ name=items
+PUT /api/items host={tenant}.example.com custom
+* -
+`
+	var b strings.Builder
+	if err := router.WriteRouteTable(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != want {
+		t.Errorf("expected route table\n%s\ngot\n%s", want, b.String())
+	}
+}
@@ -0,0 +1,186 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// SamplingOptions configures request sampling, see Router.EnableSampling.
+type SamplingOptions struct {
+	// Rate is the fraction of requests sampled, between 0 and 1.
+	Rate float64
+	// Predicate selects requests which are always sampled, regardless of
+	// Rate. It is optional.
+	Predicate func(r *http.Request) bool
+	// Callback receives every sample after the request was handled. It is
+	// required.
+	Callback func(ctx context.Context, sample *Sample)
+}
+
+// Sample is the timing breakdown of a single sampled request.
+type Sample struct {
+	// Request is the sampled request, as seen by the router.
+	Request *http.Request
+	// Route is the matched route, or nil if no route matched.
+	Route *Route
+	// Start is the time the router started to handle the request.
+	Start time.Time
+	// Match is the time spent matching the request against the routes.
+	Match time.Duration
+	// Layers holds the middlewares and the route handler in the order they
+	// were entered.
+	Layers []SampleLayer
+	// Total is the time spent in ServeHTTP.
+	Total time.Duration
+	// Status is the response status.
+	Status int
+	// Err is the error returned by the handler chain.
+	Err error
+}
+
+// SampleLayer is the timing of a single middleware or handler.
+type SampleLayer struct {
+	// Name identifies the middleware function or type, or is "handler" for
+	// the route handler.
+	Name string
+	// Depth is the nesting level, starting at 0 for the outermost layer.
+	Depth int
+	// Duration is the time spent in the layer including all inner layers.
+	Duration time.Duration
+	// Self is the time spent in the layer itself.
+	Self time.Duration
+}
+
+// sampleTrace collects the layer timings of a sampled request.
+type sampleTrace struct {
+	mu     sync.Mutex
+	depth  int
+	layers []SampleLayer
+}
+
+// EnableSampling turns on deep diagnostics for a fraction of the requests
+// served by the router: the time spent matching and in each middleware and
+// handler is delivered to options.Callback. Requests which are not sampled
+// are not affected.
+func (r *Router) EnableSampling(options SamplingOptions) *Router {
+	r.sampling = &options
+	return r
+}
+
+// startSample returns a new trace if the request is selected for sampling.
+func (r *Router) startSample(req *http.Request) *sampleTrace {
+	if r.sampling == nil {
+		return nil
+	}
+	if (r.sampling.Predicate == nil || !r.sampling.Predicate(req)) && (r.sampling.Rate <= 0 || rand.Float64() >= r.sampling.Rate) {
+		return nil
+	}
+	return new(sampleTrace)
+}
+
+// serveSampled serves a sampled request and delivers its sample.
+func (r *Router) serveSampled(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder, handler Handler, match *RouteMatch, start time.Time) error {
+	sample := &Sample{
+		Request: req,
+		Route:   match.Route,
+		Start:   start,
+		Match:   time.Since(start),
+	}
+
+	rw := NewResponseRecorderWriter(w)
+	var err error
+	if r.instrumented() {
+		err = r.serveInstrumented(ctx, rw, req, binder, handler, match)
+	} else {
+		err = handler.ServeHTTP(ctx, rw, req, binder)
+	}
+
+	sample.Total = time.Since(start)
+	sample.Status = rw.StatusOrDefault(err)
+	sample.Err = err
+
+	match.trace.mu.Lock()
+	sample.Layers = match.trace.layers
+	match.trace.mu.Unlock()
+
+	for i := range sample.Layers {
+		layer := &sample.Layers[i]
+		layer.Self = layer.Duration
+		for _, inner := range sample.Layers[i+1:] {
+			if inner.Depth <= layer.Depth {
+				break
+			}
+			if inner.Depth == layer.Depth+1 {
+				layer.Self -= inner.Duration
+			}
+		}
+	}
+
+	r.sampling.Callback(ctx, sample)
+	return err
+}
+
+// wrap returns h timed as a layer called name.
+func (t *sampleTrace) wrap(name string, h Handler) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		t.mu.Lock()
+		i := len(t.layers)
+		t.layers = append(t.layers, SampleLayer{Name: name, Depth: t.depth})
+		t.depth++
+		t.mu.Unlock()
+
+		start := time.Now()
+		err := h.ServeHTTP(ctx, w, req, binder)
+		elapsed := time.Since(start)
+
+		t.mu.Lock()
+		t.layers[i].Duration = elapsed
+		t.depth--
+		t.mu.Unlock()
+
+		return err
+	}
+}
+
+// applyMiddleware wraps next in mw, timing the resulting layer if trace is
+// not nil.
+func applyMiddleware(trace *sampleTrace, mw middleware, next Handler) Handler {
+	h := mw.Middleware(HandlerToHandlerFunc(next))
+	if trace == nil {
+		return h
+	}
+	return trace.wrap(middlewareName(mw), h)
+}
+
+// tracedHandler is like GetHandlerWithMiddlewares, timing the handler and
+// each middleware.
+func (r *Route) tracedHandler(trace *sampleTrace) HandlerFunc {
+	if r.handler == nil {
+		return nil
+	}
+	var handler Handler = trace.wrap("handler", r.handler)
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		handler = applyMiddleware(trace, r.middlewares[i], handler)
+	}
+	return HandlerToHandlerFunc(handler)
+}
+
+// middlewareName returns the function name of a MiddlewareFunc or the type
+// of any other middleware.
+func middlewareName(mw middleware) string {
+	if m, ok := mw.(metadataMiddleware); ok {
+		mw = m.mw
+	}
+	if fn, ok := mw.(MiddlewareFunc); ok {
+		if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+			return f.Name()
+		}
+	}
+	return fmt.Sprintf("%T", mw)
+}
@@ -0,0 +1,80 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func slowMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		time.Sleep(5 * time.Millisecond)
+		return next(ctx, w, r, binder)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	var samples []*Sample
+
+	router := NewRouter().EnableSampling(SamplingOptions{
+		Predicate: func(r *http.Request) bool { return r.Header.Get("X-Debug") != "" },
+		Callback:  func(ctx context.Context, sample *Sample) { samples = append(samples, sample) },
+	})
+	mw := &testMiddleware{}
+	router.Use(mw.Middleware)
+	sub := router.PathPrefix("/api").Subrouter()
+	sub.Use(slowMiddleware)
+	route := sub.HandleFunc("/items", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	})
+
+	for _, debug := range []string{"", "1"} {
+		req := newRequestWithHeaders("GET", "http://localhost/api/items", "X-Debug", debug)
+		if err := router.ServeHTTP(context.Background(), NewRecorder(), req, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if mw.timesCalled != 2 {
+		t.Fatalf("expected middleware to be called twice, got %d", mw.timesCalled)
+	}
+	if len(samples) != 1 {
+		t.Fatalf("expected 1 sample, got %d", len(samples))
+	}
+
+	sample := samples[0]
+	if sample.Route != route || sample.Status != http.StatusAccepted || sample.Total < sample.Match {
+		t.Errorf("unexpected sample %+v", sample)
+	}
+	if len(sample.Layers) != 3 {
+		t.Fatalf("expected 3 layers, got %+v", sample.Layers)
+	}
+
+	outer, slow, handler := sample.Layers[0], sample.Layers[1], sample.Layers[2]
+	if !strings.Contains(outer.Name, "testMiddleware") || !strings.HasSuffix(slow.Name, "slowMiddleware") || handler.Name != "handler" {
+		t.Errorf("unexpected layer names %q, %q, %q", outer.Name, slow.Name, handler.Name)
+	}
+	if outer.Depth != 0 || slow.Depth != 1 || handler.Depth != 2 {
+		t.Errorf("unexpected depths %d, %d, %d", outer.Depth, slow.Depth, handler.Depth)
+	}
+	if slow.Self < 5*time.Millisecond || slow.Self != slow.Duration-handler.Duration || outer.Self != outer.Duration-slow.Duration {
+		t.Errorf("unexpected self times %+v", sample.Layers)
+	}
+}
+
+func TestSamplingRate(t *testing.T) {
+	var sampled int
+	router := NewRouter().EnableSampling(SamplingOptions{
+		Rate:     1,
+		Callback: func(ctx context.Context, sample *Sample) { sampled++ },
+	})
+
+	_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/missing"), nil)
+
+	if sampled != 1 {
+		t.Errorf("expected unmatched request to be sampled")
+	}
+}
@@ -0,0 +1,333 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// HandlePattern registers a new route for a pattern of http.ServeMux, such as
+// "GET /items/{id}", "example.com/static/" or "/files/{path...}", with the
+// semantics of http.ServeMux:
+//
+//   - A method matches that method only, except GET which also matches HEAD.
+//   - "{name}" matches a path segment and "{name...}" the rest of the path.
+//   - A pattern ending with a slash matches any path with that prefix, unless
+//     it ends with "{$}".
+//   - The most specific pattern wins: pattern routes are ordered among
+//     themselves by precedence instead of registration order. A new pattern
+//     route is inserted before the first pattern route of the router it takes
+//     precedence over, and thus before the routes registered after that one.
+//   - Patterns which conflict with a pattern registered before on the router
+//     make the route invalid.
+//
+// The route variables are available with Vars and, with Go 1.22 or later,
+// with Request.PathValue. Conflicts are detected by http.ServeMux, so only
+// duplicate patterns are found with the patterns of Go 1.21, that is before
+// Go 1.22 or with GODEBUG httpmuxgo121=1.
+func (r *Router) HandlePattern(pattern string, handler Handler) *Route {
+	route := r.newRoute()
+	// The templates follow the rules of http.ServeMux.
+	route.strictSlash = false
+	route.colonPatterns = false
+
+	// The pattern is validated here: with the patterns of Go 1.21,
+	// http.ServeMux accepts patterns which are invalid for HandlePattern.
+	p, err := parseServePattern(pattern)
+	if err == nil {
+		r.mu.Lock()
+		if r.servePatterns == nil {
+			r.servePatterns = http.NewServeMux()
+		}
+		err = registerServePattern(r.servePatterns, pattern)
+		r.mu.Unlock()
+	}
+	if err != nil {
+		route.setErr(err)
+		r.addRoutes(route)
+		return route
+	}
+
+	route.servePattern = p
+	if p.method != "" {
+		if p.method == http.MethodGet {
+			route.Methods(http.MethodGet, http.MethodHead)
+		} else {
+			route.Methods(p.method)
+		}
+	}
+	if p.host != "" {
+		route.Host(p.host)
+	}
+	if tpl, prefix := p.template(); prefix {
+		route.PathPrefix(tpl)
+	} else {
+		route.Path(tpl)
+	}
+	if handler != nil {
+		route.Handler(withPathValues(handler))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	routes := r.getRoutes()
+	i := slices.IndexFunc(routes, func(other *Route) bool {
+		return other.servePattern != nil && p.precedes(other.servePattern)
+	})
+	if i == -1 {
+		i = len(routes)
+	}
+	// Readers may hold the current snapshot, insert into a copy.
+	r.table.Store(&routeTable{routes: slices.Insert(slices.Clip(routes), i, route)})
+	r.generations().routes.Add(1)
+	return route
+}
+
+// HandlePatternFunc registers a new route for a pattern of http.ServeMux, see
+// HandlePattern.
+func (r *Router) HandlePatternFunc(pattern string, f func(context.Context, http.ResponseWriter, *http.Request, Binder) error) *Route {
+	return r.HandlePattern(pattern, HandlerFunc(f))
+}
+
+// registerServePattern registers pattern on m to validate it and check it for
+// conflicts.
+func registerServePattern(m *http.ServeMux, pattern string) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("mux: %v", rec)
+		}
+	}()
+	m.Handle(pattern, http.NotFoundHandler())
+	return nil
+}
+
+// servePattern is a parsed pattern of http.ServeMux.
+type servePattern struct {
+	method, host string
+	segments     []servePatternSegment
+}
+
+// servePatternSegment is a path segment of a servePattern: a literal, a
+// wildcard, or a multi wildcard matching the rest of the path. The literal
+// "/" stands for the trailing slash of a pattern ending with "{$}".
+type servePatternSegment struct {
+	s           string
+	wild, multi bool
+}
+
+// parseServePattern parses and validates a pattern of http.ServeMux, with
+// the syntax of Go 1.22.
+func parseServePattern(pattern string) (*servePattern, error) {
+	if pattern == "" {
+		return nil, errors.New("mux: empty pattern")
+	}
+	p := &servePattern{}
+	rest := pattern
+	if i := strings.IndexAny(rest, " \t"); i != -1 {
+		p.method, rest = rest[:i], strings.TrimLeft(rest[i+1:], " \t")
+		if !isServePatternMethod(p.method) {
+			return nil, fmt.Errorf("mux: pattern %q: bad method %q", pattern, p.method)
+		}
+	}
+	i := strings.IndexByte(rest, '/')
+	if i == -1 {
+		return nil, fmt.Errorf("mux: pattern %q: host/path missing /", pattern)
+	}
+	p.host, rest = rest[:i], rest[i+1:]
+	if strings.IndexByte(p.host, '{') != -1 {
+		return nil, fmt.Errorf("mux: pattern %q: host contains '{' (missing initial '/'?)", pattern)
+	}
+	names := map[string]bool{}
+	for {
+		seg, tail, more := strings.Cut(rest, "/")
+		switch {
+		case seg == "" && !more:
+			p.segments = append(p.segments, servePatternSegment{multi: true})
+		case strings.IndexByte(seg, '{') == -1 && strings.IndexByte(seg, '}') == -1:
+			p.segments = append(p.segments, servePatternSegment{s: seg})
+		case seg[0] != '{' || seg[len(seg)-1] != '}' || strings.Count(seg, "{") != 1 || strings.Count(seg, "}") != 1:
+			return nil, fmt.Errorf("mux: pattern %q: bad wildcard segment %q (must be entire segment)", pattern, seg)
+		case seg == "{$}":
+			if more {
+				return nil, fmt.Errorf("mux: pattern %q: {$} not at end", pattern)
+			}
+			p.segments = append(p.segments, servePatternSegment{s: "/"})
+		default:
+			name, multi := strings.CutSuffix(seg[1:len(seg)-1], "...")
+			if multi && more {
+				return nil, fmt.Errorf("mux: pattern %q: {...} wildcard not at end", pattern)
+			}
+			if !isServePatternName(name) {
+				return nil, fmt.Errorf("mux: pattern %q: bad wildcard name %q", pattern, name)
+			}
+			if names[name] {
+				return nil, fmt.Errorf("mux: pattern %q: duplicate wildcard name %q", pattern, name)
+			}
+			names[name] = true
+			p.segments = append(p.segments, servePatternSegment{s: name, wild: true, multi: multi})
+		}
+		if !more {
+			return p, nil
+		}
+		rest = tail
+	}
+}
+
+// isServePatternMethod reports whether s is a valid method token.
+func isServePatternMethod(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c >= 0x7f || strings.IndexByte("\"(),/:;<=>?@[\\]{}", c) != -1 {
+			return false
+		}
+	}
+	return true
+}
+
+// isServePatternName reports whether s is a valid wildcard name, a Go
+// identifier.
+func isServePatternName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// template returns the path template of p, and whether it is a prefix.
+func (p *servePattern) template() (tpl string, prefix bool) {
+	var b strings.Builder
+	for _, seg := range p.segments {
+		b.WriteByte('/')
+		switch {
+		case seg.multi && !seg.wild:
+			return b.String(), true
+		case seg.multi:
+			b.WriteString("{" + seg.s + ":.*}")
+		case seg.wild:
+			b.WriteString("{" + seg.s + "}")
+		case seg.s != "/":
+			b.WriteString(seg.s)
+		}
+	}
+	return b.String(), false
+}
+
+// precedes reports whether p takes precedence over q: patterns with a host
+// take precedence over patterns without, and more specific patterns over
+// more general ones.
+func (p *servePattern) precedes(q *servePattern) bool {
+	if p.host != q.host {
+		return q.host == ""
+	}
+	return p.compare(q) == moreSpecific
+}
+
+// patternRelationship is the relationship of the requests matched by two
+// patterns, as defined by http.ServeMux.
+type patternRelationship int
+
+const (
+	equivalent patternRelationship = iota
+	moreGeneral
+	moreSpecific
+	disjoint
+	overlaps
+)
+
+func (p *servePattern) compare(q *servePattern) patternRelationship {
+	var methods patternRelationship
+	switch {
+	case p.method == q.method:
+		methods = equivalent
+	case p.method == "" || p.method == http.MethodGet && q.method == http.MethodHead:
+		methods = moreGeneral
+	case q.method == "" || q.method == http.MethodGet && p.method == http.MethodHead:
+		methods = moreSpecific
+	default:
+		return disjoint
+	}
+	return combineRelationships(methods, p.comparePaths(q))
+}
+
+func (p *servePattern) comparePaths(q *servePattern) patternRelationship {
+	pMulti, qMulti := p.segments[len(p.segments)-1].multi, q.segments[len(q.segments)-1].multi
+	if len(p.segments) != len(q.segments) && !pMulti && !qMulti {
+		return disjoint
+	}
+	rel := equivalent
+	segs1, segs2 := p.segments, q.segments
+	for ; len(segs1) > 0 && len(segs2) > 0; segs1, segs2 = segs1[1:], segs2[1:] {
+		if rel = combineRelationships(rel, compareSegments(segs1[0], segs2[0])); rel == disjoint {
+			return rel
+		}
+	}
+	switch {
+	case len(segs1) == 0 && len(segs2) == 0:
+		return rel
+	case len(segs1) < len(segs2) && pMulti:
+		return combineRelationships(rel, moreGeneral)
+	case len(segs2) < len(segs1) && qMulti:
+		return combineRelationships(rel, moreSpecific)
+	}
+	return disjoint
+}
+
+func compareSegments(s1, s2 servePatternSegment) patternRelationship {
+	switch {
+	case s1.multi && s2.multi:
+		return equivalent
+	case s1.multi:
+		return moreGeneral
+	case s2.multi:
+		return moreSpecific
+	case s1.wild && s2.wild:
+		return equivalent
+	case s1.wild:
+		// A wildcard does not match the trailing slash of "{$}".
+		if s2.s == "/" {
+			return disjoint
+		}
+		return moreGeneral
+	case s2.wild:
+		if s1.s == "/" {
+			return disjoint
+		}
+		return moreSpecific
+	case s1.s == s2.s:
+		return equivalent
+	}
+	return disjoint
+}
+
+func combineRelationships(r1, r2 patternRelationship) patternRelationship {
+	switch r1 {
+	case equivalent:
+		return r2
+	case disjoint:
+		return disjoint
+	case overlaps:
+		if r2 == disjoint {
+			return disjoint
+		}
+		return overlaps
+	}
+	// r1 is moreGeneral or moreSpecific.
+	switch {
+	case r2 == equivalent:
+		return r1
+	case r2 == moreGeneral && r1 == moreSpecific, r2 == moreSpecific && r1 == moreGeneral:
+		return overlaps
+	}
+	return r2
+}
@@ -0,0 +1,56 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// TestHandlePatternGo121 checks the validation of patterns with the patterns
+// of Go 1.21, the default of a go.mod at go 1.21. servemux_test.go sets
+// httpmuxgo121=0 for the whole test binary, so the test runs again in a
+// subprocess with GODEBUG, which takes precedence.
+func TestHandlePatternGo121(t *testing.T) {
+	if os.Getenv("MUX_TEST_HTTPMUXGO121") == "" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestHandlePatternGo121$")
+		cmd.Env = append(os.Environ(), "MUX_TEST_HTTPMUXGO121=1", "GODEBUG=httpmuxgo121=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+	}
+
+	router := NewRouter()
+	for _, pattern := range []string{
+		"",
+		"GET items",
+		"items",
+		"{host}/items",
+		"/items/{id",
+		"/items/x{id}",
+		"/items/{id}x",
+		"/items/{}",
+		"/items/{1d}",
+		"/items/{id}/{id}",
+		"/files/{path...}/x",
+		"/{$}/x",
+		"G(T /items",
+	} {
+		if err := router.HandlePatternFunc(pattern, dummyHandler).GetError(); err == nil {
+			t.Errorf("%q: expected an error", pattern)
+		}
+	}
+
+	router.HandlePatternFunc("GET /items/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		w.Header().Set("X-Id", Vars(r)["id"])
+		return nil
+	})
+	rw := NewRecorder()
+	if err := router.ServeHTTP(context.Background(), rw, newRequest("GET", "http://localhost/items/1"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := rw.Header().Get("X-Id"); got != "1" {
+		t.Errorf("expected id 1, got %q", got)
+	}
+}
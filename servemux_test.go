@@ -0,0 +1,121 @@
+//go:build go1.22
+
+// The patterns of http.ServeMux tested here and in gateway_test.go are
+// those of Go 1.22, which a go.mod at go 1.21 disables by default.
+//go:debug httpmuxgo121=0
+
+package mux
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestHandlePattern(t *testing.T) {
+	router := NewRouter()
+	handle := func(pattern string) {
+		router.HandlePatternFunc(pattern, func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+			w.Header().Set("X-Pattern", pattern)
+			w.Header().Set("X-Id", r.PathValue("id"))
+			w.Header().Set("X-Path", r.PathValue("path"))
+			return nil
+		})
+	}
+	handle("/items/{id}")
+	handle("GET /items/new")
+	handle("GET /items/{id}")
+	handle("/")
+	handle("/{$}")
+	handle("/static/")
+	handle("/files/{path...}")
+	handle("example.com/items/{id}")
+	handle("POST /items/new")
+
+	tests := []struct {
+		method, url string
+		pattern     string
+		id, path    string
+	}{
+		{"GET", "http://localhost/items/1", "GET /items/{id}", "1", ""},
+		{"HEAD", "http://localhost/items/1", "GET /items/{id}", "1", ""},
+		{"DELETE", "http://localhost/items/1", "/items/{id}", "1", ""},
+		{"GET", "http://localhost/items/new", "GET /items/new", "", ""},
+		{"DELETE", "http://localhost/items/new", "/items/{id}", "new", ""},
+		{"POST", "http://localhost/items/new", "POST /items/new", "", ""},
+		{"GET", "http://example.com/items/1", "example.com/items/{id}", "1", ""},
+		{"GET", "http://localhost/", "/{$}", "", ""},
+		{"GET", "http://localhost/other", "/", "", ""},
+		{"GET", "http://localhost/static/css/site.css", "/static/", "", ""},
+		{"GET", "http://localhost/files/a/b.txt", "/files/{path...}", "", "a/b.txt"},
+		{"GET", "http://localhost/files/", "/files/{path...}", "", ""},
+	}
+	for _, tt := range tests {
+		rw := NewRecorder()
+		if err := router.ServeHTTP(context.Background(), rw, newRequest(tt.method, tt.url), nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := rw.Header().Get("X-Pattern"); got != tt.pattern {
+			t.Errorf("%s %s: expected pattern %q, got %q", tt.method, tt.url, tt.pattern, got)
+		}
+		if rw.Header().Get("X-Id") != tt.id || rw.Header().Get("X-Path") != tt.path {
+			t.Errorf("%s %s: unexpected path values %v", tt.method, tt.url, rw.Header())
+		}
+	}
+}
+
+func TestHandlePatternMethodNotAllowed(t *testing.T) {
+	router := NewRouter()
+	router.HandlePatternFunc("GET /items/{id}", dummyHandler)
+	router.HandlePatternFunc("PUT /items/{id}", dummyHandler)
+
+	rw := NewRecorder()
+	if err := router.ServeHTTP(context.Background(), rw, newRequest("POST", "http://localhost/items/1"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if rw.Code != http.StatusMethodNotAllowed || rw.Header().Get("Allow") != "GET, HEAD, PUT" {
+		t.Errorf("expected 405 with Allow header, got %d %v", rw.Code, rw.Header())
+	}
+}
+
+func TestHandlePatternErrors(t *testing.T) {
+	router := NewRouter()
+	router.HandlePatternFunc("/a/{x}", dummyHandler)
+	for _, pattern := range []string{"/{y}/b", "/a/{x}", "/a/b{c}", "GET"} {
+		if err := router.HandlePatternFunc(pattern, dummyHandler).GetError(); err == nil {
+			t.Errorf("%q: expected an error", pattern)
+		}
+	}
+}
+
+func TestServePatternPrecedes(t *testing.T) {
+	tests := []struct {
+		p, q     string
+		precedes bool
+	}{
+		{"/items/new", "/items/{id}", true},
+		{"/items/{id}", "/items/new", false},
+		{"GET /items/{id}", "/items/{id}", true},
+		{"GET /items", "HEAD /items", false},
+		{"HEAD /items", "GET /items", true},
+		{"/items/{id}", "/items/", true},
+		{"/{$}", "/", true},
+		{"/items/{$}", "/items/{id}", false},
+		{"example.com/", "/items/new", true},
+		{"/a/{x}", "/b/{x}", false},
+		{"/files/{path...}", "/files/", false},
+	}
+	for _, tt := range tests {
+		p, err := parseServePattern(tt.p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := parseServePattern(tt.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.precedes(q); got != tt.precedes {
+			t.Errorf("%q precedes %q: expected %v, got %v", tt.p, tt.q, tt.precedes, got)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package mux
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownTimeout is the time Serve waits for in-flight requests to
+// finish before closing their connections.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// ServerErrorHandler handles an error returned by the router when it is
+// served by Serve. wroteHeader tells whether the handler already sent a
+// response status.
+type ServerErrorHandler func(ctx context.Context, w http.ResponseWriter, r *http.Request, err error, wroteHeader bool)
+
+// ServerOption configures Serve.
+type ServerOption func(*serverConfig)
+
+type serverConfig struct {
+	shutdownTimeout time.Duration
+	signals         []os.Signal
+	binder          Binder
+	errorHandler    ServerErrorHandler
+	configure       []func(*http.Server)
+	wrappers        []func(http.Handler) http.Handler
+	onListen        func(addr net.Addr)
+
+	tlsConfig         *tls.Config
+	certFile, keyFile string
+	certManager       CertificateManager
+	challengeAddr     string
+
+	h2c   bool
+	http2 *HTTP2Config
+}
+
+// CertificateManager obtains certificates on demand, e.g. from an ACME CA.
+// It is implemented by *autocert.Manager from golang.org/x/crypto.
+type CertificateManager interface {
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+	// HTTPHandler answers HTTP-01 challenges and handles other requests
+	// with fallback, redirecting to HTTPS if it is nil.
+	HTTPHandler(fallback http.Handler) http.Handler
+}
+
+// WithShutdownTimeout sets the time in-flight requests are given to finish
+// on shutdown. It defaults to DefaultShutdownTimeout.
+func WithShutdownTimeout(timeout time.Duration) ServerOption {
+	return func(c *serverConfig) {
+		c.shutdownTimeout = timeout
+	}
+}
+
+// WithSignals sets the signals triggering a graceful shutdown. It defaults
+// to SIGINT and SIGTERM. Calling it without signals disables signal
+// handling.
+func WithSignals(signals ...os.Signal) ServerOption {
+	return func(c *serverConfig) {
+		c.signals = signals
+	}
+}
+
+// WithBinder sets the binder passed to the router for every request.
+func WithBinder(binder Binder) ServerOption {
+	return func(c *serverConfig) {
+		c.binder = binder
+	}
+}
+
+// WithErrorHandler sets the handler for errors returned by the router. By
+// default errors are logged with the router's Logger and answered with the
+// status of ErrorStatus, usually 500 Internal Server Error, if no response was
+// sent yet.
+func WithErrorHandler(handler ServerErrorHandler) ServerOption {
+	return func(c *serverConfig) {
+		c.errorHandler = handler
+	}
+}
+
+// WithServerConfig registers a function adjusting the http.Server before it
+// starts, e.g. to set timeouts or limits. The Handler and BaseContext fields
+// are owned by Serve.
+func WithServerConfig(fn func(srv *http.Server)) ServerOption {
+	return func(c *serverConfig) {
+		c.configure = append(c.configure, fn)
+	}
+}
+
+// WithHandlerWrapper registers a function wrapping the http.Handler serving
+// the router, e.g. to set response headers on every protocol level request.
+// Wrappers are applied in order, the last one being the outermost.
+func WithHandlerWrapper(fn func(next http.Handler) http.Handler) ServerOption {
+	return func(c *serverConfig) {
+		c.wrappers = append(c.wrappers, fn)
+	}
+}
+
+// WithOnListen registers a function called with the listening address once
+// the server accepts connections.
+func WithOnListen(fn func(addr net.Addr)) ServerOption {
+	return func(c *serverConfig) {
+		c.onListen = fn
+	}
+}
+
+// WithTLS serves HTTPS using config, which must provide certificates with
+// Certificates or GetCertificate unless WithCertificateFiles is used too.
+func WithTLS(config *tls.Config) ServerOption {
+	return func(c *serverConfig) {
+		c.tlsConfig = config
+	}
+}
+
+// WithCertificateFiles serves HTTPS using the PEM encoded certificate chain
+// and private key from the given files.
+func WithCertificateFiles(certFile, keyFile string) ServerOption {
+	return func(c *serverConfig) {
+		c.certFile, c.keyFile = certFile, keyFile
+	}
+}
+
+// WithCertificateManager serves HTTPS with certificates obtained from
+// manager. If challengeAddr is not empty, manager.HTTPHandler is served on
+// it, usually ":80", to answer HTTP-01 challenges and redirect to HTTPS.
+func WithCertificateManager(manager CertificateManager, challengeAddr string) ServerOption {
+	return func(c *serverConfig) {
+		c.certManager = manager
+		c.challengeAddr = challengeAddr
+	}
+}
+
+// WithH2C additionally accepts HTTP/2 without TLS ("h2c" with prior
+// knowledge), e.g. for gRPC-style internal clients. Streaming, trailers and
+// full duplex work through the router's writers, see ResponseRecorderWriter.
+// It is served by net/http with Go 1.24 or later, and by the HTTP/2 server of
+// golang.org/x/net before, which also accepts the deprecated upgrade from
+// HTTP/1.1 with the "Upgrade: h2c" header.
+func WithH2C() ServerOption {
+	return func(c *serverConfig) {
+		c.h2c = true
+	}
+}
+
+// WithHTTP2Config sets the HTTP/2 settings, such as the maximum number of
+// concurrent streams. With Go 1.24 or later they configure the HTTP/2 server
+// of net/http, before they configure the HTTP/2 server of golang.org/x/net.
+func WithHTTP2Config(config HTTP2Config) ServerOption {
+	return func(c *serverConfig) {
+		c.http2 = &config
+	}
+}
+
+// HTTP2Config holds the HTTP/2 settings of WithHTTP2Config. The fields are
+// those of http.HTTP2Config of Go 1.24, with the same meaning: zero values
+// select the defaults. Before Go 1.24, SendPingTimeout sets the
+// ReadIdleTimeout of the HTTP/2 server of golang.org/x/net, and
+// MaxReceiveBufferPerConnection and MaxReceiveBufferPerStream its
+// MaxUploadBuffer settings.
+type HTTP2Config struct {
+	MaxConcurrentStreams          int
+	MaxDecoderHeaderTableSize     int
+	MaxEncoderHeaderTableSize     int
+	MaxReadFrameSize              int
+	MaxReceiveBufferPerConnection int
+	MaxReceiveBufferPerStream     int
+	SendPingTimeout               time.Duration
+	PingTimeout                   time.Duration
+	WriteByteTimeout              time.Duration
+	PermitProhibitedCipherSuites  bool
+	CountError                    func(errType string)
+}
+
+func (c *serverConfig) tlsEnabled() bool {
+	return c.tlsConfig != nil || c.certFile != "" || c.certManager != nil
+}
+
+// configureProtocols sets the TLS and HTTP/2 configuration of srv.
+func (c *serverConfig) configureProtocols(srv *http.Server) error {
+	if c.tlsConfig != nil {
+		srv.TLSConfig = c.tlsConfig.Clone()
+	}
+	if c.certManager != nil {
+		if srv.TLSConfig == nil {
+			srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		srv.TLSConfig.GetCertificate = c.certManager.GetCertificate
+		// Allow TLS-ALPN-01 challenges next to the protocols added by
+		// ServeTLS.
+		for _, proto := range []string{"h2", "http/1.1", "acme-tls/1"} {
+			if !slices.Contains(srv.TLSConfig.NextProtos, proto) {
+				srv.TLSConfig.NextProtos = append(srv.TLSConfig.NextProtos, proto)
+			}
+		}
+	}
+	return c.configureHTTP2(srv)
+}
+
+// Serve serves router on addr until ctx is cancelled or one of the shutdown
+// signals is received. See Listen for the supported address forms. It serves
+// HTTPS if any of the TLS options is given. On shutdown it stops accepting
+// connections and waits for in-flight requests to finish, bounded by the
+// shutdown timeout. Serve returns nil after a graceful shutdown, and
+// otherwise all errors encountered while serving and shutting down.
+//
+// Handlers receive the request context, which is not cancelled when the
+// shutdown starts.
+func Serve(ctx context.Context, addr string, router *Router, opts ...ServerOption) error {
+	config := newServerConfig(router, opts)
+
+	if addr == "" {
+		addr = ":http"
+		if config.tlsEnabled() {
+			addr = ":https"
+		}
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := config.configureProtocols(srv); err != nil {
+		return err
+	}
+	for _, fn := range config.configure {
+		fn(srv)
+	}
+	baseCtx := context.WithoutCancel(ctx)
+	srv.BaseContext = func(net.Listener) context.Context { return baseCtx }
+	srv.Handler = config.handler(router)
+
+	servers := []*serverInstance{{srv: srv, tls: config.tlsEnabled()}}
+	if config.certManager != nil && config.challengeAddr != "" {
+		servers = append(servers, &serverInstance{srv: &http.Server{
+			Addr:              config.challengeAddr,
+			Handler:           config.certManager.HTTPHandler(nil),
+			ReadHeaderTimeout: srv.ReadHeaderTimeout,
+		}})
+	}
+
+	for i, s := range servers {
+		ln, err := Listen(s.srv.Addr)
+		if err != nil {
+			for _, s := range servers[:i] {
+				s.ln.Close()
+			}
+			return err
+		}
+		s.ln = ln
+	}
+
+	if len(config.signals) > 0 {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, config.signals...)
+		defer stop()
+	}
+
+	errc := make(chan error, len(servers))
+	for _, s := range servers {
+		go func(s *serverInstance) {
+			if s.tls {
+				errc <- s.srv.ServeTLS(s.ln, config.certFile, config.keyFile)
+			} else {
+				errc <- s.srv.Serve(s.ln)
+			}
+		}(s)
+	}
+	if config.onListen != nil {
+		config.onListen(servers[0].ln.Addr())
+	}
+
+	var errs []error
+	pending := len(servers)
+	select {
+	case err := <-errc:
+		errs = append(errs, err)
+		pending--
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.shutdownTimeout)
+	defer cancel()
+
+	for _, s := range servers {
+		if err := s.srv.Shutdown(shutdownCtx); err != nil {
+			errs = append(errs, err)
+			if err := s.srv.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for ; pending > 0; pending-- {
+		if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// newServerConfig returns the configuration of opts, with the defaults of
+// router.
+func newServerConfig(router *Router, opts []ServerOption) *serverConfig {
+	config := &serverConfig{
+		shutdownTimeout: DefaultShutdownTimeout,
+		signals:         []os.Signal{os.Interrupt, syscall.SIGTERM},
+		errorHandler:    defaultServerErrorHandler(router),
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// handler returns the http.Handler serving router, wrapped by the functions
+// of WithHandlerWrapper.
+func (c *serverConfig) handler(router *Router) http.Handler {
+	var h http.Handler = &serverHandler{router: router, binder: c.binder, errorHandler: c.errorHandler}
+	for _, wrap := range c.wrappers {
+		h = wrap(h)
+	}
+	return h
+}
+
+// serverInstance is an http.Server run by Serve.
+type serverInstance struct {
+	srv *http.Server
+	ln  net.Listener
+	tls bool
+}
+
+// serverHandler adapts a router to http.Handler.
+type serverHandler struct {
+	router       *Router
+	binder       Binder
+	errorHandler ServerErrorHandler
+}
+
+func (h *serverHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rw := NewResponseRecorderWriter(w)
+	if err := h.router.ServeHTTP(r.Context(), rw, r, h.binder); err != nil {
+		h.errorHandler(r.Context(), rw, r, err, rw.WroteHeader())
+	}
+}
+
+func defaultServerErrorHandler(router *Router) ServerErrorHandler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, err error, wroteHeader bool) {
+		router.getLogger().Log(ctx, slog.LevelError, "mux: handler failed", "method", r.Method, "path", r.URL.Path, "error", err)
+		if !wroteHeader {
+			status := ErrorStatus(err)
+			http.Error(w, http.StatusText(status), status)
+		}
+	}
+}
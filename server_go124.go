@@ -0,0 +1,31 @@
+//go:build go1.24
+
+package mux
+
+import "net/http"
+
+// configureHTTP2 sets the HTTP/2 configuration of srv.
+func (c *serverConfig) configureHTTP2(srv *http.Server) error {
+	if c.h2c {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	if cfg := c.http2; cfg != nil {
+		srv.HTTP2 = &http.HTTP2Config{
+			MaxConcurrentStreams:          cfg.MaxConcurrentStreams,
+			MaxDecoderHeaderTableSize:     cfg.MaxDecoderHeaderTableSize,
+			MaxEncoderHeaderTableSize:     cfg.MaxEncoderHeaderTableSize,
+			MaxReadFrameSize:              cfg.MaxReadFrameSize,
+			MaxReceiveBufferPerConnection: cfg.MaxReceiveBufferPerConnection,
+			MaxReceiveBufferPerStream:     cfg.MaxReceiveBufferPerStream,
+			SendPingTimeout:               cfg.SendPingTimeout,
+			PingTimeout:                   cfg.PingTimeout,
+			WriteByteTimeout:              cfg.WriteByteTimeout,
+			PermitProhibitedCipherSuites:  cfg.PermitProhibitedCipherSuites,
+			CountError:                    cfg.CountError,
+		}
+	}
+	return nil
+}
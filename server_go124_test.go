@@ -0,0 +1,75 @@
+//go:build go1.24
+
+package mux
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestServeH2C(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/", protoHandler)
+	addr, stop := serveForTest(t, router, "127.0.0.1:0", WithH2C())
+	defer stop()
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "HTTP/2.0" {
+		t.Errorf("expected cleartext HTTP/2, got %q", body)
+	}
+}
+
+func TestServeH2CTrailers(t *testing.T) {
+	router := NewRouter()
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+			return next(ctx, opaqueWriter{w}, r, binder)
+		}
+	})
+	router.HandleFunc("/stream", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		rc := http.NewResponseController(w)
+		if err := rc.EnableFullDuplex(); err != nil {
+			return err
+		}
+		w.Header().Set("Trailer", "Grpc-Status")
+		io.WriteString(w, "chunk")
+		if err := rc.Flush(); err != nil {
+			return err
+		}
+		w.Header().Set("Grpc-Status", "0")
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", "ok")
+		return nil
+	})
+	addr, stop := serveForTest(t, router, "127.0.0.1:0", WithH2C())
+	defer stop()
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get("http://" + addr + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "chunk" || resp.ProtoMajor != 2 {
+		t.Errorf("unexpected response %s %q", resp.Proto, body)
+	}
+	if resp.Trailer.Get("Grpc-Status") != "0" || resp.Trailer.Get("Grpc-Message") != "ok" {
+		t.Errorf("expected the trailers to be sent, got %v", resp.Trailer)
+	}
+}
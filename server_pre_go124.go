@@ -0,0 +1,60 @@
+//go:build !go1.24
+
+package mux
+
+import (
+	"math"
+	"net/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// configureHTTP2 configures the HTTP/2 server of golang.org/x/net for srv,
+// as net/http supports neither h2c nor HTTP/2 settings before Go 1.24.
+func (c *serverConfig) configureHTTP2(srv *http.Server) error {
+	if !c.h2c && c.http2 == nil {
+		return nil
+	}
+	h2s := &http2.Server{}
+	if cfg := c.http2; cfg != nil {
+		h2s.MaxConcurrentStreams = clampUint32(cfg.MaxConcurrentStreams)
+		h2s.MaxDecoderHeaderTableSize = clampUint32(cfg.MaxDecoderHeaderTableSize)
+		h2s.MaxEncoderHeaderTableSize = clampUint32(cfg.MaxEncoderHeaderTableSize)
+		h2s.MaxReadFrameSize = clampUint32(cfg.MaxReadFrameSize)
+		h2s.MaxUploadBufferPerConnection = clampInt32(cfg.MaxReceiveBufferPerConnection)
+		h2s.MaxUploadBufferPerStream = clampInt32(cfg.MaxReceiveBufferPerStream)
+		h2s.ReadIdleTimeout = cfg.SendPingTimeout
+		h2s.PingTimeout = cfg.PingTimeout
+		h2s.WriteByteTimeout = cfg.WriteByteTimeout
+		h2s.PermitProhibitedCipherSuites = cfg.PermitProhibitedCipherSuites
+		h2s.CountError = cfg.CountError
+	}
+	if err := http2.ConfigureServer(srv, h2s); err != nil {
+		return err
+	}
+	if c.h2c {
+		// The last wrapper is the outermost, see handler.
+		c.wrappers = append(c.wrappers, func(next http.Handler) http.Handler {
+			return h2c.NewHandler(next, h2s)
+		})
+	}
+	return nil
+}
+
+// clampUint32 converts n to uint32, an invalid value for out of range values
+// is replaced by the default.
+func clampUint32(n int) uint32 {
+	if n < 0 || uint64(n) > math.MaxUint32 {
+		return 0
+	}
+	return uint32(n)
+}
+
+// clampInt32 converts n to int32, like clampUint32.
+func clampInt32(n int) int32 {
+	if n < 0 || n > math.MaxInt32 {
+		return 0
+	}
+	return int32(n)
+}
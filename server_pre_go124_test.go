@@ -0,0 +1,98 @@
+//go:build !go1.24
+
+package mux
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"golang.org/x/net/http2"
+)
+
+func h2cClient() *http.Client {
+	return &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+}
+
+func TestServeH2C(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/", protoHandler)
+	addr, stop := serveForTest(t, router, "127.0.0.1:0", WithH2C(), WithHTTP2Config(HTTP2Config{MaxConcurrentStreams: 10}))
+	defer stop()
+
+	client := h2cClient()
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "HTTP/2.0" {
+		t.Errorf("expected cleartext HTTP/2, got %q", body)
+	}
+}
+
+func TestServeH2CTrailers(t *testing.T) {
+	router := NewRouter()
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+			return next(ctx, opaqueWriter{w}, r, binder)
+		}
+	})
+	router.HandleFunc("/stream", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		w.Header().Set("Trailer", "Grpc-Status")
+		io.WriteString(w, "chunk")
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			return err
+		}
+		w.Header().Set("Grpc-Status", "0")
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", "ok")
+		return nil
+	})
+	addr, stop := serveForTest(t, router, "127.0.0.1:0", WithH2C())
+	defer stop()
+
+	client := h2cClient()
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get("http://" + addr + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "chunk" || resp.ProtoMajor != 2 {
+		t.Errorf("unexpected response %s %q", resp.Proto, body)
+	}
+	if resp.Trailer.Get("Grpc-Status") != "0" || resp.Trailer.Get("Grpc-Message") != "ok" {
+		t.Errorf("expected the trailers to be sent, got %v", resp.Trailer)
+	}
+}
+
+func TestServeH2CHTTP1(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/", protoHandler)
+	addr, stop := serveForTest(t, router, "127.0.0.1:0", WithH2C())
+	defer stop()
+
+	resp, err := http.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "HTTP/1.1" {
+		t.Errorf("expected HTTP/1.1 next to h2c, got %q", body)
+	}
+}
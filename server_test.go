@@ -0,0 +1,161 @@
+package mux
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServe(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	router := NewRouter().SetLogger(NopLogger())
+	router.HandleFunc("/slow", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		close(started)
+		<-release
+		_, err := w.Write([]byte("done"))
+		return err
+	})
+	router.HandleFunc("/fail", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		return errors.New("boom")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addrc := make(chan net.Addr, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, "127.0.0.1:0", router,
+			WithSignals(),
+			WithShutdownTimeout(5*time.Second),
+			WithOnListen(func(addr net.Addr) { addrc <- addr }))
+	}()
+	base := "http://" + (<-addrc).String()
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	resp, err := client.Get(base + "/fail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected 500 for handler error, got %d", resp.StatusCode)
+	}
+
+	bodyc := make(chan string, 1)
+	go func() {
+		resp, err := client.Get(base + "/slow")
+		if err != nil {
+			bodyc <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		bodyc <- string(b)
+	}()
+
+	<-started
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if body := <-bodyc; body != "done" {
+		t.Errorf("expected in-flight request to be drained, got %q", body)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("expected graceful shutdown, got %v", err)
+	}
+}
+
+func TestServeListenError(t *testing.T) {
+	if err := Serve(context.Background(), "127.0.0.1:-1", NewRouter()); err == nil {
+		t.Error("expected listen error")
+	}
+}
+
+func serveForTest(t *testing.T, router *Router, addr string, opts ...ServerOption) (string, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	addrc := make(chan net.Addr, 1)
+	done := make(chan error, 1)
+	opts = append(opts, WithSignals(), WithOnListen(func(addr net.Addr) { addrc <- addr }))
+	go func() {
+		done <- Serve(ctx, addr, router, opts...)
+	}()
+
+	select {
+	case addr := <-addrc:
+		return addr.String(), func() {
+			cancel()
+			if err := <-done; err != nil {
+				t.Errorf("unexpected shutdown error %v", err)
+			}
+		}
+	case err := <-done:
+		cancel()
+		t.Fatalf("serve failed: %v", err)
+		return "", nil
+	}
+}
+
+func protoHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+	_, err := w.Write([]byte(r.Proto))
+	return err
+}
+
+func TestServeTLS(t *testing.T) {
+	// Borrow the test certificate and a client trusting it from httptest.
+	ts := httptest.NewTLSServer(nil)
+	cert := ts.TLS.Certificates[0]
+	client := ts.Client()
+	client.Transport.(*http.Transport).ForceAttemptHTTP2 = true
+	ts.Close()
+
+	router := NewRouter()
+	router.HandleFunc("/", protoHandler)
+	addr, stop := serveForTest(t, router, "127.0.0.1:0", WithTLS(&tls.Config{Certificates: []tls.Certificate{cert}}))
+	defer stop()
+
+	resp, err := client.Get("https://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "HTTP/2.0" {
+		t.Errorf("expected HTTP/2 over TLS, got %q", body)
+	}
+}
+
+func TestServeHandlerWrapper(t *testing.T) {
+	router := NewRouter().SetLogger(NopLogger())
+	router.HandleFunc("/", protoHandler)
+
+	header := func(value string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Wrapper", value)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	addr, stop := serveForTest(t, router, "127.0.0.1:0", WithHandlerWrapper(header("inner")), WithHandlerWrapper(header("outer")))
+	defer stop()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Values("X-Wrapper"); len(got) != 2 || got[0] != "outer" || got[1] != "inner" {
+		t.Errorf("expected the wrappers outermost last, got %q", got)
+	}
+}
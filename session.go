@@ -0,0 +1,316 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidSession is returned by a SessionStore when the session data sent
+	// by the client could not be decoded or failed verification.
+	ErrInvalidSession = errors.New("mux: invalid session")
+	// ErrNoSession is returned by SessionFromRequest when the request was not
+	// handled by a SessionMiddleware.
+	ErrNoSession = errors.New("mux: no session in context")
+)
+
+// SessionOptions stores the cookie configuration of a session.
+//
+// MaxAge=0 means no Max-Age attribute is set, i.e. the cookie lives for the
+// browser session. MaxAge<0 deletes the cookie on the next save.
+type SessionOptions struct {
+	Path     string
+	Domain   string
+	MaxAge   int
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+// SessionData stores the values of a single client session.
+type SessionData struct {
+	// Values holds the data stored in the session. Values must be
+	// encodable with encoding/gob; custom types need to be registered
+	// with gob.Register.
+	Values map[string]any
+	// Options is used when the session cookie is written.
+	Options *SessionOptions
+	// IsNew is true if the session was created for this request.
+	IsNew bool
+
+	name  string
+	store SessionStore
+}
+
+// NewSession returns an empty session for the given store and cookie name.
+// It is meant to be used by SessionStore implementations.
+func NewSession(store SessionStore, name string) *SessionData {
+	return &SessionData{
+		Values:  make(map[string]any),
+		Options: new(SessionOptions),
+		IsNew:   true,
+		name:    name,
+		store:   store,
+	}
+}
+
+// Name returns the cookie name of the session.
+func (s *SessionData) Name() string {
+	return s.name
+}
+
+// Store returns the store the session was loaded from.
+func (s *SessionData) Store() SessionStore {
+	return s.store
+}
+
+// Save persists the session using its store. Sessions handled by a
+// SessionMiddleware are saved automatically before the response is written.
+func (s *SessionData) Save(r *http.Request, w http.ResponseWriter) error {
+	return s.store.Save(r, w, s)
+}
+
+// SessionStore loads and persists sessions.
+type SessionStore interface {
+	// Get returns the session with the given name for the request. If the
+	// request carries no (valid) session, a new one is returned. An
+	// ErrInvalidSession error may be returned alongside a new session if the
+	// client sent data which could not be decoded.
+	Get(r *http.Request, name string) (*SessionData, error)
+	// Save persists the session and writes the session cookie to w.
+	Save(r *http.Request, w http.ResponseWriter, s *SessionData) error
+}
+
+// CookieStore is a SessionStore keeping all session values in a signed
+// cookie. The values are not encrypted and thus readable by the client.
+type CookieStore struct {
+	// Options are copied into every new session.
+	Options *SessionOptions
+
+	keys [][]byte
+}
+
+// NewCookieStore returns a CookieStore signing cookies with the given keys.
+// The first key is used for signing, all keys are accepted for verification
+// which allows rotating keys without invalidating existing sessions.
+func NewCookieStore(keys ...[]byte) *CookieStore {
+	return &CookieStore{
+		Options: &SessionOptions{
+			Path:     "/",
+			MaxAge:   86400 * 30,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+		keys: keys,
+	}
+}
+
+// Get implements SessionStore.
+func (s *CookieStore) Get(r *http.Request, name string) (*SessionData, error) {
+	session := NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	values, err := s.decode(name, cookie.Value)
+	if err != nil {
+		return session, err
+	}
+
+	session.Values = values
+	session.IsNew = false
+
+	return session, nil
+}
+
+// Save implements SessionStore.
+func (s *CookieStore) Save(r *http.Request, w http.ResponseWriter, session *SessionData) error {
+	value := ""
+	if session.Options.MaxAge >= 0 {
+		var err error
+		if value, err = s.encode(session.name, session.Values); err != nil {
+			return err
+		}
+	}
+
+	http.SetCookie(w, newSessionCookie(session.name, value, session.Options))
+
+	return nil
+}
+
+func (s *CookieStore) encode(name string, values map[string]any) (string, error) {
+	if len(s.keys) == 0 {
+		return "", errors.New("mux: cookie store has no keys")
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		return "", fmt.Errorf("mux: encoding session %q: %w", name, err)
+	}
+
+	payload := strconv.FormatInt(time.Now().Unix(), 10) + "|" + base64.RawURLEncoding.EncodeToString(buf.Bytes())
+	mac := signSession(s.keys[0], name, payload)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(mac), nil
+}
+
+func (s *CookieStore) decode(name, value string) (map[string]any, error) {
+	encPayload, encMac, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(encMac)
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	verified := false
+	for _, key := range s.keys {
+		if hmac.Equal(mac, signSession(key, name, string(payload))) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrInvalidSession
+	}
+
+	ts, data, ok := strings.Cut(string(payload), "|")
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+
+	issued, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	if maxAge := s.Options.MaxAge; maxAge > 0 && time.Since(time.Unix(issued, 0)) > time.Duration(maxAge)*time.Second {
+		return nil, ErrInvalidSession
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	values := make(map[string]any)
+	if err = gob.NewDecoder(bytes.NewReader(raw)).Decode(&values); err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	return values, nil
+}
+
+func signSession(key []byte, name, payload string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name + "|" + payload))
+
+	return h.Sum(nil)
+}
+
+func newSessionCookie(name, value string, options *SessionOptions) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     options.Path,
+		Domain:   options.Domain,
+		MaxAge:   options.MaxAge,
+		Secure:   options.Secure,
+		HttpOnly: options.HttpOnly,
+		SameSite: options.SameSite,
+	}
+	if options.MaxAge > 0 {
+		cookie.Expires = time.Now().Add(time.Duration(options.MaxAge) * time.Second)
+	} else if options.MaxAge < 0 {
+		cookie.Expires = time.Unix(1, 0)
+	}
+
+	return cookie
+}
+
+// Session returns the session stored in ctx by a SessionMiddleware, or nil.
+func Session(ctx context.Context) *SessionData {
+	if s, ok := ctx.Value(sessionKey).(*SessionData); ok {
+		return s
+	}
+	return nil
+}
+
+// SessionFromRequest returns the session stored in the request context by a
+// SessionMiddleware. ErrNoSession is returned if there is none.
+func SessionFromRequest(r *http.Request) (*SessionData, error) {
+	if s := Session(r.Context()); s != nil {
+		return s, nil
+	}
+	return nil, ErrNoSession
+}
+
+// SessionMiddleware loads the session with the given cookie name from store
+// and makes it available through Session(ctx) and SessionFromRequest.
+//
+// The session is saved automatically right before the response header is
+// written, or after the handler returns if it did not write anything. New
+// sessions without any values are not saved, so no cookie is set for clients
+// which never store data in their session.
+//
+// Sessions sent by the client which fail verification are discarded and
+// replaced by a new session.
+func SessionMiddleware(store SessionStore, name string) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+			session, err := store.Get(req, name)
+			if errors.Is(err, ErrInvalidSession) {
+				requestLogger(req).Log(ctx, slog.LevelDebug, "mux: discarding invalid session", "session", name, "error", err)
+			} else if err != nil {
+				return err
+			}
+
+			ctx = withRouterValue(ctx, sessionKey, session)
+			req = req.WithContext(withRouterValue(req.Context(), sessionKey, session))
+
+			rw := NewResponseRecorderWriter(w)
+			saved := false
+			var saveErr error
+			save := func() {
+				if saved {
+					return
+				}
+				saved = true
+				if session.IsNew && len(session.Values) == 0 {
+					return
+				}
+				saveErr = session.Save(req, rw)
+			}
+			rw.BeforeWriteHeader(func(int) { save() })
+
+			err = next(ctx, rw, req, binder)
+			if !rw.WroteHeader() {
+				save()
+			}
+
+			return errors.Join(err, saveErr)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionMiddleware(t *testing.T) {
+	store := NewCookieStore([]byte("secret"))
+
+	router := NewRouter()
+	router.Use(SessionMiddleware(store, "session"))
+	router.HandleFunc("/set", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		Session(ctx).Values["user"] = "gopher"
+		_, err := w.Write([]byte("ok"))
+		return err
+	})
+	router.HandleFunc("/get", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		s, err := SessionFromRequest(r)
+		if err != nil {
+			return err
+		}
+		user, _ := s.Values["user"].(string)
+		_, err = w.Write([]byte(user))
+		return err
+	})
+
+	rw := httptest.NewRecorder()
+	if err := router.ServeHTTP(context.Background(), rw, httptest.NewRequest("GET", "/set", nil), nil); err != nil {
+		t.Fatal(err)
+	}
+	cookies := rw.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "session" {
+		t.Fatalf("expected a session cookie, got %v", cookies)
+	}
+
+	t.Run("valid cookie", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/get", nil)
+		req.AddCookie(cookies[0])
+		rw := httptest.NewRecorder()
+		if err := router.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+			t.Fatal(err)
+		}
+		if rw.Body.String() != "gopher" {
+			t.Fatalf("expected body %q, got %q", "gopher", rw.Body.String())
+		}
+	})
+
+	t.Run("tampered cookie", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/get", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: cookies[0].Value + "x"})
+		rw := httptest.NewRecorder()
+		if err := router.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+			t.Fatal(err)
+		}
+		if rw.Body.String() != "" {
+			t.Fatalf("expected empty body, got %q", rw.Body.String())
+		}
+		if len(rw.Result().Cookies()) != 0 {
+			t.Fatal("expected no cookie for an empty new session")
+		}
+	})
+
+	t.Run("rotated key", func(t *testing.T) {
+		rotated := NewCookieStore([]byte("new"), []byte("secret"))
+		req := httptest.NewRequest("GET", "/get", nil)
+		req.AddCookie(cookies[0])
+		s, err := rotated.Get(req, "session")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.IsNew || s.Values["user"] != "gopher" {
+			t.Fatalf("expected existing session, got %+v", s)
+		}
+	})
+}
+
+func TestSessionFromRequestWithoutMiddleware(t *testing.T) {
+	if _, err := SessionFromRequest(httptest.NewRequest("GET", "/", nil)); err != ErrNoSession {
+		t.Fatalf("expected ErrNoSession, got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SLOMetadataKey is the route metadata key read by SLOMonitor. The value is
+// either a time.Duration, declaring a latency objective only, or an SLO:
+//
+//	r.HandleFunc("/search", search).Metadata(mux.SLOMetadataKey, 200*time.Millisecond)
+const SLOMetadataKey = "slo"
+
+// defaultSLOWindow is the error budget window used when SLO.Window is zero.
+const defaultSLOWindow = 100
+
+// SLO declares the service level objectives of a route.
+type SLO struct {
+	// Latency is the maximum time a request may take. Zero disables the
+	// latency objective.
+	Latency time.Duration
+	// ErrorBudget is the tolerated fraction of failed requests, e.g. 0.01.
+	// Requests fail if the handler returns an error or the status is 5xx.
+	// Zero disables the error objective.
+	ErrorBudget float64
+	// Window is the number of most recent requests the error rate is
+	// computed over. It defaults to 100.
+	Window int
+}
+
+// SLOViolationKind tells which objective was violated.
+type SLOViolationKind int
+
+const (
+	// SLOViolationLatency is reported for every request slower than
+	// SLO.Latency.
+	SLOViolationLatency SLOViolationKind = iota
+	// SLOViolationErrorBudget is reported when the error rate of a route
+	// exceeds SLO.ErrorBudget. It is reported again only after the rate
+	// went back within the budget.
+	SLOViolationErrorBudget
+)
+
+// SLOViolation describes a violated objective.
+type SLOViolation struct {
+	Kind  SLOViolationKind
+	Route *Route
+	SLO   SLO
+	// Duration is the duration of the request which caused the violation.
+	Duration time.Duration
+	// ErrorRate is the error rate over the SLO window.
+	ErrorRate float64
+	Status    int
+	Err       error
+}
+
+// SLOViolationCounts holds the number of violations of a route.
+type SLOViolationCounts struct {
+	Latency     uint64
+	ErrorBudget uint64
+}
+
+// SLOMonitor is a middleware checking requests against the objectives
+// declared with SLOMetadataKey. Routes without objectives are passed through.
+type SLOMonitor struct {
+	callback func(ctx context.Context, violation *SLOViolation)
+	routes   sync.Map // *Route -> *sloState
+}
+
+// sloState tracks the violations and error window of a route.
+type sloState struct {
+	latency     atomic.Uint64
+	errorBudget atomic.Uint64
+
+	mu       sync.Mutex
+	outcomes []bool // ring of the most recent requests, true if failed
+	next     int
+	full     bool
+	failed   int
+	exceeded bool
+}
+
+// NewSLOMonitor returns an SLOMonitor invoking callback for every violation.
+// The callback may be nil if only the counters are of interest.
+func NewSLOMonitor(callback func(ctx context.Context, violation *SLOViolation)) *SLOMonitor {
+	return &SLOMonitor{callback: callback}
+}
+
+// Violations returns the number of violations recorded for route.
+func (m *SLOMonitor) Violations(route *Route) SLOViolationCounts {
+	s, ok := m.routes.Load(route)
+	if !ok {
+		return SLOViolationCounts{}
+	}
+	state := s.(*sloState)
+	return SLOViolationCounts{
+		Latency:     state.latency.Load(),
+		ErrorBudget: state.errorBudget.Load(),
+	}
+}
+
+// Middleware implements the middleware interface. It has to be registered
+// with Router.Use or Route.Use so the matched route is known.
+func (m *SLOMonitor) Middleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		route := CurrentRoute(req)
+		if route == nil {
+			return next(ctx, w, req, binder)
+		}
+		slo, ok := routeSLO(route)
+		if !ok {
+			return next(ctx, w, req, binder)
+		}
+
+		rw := NewResponseRecorderWriter(w)
+		start := time.Now()
+		err := next(ctx, rw, req, binder)
+		elapsed := time.Since(start)
+
+		status := rw.StatusOrDefault(err)
+		state := m.state(route)
+
+		if slo.Latency > 0 && elapsed > slo.Latency {
+			state.latency.Add(1)
+			m.report(ctx, &SLOViolation{Kind: SLOViolationLatency, Route: route, SLO: slo, Duration: elapsed, Status: status, Err: err})
+		}
+
+		if slo.ErrorBudget > 0 {
+			failed := err != nil || status >= http.StatusInternalServerError
+			if rate, exceeded := state.observe(slo, failed); exceeded {
+				state.errorBudget.Add(1)
+				m.report(ctx, &SLOViolation{Kind: SLOViolationErrorBudget, Route: route, SLO: slo, Duration: elapsed, ErrorRate: rate, Status: status, Err: err})
+			}
+		}
+
+		return err
+	}
+}
+
+func (m *SLOMonitor) state(route *Route) *sloState {
+	if s, ok := m.routes.Load(route); ok {
+		return s.(*sloState)
+	}
+	s, _ := m.routes.LoadOrStore(route, new(sloState))
+	return s.(*sloState)
+}
+
+func (m *SLOMonitor) report(ctx context.Context, violation *SLOViolation) {
+	if m.callback != nil {
+		m.callback(ctx, violation)
+	}
+}
+
+// observe records the outcome of a request and returns the error rate over
+// the window, and whether the budget has just been exceeded. The budget is
+// only evaluated once the window is full.
+func (s *sloState) observe(slo SLO, failed bool) (float64, bool) {
+	window := slo.Window
+	if window <= 0 {
+		window = defaultSLOWindow
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.outcomes) != window {
+		s.outcomes = make([]bool, window)
+		s.next, s.full, s.failed, s.exceeded = 0, false, 0, false
+	}
+	if s.outcomes[s.next] {
+		s.failed--
+	}
+	s.outcomes[s.next] = failed
+	if failed {
+		s.failed++
+	}
+	s.next++
+	if s.next == window {
+		s.next = 0
+		s.full = true
+	}
+	if !s.full {
+		return 0, false
+	}
+
+	rate := float64(s.failed) / float64(window)
+	wasExceeded := s.exceeded
+	s.exceeded = rate > slo.ErrorBudget
+	return rate, s.exceeded && !wasExceeded
+}
+
+// routeSLO reads the objectives declared on route.
+func routeSLO(route *Route) (SLO, bool) {
+	switch v := route.GetMetadataValueOr(SLOMetadataKey, nil).(type) {
+	case time.Duration:
+		return SLO{Latency: v}, true
+	case SLO:
+		return v, true
+	case *SLO:
+		if v != nil {
+			return *v, true
+		}
+	}
+	return SLO{}, false
+}
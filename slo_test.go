@@ -0,0 +1,62 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSLOMonitor(t *testing.T) {
+	var violations []*SLOViolation
+	monitor := NewSLOMonitor(func(ctx context.Context, v *SLOViolation) {
+		violations = append(violations, v)
+	})
+
+	router := NewRouter()
+	router.Use(monitor.Middleware)
+	slow := router.HandleFunc("/slow", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	}).Metadata(SLOMetadataKey, time.Millisecond)
+	flaky := router.HandleFunc("/flaky/{fail}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		if Vars(r)["fail"] == "1" {
+			return errors.New("boom")
+		}
+		return nil
+	}).Metadata(SLOMetadataKey, SLO{ErrorBudget: 0.25, Window: 4})
+	plain := router.HandleFunc("/plain", dummyHandler)
+
+	serve := func(path string) {
+		_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost"+path), nil)
+	}
+
+	serve("/slow")
+	serve("/plain")
+	// The window fills up at 25% errors, then exceeds the budget twice
+	// without recovering in between, recovers and exceeds it again.
+	for _, fail := range []string{"1", "0", "0", "0", "1", "1", "0", "0", "0", "0", "0", "1", "1"} {
+		serve("/flaky/" + fail)
+	}
+
+	if c := monitor.Violations(slow); c.Latency != 1 || c.ErrorBudget != 0 {
+		t.Errorf("unexpected counts for slow route %+v", c)
+	}
+	if c := monitor.Violations(flaky); c.Latency != 0 || c.ErrorBudget != 2 {
+		t.Errorf("unexpected counts for flaky route %+v", c)
+	}
+	if c := monitor.Violations(plain); c != (SLOViolationCounts{}) {
+		t.Errorf("unexpected counts for plain route %+v", c)
+	}
+
+	if len(violations) != 3 {
+		t.Fatalf("expected 3 violations, got %d", len(violations))
+	}
+	if v := violations[0]; v.Kind != SLOViolationLatency || v.Route != slow || v.Duration < time.Millisecond {
+		t.Errorf("unexpected latency violation %+v", v)
+	}
+	if v := violations[1]; v.Kind != SLOViolationErrorBudget || v.Route != flaky || v.ErrorRate != 0.5 || v.Err == nil {
+		t.Errorf("unexpected error budget violation %+v", v)
+	}
+}
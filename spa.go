@@ -0,0 +1,99 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// defaultHashedAssets matches file names containing a hex content hash, such
+// as "app.3f2a9c1b.js" or "logo-9e107d9d.svg".
+var defaultHashedAssets = regexp.MustCompile(`[.-][0-9a-f]{8,}\.[0-9A-Za-z]+$`)
+
+// SPAOptions configures the serving of single page applications.
+type SPAOptions struct {
+	// Index is the file served for paths without a file, so the application
+	// can route them on the client. It defaults to "index.html".
+	Index string
+
+	// Exclude lists path prefixes, such as "/api", which are never answered
+	// with the index: requests for them which don't match a file return a
+	// *FileError with status 404.
+	Exclude []string
+
+	// HashedAssets matches the paths of assets whose names change with their
+	// content. They are served with a Cache-Control header allowing clients
+	// to cache them forever, while the index is served with "no-cache". It
+	// defaults to names containing a hex hash of at least 8 digits, e.g.
+	// "app.3f2a9c1b.js".
+	HashedAssets *regexp.Regexp
+}
+
+// SPA returns a handler serving a single page application from fsys, usually
+// an embed.FS, at the request path:
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	assets, _ := fs.Sub(dist, "dist")
+//	r.PathPrefix("/").Handler(mux.SPA(assets, mux.SPAOptions{Exclude: []string{"/api"}}))
+//
+// Files are served as they are, and all other paths without a file extension
+// are answered with the index. Missing files with an extension are not, so a
+// missing script does not get the HTML of the index; a *FileError is returned
+// for them, like for the handlers of FileServer.
+func SPA(fsys fs.FS, opts SPAOptions) HandlerFunc {
+	return spa(fsys, opts, "")
+}
+
+// ServeSPA registers a route serving a single page application under the path
+// prefix prefix, see SPA. The prefix is removed from the request path before
+// looking up files and excluded paths.
+func (r *Router) ServeSPA(prefix string, fsys fs.FS, opts SPAOptions) *Route {
+	return r.NewRoute().PathPrefix(prefix).Methods(http.MethodGet, http.MethodHead).
+		Handler(spa(fsys, opts, strings.TrimSuffix(prefix, "/")))
+}
+
+func spa(fsys fs.FS, opts SPAOptions, prefix string) HandlerFunc {
+	if opts.Index == "" {
+		opts.Index = "index.html"
+	}
+	if opts.HashedAssets == nil {
+		opts.HashedAssets = defaultHashedAssets
+	}
+	hfs := http.FS(fsys)
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		name := path.Clean("/" + strings.TrimPrefix(req.URL.Path, prefix))
+		if name != "/" {
+			info, err := fs.Stat(fsys, name[1:])
+			switch {
+			case err == nil && !info.IsDir():
+				if opts.HashedAssets.MatchString(name) {
+					w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+				}
+				return serveFile(w, req, hfs, name)
+			case err != nil && !errors.Is(err, fs.ErrNotExist):
+				return fileError(name, err)
+			case spaExcluded(name, opts.Exclude) || path.Ext(name) != "" && err != nil:
+				return fileError(name, fs.ErrNotExist)
+			}
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		return serveFile(w, req, hfs, "/"+strings.TrimPrefix(opts.Index, "/"))
+	}
+}
+
+// spaExcluded reports whether name is one of the path prefixes exclude.
+func spaExcluded(name string, exclude []string) bool {
+	for _, prefix := range exclude {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if name == prefix || strings.HasPrefix(name, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
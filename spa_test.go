@@ -0,0 +1,52 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"testing"
+	"testing/fstest"
+)
+
+func TestServeSPA(t *testing.T) {
+	files := fstest.MapFS{
+		"index.html":             {Data: []byte("app")},
+		"favicon.ico":            {Data: []byte("icon")},
+		"assets/app.3f2a9c1b.js": {Data: []byte("js")},
+	}
+	router := NewRouter()
+	router.ServeSPA("/ui/", files, SPAOptions{Exclude: []string{"/api"}})
+
+	tests := []struct {
+		url          string
+		body         string
+		cacheControl string
+	}{
+		{"http://localhost/ui/", "app", "no-cache"},
+		{"http://localhost/ui/users/42", "app", "no-cache"},
+		{"http://localhost/ui/assets", "app", "no-cache"},
+		{"http://localhost/ui/favicon.ico", "icon", ""},
+		{"http://localhost/ui/assets/app.3f2a9c1b.js", "js", "public, max-age=31536000, immutable"},
+	}
+	for _, tt := range tests {
+		rw := NewRecorder()
+		if err := router.ServeHTTP(context.Background(), rw, newRequest(http.MethodGet, tt.url), nil); err != nil {
+			t.Fatalf("%s: %v", tt.url, err)
+		}
+		if rw.Body.String() != tt.body || rw.Header().Get("Cache-Control") != tt.cacheControl {
+			t.Errorf("%s: unexpected response %q with Cache-Control %q", tt.url, rw.Body.String(), rw.Header().Get("Cache-Control"))
+		}
+	}
+
+	for _, url := range []string{"http://localhost/ui/api/users", "http://localhost/ui/api", "http://localhost/ui/assets/missing.js"} {
+		rw := NewRecorder()
+		err := router.ServeHTTP(context.Background(), rw, newRequest(http.MethodGet, url), nil)
+		if !errors.Is(err, fs.ErrNotExist) || ErrorStatus(err) != http.StatusNotFound {
+			t.Errorf("%s: expected a not found error, got %v", url, err)
+		}
+		if rw.Header().Get("Cache-Control") != "" || rw.Body.Len() != 0 {
+			t.Errorf("%s: expected no response, got %v %q", url, rw.Header(), rw.Body.String())
+		}
+	}
+}
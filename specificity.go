@@ -0,0 +1,154 @@
+package mux
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// EnableSpecificityOrder makes the router, and subrouters created afterwards,
+// match routes from the most to the least specific instead of in
+// registration order, so that matching doesn't depend on which package
+// registered its routes first. Route paths are compared segment by segment:
+// a static segment, such as "users", precedes a segment with variables, such
+// as "{id}", which precedes a segment with a variable whose pattern can match
+// a slash, such as "{path:.*}". A route whose path has more segments precedes
+// a route whose path is a prefix of it, and a path precedes a path prefix.
+// Between routes with equal paths, a route with a host template precedes one
+// without. Routes without a path follow all others. Remaining ties are kept
+// in registration order.
+//
+// Walk, and with it RouteTable and the DebugHandler, visit the routes in the
+// same order. The order is computed on the first match after routes or
+// matchers were added.
+func (r *Router) EnableSpecificityOrder() *Router {
+	r.specificityOrder = true
+	return r
+}
+
+// sortedRoutes is the specificity order of the routes of a snapshot,
+// computed at a routes generation of the router tree.
+type sortedRoutes struct {
+	generation uint64
+	routes     []*Route
+}
+
+// matchOrder returns the routes of the snapshot t in the order they are
+// matched.
+func (r *Router) matchOrder(t *routeTable) []*Route {
+	if t == nil {
+		return nil
+	}
+	if !r.specificityOrder {
+		return t.routes
+	}
+	generation := r.tree.loadRoutes()
+	if s := t.sorted.Load(); s != nil && s.generation == generation {
+		return s.routes
+	}
+	keys := make(map[*Route]routeSpecificity, len(t.routes))
+	for _, route := range t.routes {
+		keys[route] = newRouteSpecificity(route)
+	}
+	routes := slices.Clone(t.routes)
+	slices.SortStableFunc(routes, func(a, b *Route) int {
+		return keys[a].compare(keys[b])
+	})
+	t.sorted.Store(&sortedRoutes{generation: generation, routes: routes})
+	return routes
+}
+
+// segmentKind is the kind of a path template segment, in order of
+// decreasing specificity.
+type segmentKind int
+
+const (
+	staticSegment segmentKind = iota
+	variableSegment
+	wildcardSegment
+)
+
+// routeSpecificity is the sort key of a route, see EnableSpecificityOrder.
+type routeSpecificity struct {
+	hasPath  bool
+	segments []segmentKind
+	prefix   bool
+	host     bool
+}
+
+func newRouteSpecificity(route *Route) routeSpecificity {
+	s := routeSpecificity{host: route.regexp.host != nil}
+	rr := route.regexp.path
+	if rr == nil {
+		return s
+	}
+	s.hasPath = true
+	s.prefix = rr.regexpType == regexpTypePrefix
+	tpl := strings.TrimPrefix(rr.template, "/")
+	idxs, err := braceIndices(tpl)
+	if err != nil {
+		return s
+	}
+	start, brace := 0, 0
+	for i := 0; i <= len(tpl); i++ {
+		if brace < len(idxs) && i == idxs[brace] {
+			i = idxs[brace+1] - 1
+			brace += 2
+			continue
+		}
+		if i < len(tpl) && tpl[i] != '/' {
+			continue
+		}
+		if seg := tpl[start:i]; seg != "" || i < len(tpl) {
+			s.segments = append(s.segments, segmentSpecificity(seg))
+		}
+		start = i + 1
+	}
+	return s
+}
+
+// segmentSpecificity returns the kind of a template segment.
+func segmentSpecificity(seg string) segmentKind {
+	idxs, _ := braceIndices(seg)
+	if len(idxs) == 0 {
+		return staticSegment
+	}
+	for i := 0; i < len(idxs); i += 2 {
+		tag := seg[idxs[i]+1 : idxs[i+1]-1]
+		if _, patt, ok := strings.Cut(tag, ":"); ok && patternMatchesSlash(patt) {
+			return wildcardSegment
+		}
+	}
+	return variableSegment
+}
+
+// compare returns a negative number if s is more specific than o, and a
+// positive number if it is less specific.
+func (s routeSpecificity) compare(o routeSpecificity) int {
+	if s.hasPath != o.hasPath {
+		return boolOrder(s.hasPath)
+	}
+	for i := 0; i < len(s.segments) && i < len(o.segments); i++ {
+		if c := cmp.Compare(s.segments[i], o.segments[i]); c != 0 {
+			return c
+		}
+	}
+	if c := cmp.Compare(len(o.segments), len(s.segments)); c != 0 {
+		return c
+	}
+	if s.prefix != o.prefix {
+		return boolOrder(!s.prefix)
+	}
+	if s.host != o.host {
+		return boolOrder(s.host)
+	}
+	return 0
+}
+
+// boolOrder orders true before false.
+func boolOrder(first bool) int {
+	if first {
+		return -1
+	}
+	return 1
+}
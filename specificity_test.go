@@ -0,0 +1,79 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestSpecificityOrder(t *testing.T) {
+	for _, indexed := range []bool{false, true} {
+		router := NewRouter().EnableSpecificityOrder().RouteIndex(indexed)
+		named := func(tpl, name string) {
+			router.HandleFunc(tpl, dummyHandler).Name(name)
+		}
+		router.NewRoute().HandlerFunc(dummyHandler).Name("catch-all")
+		router.PathPrefix("/").HandlerFunc(dummyHandler).Name("root")
+		named("/files/{path:.*}", "files")
+		named("/files/{name}", "file")
+		named("/files/readme", "readme")
+		named("/{section}/about", "section-about")
+		named("/users/{id}", "user")
+		router.PathPrefix("/users/").HandlerFunc(dummyHandler).Name("users-prefix")
+		router.HandleFunc("/users/{id}", dummyHandler).Host("admin.example.com").Name("admin-user")
+		api := router.PathPrefix("/api").Subrouter()
+		api.HandleFunc("/{version}/{rest:.*}", dummyHandler).Name("api-fallback")
+		api.HandleFunc("/v1/users", dummyHandler).Name("api-users")
+
+		tests := map[string]string{
+			"http://localhost/files/readme":    "readme",
+			"http://localhost/files/a.txt":     "file",
+			"http://localhost/files/a/b.txt":   "files",
+			"http://localhost/users/about":     "user",
+			"http://localhost/blog/about":      "section-about",
+			"http://localhost/users/1":         "user",
+			"http://admin.example.com/users/1": "admin-user",
+			"http://localhost/users/1/posts":   "users-prefix",
+			"http://localhost/api/v1/users":    "api-users",
+			"http://localhost/api/v2/users":    "api-fallback",
+			"http://localhost/other":           "root",
+		}
+		for url, name := range tests {
+			var match RouteMatch
+			if !router.Match(newRequest(http.MethodGet, url), &match) {
+				t.Errorf("indexed %v, %s: no match", indexed, url)
+				continue
+			}
+			if got := match.Route.GetName(); got != name {
+				t.Errorf("indexed %v, %s: expected route %q, got %q", indexed, url, name, got)
+			}
+		}
+
+		var walked []string
+		_ = router.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+			walked = append(walked, route.GetName())
+			return nil
+		})
+		if last := walked[len(walked)-1]; last != "catch-all" {
+			t.Errorf("expected the route without a path to be walked last, got %q", walked)
+		}
+	}
+}
+
+func TestSpecificityOrderUpdates(t *testing.T) {
+	router := NewRouter().EnableSpecificityOrder()
+	router.HandleFunc("/{name}", dummyHandler).Name("var")
+	serve := func() string {
+		var match RouteMatch
+		router.Match(newRequest(http.MethodGet, "http://localhost/static"), &match)
+		return match.Route.GetName()
+	}
+	if got := serve(); got != "var" {
+		t.Fatalf("expected var, got %q", got)
+	}
+	router.HandleFunc("/static", dummyHandler).Name("static")
+	if got := serve(); got != "static" {
+		t.Errorf("expected static after adding a route, got %q", got)
+	}
+	_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest(http.MethodGet, "http://localhost/static"), nil)
+}
@@ -0,0 +1,279 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultStatsPath is the conventional path to mount Router.StatsHandler on.
+const DefaultStatsPath = "/_mux/stats"
+
+// statsSampleSize is the number of most recent latencies kept per route to
+// compute quantiles.
+const statsSampleSize = 512
+
+// RouterStats is a snapshot of the statistics collected by a router.
+type RouterStats struct {
+	// Routes holds the statistics of all routes with a handler, in the order
+	// they are walked by Router.Walk.
+	Routes []RouteStats `json:"routes"`
+	// NotFound is the number of requests which did not match any route.
+	NotFound uint64 `json:"not_found"`
+	// MethodNotAllowed is the number of requests which matched a route
+	// except for its method.
+	MethodNotAllowed uint64 `json:"method_not_allowed"`
+	// Maintenance is the number of requests diverted to the maintenance
+	// handler, see Router.SetMaintenance. They are not counted under the
+	// route they matched.
+	Maintenance uint64 `json:"maintenance"`
+}
+
+// RouteStats is a snapshot of the statistics of a single route.
+type RouteStats struct {
+	Name    string   `json:"name,omitempty"`
+	Host    string   `json:"host,omitempty"`
+	Path    string   `json:"path,omitempty"`
+	Methods []string `json:"methods,omitempty"`
+	// Hits is the number of requests dispatched to the route.
+	Hits uint64 `json:"hits"`
+	// Errors is the number of requests for which the handler returned an error.
+	Errors uint64 `json:"errors"`
+	// ErrorTypes breaks Errors down by the class assigned by the router's
+	// ErrorClassifier.
+	ErrorTypes map[string]uint64 `json:"error_types,omitempty"`
+	// Panics is the number of requests for which the handler panicked.
+	Panics uint64 `json:"panics"`
+	// Latency summarizes the most recent handler durations.
+	Latency LatencyQuantiles `json:"latency"`
+	// Histogram is the latency distribution of the route, if enabled with
+	// Router.EnableLatencyHistograms.
+	Histogram *LatencyHistogram `json:"histogram,omitempty"`
+}
+
+// LatencyQuantiles summarizes a set of latency samples. It is encoded to
+// JSON with all values in milliseconds.
+type LatencyQuantiles struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// MarshalJSON implements json.Marshaler.
+func (q LatencyQuantiles) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	return json.Marshal(map[string]float64{
+		"p50_ms": ms(q.P50),
+		"p90_ms": ms(q.P90),
+		"p99_ms": ms(q.P99),
+		"max_ms": ms(q.Max),
+	})
+}
+
+// ErrorClassifier maps a handler error to the class it is counted under in
+// RouteStats.ErrorTypes.
+type ErrorClassifier func(err error) string
+
+// DefaultErrorClassifier classifies errors by their dynamic type, e.g.
+// "*fs.PathError".
+func DefaultErrorClassifier(err error) string {
+	return fmt.Sprintf("%T", err)
+}
+
+// routerStats holds the statistics of a router and all its subrouters.
+type routerStats struct {
+	routes           sync.Map // *Route -> *routeStats
+	notFound         atomic.Uint64
+	methodNotAllowed atomic.Uint64
+	maintenance      atomic.Uint64
+}
+
+// routeStats holds the statistics of a single route.
+type routeStats struct {
+	hits   atomic.Uint64
+	errors atomic.Uint64
+	panics atomic.Uint64
+
+	mu         sync.Mutex
+	samples    [statsSampleSize]time.Duration
+	next       int
+	full       bool
+	errorTypes map[string]uint64
+}
+
+func (s *routerStats) forRoute(route *Route) *routeStats {
+	if rs, ok := s.routes.Load(route); ok {
+		return rs.(*routeStats)
+	}
+	rs, _ := s.routes.LoadOrStore(route, new(routeStats))
+	return rs.(*routeStats)
+}
+
+// record updates the statistics after a request has been handled. errorType
+// is the class of err, if any.
+func (s *routerStats) record(match *RouteMatch, elapsed time.Duration, err error, errorType string) {
+	switch {
+	case match.maintenance:
+		s.maintenance.Add(1)
+		return
+	case match.MatchErr == ErrMethodMismatch:
+		s.methodNotAllowed.Add(1)
+		return
+	case match.MatchErr == ErrNotFound || match.Route == nil:
+		s.notFound.Add(1)
+		return
+	}
+
+	rs := s.forRoute(match.Route)
+	rs.hits.Add(1)
+	if err != nil {
+		rs.errors.Add(1)
+	}
+
+	rs.mu.Lock()
+	if err != nil {
+		if rs.errorTypes == nil {
+			rs.errorTypes = make(map[string]uint64)
+		}
+		rs.errorTypes[errorType]++
+	}
+	rs.samples[rs.next] = elapsed
+	rs.next++
+	if rs.next == len(rs.samples) {
+		rs.next = 0
+		rs.full = true
+	}
+	rs.mu.Unlock()
+}
+
+// recordPanic counts a panic raised while handling a request.
+func (s *routerStats) recordPanic(match *RouteMatch) {
+	if match.MatchErr == nil && match.Route != nil {
+		s.forRoute(match.Route).panics.Add(1)
+	}
+}
+
+func (rs *routeStats) errorTypesSnapshot() map[string]uint64 {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if len(rs.errorTypes) == 0 {
+		return nil
+	}
+	types := make(map[string]uint64, len(rs.errorTypes))
+	for k, v := range rs.errorTypes {
+		types[k] = v
+	}
+	return types
+}
+
+func (rs *routeStats) quantiles() LatencyQuantiles {
+	rs.mu.Lock()
+	n := rs.next
+	if rs.full {
+		n = len(rs.samples)
+	}
+	samples := make([]time.Duration, n)
+	copy(samples, rs.samples[:n])
+	rs.mu.Unlock()
+
+	if n == 0 {
+		return LatencyQuantiles{}
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	at := func(q float64) time.Duration {
+		return samples[int(q*float64(n-1))]
+	}
+
+	return LatencyQuantiles{
+		P50: at(0.5),
+		P90: at(0.9),
+		P99: at(0.99),
+		Max: samples[n-1],
+	}
+}
+
+// EnableStats turns on the collection of per-route statistics, which can be
+// read with Stats or served with StatsHandler. Statistics are collected by
+// the router whose ServeHTTP method is called, including the routes of its
+// subrouters.
+//
+// Panics raised by handlers are counted and then propagated unchanged.
+func (r *Router) EnableStats() *Router {
+	if r.stats == nil {
+		r.stats = new(routerStats)
+	}
+	return r
+}
+
+// ClassifyErrors sets the classifier used to break down handler errors in
+// the statistics. DefaultErrorClassifier is used if none is set.
+func (r *Router) ClassifyErrors(classifier ErrorClassifier) *Router {
+	r.errorClassifier = classifier
+	return r
+}
+
+// Stats returns a snapshot of the statistics collected since EnableStats was
+// called. The snapshot is empty if statistics are not enabled.
+func (r *Router) Stats() RouterStats {
+	var snapshot RouterStats
+	if r.stats == nil {
+		return snapshot
+	}
+
+	snapshot.NotFound = r.stats.notFound.Load()
+	snapshot.MethodNotAllowed = r.stats.methodNotAllowed.Load()
+	snapshot.Maintenance = r.stats.maintenance.Load()
+
+	_ = r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		if route.handler == nil {
+			return nil
+		}
+		if _, ok := route.handler.(*Router); ok {
+			return nil
+		}
+
+		rs := RouteStats{Name: route.GetName()}
+		rs.Host, _ = route.GetHostTemplate()
+		rs.Path, _ = route.GetPathTemplate()
+		rs.Methods, _ = route.GetMethods()
+
+		if h := route.Latency(); h.Count > 0 {
+			rs.Histogram = &h
+		}
+
+		if s, ok := r.stats.routes.Load(route); ok {
+			s := s.(*routeStats)
+			rs.Hits = s.hits.Load()
+			rs.Errors = s.errors.Load()
+			rs.ErrorTypes = s.errorTypesSnapshot()
+			rs.Panics = s.panics.Load()
+			rs.Latency = s.quantiles()
+		}
+
+		snapshot.Routes = append(snapshot.Routes, rs)
+		return nil
+	})
+
+	return snapshot
+}
+
+// StatsHandler returns a handler serving the router statistics as JSON. It is
+// usually mounted on DefaultStatsPath:
+//
+//	r.EnableStats()
+//	r.Handle(mux.DefaultStatsPath, r.StatsHandler()).Methods(http.MethodGet)
+func (r *Router) StatsHandler() HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		w.Header().Set("Content-Type", "application/json")
+		return json.NewEncoder(w).Encode(r.Stats())
+	}
+}
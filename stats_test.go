@@ -0,0 +1,137 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRouterStats(t *testing.T) {
+	router := NewRouter().EnableStats()
+	router.HandleFunc("/users/{id}", dummyHandler).Methods("GET").Name("user")
+	router.HandleFunc("/fail", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		return errors.New("boom")
+	})
+	sub := router.PathPrefix("/api").Subrouter()
+	sub.HandleFunc("/items", dummyHandler)
+	router.Handle(DefaultStatsPath, router.StatsHandler())
+
+	requests := []struct{ method, path string }{
+		{"GET", "/users/1"},
+		{"GET", "/users/2"},
+		{"POST", "/users/2"},
+		{"GET", "/fail"},
+		{"GET", "/api/items"},
+		{"GET", "/missing"},
+	}
+	for _, r := range requests {
+		_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest(r.method, "http://localhost"+r.path), nil)
+	}
+
+	stats := router.Stats()
+	if stats.NotFound != 1 || stats.MethodNotAllowed != 1 {
+		t.Errorf("expected 1 not found and 1 method not allowed, got %d and %d", stats.NotFound, stats.MethodNotAllowed)
+	}
+
+	byPath := map[string]RouteStats{}
+	for _, rs := range stats.Routes {
+		byPath[rs.Path] = rs
+	}
+
+	if rs := byPath["/users/{id}"]; rs.Hits != 2 || rs.Errors != 0 || rs.Name != "user" {
+		t.Errorf("unexpected stats for /users/{id}: %+v", rs)
+	}
+	if rs := byPath["/fail"]; rs.Hits != 1 || rs.Errors != 1 {
+		t.Errorf("unexpected stats for /fail: %+v", rs)
+	}
+	if rs := byPath["/api/items"]; rs.Hits != 1 {
+		t.Errorf("unexpected stats for subrouter route: %+v", rs)
+	}
+
+	rw := NewRecorder()
+	if err := router.ServeHTTP(context.Background(), rw, newRequest("GET", "http://localhost"+DefaultStatsPath), nil); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(rw.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("stats endpoint returned invalid JSON: %v", err)
+	}
+	if decoded["not_found"].(float64) != 1 {
+		t.Errorf("unexpected stats document: %s", rw.Body.String())
+	}
+}
+
+func TestRouterStatsDisabled(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/", dummyHandler)
+	_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/"), nil)
+
+	if stats := router.Stats(); len(stats.Routes) != 0 {
+		t.Errorf("expected empty stats, got %+v", stats)
+	}
+}
+
+func TestLatencyQuantiles(t *testing.T) {
+	rs := new(routeStats)
+	for i := 1; i <= 100; i++ {
+		rs.samples[rs.next] = time.Duration(i) * time.Millisecond
+		rs.next++
+	}
+
+	q := rs.quantiles()
+	if q.P50 != 50*time.Millisecond || q.P99 != 99*time.Millisecond || q.Max != 100*time.Millisecond {
+		t.Errorf("unexpected quantiles %+v", q)
+	}
+}
+
+var errStatsNotFound = errors.New("not found")
+
+func TestRouterStatsErrorsAndPanics(t *testing.T) {
+	router := NewRouter().EnableStats().ClassifyErrors(func(err error) string {
+		if errors.Is(err, errStatsNotFound) {
+			return "not_found"
+		}
+		return "internal"
+	})
+	router.HandleFunc("/items/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		switch Vars(r)["id"] {
+		case "missing":
+			return fmt.Errorf("item: %w", errStatsNotFound)
+		case "panic":
+			panic("boom")
+		}
+		return errors.New("db down")
+	})
+
+	for _, id := range []string{"missing", "missing", "broken", "panic"} {
+		func() {
+			defer func() {
+				if p := recover(); p != nil && id != "panic" {
+					t.Errorf("unexpected panic %v", p)
+				} else if p == nil && id == "panic" {
+					t.Error("expected panic to be propagated")
+				}
+			}()
+			_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/items/"+id), nil)
+		}()
+	}
+
+	rs := router.Stats().Routes[0]
+	if rs.Errors != 3 || rs.Panics != 1 {
+		t.Errorf("expected 3 errors and 1 panic, got %+v", rs)
+	}
+	if !reflect.DeepEqual(rs.ErrorTypes, map[string]uint64{"not_found": 2, "internal": 1}) {
+		t.Errorf("unexpected error types %v", rs.ErrorTypes)
+	}
+}
+
+func TestDefaultErrorClassifier(t *testing.T) {
+	if c := DefaultErrorClassifier(errors.New("x")); c != "*errors.errorString" {
+		t.Errorf("unexpected class %q", c)
+	}
+}
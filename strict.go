@@ -0,0 +1,250 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp/syntax"
+	"slices"
+	"strings"
+)
+
+// Strict defines whether Compile reports routes which can match the same
+// request with the same method, instead of letting registration order
+// decide silently. The initial value is false.
+//
+// Two routes conflict if a request built from one of them, with sample
+// values for its variables, is matched by the other. With
+// EnableSpecificityOrder, only routes of equal specificity conflict, so that
+// "/users/me" and "/users/{id}" may coexist. Routes with matchers other than
+// host, path, query, method, scheme and header matchers, such as MatcherFunc,
+// are not checked.
+func (r *Router) Strict(value bool) *Router {
+	r.strict = value
+	return r
+}
+
+// strictRoute is a route checked for conflicts, with a sample request it
+// matches.
+type strictRoute struct {
+	route   *Route
+	methods []string
+	literal string
+	sample  *http.Request
+	key     routeSpecificity
+}
+
+// conflicts returns the errors of the routes of r and its subrouters which
+// can match the same request.
+func (r *Router) conflicts() []error {
+	var routes []*strictRoute
+	_ = r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		if route.buildOnly || route.err != nil || route.handler == nil || isSubrouterRoute(route) {
+			return nil
+		}
+		if sr := newStrictRoute(route); sr != nil {
+			routes = append(routes, sr)
+		}
+		return nil
+	})
+
+	var errs []error
+	for i, a := range routes {
+		for _, b := range routes[i+1:] {
+			if err := a.conflict(b, r.specificityOrder); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// newStrictRoute returns route prepared for the conflict check, or nil if it
+// can't be checked.
+func newStrictRoute(route *Route) *strictRoute {
+	sr := &strictRoute{route: route, key: newRouteSpecificity(route)}
+	u := &url.URL{Scheme: "http", Host: "localhost", Path: "/"}
+	header := make(http.Header)
+	var query []string
+	for _, m := range route.matchers {
+		switch m := m.(type) {
+		case methodMatcher:
+			if sr.methods == nil {
+				sr.methods = slices.Clone(m)
+			} else {
+				sr.methods = slices.DeleteFunc(sr.methods, func(method string) bool { return !slices.Contains(m, method) })
+			}
+		case schemeMatcher:
+			u.Scheme = m[0]
+		case headerMatcher:
+			for _, h := range m {
+				value := h.value
+				if h.regexp != nil {
+					var ok bool
+					if value, ok = sampleMatch(h.regexp.String()); !ok {
+						return nil
+					}
+				} else if value == "" {
+					value = "x"
+				}
+				header.Set(h.key, value)
+			}
+		case *routeRegexp:
+			sample, ok := m.sample()
+			if !ok {
+				return nil
+			}
+			switch m.regexpType {
+			case regexpTypeHost:
+				u.Host = sample
+			case regexpTypeQuery:
+				query = append(query, sample)
+			default:
+				u.Path = sample
+				sr.literal, _, _ = strings.Cut(m.template, "{")
+			}
+		default:
+			return nil
+		}
+	}
+	u.RawQuery = strings.Join(query, "&")
+
+	method := http.MethodGet
+	if len(sr.methods) > 0 {
+		method = sr.methods[0]
+	}
+	sr.sample = &http.Request{Method: method, URL: u, Host: u.Host, Header: header}
+	var match RouteMatch
+	if !route.Match(sr.sample, &match) {
+		// The sample is wrong, the route can't be checked.
+		return nil
+	}
+	return sr
+}
+
+// sample returns a string matched by rr, with sample values for its
+// variables.
+func (rr *routeRegexp) sample() (string, bool) {
+	values := make([]any, len(rr.varsP))
+	for i, patt := range rr.varsP {
+		value, ok := sampleMatch(patt)
+		if !ok {
+			return "", false
+		}
+		values[i] = value
+	}
+	return fmt.Sprintf(rr.reverse, values...), true
+}
+
+// conflict returns an error if a and b can match the same request.
+func (a *strictRoute) conflict(b *strictRoute, specificityOrder bool) error {
+	if !strings.HasPrefix(a.literal, b.literal) && !strings.HasPrefix(b.literal, a.literal) {
+		return nil
+	}
+	if specificityOrder && a.key.compare(b.key) != 0 {
+		return nil
+	}
+	var methods []string
+	switch {
+	case a.methods == nil && b.methods == nil:
+		methods = []string{http.MethodGet}
+	case a.methods == nil:
+		methods = b.methods
+	case b.methods == nil:
+		methods = a.methods
+	default:
+		for _, m := range a.methods {
+			if slices.Contains(b.methods, m) {
+				methods = append(methods, m)
+			}
+		}
+	}
+	if len(methods) == 0 {
+		return nil
+	}
+	for _, pair := range [][2]*strictRoute{{a, b}, {b, a}} {
+		req := pair[0].sample.Clone(pair[0].sample.Context())
+		req.Method = methods[0]
+		var match RouteMatch
+		if pair[1].route.Match(req, &match) && match.MatchErr == nil {
+			return fmt.Errorf("mux: routes %q and %q both match %s %s", routeLabel(a.route), routeLabel(b.route), req.Method, req.URL)
+		}
+	}
+	return nil
+}
+
+// sampleMatch returns a string matched by the regexp pattern, or false if it
+// can't find one.
+func sampleMatch(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	var b strings.Builder
+	if !writeSample(&b, re.Simplify()) {
+		return "", false
+	}
+	return b.String(), true
+}
+
+// sampleRunes are the runes preferred in samples of character classes.
+const sampleRunes = "a0xA_-"
+
+func writeSample(b *strings.Builder, re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary, syntax.OpStar, syntax.OpQuest:
+		return true
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+		return true
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteByte('a')
+		return true
+	case syntax.OpCharClass:
+		for _, c := range sampleRunes {
+			if classContains(re.Rune, c) {
+				b.WriteRune(c)
+				return true
+			}
+		}
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for c := max(re.Rune[i], '!'); c <= re.Rune[i+1] && c < 0x7f; c++ {
+				if !strings.ContainsRune("/?#%.", c) {
+					b.WriteRune(c)
+					return true
+				}
+			}
+		}
+		return false
+	case syntax.OpCapture, syntax.OpPlus:
+		return writeSample(b, re.Sub[0])
+	case syntax.OpRepeat:
+		for i := 0; i < re.Min; i++ {
+			if !writeSample(b, re.Sub[0]) {
+				return false
+			}
+		}
+		return true
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !writeSample(b, sub) {
+				return false
+			}
+		}
+		return true
+	case syntax.OpAlternate:
+		return writeSample(b, re.Sub[0])
+	}
+	return false
+}
+
+// classContains reports whether the character class ranges contain c.
+func classContains(ranges []rune, c rune) bool {
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i] <= c && c <= ranges[i+1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		routes   func(r *Router)
+		conflict string
+	}{
+		{
+			name: "duplicate",
+			routes: func(r *Router) {
+				r.HandleFunc("/users", dummyHandler).Name("a")
+				r.HandleFunc("/users", dummyHandler).Name("b")
+			},
+			conflict: `mux: routes "a" and "b" both match GET http://localhost/users`,
+		},
+		{
+			name: "variable",
+			routes: func(r *Router) {
+				r.HandleFunc("/users/{id}", dummyHandler)
+				r.HandleFunc("/users/me", dummyHandler)
+			},
+			conflict: `mux: routes "/users/{id}" and "/users/me" both match GET http://localhost/users/me`,
+		},
+		{
+			name: "overlapping methods",
+			routes: func(r *Router) {
+				r.HandleFunc("/users/{id}", dummyHandler).Methods(http.MethodGet, http.MethodPut)
+				r.HandleFunc("/users/{name}", dummyHandler).Methods(http.MethodPut)
+			},
+			conflict: "both match PUT",
+		},
+		{
+			name: "subrouter",
+			routes: func(r *Router) {
+				api := r.PathPrefix("/api").Subrouter()
+				api.HandleFunc("/{version}", dummyHandler)
+				r.HandleFunc("/api/v1", dummyHandler)
+			},
+			conflict: "both match GET http://localhost/api/v1",
+		},
+		{
+			name: "disjoint patterns",
+			routes: func(r *Router) {
+				r.HandleFunc("/users/{id:[0-9]+}", dummyHandler)
+				r.HandleFunc("/users/{name:[a-z]+}", dummyHandler)
+			},
+		},
+		{
+			name: "disjoint methods",
+			routes: func(r *Router) {
+				r.HandleFunc("/users", dummyHandler).Methods(http.MethodGet)
+				r.HandleFunc("/users", dummyHandler).Methods(http.MethodPost)
+			},
+		},
+		{
+			name: "headers",
+			routes: func(r *Router) {
+				r.HandleFunc("/items", dummyHandler).Headers("Accept", "application/vnd.v2")
+				r.HandleFunc("/items", dummyHandler).HeadersRegexp("Accept", "^application/json$")
+			},
+		},
+		{
+			name: "hosts",
+			routes: func(r *Router) {
+				r.HandleFunc("/", dummyHandler).Host("a.example.com")
+				r.HandleFunc("/", dummyHandler).Host("b.example.com")
+			},
+		},
+		{
+			name: "matcher func",
+			routes: func(r *Router) {
+				r.HandleFunc("/users", dummyHandler).MatcherFunc(func(*http.Request, *RouteMatch) bool { return true })
+				r.HandleFunc("/users", dummyHandler)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := NewRouter().Strict(true)
+			test.routes(router)
+			err := router.Compile()
+			switch {
+			case test.conflict == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case test.conflict != "" && (err == nil || !strings.Contains(err.Error(), test.conflict)):
+				t.Errorf("expected error containing %q, got %v", test.conflict, err)
+			}
+
+			router = NewRouter()
+			test.routes(router)
+			if err := router.Compile(); err != nil {
+				t.Errorf("unexpected error without strict mode: %v", err)
+			}
+		})
+	}
+}
+
+func TestStrictSpecificityOrder(t *testing.T) {
+	router := NewRouter().Strict(true).EnableSpecificityOrder()
+	router.HandleFunc("/users/{id}", dummyHandler)
+	router.HandleFunc("/users/me", dummyHandler)
+	if err := router.Compile(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	router.HandleFunc("/users/{name}", dummyHandler)
+	if err := router.Compile(); err == nil || !strings.Contains(err.Error(), `"/users/{id}" and "/users/{name}"`) {
+		t.Errorf("expected a conflict of routes of equal specificity, got %v", err)
+	}
+}
+
+func TestSampleMatch(t *testing.T) {
+	tests := map[string]string{
+		"[0-9]+":        "0",
+		"[^/]+":         "a",
+		"v[12]":         "v1",
+		"(?:json|xml)":  "json",
+		"[A-Z]{2}-\\d*": "AA-",
+		".*":            "",
+		"[^a-zA-Z0-9]":  "_",
+	}
+	for patt, want := range tests {
+		if got, ok := sampleMatch(patt); !ok || got != want {
+			t.Errorf("%q: expected %q, got %q, %v", patt, want, got, ok)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+// UnknownTenant is the label of the tenants unknown to the router, see
+// TenantLabel.
+const UnknownTenant = "unknown"
+
+// TenantResolver maps requests to tenant IDs by host or header, see
+// Router.Tenants:
+//
+//	tenants := mux.NewTenantResolver().
+//	    Host("{tenant}.shop.example.com", "").
+//	    Host("shop.acme.com", "acme").
+//	    Header("X-Tenant-ID", fromProxy)
+type TenantResolver struct {
+	hosts   []tenantHost
+	header  string
+	trusted func(*http.Request) bool
+	err     error
+}
+
+// tenantHost maps the hosts matching a host template to a tenant.
+type tenantHost struct {
+	rr *routeRegexp
+	// tenant is the tenant ID, or "" for the value of the tenant variable
+	// of the template at index variable.
+	tenant   string
+	variable int
+}
+
+// NewTenantResolver returns a TenantResolver resolving no tenant.
+func NewTenantResolver() *TenantResolver {
+	return &TenantResolver{}
+}
+
+// Host maps the hosts matching the host template tpl, as accepted by
+// Route.Host, to tenant. If tenant is empty, the tenant ID is the value of
+// the "tenant" variable of tpl. Templates are tried in the order they were
+// added, before the header.
+func (t *TenantResolver) Host(tpl, tenant string) *TenantResolver {
+	if t.err != nil {
+		return t
+	}
+	rr, err := newRouteRegexp(tpl, regexpTypeHost, routeRegexpOptions{})
+	if err == nil {
+		err = rr.compile()
+	}
+	if err != nil {
+		t.err = err
+		return t
+	}
+	h := tenantHost{rr: rr, tenant: tenant, variable: slices.Index(rr.varsN, "tenant")}
+	if tenant == "" && h.variable < 0 {
+		t.err = fmt.Errorf("mux: tenant host template %q has no tenant variable", tpl)
+		return t
+	}
+	t.hosts = append(t.hosts, h)
+	return t
+}
+
+// Header maps the requests matching no host template to the tenant ID in the
+// header with the given name, if present and trusted returns true for the
+// request.
+//
+// Clients can send any header, so a tenant taken from it is only as
+// trustworthy as trusted: it must only accept the requests whose header was
+// set by a trusted party, such as a proxy replacing the header, e.g.
+//
+//	proxies := netip.MustParsePrefix("10.0.0.0/8")
+//	fromProxy := func(r *http.Request) bool {
+//	    addr, err := netip.ParseAddrPort(r.RemoteAddr)
+//	    return err == nil && proxies.Contains(addr.Addr())
+//	}
+//
+// The header of other requests is ignored. A nil trusted is an error.
+func (t *TenantResolver) Header(name string, trusted func(r *http.Request) bool) *TenantResolver {
+	if t.err != nil {
+		return t
+	}
+	if trusted == nil {
+		t.err = fmt.Errorf("mux: tenant header %q has no trust predicate", name)
+		return t
+	}
+	t.header = name
+	t.trusted = trusted
+	return t
+}
+
+// Err returns the error of the first invalid host template or header, if any.
+func (t *TenantResolver) Err() error {
+	return t.err
+}
+
+// Resolve returns the tenant ID of req. It returns false if req matches no
+// host template and carries no trusted tenant header.
+func (t *TenantResolver) Resolve(req *http.Request) (string, bool) {
+	var match RouteMatch
+	for _, h := range t.hosts {
+		host := match.requestHost(req, h.rr.wildcardHostPort)
+		if h.tenant != "" {
+			if h.rr.compiled.regexp.MatchString(host) {
+				return h.tenant, true
+			}
+			continue
+		}
+		if m := h.rr.compiled.regexp.FindStringSubmatch(host); m != nil {
+			return m[h.variable+1], true
+		}
+	}
+	if t.header != "" && t.trusted(req) {
+		if tenant := req.Header.Get(t.header); tenant != "" {
+			return tenant, true
+		}
+	}
+	return "", false
+}
+
+// Tenants makes the router resolve the tenant of every request with
+// resolver before matching it. The tenant ID is stored in the handler and
+// request contexts, see GetTenant, selects the tenant overlay of the router
+// and its subrouters, see TenantOverlay, and labels the access log of
+// LoggingMiddleware. This replaces a Host subrouter per tenant:
+//
+//	r := mux.NewRouter().Tenants(tenants)
+//	r.HandleFunc("/products", listProducts)
+//	r.TenantOverlay("acme").HandleFunc("/products", listAcmeProducts)
+//
+// Clients choose the host and headers of their requests: a tenant taken from
+// a header can only be trusted for the requests accepted by the trust
+// predicate of TenantResolver.Header, and the tenant, like the overlay it
+// selects, does not replace authorization.
+//
+// It must be called on the router serving requests, before it does so. If
+// resolver has an invalid host template or header, the error is logged,
+// returned by Router.Compile and returned by ServeHTTP for every request,
+// instead of serving requests without their tenant.
+func (r *Router) Tenants(resolver *TenantResolver) *Router {
+	if err := resolver.Err(); err != nil {
+		r.getLogger().Log(context.Background(), slog.LevelError, "mux: invalid tenant resolver", "error", err)
+	}
+	r.tenants = resolver
+	return r
+}
+
+// TenantOverlay returns the router of the routes of the given tenant. They
+// take precedence over the routes of r for the requests of the tenant, which
+// are matched against them first, so that tenants can override and extend
+// the routes shared by all tenants. Like a subrouter, the overlay is
+// configured like r and its matches are wrapped with the middlewares of r.
+// Its routes are not visited by Walk.
+//
+// It must be called before the router serves requests.
+func (r *Router) TenantOverlay(tenant string) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if route, ok := r.tenantOverlays[tenant]; ok {
+		return route.matchers[0].(*Router)
+	}
+	if r.tenantOverlays == nil {
+		r.tenantOverlays = make(map[string]*Route)
+	}
+	route := r.newRoute()
+	overlay := route.Subrouter()
+	r.tenantOverlays[tenant] = route
+	return overlay
+}
+
+// matchTenantOverlay matches req against the tenant overlay of its tenant.
+func (r *Router) matchTenantOverlay(req *http.Request, match *RouteMatch) bool {
+	tenant, ok := GetTenant(req.Context())
+	if !ok {
+		return false
+	}
+	route, ok := r.tenantOverlays[tenant]
+	return ok && r.matchRoute(route, req, match)
+}
+
+// GetTenant returns the tenant ID of the request resolved by the router, see
+// Router.Tenants.
+func GetTenant(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey).(string)
+	return tenant, ok
+}
+
+// TenantLabel returns a label of the tenant of the request resolved by the
+// router, for metrics and other places where the number of distinct values
+// must be bounded. It is the tenant ID if the tenant has a literal host
+// template, see TenantResolver.Host, or a tenant overlay, see
+// Router.TenantOverlay, and UnknownTenant for other tenants, such as those
+// taken from a host variable or header, which clients choose freely. It is
+// empty for requests without a tenant.
+func TenantLabel(ctx context.Context) string {
+	label, _ := ctx.Value(tenantLabelKey).(string)
+	return label
+}
+
+// resolvedTenant is the tenant of a request, see Router.Tenants.
+type resolvedTenant struct {
+	id    string
+	label string
+}
+
+// knownTenants holds the tenants with a literal host template or a tenant
+// overlay, computed at a routes generation of the router tree.
+type knownTenants struct {
+	generation uint64
+	tenants    map[string]bool
+}
+
+// tenantLabel returns the label of tenant, see TenantLabel.
+func (r *Router) tenantLabel(tenant string) string {
+	generation := r.tree.loadRoutes()
+	known := r.knownTenants.Load()
+	if known == nil || known.generation != generation {
+		known = &knownTenants{generation: generation, tenants: make(map[string]bool)}
+		for _, h := range r.tenants.hosts {
+			if h.tenant != "" {
+				known.tenants[h.tenant] = true
+			}
+		}
+		addOverlays := func(router *Router) {
+			for tenant := range router.tenantOverlays {
+				known.tenants[tenant] = true
+			}
+		}
+		addOverlays(r)
+		_ = r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+			if sub, ok := route.handler.(*Router); ok {
+				addOverlays(sub)
+			}
+			for _, m := range route.matchers {
+				if sub, ok := m.(*Router); ok {
+					addOverlays(sub)
+				}
+			}
+			return nil
+		})
+		r.knownTenants.Store(known)
+	}
+	if known.tenants[tenant] {
+		return tenant
+	}
+	return UnknownTenant
+}
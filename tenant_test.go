@@ -0,0 +1,235 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/netip"
+	"testing"
+)
+
+// trustAll trusts the tenant header of every request.
+func trustAll(*http.Request) bool {
+	return true
+}
+
+// fromProxy trusts the tenant header of the requests from 10.0.0.1.
+func fromProxy(r *http.Request) bool {
+	addr, err := netip.ParseAddrPort(r.RemoteAddr)
+	return err == nil && addr.Addr() == netip.MustParseAddr("10.0.0.1")
+}
+
+func TestTenantResolver(t *testing.T) {
+	resolver := NewTenantResolver().
+		Host("shop.acme.com", "acme").
+		Host("{tenant}.shop.example.com", "").
+		Host("{region}.{tenant:[a-z]+}.example.org", "").
+		Header("X-Tenant-ID", trustAll)
+	if err := resolver.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url, header string
+		tenant      string
+		ok          bool
+	}{
+		{url: "http://shop.acme.com/", tenant: "acme", ok: true},
+		{url: "http://shop.acme.com:8080/", tenant: "acme", ok: true},
+		{url: "http://Shop.ACME.com/", tenant: "acme", ok: true},
+		{url: "http://GLOBEX.Shop.Example.com/", tenant: "globex", ok: true},
+		{url: "http://EU.Initech.example.org/", tenant: "initech", ok: true},
+		{url: "http://globex.shop.example.com/", tenant: "globex", ok: true},
+		{url: "http://eu.initech.example.org/", tenant: "initech", ok: true},
+		{url: "http://eu.initech.example.org/", header: "acme", tenant: "initech", ok: true},
+		{url: "http://api.example.com/", header: "umbrella", tenant: "umbrella", ok: true},
+		{url: "http://api.example.com/"},
+	}
+	for _, tt := range tests {
+		req := newRequest(http.MethodGet, tt.url)
+		if tt.header != "" {
+			req.Header.Set("X-Tenant-ID", tt.header)
+		}
+		if tenant, ok := resolver.Resolve(req); tenant != tt.tenant || ok != tt.ok {
+			t.Errorf("%s (header %q): expected %q, %v, got %q, %v", tt.url, tt.header, tt.tenant, tt.ok, tenant, ok)
+		}
+	}
+
+	if err := NewTenantResolver().Header("X-Tenant-ID", nil).Err(); err == nil {
+		t.Error("expected an error for a header without trust predicate")
+	}
+	for _, tpl := range []string{"shop.example.com", "{id}.example.com", "{tenant.example.com"} {
+		if err := NewTenantResolver().Host(tpl, "").Err(); err == nil {
+			t.Errorf("%s: expected an error", tpl)
+		}
+	}
+}
+
+func TestRouterTenants(t *testing.T) {
+	tenantHandler := func(name string) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+			tenant, _ := GetTenant(ctx)
+			reqTenant, _ := GetTenant(req.Context())
+			_, err := w.Write([]byte(name + ":" + tenant + ":" + reqTenant))
+			return err
+		}
+	}
+
+	r := NewRouter().Tenants(NewTenantResolver().Host("{tenant}.example.com", ""))
+	r.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+			w.Header().Set("X-Middleware", "router")
+			return next(ctx, w, req, binder)
+		}
+	})
+	r.HandleFunc("/products", tenantHandler("products"))
+	r.HandleFunc("/orders", tenantHandler("orders"))
+	r.TenantOverlay("acme").HandleFunc("/products", tenantHandler("acme-products"))
+	r.TenantOverlay("acme").HandleFunc("/reports", tenantHandler("acme-reports"))
+	api := r.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/status", tenantHandler("status"))
+	api.TenantOverlay("globex").HandleFunc("/status", tenantHandler("globex-status"))
+
+	tests := []struct {
+		url, body string
+		status    int
+	}{
+		{url: "http://acme.example.com/products", body: "acme-products:acme:acme"},
+		{url: "http://acme.example.com/orders", body: "orders:acme:acme"},
+		{url: "http://acme.example.com/reports", body: "acme-reports:acme:acme"},
+		{url: "http://globex.example.com/products", body: "products:globex:globex"},
+		{url: "http://globex.example.com/reports", status: http.StatusNotFound},
+		{url: "http://globex.example.com/api/status", body: "globex-status:globex:globex"},
+		{url: "http://acme.example.com/api/status", body: "status:acme:acme"},
+		{url: "http://localhost/products", body: "products::"},
+	}
+	for _, tt := range tests {
+		rw := NewRecorder()
+		if err := r.ServeHTTP(context.Background(), rw, newRequest(http.MethodGet, tt.url), nil); err != nil {
+			t.Fatal(err)
+		}
+		if tt.status != 0 {
+			if rw.Code != tt.status {
+				t.Errorf("%s: expected status %d, got %d", tt.url, tt.status, rw.Code)
+			}
+			continue
+		}
+		if rw.Body.String() != tt.body {
+			t.Errorf("%s: expected %q, got %q", tt.url, tt.body, rw.Body.String())
+		}
+		if rw.Header().Get("X-Middleware") != "router" {
+			t.Errorf("%s: expected the router middleware to run", tt.url)
+		}
+	}
+
+	if r.TenantOverlay("acme") != r.TenantOverlay("acme") {
+		t.Error("expected the same overlay for the same tenant")
+	}
+}
+
+func TestRouterTenantsInvalidResolver(t *testing.T) {
+	r := NewRouter().SetLogger(NopLogger()).Tenants(NewTenantResolver().Host("example.com", ""))
+	r.HandleFunc("/", stringHandler("ok"))
+
+	if err := r.Compile(); err == nil {
+		t.Error("expected Compile to report the invalid resolver")
+	}
+	for i := 0; i < 2; i++ {
+		rw := NewRecorder()
+		req := newRequest(http.MethodGet, "http://example.com/")
+		if err := r.ServeHTTP(context.Background(), rw, req, nil); err == nil {
+			t.Error("expected ServeHTTP to report the invalid resolver")
+		}
+		if rw.Body.String() != "" {
+			t.Errorf("expected no request to be served without its tenant, got %q", rw.Body.String())
+		}
+	}
+}
+
+func TestTenantResolverTrustedHeader(t *testing.T) {
+	r := NewRouter().Tenants(NewTenantResolver().
+		Host("{tenant}.example.com", "").
+		Header("X-Tenant-ID", fromProxy))
+	r.HandleFunc("/products", stringHandler("products"))
+	r.TenantOverlay("acme").HandleFunc("/products", stringHandler("acme-products"))
+
+	tests := []struct {
+		url, remoteAddr string
+		body            string
+	}{
+		{url: "http://localhost/products", remoteAddr: "10.0.0.1:1234", body: "acme-products"},
+		// The header of clients is ignored, they only reach the tenant
+		// through its host.
+		{url: "http://localhost/products", remoteAddr: "192.0.2.1:1234", body: "products"},
+		{url: "http://localhost/products", body: "products"},
+		{url: "http://globex.example.com/products", remoteAddr: "10.0.0.1:1234", body: "products"},
+	}
+	for _, tt := range tests {
+		req := newRequest(http.MethodGet, tt.url)
+		req.RemoteAddr = tt.remoteAddr
+		req.Header.Set("X-Tenant-ID", "acme")
+		rw := NewRecorder()
+		if err := r.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+			t.Fatal(err)
+		}
+		if rw.Body.String() != tt.body {
+			t.Errorf("%s from %q: expected %q, got %q", tt.url, tt.remoteAddr, tt.body, rw.Body.String())
+		}
+	}
+}
+
+func TestLoggingMiddlewareTenant(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRouter().Tenants(NewTenantResolver().Header("X-Tenant-ID", trustAll))
+	r.Use(LoggingMiddleware(LoggingOptions{Output: &buf, Format: LogFormatJSON}))
+	r.HandleFunc("/", stringHandler("ok"))
+
+	req := newRequest(http.MethodGet, "http://localhost/")
+	req.Header.Set("X-Tenant-ID", "acme")
+	if err := r.ServeHTTP(context.Background(), NewRecorder(), req, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var entry LogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Tenant != "acme" {
+		t.Errorf("expected tenant %q, got %q", "acme", entry.Tenant)
+	}
+}
+
+func TestTenantLabel(t *testing.T) {
+	r := NewRouter().Tenants(NewTenantResolver().
+		Host("shop.acme.com", "acme").
+		Header("X-Tenant-ID", trustAll))
+	r.HandleFunc("/", func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		_, err := w.Write([]byte(TenantLabel(ctx) + ":" + TenantLabel(req.Context())))
+		return err
+	})
+	r.PathPrefix("/api").Subrouter().TenantOverlay("globex")
+
+	tests := []struct {
+		url, header string
+		label       string
+	}{
+		{url: "http://shop.acme.com/", label: "acme"},
+		{url: "http://api.example.com/", header: "globex", label: "globex"},
+		{url: "http://api.example.com/", header: "random-1234", label: UnknownTenant},
+		{url: "http://api.example.com/"},
+	}
+	for _, tt := range tests {
+		rw := NewRecorder()
+		req := newRequest(http.MethodGet, tt.url)
+		if tt.header != "" {
+			req.Header.Set("X-Tenant-ID", tt.header)
+		}
+		if err := r.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+			t.Fatal(err)
+		}
+		if want := tt.label + ":" + tt.label; rw.Body.String() != want {
+			t.Errorf("%s (header %q): expected %q, got %q", tt.url, tt.header, want, rw.Body.String())
+		}
+	}
+}
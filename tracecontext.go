@@ -0,0 +1,143 @@
+package mux
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// W3C Trace Context header names.
+const (
+	TraceParentHeader = "Traceparent"
+	TraceStateHeader  = "Tracestate"
+)
+
+// TraceID is a W3C trace id.
+type TraceID [16]byte
+
+// IsValid reports whether the id is not all zeros.
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// String returns the lowercase hex encoding of the id.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID is a W3C parent id, identifying a single hop of a trace.
+type SpanID [8]byte
+
+// IsValid reports whether the id is not all zeros.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// String returns the lowercase hex encoding of the id.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// TraceContext is the W3C trace context of a request.
+type TraceContext struct {
+	TraceID TraceID
+	// SpanID identifies the current request.
+	SpanID SpanID
+	// ParentSpanID identifies the caller. It is zero if the trace started
+	// with the current request.
+	ParentSpanID SpanID
+	// Sampled is the sampled flag propagated by the caller.
+	Sampled bool
+	// TraceState is the vendor specific tracestate, passed on unchanged.
+	TraceState string
+}
+
+// TraceParent returns the traceparent header value for calls made on behalf
+// of the current request.
+func (tc TraceContext) TraceParent() string {
+	flags := "00"
+	if tc.Sampled {
+		flags = "01"
+	}
+	return "00-" + tc.TraceID.String() + "-" + tc.SpanID.String() + "-" + flags
+}
+
+// Inject sets the trace context headers on h, e.g. on an outgoing request.
+func (tc TraceContext) Inject(h http.Header) {
+	h.Set(TraceParentHeader, tc.TraceParent())
+	if tc.TraceState != "" {
+		h.Set(TraceStateHeader, tc.TraceState)
+	} else {
+		h.Del(TraceStateHeader)
+	}
+}
+
+// ParseTraceParent parses a traceparent header value. It returns false if the
+// value is malformed or carries invalid ids.
+func ParseTraceParent(value string) (traceID TraceID, parentID SpanID, sampled bool, ok bool) {
+	// version "-" trace-id "-" parent-id "-" trace-flags
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return TraceID{}, SpanID{}, false, false
+	}
+	// Future versions may append fields, version 00 may not.
+	version, ok := decodeLowerHex(value[0:2], 1)
+	if !ok || version[0] == 0xff || (version[0] == 0 && len(value) != 55) || (len(value) > 55 && value[55] != '-') {
+		return TraceID{}, SpanID{}, false, false
+	}
+	tid, ok1 := decodeLowerHex(value[3:35], 16)
+	pid, ok2 := decodeLowerHex(value[36:52], 8)
+	flags, ok3 := decodeLowerHex(value[53:55], 1)
+	if !ok1 || !ok2 || !ok3 {
+		return TraceID{}, SpanID{}, false, false
+	}
+	copy(traceID[:], tid)
+	copy(parentID[:], pid)
+	if !traceID.IsValid() || !parentID.IsValid() {
+		return TraceID{}, SpanID{}, false, false
+	}
+	return traceID, parentID, flags[0]&1 == 1, true
+}
+
+// decodeLowerHex decodes s into n bytes, rejecting upper case digits as
+// required by the specification.
+func decodeLowerHex(s string, n int) ([]byte, bool) {
+	if strings.ToLower(s) != s {
+		return nil, false
+	}
+	b, err := hex.DecodeString(s)
+	return b, err == nil && len(b) == n
+}
+
+// TraceContextMiddleware reads the traceparent and tracestate headers into a
+// TraceContext available from GetTraceContext. A new span id is assigned to
+// the request, and a new trace is started if the incoming headers are missing
+// or invalid. The resulting traceparent is set on the response.
+//
+// It provides correlation across services without depending on a tracing
+// SDK.
+func TraceContextMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request, binder Binder) error {
+		var tc TraceContext
+		if traceID, parentID, sampled, ok := ParseTraceParent(req.Header.Get(TraceParentHeader)); ok {
+			tc = TraceContext{TraceID: traceID, ParentSpanID: parentID, Sampled: sampled}
+			tc.TraceState = strings.Join(req.Header.Values(TraceStateHeader), ",")
+		} else {
+			_, _ = rand.Read(tc.TraceID[:])
+		}
+		_, _ = rand.Read(tc.SpanID[:])
+
+		ctx = withRouterValue(ctx, traceKey, &tc)
+		req = req.WithContext(withRouterValue(req.Context(), traceKey, &tc))
+		tc.Inject(w.Header())
+
+		return next(ctx, w, req, binder)
+	}
+}
+
+// GetTraceContext returns the trace context set by TraceContextMiddleware.
+func GetTraceContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceKey).(TraceContext)
+	return tc, ok
+}
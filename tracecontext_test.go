@@ -0,0 +1,78 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		value   string
+		ok      bool
+		sampled bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false, false},
+		{"", false, false},
+	}
+
+	for _, test := range tests {
+		traceID, _, sampled, ok := ParseTraceParent(test.value)
+		if ok != test.ok || sampled != test.sampled {
+			t.Errorf("%q: expected ok=%v sampled=%v, got ok=%v sampled=%v", test.value, test.ok, test.sampled, ok, sampled)
+		}
+		if ok && traceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("%q: unexpected trace id %s", test.value, traceID)
+		}
+	}
+}
+
+func TestTraceContextMiddleware(t *testing.T) {
+	var got TraceContext
+	router := NewRouter()
+	router.Use(TraceContextMiddleware)
+	router.HandleFunc("/", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		got, _ = GetTraceContext(ctx)
+		if fromReq, _ := GetTraceContext(r.Context()); fromReq != got {
+			t.Error("expected the trace context in the request context")
+		}
+		return nil
+	})
+
+	rw := NewRecorder()
+	req := newRequestWithHeaders("GET", "http://localhost/",
+		"Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"Tracestate", "congo=t61rcWkgMzE")
+	if err := router.ServeHTTP(context.Background(), rw, req, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || got.ParentSpanID.String() != "00f067aa0ba902b7" || !got.Sampled {
+		t.Errorf("unexpected trace context %+v", got)
+	}
+	if !got.SpanID.IsValid() || got.SpanID == got.ParentSpanID {
+		t.Errorf("expected a new span id, got %s", got.SpanID)
+	}
+	if rw.Header().Get(TraceParentHeader) != got.TraceParent() || rw.Header().Get(TraceStateHeader) != "congo=t61rcWkgMzE" {
+		t.Errorf("unexpected response headers %v", rw.Header())
+	}
+
+	rw = NewRecorder()
+	if err := router.ServeHTTP(context.Background(), rw, newRequest("GET", "http://localhost/"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if !got.TraceID.IsValid() || got.ParentSpanID.IsValid() || got.TraceState != "" {
+		t.Errorf("expected a new trace, got %+v", got)
+	}
+	if _, _, _, ok := ParseTraceParent(rw.Header().Get(TraceParentHeader)); !ok {
+		t.Errorf("invalid traceparent response header %q", rw.Header().Get(TraceParentHeader))
+	}
+}
@@ -0,0 +1,27 @@
+package mux
+
+import "net/http"
+
+// TwirpServer is the part of the interface of the servers generated by
+// protoc-gen-twirp used by MountTwirp.
+type TwirpServer interface {
+	http.Handler
+
+	// PathPrefix returns the path prefix of the service, e.g.
+	// "/twirp/example.haberdasher.Haberdasher/".
+	PathPrefix() string
+}
+
+// MountTwirp registers a route serving the Twirp service server under its
+// path prefix:
+//
+//	r.Use(authMiddleware)
+//	r.MountTwirp(haberdasher.NewHaberdasherServer(service))
+//
+// The middlewares of the router apply to the route, and the service methods
+// receive a context holding the values of the handler context and the request
+// context, see WrapHTTPHandler. Requests with the wrong method or content type
+// are answered by the server, with Twirp errors.
+func (r *Router) MountTwirp(server TwirpServer) *Route {
+	return r.PathPrefix(server.PathPrefix()).Handler(WrapHTTPHandler(server))
+}
@@ -0,0 +1,54 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type twirpKey struct{}
+
+// testTwirpServer stands in for a server generated by protoc-gen-twirp.
+type testTwirpServer struct{}
+
+func (testTwirpServer) PathPrefix() string {
+	return "/twirp/example.Haberdasher/"
+}
+
+func (s testTwirpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method, ok := strings.CutPrefix(r.URL.Path, s.PathPrefix())
+	if !ok || r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	fmt.Fprintf(w, "%s %v %v", method, r.Context().Value(twirpKey{}), r.Header.Get("X-Middleware"))
+}
+
+func TestMountTwirp(t *testing.T) {
+	router := NewRouter()
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+			r.Header.Set("X-Middleware", "1")
+			return next(context.WithValue(ctx, twirpKey{}, "ctx"), w, r, binder)
+		}
+	})
+	router.MountTwirp(testTwirpServer{})
+
+	rw := NewRecorder()
+	if err := router.ServeHTTP(context.Background(), rw, newRequest(http.MethodPost, "http://localhost/twirp/example.Haberdasher/MakeHat"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if rw.Body.String() != "MakeHat ctx 1" {
+		t.Errorf("unexpected response %q", rw.Body.String())
+	}
+
+	rw = NewRecorder()
+	if err := router.ServeHTTP(context.Background(), rw, newRequest(http.MethodPost, "http://localhost/twirp/other.Service/Method"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if rw.Code != http.StatusNotFound {
+		t.Errorf("expected 404 outside of the prefix, got %d", rw.Code)
+	}
+}
@@ -0,0 +1,162 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Handle registers a route for a pattern of http.ServeMux, see
+// Router.HandlePattern, whose handler fn takes the request body decoded into
+// Req and returns the response body:
+//
+//	mux.Handle(router, "POST /orders", func(ctx context.Context, r *http.Request, order CreateOrder) (Order, error) {
+//	    return orders.Create(ctx, order)
+//	})
+//
+// The request body is decoded by the binder passed to the router if it
+// implements BodyBinder. Otherwise a JSON or XML body is decoded according to
+// its Content-Type, a missing body leaves Req the zero value, and other
+// bodies result in an error with status 415, see ErrorStatus. Bodies which
+// can't be decoded result in an error with status 400.
+//
+// The response is encoded as JSON or XML, whichever the Accept header
+// prefers, with status 200; JSON if the request accepts anything. A request
+// accepting neither results in an error with status 406. Errors of fn are
+// returned as is and nothing is written.
+//
+// The route documents Req and Resp as its request and response types, see
+// Route.RequestType and Route.ResponseType.
+func Handle[Req, Resp any](router *Router, pattern string, fn func(ctx context.Context, r *http.Request, req Req) (Resp, error)) *Route {
+	var req Req
+	var resp Resp
+	return router.HandlePattern(pattern, typedHandler(fn)).RequestType(req).ResponseType(resp)
+}
+
+// typedHandler returns the handler of a route registered with Handle.
+func typedHandler[Req, Resp any](fn func(ctx context.Context, r *http.Request, req Req) (Resp, error)) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		encode, contentType, err := negotiateEncoder(r.Header.Get("Accept"))
+		if err != nil {
+			return err
+		}
+		var req Req
+		if err := bindBody(r, binder, &req); err != nil {
+			return err
+		}
+		resp, err := fn(ctx, r, req)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodHead {
+			return nil
+		}
+		return encode(w, resp)
+	}
+}
+
+// bindBody decodes the body of r into v, see Handle.
+func bindBody(r *http.Request, binder Binder, v any) error {
+	if b, ok := binder.(BodyBinder); ok {
+		return b.Bind(r, v)
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	switch {
+	case isJSONMediaType(contentType):
+		err = json.Unmarshal(data, v)
+	case isXMLMediaType(contentType):
+		err = xml.Unmarshal(data, v)
+	default:
+		return &statusError{status: http.StatusUnsupportedMediaType, err: fmt.Errorf("mux: unsupported request content type %q", contentType)}
+	}
+	if err != nil {
+		return &statusError{status: http.StatusBadRequest, err: fmt.Errorf("mux: invalid request body: %w", err)}
+	}
+	return nil
+}
+
+func isXMLMediaType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// responseEncoders are the encoders of Handle, in order of preference.
+var responseEncoders = []struct {
+	mediaType string
+	encode    func(w io.Writer, v any) error
+}{
+	{"application/json", func(w io.Writer, v any) error { return json.NewEncoder(w).Encode(v) }},
+	{"application/xml", func(w io.Writer, v any) error { return xml.NewEncoder(w).Encode(v) }},
+}
+
+// negotiateEncoder returns the response encoder preferred by the Accept
+// header value accept.
+func negotiateEncoder(accept string) (func(w io.Writer, v any) error, string, error) {
+	if strings.TrimSpace(accept) == "" {
+		return responseEncoders[0].encode, responseEncoders[0].mediaType, nil
+	}
+	best, bestQ := -1, 0.0
+	for i, enc := range responseEncoders {
+		if q := acceptQuality(accept, enc.mediaType); q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+	if best == -1 {
+		return nil, "", &statusError{status: http.StatusNotAcceptable, err: errors.New("mux: no acceptable response content type")}
+	}
+	return responseEncoders[best].encode, responseEncoders[best].mediaType, nil
+}
+
+// acceptQuality returns the weight the Accept header value accept gives
+// mediaType, using the most specific range matching it.
+func acceptQuality(accept, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, value := range strings.Split(accept, ",") {
+		rng, params, _ := strings.Cut(value, ";")
+		rng = strings.ToLower(strings.TrimSpace(rng))
+		var s int
+		switch rng {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+		specificity, q = s, 1
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if weight, err := strconv.ParseFloat(v, 64); err == nil {
+					q = weight
+				}
+			}
+		}
+	}
+	return q
+}
@@ -0,0 +1,169 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type createOrder struct {
+	Item     string `json:"item" xml:"item"`
+	Quantity int    `json:"quantity" xml:"quantity"`
+}
+
+type order struct {
+	ID       string `json:"id" xml:"id"`
+	Item     string `json:"item" xml:"item"`
+	Quantity int    `json:"quantity" xml:"quantity"`
+}
+
+// upperBinder binds request bodies as upper case items.
+type upperBinder struct{}
+
+func (upperBinder) Bind(r *http.Request, v any) error {
+	v.(*createOrder).Item = "BOUND"
+	return nil
+}
+
+func typedTestRouter() *Router {
+	router := NewRouter()
+	Handle(router, "POST /users/{user}/orders", func(ctx context.Context, r *http.Request, req createOrder) (order, error) {
+		if req.Quantity < 0 {
+			return order{}, errors.New("negative quantity")
+		}
+		return order{ID: Var(r, "user") + "-1", Item: req.Item, Quantity: req.Quantity}, nil
+	})
+	return router
+}
+
+func TestHandle(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		accept      string
+		binder      Binder
+		status      int
+		response    string
+		err         string
+	}{
+		{
+			name:        "json",
+			body:        `{"item":"book","quantity":2}`,
+			contentType: "application/json",
+			status:      http.StatusOK,
+			response:    `{"id":"ann-1","item":"book","quantity":2}` + "\n",
+		},
+		{
+			name:        "xml",
+			body:        `<createOrder><item>pen</item><quantity>3</quantity></createOrder>`,
+			contentType: "application/xml; charset=utf-8",
+			accept:      "application/json;q=0.5, application/xml",
+			status:      http.StatusOK,
+			response:    `<order><id>ann-1</id><item>pen</item><quantity>3</quantity></order>`,
+		},
+		{
+			name:     "no body",
+			accept:   "text/html, */*;q=0.1",
+			status:   http.StatusOK,
+			response: `{"id":"ann-1","item":"","quantity":0}` + "\n",
+		},
+		{
+			name:     "binder",
+			body:     "ignored",
+			binder:   upperBinder{},
+			status:   http.StatusOK,
+			response: `{"id":"ann-1","item":"BOUND","quantity":0}` + "\n",
+		},
+		{
+			name:        "invalid body",
+			body:        `{"item":`,
+			contentType: "application/json",
+			status:      http.StatusBadRequest,
+			err:         "mux: invalid request body",
+		},
+		{
+			name:        "unsupported content type",
+			body:        "item=book",
+			contentType: "application/x-www-form-urlencoded",
+			status:      http.StatusUnsupportedMediaType,
+			err:         "mux: unsupported request content type",
+		},
+		{
+			name:   "not acceptable",
+			accept: "text/html, application/json;q=0",
+			status: http.StatusNotAcceptable,
+			err:    "mux: no acceptable response content type",
+		},
+		{
+			name:        "handler error",
+			body:        `{"quantity":-1}`,
+			contentType: "application/json",
+			status:      http.StatusInternalServerError,
+			err:         "negative quantity",
+		},
+	}
+	router := typedTestRouter()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := newRequest(http.MethodPost, "http://localhost/users/ann/orders")
+			if test.body != "" {
+				req.Body = io.NopCloser(strings.NewReader(test.body))
+			}
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+			rec := NewRecorder()
+			err := router.ServeHTTP(context.Background(), rec, req, test.binder)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) || ErrorStatus(err) != test.status {
+					t.Fatalf("expected error %q with status %d, got %v", test.err, test.status, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rec.Code != test.status || rec.Body.String() != test.response {
+				t.Errorf("expected %d %q, got %d %q", test.status, test.response, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleTypes(t *testing.T) {
+	route := typedTestRouter().getRoutes()[0]
+	request, _ := route.metadata[requestTypeKey{}].(bodyType)
+	response, _ := route.metadata[responseTypeKey{}].(bodyType)
+	if request.typ != reflect.TypeOf(createOrder{}) || response.typ != reflect.TypeOf(order{}) {
+		t.Errorf("unexpected body types %v and %v", request.typ, response.typ)
+	}
+	if methods, _ := route.GetMethods(); !reflect.DeepEqual(methods, []string{http.MethodPost}) {
+		t.Errorf("unexpected methods %v", methods)
+	}
+}
+
+func TestAcceptQuality(t *testing.T) {
+	tests := []struct {
+		accept string
+		q      float64
+	}{
+		{"application/json", 1},
+		{"application/*;q=0.4", 0.4},
+		{"*/*;q=0.1, application/json;q=0.7", 0.7},
+		{"application/json;q=0, */*", 0},
+		{"text/html", 0},
+	}
+	for _, test := range tests {
+		if q := acceptQuality(test.accept, "application/json"); q != test.q {
+			t.Errorf("%q: expected %v, got %v", test.accept, test.q, q)
+		}
+	}
+}
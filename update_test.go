@@ -0,0 +1,63 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestRouterUpdate(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/static", dummyHandler)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/dynamic/3/42"), nil)
+			}
+		}
+	}()
+
+	for i := 0; i < 10; i++ {
+		router.Update(func(staging *Router) {
+			staging.HandleFunc(fmt.Sprintf("/dynamic/%d/{id}", i), dummyHandler).Methods("GET")
+		})
+	}
+	close(stop)
+	wg.Wait()
+
+	var match RouteMatch
+	if !router.Match(newRequest("GET", "http://localhost/dynamic/3/42"), &match) || match.Vars["id"] != "42" {
+		t.Errorf("expected the added route to match, got %+v", match)
+	}
+	if routes := router.getRoutes(); len(routes) != 11 {
+		t.Errorf("expected 11 routes, got %d", len(routes))
+	}
+}
+
+func TestRouterConcurrentRegistration(t *testing.T) {
+	router := NewRouter()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			router.HandleFunc(fmt.Sprintf("/r%d", i), func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+	if routes := router.getRoutes(); len(routes) != 8 {
+		t.Errorf("expected 8 routes, got %d", len(routes))
+	}
+}
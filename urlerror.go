@@ -0,0 +1,94 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// errMissingVariable is the error of building a URL without the value of a
+// route variable.
+var errMissingVariable = errors.New("missing value")
+
+// URLError is the error of building the URL of a route, see Route.URL.
+type URLError struct {
+	// Name is the name of the route, if any.
+	Name string
+	// Template is the template which failed, if any.
+	Template string
+	// Variable is the route variable which is missing or whose value doesn't
+	// match its pattern, if any.
+	Variable string
+	// Err describes the problem.
+	Err error
+}
+
+func (e *URLError) Error() string {
+	var b strings.Builder
+	b.WriteString("mux: building URL")
+	if e.Name != "" {
+		fmt.Fprintf(&b, " of route %q", e.Name)
+	}
+	switch {
+	case e.Variable != "":
+		fmt.Fprintf(&b, ": variable %q of template %q", e.Variable, e.Template)
+	case e.Template != "":
+		fmt.Fprintf(&b, ": template %q", e.Template)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+func (e *URLError) Unwrap() error {
+	return e.Err
+}
+
+// urlError returns err, an error of building the URL of r, with the name of
+// r.
+func (r *Route) urlError(err error) error {
+	var uerr *URLError
+	if errors.As(err, &uerr) {
+		uerr.Name = r.name
+		return uerr
+	}
+	return &URLError{Name: r.name, Err: err}
+}
+
+// MustURL is like URL but panics if the URL can't be built. It simplifies
+// building URLs in templates and initialization code, like template.Must:
+//
+//	link := router.Get("article").MustURL("id", "42").String()
+func (r *Route) MustURL(pairs ...string) *url.URL {
+	u, err := r.URL(pairs...)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// URL builds a URL for the route registered with name, see Route.URL. Unlike
+// Get(name).URL, it returns an error instead of panicking if there is no
+// such route.
+func (r *Router) URL(name string, pairs ...string) (*url.URL, error) {
+	route := r.Get(name)
+	if route == nil {
+		return nil, &URLError{Name: name, Err: errors.New("no route with this name")}
+	}
+	return route.URL(pairs...)
+}
+
+// MustURL is like URL but panics if the URL can't be built:
+//
+//	tmpl := template.Must(template.New("page").Funcs(template.FuncMap{
+//	    "url": func(name string, pairs ...string) string {
+//	        return router.MustURL(name, pairs...).String()
+//	    },
+//	}).Parse(page))
+func (r *Router) MustURL(name string, pairs ...string) *url.URL {
+	u, err := r.URL(name, pairs...)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
@@ -0,0 +1,109 @@
+package mux
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestURLError(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/users/{id:[0-9]+}", dummyHandler).Name("user")
+	router.HandleFunc("/files/{path}", dummyHandler)
+	router.Host("{tenant}.example.com").Name("home")
+	router.HandleFunc("/search", dummyHandler).Queries("q", "{q}").Name("search")
+
+	tests := []struct {
+		name    string
+		build   func() error
+		message string
+	}{
+		{
+			name: "missing",
+			build: func() error {
+				_, err := router.Get("user").URL()
+				return err
+			},
+			message: `mux: building URL of route "user": variable "id" of template "/users/{id:[0-9]+}": missing value`,
+		},
+		{
+			name: "mismatch",
+			build: func() error {
+				_, err := router.Get("user").URL("id", "x")
+				return err
+			},
+			message: `mux: building URL of route "user": variable "id" of template "/users/{id:[0-9]+}": value "x" doesn't match "[0-9]+"`,
+		},
+		{
+			name: "unnamed",
+			build: func() error {
+				_, err := router.getRoutes()[1].URLPath()
+				return err
+			},
+			message: `mux: building URL: variable "path" of template "/files/{path}": missing value`,
+		},
+		{
+			name: "no path",
+			build: func() error {
+				_, err := router.Get("home").URLPath("tenant", "a")
+				return err
+			},
+			message: `mux: building URL of route "home": route doesn't have a path`,
+		},
+		{
+			name: "query",
+			build: func() error {
+				_, err := router.URL("search")
+				return err
+			},
+			message: `mux: building URL of route "search": variable "q" of template "q={q}": missing value`,
+		},
+		{
+			name: "unknown route",
+			build: func() error {
+				_, err := router.URL("unknown")
+				return err
+			},
+			message: `mux: building URL of route "unknown": no route with this name`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.build()
+			var uerr *URLError
+			if !errors.As(err, &uerr) || err.Error() != test.message {
+				t.Errorf("expected %q, got %v", test.message, err)
+			}
+		})
+	}
+
+	if _, err := router.Get("user").URL("id"); err == nil || errors.As(err, new(*URLError)) {
+		t.Errorf("expected the pairs error unchanged, got %v", err)
+	}
+}
+
+func TestMustURL(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/users/{id:[0-9]+}", dummyHandler).Name("user")
+
+	if u := router.MustURL("user", "id", "42"); u.String() != "/users/42" {
+		t.Errorf("expected /users/42, got %s", u)
+	}
+	if u := router.Get("user").MustURL("id", "7"); u.String() != "/users/7" {
+		t.Errorf("expected /users/7, got %s", u)
+	}
+
+	for name, build := range map[string]func(){
+		"route":   func() { router.Get("user").MustURL("id", "x") },
+		"router":  func() { router.MustURL("user") },
+		"unknown": func() { router.MustURL("unknown") },
+	} {
+		func() {
+			defer func() {
+				if _, ok := recover().(*URLError); !ok {
+					t.Errorf("%s: expected a panic with a URLError", name)
+				}
+			}()
+			build()
+		}()
+	}
+}
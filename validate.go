@@ -0,0 +1,128 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// TemplateError is the error of an invalid route template. It tells which
+// part of the template is invalid.
+type TemplateError struct {
+	// Template is the template, including the template of the route of the
+	// subrouter for paths.
+	Template string
+	// Pos is the byte offset of Segment in Template.
+	Pos int
+	// Segment is the invalid part of the template: a variable, or the path
+	// segment with an unbalanced brace.
+	Segment string
+	// Err describes the problem.
+	Err error
+}
+
+func newTemplateError(tpl string, pos int, err error) *TemplateError {
+	start := strings.LastIndexByte(tpl[:pos], '/') + 1
+	end := len(tpl)
+	if i := strings.IndexByte(tpl[pos:], '/'); i > 0 {
+		end = pos + i
+	}
+	return &TemplateError{Template: tpl, Pos: start, Segment: tpl[start:end], Err: err}
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("%v (template %q, position %d, segment %q)", e.Err, e.Template, e.Pos, e.Segment)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// Validate returns the errors of the route, unlike GetError which only
+// returns the first one, which made the route invalid. The templates given
+// after an error are still checked. Errors of templates are TemplateErrors,
+// which tell the invalid part of the template:
+//
+//	for _, err := range route.Validate() {
+//	    var terr *mux.TemplateError
+//	    if errors.As(err, &terr) {
+//	        log.Printf("%s at %d: %v", terr.Template, terr.Pos, terr.Err)
+//	    }
+//	}
+//
+// It compiles the regular expressions of a route created with LazyCompile,
+// see Route.Compile.
+func (r *Route) Validate() []error {
+	for _, m := range r.matchers {
+		if rr, ok := m.(*routeRegexp); ok {
+			// The error of a compiled regexp is cached.
+			if err := rr.compile(); err != nil && !slices.Contains(r.errs, err) {
+				r.errs = append(r.errs, err)
+				if r.err == nil {
+					r.err = err
+				}
+			}
+		}
+	}
+	return slices.Clone(r.errs)
+}
+
+// Validate returns the errors of the routes of r and its subrouters, see
+// Route.Validate, in the order Walk visits the routes.
+func (r *Router) Validate() []error {
+	var errs []error
+	_ = r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		errs = append(errs, route.Validate()...)
+		return nil
+	})
+	return errs
+}
+
+// Err returns the errors of the route joined, see Validate, or nil if the
+// route is valid. Unlike GetError, it compiles the regular expressions of a
+// route created with LazyCompile, so that the route is known to be valid
+// before it serves requests:
+//
+//	route := r.NewRoute().Path(cfg.Path).Methods(cfg.Methods...).Handler(h)
+//	if err := route.Err(); err != nil {
+//	    return fmt.Errorf("route %s: %w", cfg.Name, err)
+//	}
+func (r *Route) Err() error {
+	return errors.Join(r.Validate()...)
+}
+
+// TryHandle registers a new route with a matcher for the URL path, like
+// Handle, unless the path template is invalid or handler is nil. It then
+// returns the errors of the route, see Route.Err, and registers nothing, so
+// that route definitions given by users, such as configuration, can be
+// rejected. The errors are not logged.
+//
+// Matchers added to the returned route can still make it invalid, which
+// Route.Err reports.
+func (r *Router) TryHandle(path string, handler Handler) (*Route, error) {
+	route := r.newRoute()
+	route.logger = NopLogger()
+	route.Path(path)
+	if isNil(handler) {
+		route.setErr(errors.New("mux: nil handler"))
+	}
+	route.Handler(handler)
+	if err := route.Err(); err != nil {
+		return nil, err
+	}
+	route.logger = r.logger
+	r.addRoutes(route)
+	return route, nil
+}
+
+// TryHandleFunc registers a new route with a matcher for the URL path and a
+// handler function, see TryHandle.
+func (r *Router) TryHandleFunc(path string, f func(context.Context, http.ResponseWriter, *http.Request, Binder) error) (*Route, error) {
+	if f == nil {
+		return r.TryHandle(path, nil)
+	}
+	return r.TryHandle(path, HandlerFunc(f))
+}
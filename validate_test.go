@@ -0,0 +1,118 @@
+package mux
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRouteValidate(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		router := NewRouter().LazyCompile(lazy).SetLogger(NopLogger())
+		route := router.HandleFunc("/users/{id", dummyHandler).
+			Host("{tenant}.example.com").
+			Queries("page", "{page:[0-9}", "sort", "{}").
+			Name("users")
+		router.HandleFunc("/valid/{id}", dummyHandler)
+
+		want := []TemplateError{
+			{Template: "/users/{id", Pos: 7, Segment: "{id"},
+			{Template: "page={page:[0-9}", Pos: 5, Segment: "{page:[0-9}"},
+			{Template: "sort={}", Pos: 5, Segment: "{}"},
+		}
+		errs := route.Validate()
+		if len(errs) != len(want) {
+			t.Fatalf("lazy %v: expected %d errors, got %q", lazy, len(want), errs)
+		}
+		for i, err := range errs {
+			var terr *TemplateError
+			if !errors.As(err, &terr) {
+				t.Errorf("lazy %v: expected a TemplateError, got %v", lazy, err)
+				continue
+			}
+			if terr.Template != want[i].Template || terr.Pos != want[i].Pos || terr.Segment != want[i].Segment {
+				t.Errorf("lazy %v: expected %q at %d (%q), got %v", lazy, want[i].Template, want[i].Pos, want[i].Segment, err)
+			}
+		}
+		if route.GetError() != errs[0] {
+			t.Errorf("lazy %v: expected GetError to return the first error, got %v", lazy, route.GetError())
+		}
+		if got := router.Validate(); len(got) != len(want) {
+			t.Errorf("lazy %v: expected %d router errors, got %q", lazy, len(want), got)
+		}
+	}
+}
+
+func TestTemplateError(t *testing.T) {
+	tests := []struct {
+		tpl     string
+		message string
+	}{
+		{"/a/{b", `mux: unbalanced braces (template "/a/{b", position 3, segment "{b")`},
+		{"/a/b}/c", `mux: unbalanced braces (template "/a/b}/c", position 3, segment "b}")`},
+		{"/a/x{}y/c", `mux: missing name or pattern in "{}" (template "/a/x{}y/c", position 4, segment "{}")`},
+		{"a/b", `mux: path must start with a slash, got "a/b" (template "a/b", position 0, segment "a")`},
+	}
+	for _, test := range tests {
+		route := NewRouter().SetLogger(NopLogger()).NewRoute().Path(test.tpl)
+		if err := route.GetError(); err == nil || err.Error() != test.message {
+			t.Errorf("%q: expected %q, got %v", test.tpl, test.message, err)
+		}
+	}
+	if err := NewRouter().SetLogger(NopLogger()).NewRoute().Path("/{a").GetError(); !errors.Is(err, errUnbalancedBraces) {
+		t.Errorf("expected errUnbalancedBraces, got %v", err)
+	}
+}
+
+func TestRouteErr(t *testing.T) {
+	router := NewRouter().LazyCompile(true).SetLogger(NopLogger())
+	route := router.HandleFunc("/users/{id:[0-9}", dummyHandler)
+	if route.GetError() != nil {
+		t.Fatalf("expected the lazy route to be compiled on demand, got %v", route.GetError())
+	}
+	err := route.Queries("sort", "{}").Err()
+	if err == nil || !strings.Contains(err.Error(), `segment "{id:[0-9}"`) || !strings.Contains(err.Error(), `segment "{}"`) {
+		t.Errorf("expected the compile and query errors, got %v", err)
+	}
+	if err := router.HandleFunc("/valid", dummyHandler).Err(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTryHandle(t *testing.T) {
+	router := NewRouter()
+	route, err := router.TryHandleFunc("/users/{id:[0-9]+}", dummyHandler)
+	if err != nil || route == nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		handler Handler
+		err     string
+	}{
+		{"/users/{id", HandlerFunc(dummyHandler), "mux: unbalanced braces"},
+		{"users", HandlerFunc(dummyHandler), "mux: path must start with a slash"},
+		{"/users/{id:(}", HandlerFunc(dummyHandler), "mux: error compiling regex"},
+		{"/orders", nil, "mux: nil handler"},
+		{"/orders", HandlerFunc(nil), "mux: nil handler"},
+	}
+	for _, test := range tests {
+		route, err := router.TryHandle(test.path, test.handler)
+		if route != nil || err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: expected error %q, got %v, %v", test.path, test.err, route, err)
+		}
+	}
+	if _, err := router.TryHandleFunc("/orders", nil); err == nil {
+		t.Error("expected an error for a nil function")
+	}
+
+	if n := len(router.getRoutes()); n != 1 {
+		t.Errorf("expected only the valid route to be registered, got %d routes", n)
+	}
+	var match RouteMatch
+	if !router.Match(newRequest(http.MethodGet, "http://localhost/users/1"), &match) || match.Route != route {
+		t.Errorf("expected the valid route to match")
+	}
+}
@@ -0,0 +1,154 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestVar(t *testing.T) {
+	var vars map[string]string
+	var id, name, missing string
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		vars = Vars(r)
+		id, name, missing = Var(r, "id"), Var(r, "name"), Var(r, "missing")
+		return nil
+	}
+
+	router := NewRouter()
+	router.Host("{name}.example.com").Path("/users/{id}").HandlerFunc(handler)
+	sub := router.PathPrefix("/{id}").Subrouter()
+	sub.HandleFunc("/items/{id}", handler)
+
+	tests := []struct {
+		url      string
+		expected map[string]string
+	}{
+		{"http://bob.example.com/users/42", map[string]string{"name": "bob", "id": "42"}},
+		// The enclosing route sets its variables last.
+		{"http://localhost/shop/items/7", map[string]string{"id": "shop"}},
+	}
+	for _, tt := range tests {
+		vars = nil
+		_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", tt.url), nil)
+		if !reflect.DeepEqual(vars, tt.expected) {
+			t.Errorf("%s: expected vars %v, got %v", tt.url, tt.expected, vars)
+		}
+		if id != tt.expected["id"] || name != tt.expected["name"] || missing != "" {
+			t.Errorf("%s: unexpected Var results %q %q %q", tt.url, id, name, missing)
+		}
+	}
+}
+
+func TestVarsOmitRoute(t *testing.T) {
+	router := NewRouter()
+	router.OmitRouteFromContext(true).OmitRouterFromContext(true)
+	router.HandleFunc("/users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		if CurrentRoute(r) != nil || CurrentRouter(r) != nil {
+			t.Error("expected route and router to be omitted")
+		}
+		if Var(r, "id") != "42" || Vars(r)["id"] != "42" {
+			t.Errorf("unexpected vars %v", Vars(r))
+		}
+		return nil
+	})
+	_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/users/42"), nil)
+}
+
+func TestVarSetURLVars(t *testing.T) {
+	req := SetURLVars(newRequest("GET", "http://localhost/"), map[string]string{"id": "1"})
+	if Var(req, "id") != "1" || Var(req, "other") != "" {
+		t.Error("expected Var to read variables set with SetURLVars")
+	}
+}
+
+func TestVarsOutliveMatch(t *testing.T) {
+	var reqs []*http.Request
+	router := NewRouter()
+	router.HandleFunc("/users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		reqs = append(reqs, r)
+		return nil
+	})
+	for _, id := range []string{"1", "2"} {
+		_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/users/"+id), nil)
+	}
+	// The pooled match must not be shared with the request context.
+	if len(reqs) != 2 || Var(reqs[0], "id") != "1" || Vars(reqs[1])["id"] != "2" {
+		t.Error("expected the variables to remain valid after the handler returned")
+	}
+}
+
+func TestVarsMapBuiltOnce(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		if a, b := Vars(r), Vars(r); reflect.ValueOf(a).Pointer() != reflect.ValueOf(b).Pointer() {
+			t.Error("expected Vars to return the same map for a request")
+		}
+		return nil
+	})
+	_ = router.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/users/42"), nil)
+}
+
+func TestContextValuesSingleLayer(t *testing.T) {
+	type key struct{}
+	base := context.WithValue(context.Background(), key{}, "value")
+	router := NewRouter()
+	router.Use(TraceContextMiddleware)
+	router.Use(SessionMiddleware(NewCookieStore([]byte("secret")), "session"))
+	sub := router.PathPrefix("/users/{id}").Subrouter()
+	sub.HandleFunc("/{tab}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		for _, c := range []context.Context{ctx, r.Context()} {
+			mc, ok := c.(*matchContext)
+			if !ok {
+				t.Fatalf("expected a matchContext, got %T", c)
+			}
+			if _, nested := mc.Context.(*matchContext); nested {
+				t.Error("expected a single matchContext layer")
+			}
+			if _, ok := GetTraceContext(c); !ok {
+				t.Error("expected the trace context")
+			}
+			if Session(c) == nil {
+				t.Error("expected the session")
+			}
+		}
+		if r.Context().Value(key{}) != "value" {
+			t.Error("expected the values of the request context")
+		}
+		if CurrentRouter(r) != router || CurrentRoute(r) == nil {
+			t.Error("expected the router and route")
+		}
+		if expected := map[string]string{"id": "42", "tab": "posts"}; !reflect.DeepEqual(Vars(r), expected) {
+			t.Errorf("expected vars %v, got %v", expected, Vars(r))
+		}
+		return nil
+	})
+
+	req := newRequest("GET", "http://localhost/users/42/posts").WithContext(base)
+	if err := router.ServeHTTP(context.Background(), NewRecorder(), req, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNestedRouterContext(t *testing.T) {
+	inner := NewRouter()
+	inner.HandleFunc("/api/{name}", func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		if _, nested := r.Context().(*matchContext).Context.(*matchContext); nested {
+			t.Error("expected the context of the outer router to be replaced")
+		}
+		if CurrentRouter(r) != inner {
+			t.Error("expected the inner router")
+		}
+		if expected := map[string]string{"name": "users"}; !reflect.DeepEqual(Vars(r), expected) {
+			t.Errorf("expected vars %v, got %v", expected, Vars(r))
+		}
+		return nil
+	})
+	outer := NewRouter()
+	outer.PathPrefix("/{prefix}").Handler(inner)
+
+	if err := outer.ServeHTTP(context.Background(), NewRecorder(), newRequest("GET", "http://localhost/api/users"), nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,104 @@
+package mux
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// VarType is the type of a route variable declared with Route.Vars.
+type VarType struct {
+	// Name describes the type in errors, such as "int".
+	Name string
+	// Valid reports whether a variable value is of the type.
+	Valid func(value string) bool
+}
+
+// The types of route variables.
+var (
+	// String accepts any non-empty value.
+	String = VarType{Name: "string", Valid: func(value string) bool { return value != "" }}
+	// Int accepts base 10 integers which fit in an int64.
+	Int = VarType{Name: "int", Valid: func(value string) bool {
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	}}
+	// Uint accepts unsigned base 10 integers which fit in a uint64.
+	Uint = VarType{Name: "uint", Valid: func(value string) bool {
+		_, err := strconv.ParseUint(value, 10, 64)
+		return err == nil
+	}}
+	// UUID accepts UUIDs in their canonical form, such as
+	// "123e4567-e89b-12d3-a456-426614174000".
+	UUID = VarType{Name: "uuid", Valid: uuidPattern.MatchString}
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// varProbes are the values matched against variable patterns to tell whether
+// they only accept values of the declared type.
+var varProbes = []string{
+	"", "a", "abc", "A-1", "a b", "a.b", "0", "1", "42", "-1", "+1", "1.5", "1e3", "0x1f",
+	"00000000-0000-0000-0000-000000000000", "123e4567-e89b-12d3-a456-426614174000",
+	"123e4567e89b12d3a456426614174000",
+}
+
+// Vars declares that the route templates define the variable name, and that
+// its pattern only matches values of typ, so that a handler relying on the
+// variable fails at registration instead of at request time when the
+// template changes:
+//
+//	r.HandleFunc("/users/{id:[0-9]+}", getUser).Vars("id", mux.Int)
+//
+// It must be called after the templates defining the variable. The route
+// fails to build if no template defines the variable, or if its pattern
+// matches a value which isn't of typ: "{id}" matches "abc", which isn't an
+// int. The pattern is checked against a fixed set of sample values, so a
+// conflict may go unnoticed; "[0-9]+" is an int although it matches integers
+// too large for an int64.
+func (r *Route) Vars(name string, typ VarType) *Route {
+	if r.err == nil {
+		r.setErr(r.checkVar(name, typ))
+	}
+	return r
+}
+
+// checkVar returns an error if the variable name isn't defined with a pattern
+// matching only values of typ.
+func (r *Route) checkVar(name string, typ VarType) error {
+	patt, ok := r.varPattern(name)
+	if !ok {
+		return fmt.Errorf("mux: route variable %q is not defined by the route templates", name)
+	}
+	re, err := regexp.Compile("^(?:" + patt + ")$")
+	if err != nil {
+		return fmt.Errorf("mux: route variable %q: %w", name, err)
+	}
+	probes := varProbes
+	if sample, ok := sampleMatch(patt); ok {
+		probes = append(probes[:len(probes):len(probes)], sample)
+	}
+	for _, probe := range probes {
+		if re.MatchString(probe) && !typ.Valid(probe) {
+			return fmt.Errorf("mux: pattern %q of route variable %q matches %q, which is not a valid %s", patt, name, probe, typ.Name)
+		}
+	}
+	return nil
+}
+
+// varPattern returns the pattern of the variable name in the route
+// templates.
+func (r *Route) varPattern(name string) (string, bool) {
+	rrs := append([]*routeRegexp{r.regexp.host, r.regexp.path}, r.regexp.queries...)
+	for _, rr := range rrs {
+		if rr == nil {
+			continue
+		}
+		for i, n := range rr.varsN {
+			if n == name {
+				return rr.varsP[i], true
+			}
+		}
+	}
+	return "", false
+}
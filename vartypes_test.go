@@ -0,0 +1,76 @@
+package mux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRouteVars(t *testing.T) {
+	tests := []struct {
+		name  string
+		route func(r *Router) *Route
+		err   string
+	}{
+		{
+			name: "int",
+			route: func(r *Router) *Route {
+				return r.HandleFunc("/users/{id:[0-9]+}", dummyHandler).Vars("id", Int)
+			},
+		},
+		{
+			name: "several",
+			route: func(r *Router) *Route {
+				return r.HandleFunc("/users/{id:-?[0-9]{1,9}}/files/{file}", dummyHandler).
+					Host("{tenant}.example.com").
+					Queries("version", "{version:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}").
+					Vars("id", Int).Vars("file", String).Vars("tenant", String).Vars("version", UUID)
+			},
+		},
+		{
+			name: "subrouter",
+			route: func(r *Router) *Route {
+				return r.PathPrefix("/users/{id:[0-9]+}").Subrouter().HandleFunc("/posts", dummyHandler).Vars("id", Uint)
+			},
+		},
+		{
+			name: "undefined",
+			route: func(r *Router) *Route {
+				return r.HandleFunc("/users/{user}", dummyHandler).Vars("id", Int)
+			},
+			err: `mux: route variable "id" is not defined by the route templates`,
+		},
+		{
+			name: "default pattern",
+			route: func(r *Router) *Route {
+				return r.HandleFunc("/users/{id}", dummyHandler).Vars("id", Int)
+			},
+			err: `mux: pattern "[^/]+" of route variable "id" matches "a", which is not a valid int`,
+		},
+		{
+			name: "signed",
+			route: func(r *Router) *Route {
+				return r.HandleFunc("/users/{id:-?[0-9]+}", dummyHandler).Vars("id", Uint)
+			},
+			err: `matches "-1", which is not a valid uint`,
+		},
+		{
+			name: "empty query value",
+			route: func(r *Router) *Route {
+				return r.HandleFunc("/items", dummyHandler).Queries("q", "{q}").Vars("q", String)
+			},
+			err: `matches "", which is not a valid string`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			route := test.route(NewRouter().SetLogger(NopLogger()))
+			err := route.GetError()
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+				t.Errorf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package mux
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// WebSocket returns a handler upgrading requests to WebSocket connections and
+// calling handler with the connection and the route variables. upgrade
+// performs the handshake with the WebSocket library of choice, e.g. for
+// github.com/gorilla/websocket:
+//
+//	upgrader := websocket.Upgrader{}
+//	r.Handle("/chat/{room}", mux.WebSocket(
+//	    func(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
+//	        return upgrader.Upgrade(w, r, nil)
+//	    },
+//	    func(ctx context.Context, conn *websocket.Conn, vars map[string]string) error {
+//	        defer conn.Close()
+//	        ...
+//	    },
+//	))
+//
+// and for github.com/coder/websocket (formerly nhooyr.io/websocket):
+//
+//	func(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
+//	    return websocket.Accept(w, r, nil)
+//	}
+//
+// Requests which are not WebSocket handshakes get a 426 Upgrade Required
+// response. The writer passed to upgrade can be hijacked even if middlewares
+// wrapped it in writers that don't implement http.Hijacker, as long as they
+// implement Unwrap. Errors of upgrade, which usually already replied to the
+// client, are returned wrapped, and errors of handler as is.
+func WebSocket[C any](upgrade func(w http.ResponseWriter, r *http.Request) (C, error), handler func(ctx context.Context, conn C, vars map[string]string) error) HandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+		if !IsWebSocketUpgrade(r) {
+			w.Header().Set("Connection", "Upgrade")
+			w.Header().Set("Upgrade", "websocket")
+			http.Error(w, http.StatusText(http.StatusUpgradeRequired), http.StatusUpgradeRequired)
+			return nil
+		}
+		conn, err := upgrade(hijackWriter{w}, r)
+		if err != nil {
+			return fmt.Errorf("mux: websocket upgrade: %w", err)
+		}
+		return handler(ctx, conn, Vars(r))
+	}
+}
+
+// IsWebSocketUpgrade reports whether r is a WebSocket opening handshake.
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") &&
+		headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+// headerContainsToken reports whether the comma separated values of the
+// header name contain token, ignoring case.
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, v := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hijackWriter implements http.Hijacker through http.ResponseController, which
+// unwraps the writers that don't implement it themselves.
+type hijackWriter struct {
+	http.ResponseWriter
+}
+
+func (w hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w hijackWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,96 @@
+package mux
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// opaqueWriter hides the optional interfaces of the wrapped writer.
+type opaqueWriter struct {
+	http.ResponseWriter
+}
+
+func (w opaqueWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestWebSocket(t *testing.T) {
+	// upgrade stands in for a WebSocket library, replying to the handshake
+	// on the hijacked connection.
+	upgrade := func(w http.ResponseWriter, r *http.Request) (net.Conn, error) {
+		h, ok := w.(http.Hijacker)
+		if !ok {
+			return nil, errors.New("not a hijacker")
+		}
+		conn, rw, err := h.Hijack()
+		if err != nil {
+			return nil, err
+		}
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		return conn, rw.Flush()
+	}
+	router := NewRouter()
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, binder Binder) error {
+			return next(ctx, opaqueWriter{w}, r, binder)
+		}
+	})
+	router.Handle("/rooms/{room}", WebSocket(upgrade, func(ctx context.Context, conn net.Conn, vars map[string]string) error {
+		defer conn.Close()
+		_, err := fmt.Fprintf(conn, "joined %s\n", vars["room"])
+		return err
+	}))
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := router.ServeHTTP(context.Background(), w, r, nil); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(s.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "GET /rooms/lobby HTTP/1.1\r\nHost: localhost\r\nConnection: keep-alive, Upgrade\r\nUpgrade: websocket\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+	if line, _ := br.ReadString('\n'); line != "joined lobby\n" {
+		t.Errorf("unexpected message %q", line)
+	}
+
+	rw := NewRecorder()
+	if err := router.ServeHTTP(context.Background(), rw, newRequest(http.MethodGet, "http://localhost/rooms/lobby"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if rw.Code != http.StatusUpgradeRequired || rw.Header().Get("Upgrade") != "websocket" {
+		t.Errorf("expected 426 for a plain request, got %d %v", rw.Code, rw.Header())
+	}
+}
+
+func TestWebSocketUpgradeError(t *testing.T) {
+	failed := errors.New("bad handshake")
+	h := WebSocket(func(w http.ResponseWriter, r *http.Request) (net.Conn, error) {
+		return nil, failed
+	}, func(ctx context.Context, conn net.Conn, vars map[string]string) error {
+		t.Error("handler called after a failed upgrade")
+		return nil
+	})
+	req := newRequestWithHeaders(http.MethodGet, "http://localhost/", "Connection", "Upgrade", "Upgrade", "WebSocket")
+	if err := h(context.Background(), NewRecorder(), req, nil); !errors.Is(err, failed) {
+		t.Errorf("expected the upgrade error, got %v", err)
+	}
+}